@@ -1,16 +1,21 @@
 package v1
 
 import (
+	"bytes"
 	"container/heap"
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 
 	"connectrpc.com/connect"
 	service "github.com/bitbomdev/minefield/gen/api/v1"
 	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools/backup"
 	"github.com/bitbomdev/minefield/pkg/tools/ingest"
+	"github.com/bitbomdev/minefield/pkg/tools/policy"
 	"github.com/goccy/go-json"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -58,7 +63,7 @@ func (s *Service) GetNode(ctx context.Context, req *connect.Request[service.GetN
 }
 
 func (s *Service) GetNodeByName(ctx context.Context, req *connect.Request[service.GetNodeByNameRequest]) (*connect.Response[service.GetNodeByNameResponse], error) {
-	id, err := s.storage.NameToID(req.Msg.Name)
+	id, err := s.storage.NameToID(graph.CanonicalName(req.Msg.Name))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get node by name: %w", err)
 	}
@@ -89,11 +94,56 @@ func (s *Service) GetNodesByGlob(ctx context.Context, req *connect.Request[servi
 	return connect.NewResponse(&service.GetNodesByGlobResponse{Nodes: serviceNodes}), nil
 }
 
+func (s *Service) SearchMetadata(ctx context.Context, req *connect.Request[service.SearchMetadataRequest]) (*connect.Response[service.SearchMetadataResponse], error) {
+	results, err := s.storage.SearchMetadata(req.Msg.Query, int(req.Msg.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search metadata: %w", err)
+	}
+	resp := &service.SearchMetadataResponse{Results: make([]*service.SearchResult, 0, len(results))}
+	for _, result := range results {
+		node, err := NodeToServiceNode(result.Node)
+		if err != nil {
+			return nil, err
+		}
+		resp.Results = append(resp.Results, &service.SearchResult{Node: node, Score: result.Score})
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func (s *Service) SearchNodes(ctx context.Context, req *connect.Request[service.SearchNodesRequest]) (*connect.Response[service.SearchNodesResponse], error) {
+	query := graph.NodeQuery{Regex: req.Msg.Regex}
+	if purl := req.Msg.Purl; purl != nil {
+		query.Purl = &graph.PurlFilter{
+			Type:         purl.Type,
+			Namespace:    purl.Namespace,
+			Name:         purl.Name,
+			VersionRange: purl.VersionRange,
+			Qualifiers:   purl.Qualifiers,
+		}
+	}
+	nodes, nextPageToken, err := graph.SearchNodes(s.storage, query, int(req.Msg.PageSize), req.Msg.PageToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nodes: %w", err)
+	}
+	resp := &service.SearchNodesResponse{Nodes: make([]*service.Node, 0, len(nodes)), NextPageToken: nextPageToken}
+	for _, node := range nodes {
+		serviceNode, err := NodeToServiceNode(node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert node to service node: %w", err)
+		}
+		resp.Nodes = append(resp.Nodes, serviceNode)
+	}
+	return connect.NewResponse(resp), nil
+}
+
 func (s *Service) AddNode(ctx context.Context, req *connect.Request[service.AddNodeRequest]) (*connect.Response[service.AddNodeResponse], error) {
 	resultNode, err := graph.AddNode(s.storage, req.Msg.Node.Type, req.Msg.Node.Metadata, req.Msg.Node.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add node: %w", err)
 	}
+	if err := ingest.IndexLibrary(s.storage, resultNode); err != nil {
+		return nil, fmt.Errorf("failed to index node: %w", err)
+	}
 	serviceNode, err := NodeToServiceNode(resultNode)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert node to service node: %w", err)
@@ -141,6 +191,10 @@ func (s *Service) CustomLeaderboard(ctx context.Context, req *connect.Request[se
 	if len(uncachedNodes) != 0 {
 		return nil, fmt.Errorf("cannot use sorted leaderboards without caching")
 	}
+	sortBy := req.Msg.SortBy
+	if _, ok := graph.LookupField(sortBy); sortBy != "" && !ok {
+		return nil, fmt.Errorf("cannot sort by unknown field %q, the fields are %s", sortBy, strings.Join(graph.Fields(), ", "))
+	}
 
 	keys, err := s.storage.GetAllKeys()
 	if err != nil {
@@ -165,6 +219,9 @@ func (s *Service) CustomLeaderboard(ctx context.Context, req *connect.Request[se
 	h := &queryHeap{}
 	heap.Init(h)
 
+	// Every query reads the custom data of the same graph, so they share what they read
+	lookups := graph.NewQueryLookups(s.storage)
+
 	// Use maxConcurrency in your parallel processing code
 	semaphore := make(chan struct{}, s.concurrency)
 
@@ -185,7 +242,7 @@ func (s *Service) CustomLeaderboard(ctx context.Context, req *connect.Request[se
 			defer wg.Done()
 			defer func() { <-semaphore }() // Release the token
 
-			execute, err := graph.ParseAndExecute(req.Msg.Script, s.storage, node.Name, nodes, caches, len(cacheStack) == 0)
+			execute, err := graph.ParseAndExecuteWithLookups(req.Msg.Script, s.storage, node.Name, nodes, caches, len(cacheStack) == 0, lookups)
 			if err != nil {
 				errChan <- err
 				return
@@ -229,6 +286,24 @@ func (s *Service) CustomLeaderboard(ctx context.Context, req *connect.Request[se
 		}
 	}
 
+	if sortBy != "" {
+		for _, query := range queries {
+			fields, err := graph.GetFields(s.storage, query.Node.Name)
+			if err != nil {
+				return nil, err
+			}
+			query.SortValue = fields[sortBy]
+		}
+		// Nodes keep the order of the size of their output when their field is equal, and go last without one
+		sort.SliceStable(queries, func(i, j int) bool {
+			a, b := queries[i].SortValue, queries[j].SortValue
+			if a == "" || b == "" {
+				return a != "" && b == ""
+			}
+			return graph.CompareFieldValues(sortBy, a, b) > 0
+		})
+	}
+
 	res := connect.NewResponse(&service.CustomLeaderboardResponse{
 		Queries: queries,
 	})
@@ -313,7 +388,7 @@ func (s *Service) Check(ctx context.Context, req *connect.Request[emptypb.Empty]
 }
 
 func (s *Service) IngestSBOM(ctx context.Context, req *connect.Request[service.IngestSBOMRequest]) (*connect.Response[emptypb.Empty], error) {
-	err := ingest.SBOM(s.storage, req.Msg.Sbom)
+	err := ingest.SBOMWithOptions(s.storage, req.Msg.Sbom, ingest.SBOMOptions{Replace: req.Msg.Replace, Identity: req.Msg.Identity})
 	if err != nil {
 		return nil, fmt.Errorf("failed to ingest sbom: %w", err)
 	}
@@ -328,6 +403,48 @@ func (s *Service) IngestVulnerability(ctx context.Context, req *connect.Request[
 	return connect.NewResponse(&emptypb.Empty{}), nil
 }
 
+func (s *Service) IngestVulnerabilities(ctx context.Context, req *connect.Request[service.IngestVulnerabilitiesRequest]) (*connect.Response[service.IngestVulnerabilitiesResponse], error) {
+	ingested := 0
+	if len(req.Msg.Archive) > 0 {
+		count, err := ingest.VulnerabilitiesArchive(s.storage, req.Msg.Archive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to ingest vulnerability archive: %w", err)
+		}
+		ingested += count
+	}
+	if len(req.Msg.Vulnerabilities) > 0 {
+		if err := ingest.BulkVulnerabilities(s.storage, req.Msg.Vulnerabilities); err != nil {
+			return nil, fmt.Errorf("failed to ingest vulnerabilities: %w", err)
+		}
+		ingested += len(req.Msg.Vulnerabilities)
+	}
+	return connect.NewResponse(&service.IngestVulnerabilitiesResponse{Ingested: int32(ingested)}), nil
+}
+
+func (s *Service) IngestEPSS(ctx context.Context, req *connect.Request[service.IngestEPSSRequest]) (*connect.Response[service.IngestEPSSResponse], error) {
+	count, err := ingest.EPSS(s.storage, req.Msg.Epss)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest EPSS scores: %w", err)
+	}
+	return connect.NewResponse(&service.IngestEPSSResponse{Ingested: int32(count)}), nil
+}
+
+func (s *Service) IngestKEV(ctx context.Context, req *connect.Request[service.IngestKEVRequest]) (*connect.Response[service.IngestKEVResponse], error) {
+	count, err := ingest.KEV(s.storage, req.Msg.Kev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest KEV catalog: %w", err)
+	}
+	return connect.NewResponse(&service.IngestKEVResponse{Ingested: int32(count)}), nil
+}
+
+func (s *Service) IngestAttestation(ctx context.Context, req *connect.Request[service.IngestAttestationRequest]) (*connect.Response[service.IngestAttestationResponse], error) {
+	count, err := ingest.Attestation(s.storage, req.Msg.Attestation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest attestation: %w", err)
+	}
+	return connect.NewResponse(&service.IngestAttestationResponse{Ingested: int32(count)}), nil
+}
+
 func (s *Service) IngestScorecard(ctx context.Context, req *connect.Request[service.IngestScorecardRequest]) (*connect.Response[emptypb.Empty], error) {
 	err := ingest.Scorecards(s.storage, req.Msg.Scorecard)
 	if err != nil {
@@ -336,6 +453,117 @@ func (s *Service) IngestScorecard(ctx context.Context, req *connect.Request[serv
 	return connect.NewResponse(&emptypb.Empty{}), nil
 }
 
+func (s *Service) IngestVEX(ctx context.Context, req *connect.Request[service.IngestVEXRequest]) (*connect.Response[emptypb.Empty], error) {
+	err := ingest.VEX(s.storage, req.Msg.Vex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest vex: %w", err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (s *Service) IngestLockfile(ctx context.Context, req *connect.Request[service.IngestLockfileRequest]) (*connect.Response[emptypb.Empty], error) {
+	err := ingest.Lockfile(s.storage, req.Msg.Filename, req.Msg.Lockfile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest lockfile: %w", err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (s *Service) IngestGoModGraph(ctx context.Context, req *connect.Request[service.IngestGoModGraphRequest]) (*connect.Response[emptypb.Empty], error) {
+	err := ingest.GoModGraph(s.storage, req.Msg.Graph)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest go mod graph: %w", err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (s *Service) IngestGovulncheck(ctx context.Context, req *connect.Request[service.IngestGovulncheckRequest]) (*connect.Response[emptypb.Empty], error) {
+	err := ingest.Govulncheck(s.storage, req.Msg.Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ingest govulncheck output: %w", err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (s *Service) SetAnnotation(ctx context.Context, req *connect.Request[service.SetAnnotationRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := graph.SetAnnotation(s.storage, req.Msg.Name, req.Msg.Key, req.Msg.Value); err != nil {
+		return nil, fmt.Errorf("failed to set annotation: %w", err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (s *Service) RemoveAnnotation(ctx context.Context, req *connect.Request[service.RemoveAnnotationRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := graph.RemoveAnnotation(s.storage, req.Msg.Name, req.Msg.Key); err != nil {
+		return nil, fmt.Errorf("failed to remove annotation: %w", err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (s *Service) GetAnnotations(ctx context.Context, req *connect.Request[service.GetAnnotationsRequest]) (*connect.Response[service.GetAnnotationsResponse], error) {
+	annotations, err := graph.GetAnnotations(s.storage, req.Msg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get annotations: %w", err)
+	}
+	return connect.NewResponse(&service.GetAnnotationsResponse{Annotations: annotations}), nil
+}
+
+func (s *Service) Backup(ctx context.Context, req *connect.Request[emptypb.Empty]) (*connect.Response[service.BackupResponse], error) {
+	var buf bytes.Buffer
+	if _, err := backup.Write(s.storage, &buf); err != nil {
+		return nil, fmt.Errorf("failed to back up storage: %w", err)
+	}
+	return connect.NewResponse(&service.BackupResponse{Archive: buf.Bytes()}), nil
+}
+
+func (s *Service) Restore(ctx context.Context, req *connect.Request[service.RestoreRequest]) (*connect.Response[service.RestoreResponse], error) {
+	manifest, err := backup.Restore(s.storage, bytes.NewReader(req.Msg.Archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore storage: %w", err)
+	}
+	return connect.NewResponse(&service.RestoreResponse{
+		Nodes:      uint32(manifest.Nodes),
+		Caches:     uint32(manifest.Caches),
+		CustomData: uint32(manifest.CustomData),
+		IdCounter:  manifest.IDCounter,
+	}), nil
+}
+
+func (s *Service) Dedupe(ctx context.Context, req *connect.Request[service.DedupeRequest]) (*connect.Response[service.DedupeResponse], error) {
+	groups, err := ingest.FindDuplicates(s.storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicates: %w", err)
+	}
+	res := &service.DedupeResponse{Groups: make([]*service.DuplicateGroup, 0, len(groups))}
+	for _, group := range groups {
+		res.Groups = append(res.Groups, &service.DuplicateGroup{Digest: group.Digest, Nodes: group.Nodes})
+	}
+	if req.Msg.Link {
+		linked, err := ingest.LinkDuplicates(s.storage, groups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link duplicates: %w", err)
+		}
+		res.Linked = int32(linked)
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (s *Service) CheckLicenses(ctx context.Context, req *connect.Request[service.CheckLicensesRequest]) (*connect.Response[service.CheckLicensesResponse], error) {
+	violations, err := policy.CheckLicenses(s.storage, req.Msg.Roots, req.Msg.Deny)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check licenses: %w", err)
+	}
+	res := &service.CheckLicensesResponse{Violations: make([]*service.LicenseViolation, 0, len(violations))}
+	for _, violation := range violations {
+		res.Violations = append(res.Violations, &service.LicenseViolation{
+			Root:       violation.Root,
+			Library:    violation.Library,
+			License:    violation.License,
+			Expression: violation.Expression,
+		})
+	}
+	return connect.NewResponse(res), nil
+}
+
 type queryHeap []*Query
 
 func (h queryHeap) Len() int { return len(h) }
@@ -1,7 +1,10 @@
 package v1
 
 import (
+	"archive/zip"
+	"bytes"
 	"context"
+	"fmt"
 	"os"
 	"testing"
 
@@ -122,7 +125,8 @@ func TestQueriesIngestAndCache(t *testing.T) {
 	allKeysReq := connect.NewRequest(&emptypb.Empty{})
 	allKeysResp, err := s.AllKeys(context.Background(), allKeysReq)
 	require.NoError(t, err)
-	assert.Len(t, allKeysResp.Msg.Nodes, 24)
+	// 23 packages, the vulnerability and the sbom node of the document
+	assert.Len(t, allKeysResp.Msg.Nodes, 25)
 
 	clearReq := connect.NewRequest(&emptypb.Empty{})
 	_, err = s.Clear(context.Background(), clearReq)
@@ -175,6 +179,34 @@ func TestIngestVulnerability(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestIngestVulnerabilities(t *testing.T) {
+	s := setupService()
+	content, err := os.ReadFile("../../testdata/osv-vulns/GHSA-cx63-2mw6-8hw5.json")
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, err := w.Create("GO-2024-3005.json")
+	require.NoError(t, err)
+	archived, err := os.ReadFile("../../testdata/osv-vulns/GO-2024-3005.json")
+	require.NoError(t, err)
+	_, err = f.Write(archived)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	res, err := s.IngestVulnerabilities(context.Background(), connect.NewRequest(&service.IngestVulnerabilitiesRequest{
+		Archive:         buf.Bytes(),
+		Vulnerabilities: [][]byte{content},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), res.Msg.Ingested)
+
+	_, err = s.IngestVulnerabilities(context.Background(), connect.NewRequest(&service.IngestVulnerabilitiesRequest{
+		Archive: []byte("not a zip"),
+	}))
+	assert.Error(t, err)
+}
+
 func TestIngestScorecard(t *testing.T) {
 	s := setupService()
 	content, err := os.ReadFile("../../testdata/scorecards/scorecards.json")
@@ -186,6 +218,193 @@ func TestIngestScorecard(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestIngestVEX(t *testing.T) {
+	s := setupService()
+	vex := []byte(`{
+		"@context": "https://openvex.dev/ns/v0.2.0",
+		"statements": [{"vulnerability": {"name": "CVE-2024-1"}, "products": [{"@id": "pkg:npm/a@1.0.0"}], "status": "not_affected"}]
+	}`)
+	_, err := s.IngestVEX(context.Background(), connect.NewRequest(&service.IngestVEXRequest{Vex: vex}))
+	require.NoError(t, err)
+
+	_, err = s.IngestVEX(context.Background(), connect.NewRequest(&service.IngestVEXRequest{Vex: []byte("{}")}))
+	assert.Error(t, err)
+}
+
+func TestIngestLockfile(t *testing.T) {
+	s := setupService()
+	content, err := os.ReadFile("../../testdata/lockfiles/go/go.mod")
+	require.NoError(t, err)
+	_, err = s.IngestLockfile(context.Background(), connect.NewRequest(&service.IngestLockfileRequest{
+		Filename: "go.mod",
+		Lockfile: content,
+	}))
+	require.NoError(t, err)
+
+	_, err = s.IngestLockfile(context.Background(), connect.NewRequest(&service.IngestLockfileRequest{
+		Filename: "unknown.lock",
+		Lockfile: content,
+	}))
+	assert.Error(t, err)
+}
+
+func TestIngestGoModGraph(t *testing.T) {
+	s := setupService()
+	_, err := s.IngestGoModGraph(context.Background(), connect.NewRequest(&service.IngestGoModGraphRequest{
+		Graph: []byte("example.com/app golang.org/x/text@v0.3.7\n"),
+	}))
+	require.NoError(t, err)
+
+	_, err = s.IngestGoModGraph(context.Background(), connect.NewRequest(&service.IngestGoModGraphRequest{}))
+	assert.Error(t, err)
+}
+
+func TestIngestGovulncheck(t *testing.T) {
+	s := setupService()
+	content, err := os.ReadFile("../../testdata/govulncheck/govulncheck.json")
+	require.NoError(t, err)
+	_, err = s.IngestGovulncheck(context.Background(), connect.NewRequest(&service.IngestGovulncheckRequest{
+		Output: content,
+	}))
+	require.NoError(t, err)
+
+	_, err = s.IngestGovulncheck(context.Background(), connect.NewRequest(&service.IngestGovulncheckRequest{
+		Output: []byte("{}"),
+	}))
+	assert.Error(t, err)
+}
+
+func TestIngestEPSSAndKEV(t *testing.T) {
+	s := setupService()
+	epss, err := os.ReadFile("../../testdata/epss/epss_scores.csv")
+	require.NoError(t, err)
+	epssResp, err := s.IngestEPSS(context.Background(), connect.NewRequest(&service.IngestEPSSRequest{Epss: epss}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), epssResp.Msg.Ingested)
+
+	kev, err := os.ReadFile("../../testdata/kev/known_exploited_vulnerabilities.json")
+	require.NoError(t, err)
+	kevResp, err := s.IngestKEV(context.Background(), connect.NewRequest(&service.IngestKEVRequest{Kev: kev}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), kevResp.Msg.Ingested)
+
+	_, err = s.IngestEPSS(context.Background(), connect.NewRequest(&service.IngestEPSSRequest{}))
+	assert.Error(t, err)
+	_, err = s.IngestKEV(context.Background(), connect.NewRequest(&service.IngestKEVRequest{Kev: []byte("{}")}))
+	assert.Error(t, err)
+}
+
+func TestIngestAttestation(t *testing.T) {
+	s := setupService()
+	data, err := os.ReadFile("../../testdata/attestations/hello-world.intoto.jsonl")
+	require.NoError(t, err)
+	resp, err := s.IngestAttestation(context.Background(), connect.NewRequest(&service.IngestAttestationRequest{Attestation: data}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Msg.Ingested)
+
+	_, err = s.IngestAttestation(context.Background(), connect.NewRequest(&service.IngestAttestationRequest{}))
+	assert.Error(t, err)
+}
+
+func TestDedupe(t *testing.T) {
+	s := setupService()
+	for i, purl := range []string{"pkg:generic/app@1.0.0", "pkg:generic/app@1.0.0?type=tar"} {
+		sbom := fmt.Sprintf(`{
+			"bomFormat": "CycloneDX",
+			"specVersion": "1.5",
+			"serialNumber": "urn:uuid:00000000-0000-0000-0000-00000000000%d",
+			"components": [{"bom-ref": "app", "type": "library", "name": "app", "version": "1.0.0", "purl": %q,
+				"hashes": [{"alg": "SHA-512", "content": "ABCD"}]}]
+		}`, i, purl)
+		_, err := s.IngestSBOM(context.Background(), connect.NewRequest(&service.IngestSBOMRequest{Sbom: []byte(sbom)}))
+		require.NoError(t, err)
+	}
+
+	resp, err := s.Dedupe(context.Background(), connect.NewRequest(&service.DedupeRequest{}))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Groups, 1)
+	assert.Equal(t, "sha512:abcd", resp.Msg.Groups[0].Digest)
+	assert.Equal(t, []string{"pkg:generic/app@1.0.0", "pkg:generic/app@1.0.0?type=tar"}, resp.Msg.Groups[0].Nodes)
+	assert.Zero(t, resp.Msg.Linked)
+
+	resp, err = s.Dedupe(context.Background(), connect.NewRequest(&service.DedupeRequest{Link: true}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Msg.Linked)
+}
+
+func TestCustomLeaderboardSortBy(t *testing.T) {
+	s := setupService()
+	add := func(_type, name string) *graph.Node {
+		node, err := graph.AddNode(s.storage, _type, nil, name)
+		require.NoError(t, err)
+		return node
+	}
+	app := add("library", "pkg:npm/app@1.0.0")
+	lib := add("library", "pkg:npm/lib@1.0.0")
+	likely := add("vuln", "CVE-2024-1")
+	unlikely := add("vuln", "CVE-2024-2")
+	unscored := add("vuln", "CVE-2024-3")
+	for _, edge := range [][2]*graph.Node{{app, lib}, {lib, likely}, {lib, unlikely}, {lib, unscored}} {
+		require.NoError(t, edge[0].SetDependency(s.storage, edge[1]))
+	}
+	require.NoError(t, graph.SetField(s.storage, likely.Name, graph.EPSSField, "0.9"))
+	require.NoError(t, graph.SetField(s.storage, unlikely.Name, graph.EPSSField, "0.1"))
+	require.NoError(t, graph.Cache(s.storage))
+
+	resp, err := s.CustomLeaderboard(context.Background(), connect.NewRequest(&service.CustomLeaderboardRequest{
+		Script: "dependents library",
+		SortBy: graph.EPSSField,
+	}))
+	require.NoError(t, err)
+	var names, values []string
+	for _, query := range resp.Msg.Queries[:3] {
+		names = append(names, query.Node.Name)
+		values = append(values, query.SortValue)
+	}
+	assert.Equal(t, []string{likely.Name, unlikely.Name}, names[:2])
+	assert.Equal(t, []string{"0.9", "0.1", ""}, values)
+
+	_, err = s.CustomLeaderboard(context.Background(), connect.NewRequest(&service.CustomLeaderboardRequest{
+		Script: "dependents library",
+		SortBy: "owner",
+	}))
+	assert.Error(t, err)
+
+	// Libraries sort by the checks of their scorecards
+	require.NoError(t, graph.SetField(s.storage, app.Name, graph.ScorecardCheckField("Maintained"), "3"))
+	require.NoError(t, graph.SetField(s.storage, lib.Name, graph.ScorecardCheckField("Maintained"), "10"))
+	resp, err = s.CustomLeaderboard(context.Background(), connect.NewRequest(&service.CustomLeaderboardRequest{
+		Script: "dependencies library",
+		SortBy: graph.ScorecardCheckField("Maintained"),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, lib.Name, resp.Msg.Queries[0].Node.Name)
+	assert.Equal(t, "10", resp.Msg.Queries[0].SortValue)
+}
+
+func TestCheckLicenses(t *testing.T) {
+	s := setupService()
+	sbom := []byte(`{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"metadata": {"component": {"bom-ref": "app", "type": "application", "name": "app", "purl": "pkg:npm/app@1.0.0", "licenses": [{"license": {"id": "GPL-3.0-only"}}]}}
+	}`)
+	_, err := s.IngestSBOM(context.Background(), connect.NewRequest(&service.IngestSBOMRequest{Sbom: sbom}))
+	require.NoError(t, err)
+
+	res, err := s.CheckLicenses(context.Background(), connect.NewRequest(&service.CheckLicensesRequest{
+		Deny:  []string{"GPL-3.0-only"},
+		Roots: []string{"pkg:npm/app@1.0.0"},
+	}))
+	require.NoError(t, err)
+	require.Len(t, res.Msg.Violations, 1)
+	assert.Equal(t, "pkg:npm/app@1.0.0", res.Msg.Violations[0].Library)
+	assert.Equal(t, "GPL-3.0-only", res.Msg.Violations[0].License)
+
+	_, err = s.CheckLicenses(context.Background(), connect.NewRequest(&service.CheckLicensesRequest{}))
+	assert.Error(t, err)
+}
+
 func TestAddNode(t *testing.T) {
 	s := setupService()
 	addNodeReq := connect.NewRequest(&service.AddNodeRequest{
@@ -205,6 +424,22 @@ func TestAddNode(t *testing.T) {
 	assert.Nil(t, resp)
 }
 
+func TestAddNode_CanonicalName(t *testing.T) {
+	s := setupService()
+	_, err := s.AddNode(context.Background(), connect.NewRequest(&service.AddNodeRequest{
+		Node: &service.Node{Name: "pkg:maven/org.example/lib@1.0?type=jar&classifier=sources", Type: "library"},
+	}))
+	require.NoError(t, err)
+	for _, name := range []string{
+		"pkg:maven/org.example/lib@1.0?classifier=sources&type=jar",
+		"pkg:maven/org.example/lib@1.0?type=jar&classifier=sources",
+	} {
+		resp, err := s.GetNodeByName(context.Background(), connect.NewRequest(&service.GetNodeByNameRequest{Name: name}))
+		require.NoError(t, err, name)
+		assert.Equal(t, "pkg:maven/org.example/lib@1.0?classifier=sources&type=jar", resp.Msg.Node.Name)
+	}
+}
+
 func TestSetDependency(t *testing.T) {
 	s := setupService()
 	addNodeReq := connect.NewRequest(&service.AddNodeRequest{
@@ -246,3 +481,97 @@ func TestHealthCheck(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "ok", resp.Msg.Status)
 }
+
+func TestBackupAndRestore(t *testing.T) {
+	s := setupService()
+	node1, err := graph.AddNode(s.storage, "library", "metadata1", "name1")
+	require.NoError(t, err)
+	node2, err := graph.AddNode(s.storage, "library", "metadata2", "name2")
+	require.NoError(t, err)
+	require.NoError(t, node1.SetDependency(s.storage, node2))
+
+	backupResp, err := s.Backup(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	require.NoError(t, err)
+	assert.NotEmpty(t, backupResp.Msg.Archive)
+
+	restored := setupService()
+	restoreResp, err := restored.Restore(context.Background(), connect.NewRequest(&service.RestoreRequest{Archive: backupResp.Msg.Archive}))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), restoreResp.Msg.Nodes)
+	assert.Equal(t, uint32(2), restoreResp.Msg.IdCounter)
+
+	node, err := restored.storage.GetNode(node2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "name2", node.Name)
+	assert.True(t, node.Parents.Contains(node1.ID))
+
+	// Restoring into a storage that already holds nodes is rejected
+	_, err = restored.Restore(context.Background(), connect.NewRequest(&service.RestoreRequest{Archive: backupResp.Msg.Archive}))
+	assert.Error(t, err)
+}
+
+func TestAnnotations(t *testing.T) {
+	s := setupService()
+	node, err := graph.AddNode(s.storage, "library", nil, "name1")
+	require.NoError(t, err)
+
+	_, err = s.SetAnnotation(context.Background(), connect.NewRequest(&service.SetAnnotationRequest{Name: node.Name, Key: "owner", Value: "team-payments"}))
+	require.NoError(t, err)
+	_, err = s.SetAnnotation(context.Background(), connect.NewRequest(&service.SetAnnotationRequest{Name: node.Name, Key: "approved", Value: "true"}))
+	require.NoError(t, err)
+	_, err = s.RemoveAnnotation(context.Background(), connect.NewRequest(&service.RemoveAnnotationRequest{Name: node.Name, Key: "approved"}))
+	require.NoError(t, err)
+
+	resp, err := s.GetAnnotations(context.Background(), connect.NewRequest(&service.GetAnnotationsRequest{Name: node.Name}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team-payments"}, resp.Msg.Annotations)
+
+	_, err = s.SetAnnotation(context.Background(), connect.NewRequest(&service.SetAnnotationRequest{Name: "missing", Key: "owner", Value: "team-payments"}))
+	assert.Error(t, err)
+}
+
+func TestSearchMetadata(t *testing.T) {
+	s := setupService()
+	node, err := graph.AddNode(s.storage, "vuln", map[string]any{"summary": "Remote code execution"}, "GHSA-1234")
+	require.NoError(t, err)
+	_, err = graph.AddNode(s.storage, "vuln", map[string]any{"summary": "Denial of service"}, "GHSA-5678")
+	require.NoError(t, err)
+
+	resp, err := s.SearchMetadata(context.Background(), connect.NewRequest(&service.SearchMetadataRequest{Query: "remote code", Limit: 5}))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Results, 1)
+	assert.Equal(t, node.Name, resp.Msg.Results[0].Node.Name)
+	assert.Greater(t, resp.Msg.Results[0].Score, 0.0)
+
+	_, err = s.SearchMetadata(context.Background(), connect.NewRequest(&service.SearchMetadataRequest{Query: "  "}))
+	assert.ErrorIs(t, err, graph.ErrEmptySearchQuery)
+}
+
+func TestSearchNodes(t *testing.T) {
+	s := setupService()
+	for _, name := range []string{"pkg:npm/lodash@4.17.21", "pkg:npm/lodash@3.10.1", "pkg:npm/express@4.19.2"} {
+		_, err := graph.AddNode(s.storage, "library", nil, name)
+		require.NoError(t, err)
+	}
+
+	req := &service.SearchNodesRequest{Purl: &service.PurlFilter{Type: "npm", Name: "lodash"}, PageSize: 1}
+	resp, err := s.SearchNodes(context.Background(), connect.NewRequest(req))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Nodes, 1)
+	assert.Equal(t, "pkg:npm/lodash@3.10.1", resp.Msg.Nodes[0].Name)
+	require.NotEmpty(t, resp.Msg.NextPageToken)
+
+	req.PageToken = resp.Msg.NextPageToken
+	resp, err = s.SearchNodes(context.Background(), connect.NewRequest(req))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Nodes, 1)
+	assert.Equal(t, "pkg:npm/lodash@4.17.21", resp.Msg.Nodes[0].Name)
+	assert.Empty(t, resp.Msg.NextPageToken)
+
+	resp, err = s.SearchNodes(context.Background(), connect.NewRequest(&service.SearchNodesRequest{Regex: `express`}))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Nodes, 1)
+
+	_, err = s.SearchNodes(context.Background(), connect.NewRequest(&service.SearchNodesRequest{Regex: "("}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"github.com/bitbomdev/minefield/cmd/admin/backup"
+	"github.com/bitbomdev/minefield/cmd/admin/canonicalize"
+	"github.com/bitbomdev/minefield/cmd/admin/dedupe"
+	"github.com/bitbomdev/minefield/cmd/admin/migrate"
+	"github.com/bitbomdev/minefield/cmd/admin/restore"
+	"github.com/spf13/cobra"
+)
+
+type options struct{}
+
+func (o *options) AddFlags(_ *cobra.Command) {}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "admin",
+		Short:             "Administrative commands for migrating, backing up, restoring, deduplicating and canonicalizing storage",
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+	}
+
+	o.AddFlags(cmd)
+
+	cmd.AddCommand(migrate.New())
+	cmd.AddCommand(backup.New())
+	cmd.AddCommand(restore.New())
+	cmd.AddCommand(dedupe.New())
+	cmd.AddCommand(canonicalize.New())
+	return cmd
+}
@@ -0,0 +1,89 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+
+	"connectrpc.com/connect"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/storages"
+	"github.com/bitbomdev/minefield/pkg/tools/backup"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type options struct {
+	output  string // Path of the archive to write
+	addr    string // Address of the minefield server
+	storage string // URI of a storage to back up directly instead of going through the server
+
+	adminServiceClient apiv1connect.AdminServiceClient
+	openStorage        func(uri string) (graph.Storage, error)
+}
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.output, "output", "o", "minefield-backup.tar.gz", "Path of the archive to write")
+	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+	cmd.Flags().StringVar(&o.storage, "storage", "", "URI of a storage to back up directly instead of going through the server (e.g. sqlite:///path/to/minefield.db or redis://localhost:6379)")
+}
+
+func (o *options) Run(cmd *cobra.Command, _ []string) error {
+	var buf bytes.Buffer
+	if o.storage != "" {
+		if o.openStorage == nil {
+			o.openStorage = storages.NewStorageFromURI
+		}
+		storage, err := o.openStorage(o.storage)
+		if err != nil {
+			return fmt.Errorf("failed to open storage: %w", err)
+		}
+		if _, err := backup.Write(storage, &buf); err != nil {
+			return fmt.Errorf("failed to back up storage: %w", err)
+		}
+	} else {
+		if o.adminServiceClient == nil {
+			o.adminServiceClient = apiv1connect.NewAdminServiceClient(
+				http.DefaultClient,
+				o.addr,
+			)
+		}
+		res, err := o.adminServiceClient.Backup(cmd.Context(), connect.NewRequest(&emptypb.Empty{}))
+		if err != nil {
+			return fmt.Errorf("failed to back up storage: %w", err)
+		}
+		buf.Write(res.Msg.Archive)
+	}
+
+	manifest, _, err := backup.Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return fmt.Errorf("backup archive failed verification: %w", err)
+	}
+	if err := os.WriteFile(o.output, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", o.output, err)
+	}
+
+	cmd.Printf("Backed up %d nodes, %d caches and %d custom data records to %s\n", manifest.Nodes, manifest.Caches, manifest.CustomData, o.output)
+	return nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "backup",
+		Short:             "Write a portable backup archive of the graph",
+		Long:              "Write a versioned tar.gz archive holding every node, cache, cache stack entry, custom data record and the ID counter, together with a manifest of checksums. The archive can be restored into any storage backend.",
+		Args:              cobra.ExactArgs(0),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
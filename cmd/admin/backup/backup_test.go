@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"connectrpc.com/connect"
+	service "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools/backup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type fakeAdminServiceClient struct {
+	archive []byte
+}
+
+func (f *fakeAdminServiceClient) Backup(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[service.BackupResponse], error) {
+	return connect.NewResponse(&service.BackupResponse{Archive: f.archive}), nil
+}
+
+func (f *fakeAdminServiceClient) Restore(context.Context, *connect.Request[service.RestoreRequest]) (*connect.Response[service.RestoreResponse], error) {
+	return nil, nil
+}
+
+func (f *fakeAdminServiceClient) Dedupe(context.Context, *connect.Request[service.DedupeRequest]) (*connect.Response[service.DedupeResponse], error) {
+	return nil, nil
+}
+
+func TestNew(t *testing.T) {
+	cmd := New()
+	assert.Equal(t, "backup", cmd.Use)
+	assert.True(t, cmd.DisableAutoGenTag)
+	for _, name := range []string{"output", "addr", "storage"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+}
+
+func TestRunWithStorage(t *testing.T) {
+	storage := graph.NewMockStorage()
+	_, err := graph.AddNode(storage, "library", nil, "pkg:generic/a@1.0.0")
+	require.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "backup.tar.gz")
+	o := &options{
+		output:      output,
+		storage:     "sqlite:///minefield.db",
+		openStorage: func(string) (graph.Storage, error) { return storage, nil },
+	}
+	cmd := New()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	require.NoError(t, o.Run(cmd, nil))
+	assert.Contains(t, out.String(), "Backed up 1 nodes")
+
+	f, err := os.Open(output)
+	require.NoError(t, err)
+	defer f.Close()
+	manifest, _, err := backup.Read(f)
+	require.NoError(t, err)
+	assert.Equal(t, 1, manifest.Nodes)
+}
+
+func TestRunWithServer(t *testing.T) {
+	storage := graph.NewMockStorage()
+	_, err := graph.AddNode(storage, "library", nil, "pkg:generic/a@1.0.0")
+	require.NoError(t, err)
+	var archive bytes.Buffer
+	_, err = backup.Write(storage, &archive)
+	require.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "backup.tar.gz")
+	o := &options{
+		output:             output,
+		adminServiceClient: &fakeAdminServiceClient{archive: archive.Bytes()},
+	}
+	cmd := New()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetContext(context.Background())
+	require.NoError(t, o.Run(cmd, nil))
+
+	written, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, archive.Bytes(), written)
+
+	// An archive that fails verification is not written
+	o.adminServiceClient = &fakeAdminServiceClient{archive: []byte("not an archive")}
+	o.output = filepath.Join(t.TempDir(), "corrupt.tar.gz")
+	assert.Error(t, o.Run(cmd, nil))
+	assert.NoFileExists(t, o.output)
+}
@@ -0,0 +1,61 @@
+package canonicalize
+
+import (
+	"fmt"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/storages"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	storage string // URI of the storage to canonicalize
+
+	openStorage func(uri string) (graph.Storage, error)
+}
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.storage, "storage", "", "URI of the storage to canonicalize (e.g. sqlite:///path/to/minefield.db or redis://localhost:6379)")
+}
+
+func (o *options) Run(cmd *cobra.Command, _ []string) error {
+	if o.storage == "" {
+		return fmt.Errorf("--storage is required")
+	}
+	if o.openStorage == nil {
+		o.openStorage = storages.NewStorageFromURI
+	}
+
+	storage, err := o.openStorage(o.storage)
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	merges, err := graph.CanonicalizeNames(storage)
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize node names: %w", err)
+	}
+
+	for _, merge := range merges {
+		cmd.Printf("%s -> %s\n", merge.From, merge.To)
+	}
+	cmd.Printf("Merged %d nodes into the nodes of their canonical names\n", len(merges))
+	if len(merges) > 0 {
+		cmd.Println("Run minefield cache to update the cached dependencies")
+	}
+	return nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "canonicalize",
+		Short:             "Merge nodes named after non-canonical package URLs into the nodes of their canonical names",
+		Long:              "Normalize the package URL names of the nodes stored before names were canonicalized on ingestion, such as pkg:npm/@scope/x or package URLs with unsorted qualifiers. Each node is merged into the node of its canonical name, along with its edges, annotations, fields and edge attributes.",
+		Args:              cobra.ExactArgs(0),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,44 @@
+package canonicalize
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cmd := New()
+	assert.Equal(t, "canonicalize", cmd.Use)
+	assert.True(t, cmd.DisableAutoGenTag)
+	assert.NotNil(t, cmd.Flags().Lookup("storage"))
+}
+
+func TestRun(t *testing.T) {
+	storage := graph.NewMockStorage()
+	// A node stored before names were canonical
+	id, err := storage.GenerateID()
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveNode(&graph.Node{ID: id, Type: "library", Name: "pkg:npm/@scope/x@1.0.0", Children: roaring.New(), Parents: roaring.New()}))
+
+	o := &options{
+		storage:     "sqlite:///minefield.db",
+		openStorage: func(string) (graph.Storage, error) { return storage, nil },
+	}
+	cmd := New()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	require.NoError(t, o.Run(cmd, nil))
+	assert.Equal(t, "pkg:npm/@scope/x@1.0.0 -> pkg:npm/%40scope/x@1.0.0\nMerged 1 nodes into the nodes of their canonical names\nRun minefield cache to update the cached dependencies\n", out.String())
+
+	_, err = storage.NameToID("pkg:npm/%40scope/x@1.0.0")
+	assert.NoError(t, err)
+}
+
+func TestRunRequiresStorage(t *testing.T) {
+	err := (&options{}).Run(New(), nil)
+	assert.ErrorContains(t, err, "--storage is required")
+}
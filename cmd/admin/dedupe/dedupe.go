@@ -0,0 +1,97 @@
+package dedupe
+
+import (
+	"fmt"
+	"net/http"
+
+	"connectrpc.com/connect"
+	service "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/storages"
+	"github.com/bitbomdev/minefield/pkg/tools/ingest"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	addr    string // Address of the minefield server
+	storage string // URI of a storage to deduplicate directly instead of going through the server
+	link    bool   // Link the nodes of each group to the artifact node of their digest
+
+	adminServiceClient apiv1connect.AdminServiceClient
+	openStorage        func(uri string) (graph.Storage, error)
+}
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+	cmd.Flags().StringVar(&o.storage, "storage", "", "URI of a storage to deduplicate directly instead of going through the server (e.g. sqlite:///path/to/minefield.db or redis://localhost:6379)")
+	cmd.Flags().BoolVar(&o.link, "link", false, "Link the nodes of each group to the artifact node of their digest")
+}
+
+func (o *options) Run(cmd *cobra.Command, _ []string) error {
+	var groups []ingest.DuplicateGroup
+	var linked int
+	if o.storage != "" {
+		if o.openStorage == nil {
+			o.openStorage = storages.NewStorageFromURI
+		}
+		storage, err := o.openStorage(o.storage)
+		if err != nil {
+			return fmt.Errorf("failed to open storage: %w", err)
+		}
+		if groups, err = ingest.FindDuplicates(storage); err != nil {
+			return fmt.Errorf("failed to find duplicates: %w", err)
+		}
+		if o.link {
+			if linked, err = ingest.LinkDuplicates(storage, groups); err != nil {
+				return fmt.Errorf("failed to link duplicates: %w", err)
+			}
+		}
+	} else {
+		if o.adminServiceClient == nil {
+			o.adminServiceClient = apiv1connect.NewAdminServiceClient(
+				http.DefaultClient,
+				o.addr,
+			)
+		}
+		res, err := o.adminServiceClient.Dedupe(cmd.Context(), connect.NewRequest(&service.DedupeRequest{Link: o.link}))
+		if err != nil {
+			return fmt.Errorf("failed to find duplicates: %w", err)
+		}
+		for _, group := range res.Msg.Groups {
+			groups = append(groups, ingest.DuplicateGroup{Digest: group.Digest, Nodes: group.Nodes})
+		}
+		linked = int(res.Msg.Linked)
+	}
+
+	for _, group := range groups {
+		cmd.Printf("%s\n", group.Digest)
+		for _, node := range group.Nodes {
+			cmd.Printf("  %s\n", node)
+		}
+	}
+	cmd.Printf("Found %d groups of nodes sharing a digest\n", len(groups))
+	if o.link {
+		cmd.Printf("Linked %d nodes to the artifact nodes of their digests\n", linked)
+	}
+	return nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "dedupe",
+		Short:             "Report nodes that share a SHA-256 or SHA-512 digest, and likely describe the same artifact",
+		Long:              "List the groups of nodes whose SBOM components have the same SHA-256 or SHA-512 digest, such as one artifact described by two tools with different package URLs. With --link, the nodes of each group are linked to the artifact node of their digest, so that its dependents are every name of the artifact.",
+		Args:              cobra.ExactArgs(0),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,67 @@
+package dedupe
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	service "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type fakeAdminServiceClient struct {
+	link bool
+}
+
+func (f *fakeAdminServiceClient) Backup(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[service.BackupResponse], error) {
+	return nil, nil
+}
+
+func (f *fakeAdminServiceClient) Restore(context.Context, *connect.Request[service.RestoreRequest]) (*connect.Response[service.RestoreResponse], error) {
+	return nil, nil
+}
+
+func (f *fakeAdminServiceClient) Dedupe(_ context.Context, req *connect.Request[service.DedupeRequest]) (*connect.Response[service.DedupeResponse], error) {
+	f.link = req.Msg.Link
+	return connect.NewResponse(&service.DedupeResponse{
+		Groups: []*service.DuplicateGroup{{Digest: "sha256:abcd", Nodes: []string{"pkg:generic/a@1.0.0", "pkg:generic/a@1.0.0?type=jar"}}},
+		Linked: 2,
+	}), nil
+}
+
+func TestNew(t *testing.T) {
+	cmd := New()
+	assert.Equal(t, "dedupe", cmd.Use)
+	assert.True(t, cmd.DisableAutoGenTag)
+	for _, name := range []string{"addr", "storage", "link"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+}
+
+func TestRunWithServer(t *testing.T) {
+	client := &fakeAdminServiceClient{}
+	o := &options{link: true, adminServiceClient: client}
+	cmd := New()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	require.NoError(t, o.Run(cmd, nil))
+	assert.True(t, client.link)
+	assert.Equal(t, "sha256:abcd\n  pkg:generic/a@1.0.0\n  pkg:generic/a@1.0.0?type=jar\nFound 1 groups of nodes sharing a digest\nLinked 2 nodes to the artifact nodes of their digests\n", out.String())
+}
+
+func TestRunWithStorage(t *testing.T) {
+	storage := graph.NewMockStorage()
+	o := &options{
+		storage:     "sqlite:///minefield.db",
+		openStorage: func(string) (graph.Storage, error) { return storage, nil },
+	}
+	cmd := New()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	require.NoError(t, o.Run(cmd, nil))
+	assert.Equal(t, "Found 0 groups of nodes sharing a digest\n", out.String())
+}
@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/storages"
+	"github.com/bitbomdev/minefield/pkg/tools/migrate"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	from      string // URI of the source storage
+	to        string // URI of the destination storage
+	batchSize int    // Number of nodes copied at a time
+
+	openStorage func(uri string) (graph.Storage, error)
+}
+
+const (
+	defaultBatchSize = 500
+)
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.from, "from", "", "URI of the source storage (e.g. sqlite:///path/to/minefield.db or redis://localhost:6379)")
+	cmd.Flags().StringVar(&o.to, "to", "", "URI of the destination storage (e.g. sqlite:///path/to/minefield.db or redis://localhost:6379)")
+	cmd.Flags().IntVar(&o.batchSize, "batch-size", defaultBatchSize, "Number of nodes copied at a time")
+}
+
+func (o *options) Run(cmd *cobra.Command, _ []string) error {
+	if o.from == "" || o.to == "" {
+		return fmt.Errorf("both --from and --to are required")
+	}
+	if o.from == o.to {
+		return fmt.Errorf("source and destination storages must be different")
+	}
+	if o.openStorage == nil {
+		o.openStorage = storages.NewStorageFromURI
+	}
+
+	from, err := o.openStorage(o.from)
+	if err != nil {
+		return fmt.Errorf("failed to open source storage: %w", err)
+	}
+	to, err := o.openStorage(o.to)
+	if err != nil {
+		return fmt.Errorf("failed to open destination storage: %w", err)
+	}
+
+	result, err := migrate.Migrate(from, to, migrate.Options{
+		SourceName: o.from,
+		BatchSize:  o.batchSize,
+		Progress: func(phase migrate.Phase, done, total int) {
+			// Clear the line by overwriting with spaces
+			fmt.Printf("\r\033[1;36m%-80s\033[0m", " ")
+			fmt.Printf("\r\033[1;36mMigrated %d/%d\033[0m | \033[1;34m%s\033[0m", done, total, phase)
+		},
+	})
+	fmt.Println()
+	if err != nil {
+		return fmt.Errorf("failed to migrate storage: %w", err)
+	}
+
+	if result.Resumed {
+		cmd.Println("Resumed a previous migration")
+	}
+	cmd.Printf("Migrated %d nodes, %d caches, %d cache stack entries and %d custom data records (ID counter at %d)\n",
+		result.Destination.Nodes, result.Destination.Caches, result.Destination.CacheStack, result.Destination.CustomData, result.Destination.IDCounter)
+	return nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "migrate",
+		Short:             "Copy the whole graph from one storage backend to another",
+		Long:              "Copy every node, cache, cache stack entry, custom data record and the ID counter from one storage backend to another, verifying the counts afterwards. An interrupted migration resumes when run again with the same arguments.",
+		Args:              cobra.ExactArgs(0),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cmd := New()
+	assert.Equal(t, "migrate", cmd.Use)
+	assert.True(t, cmd.DisableAutoGenTag)
+	assert.NotNil(t, cmd.RunE)
+
+	for _, name := range []string{"from", "to", "batch-size"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+	assert.Equal(t, "500", cmd.Flags().Lookup("batch-size").DefValue)
+}
+
+func TestRun(t *testing.T) {
+	source := graph.NewMockStorage()
+	_, err := graph.AddNode(source, "library", nil, "pkg:generic/a@1.0.0")
+	require.NoError(t, err)
+	destination := graph.NewMockStorage()
+
+	o := &options{
+		from:      "sqlite:///source.db",
+		to:        "redis://localhost:6379",
+		batchSize: 10,
+		openStorage: func(uri string) (graph.Storage, error) {
+			if uri == "sqlite:///source.db" {
+				return source, nil
+			}
+			return destination, nil
+		},
+	}
+	cmd := New()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	require.NoError(t, o.Run(cmd, nil))
+	assert.Contains(t, out.String(), "Migrated 1 nodes")
+
+	keys, err := destination.GetAllKeys()
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{1}, keys)
+}
+
+func TestRunValidatesFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		o       *options
+		wantErr string
+	}{
+		{name: "missing from", o: &options{to: "redis://localhost:6379"}, wantErr: "both --from and --to are required"},
+		{name: "same storage", o: &options{from: "redis://a", to: "redis://a"}, wantErr: "source and destination storages must be different"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.o.Run(New(), nil)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
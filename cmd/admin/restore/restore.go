@@ -0,0 +1,90 @@
+package restore
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+
+	"connectrpc.com/connect"
+	service "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/storages"
+	"github.com/bitbomdev/minefield/pkg/tools/backup"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	addr    string // Address of the minefield server
+	storage string // URI of a storage to restore into directly instead of going through the server
+
+	adminServiceClient apiv1connect.AdminServiceClient
+	openStorage        func(uri string) (graph.Storage, error)
+}
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+	cmd.Flags().StringVar(&o.storage, "storage", "", "URI of a storage to restore into directly instead of going through the server (e.g. sqlite:///path/to/minefield.db or redis://localhost:6379)")
+}
+
+func (o *options) Run(cmd *cobra.Command, args []string) error {
+	archive, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", args[0], err)
+	}
+
+	var nodes, caches, customData int
+	if o.storage != "" {
+		if o.openStorage == nil {
+			o.openStorage = storages.NewStorageFromURI
+		}
+		storage, err := o.openStorage(o.storage)
+		if err != nil {
+			return fmt.Errorf("failed to open storage: %w", err)
+		}
+		manifest, err := backup.Restore(storage, bytes.NewReader(archive))
+		if err != nil {
+			return fmt.Errorf("failed to restore storage: %w", err)
+		}
+		nodes, caches, customData = manifest.Nodes, manifest.Caches, manifest.CustomData
+	} else {
+		// Verify the archive locally so a corrupt file is never sent to the server
+		if _, _, err := backup.Read(bytes.NewReader(archive)); err != nil {
+			return fmt.Errorf("invalid backup archive: %w", err)
+		}
+		if o.adminServiceClient == nil {
+			o.adminServiceClient = apiv1connect.NewAdminServiceClient(
+				http.DefaultClient,
+				o.addr,
+			)
+		}
+		res, err := o.adminServiceClient.Restore(cmd.Context(), connect.NewRequest(&service.RestoreRequest{Archive: archive}))
+		if err != nil {
+			return fmt.Errorf("failed to restore storage: %w", err)
+		}
+		nodes, caches, customData = int(res.Msg.Nodes), int(res.Msg.Caches), int(res.Msg.CustomData)
+	}
+
+	cmd.Printf("Restored %d nodes, %d caches and %d custom data records from %s\n", nodes, caches, customData, args[0])
+	return nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "restore [archive]",
+		Short:             "Restore a backup archive into an empty storage",
+		Long:              "Verify the checksums of a backup archive written by `minefield admin backup` and load it into an empty storage. Node IDs and the ID counter are kept as they were in the backed up graph.",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,96 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"connectrpc.com/connect"
+	service "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools/backup"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type fakeAdminServiceClient struct {
+	restored []byte
+}
+
+func (f *fakeAdminServiceClient) Backup(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[service.BackupResponse], error) {
+	return nil, nil
+}
+
+func (f *fakeAdminServiceClient) Restore(_ context.Context, req *connect.Request[service.RestoreRequest]) (*connect.Response[service.RestoreResponse], error) {
+	f.restored = req.Msg.Archive
+	return connect.NewResponse(&service.RestoreResponse{Nodes: 1}), nil
+}
+
+func (f *fakeAdminServiceClient) Dedupe(context.Context, *connect.Request[service.DedupeRequest]) (*connect.Response[service.DedupeResponse], error) {
+	return nil, nil
+}
+
+func writeArchive(t *testing.T) string {
+	t.Helper()
+	storage := graph.NewMockStorage()
+	_, err := graph.AddNode(storage, "library", nil, "pkg:generic/a@1.0.0")
+	require.NoError(t, err)
+	var archive bytes.Buffer
+	_, err = backup.Write(storage, &archive)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+	require.NoError(t, os.WriteFile(path, archive.Bytes(), 0o644))
+	return path
+}
+
+func TestNew(t *testing.T) {
+	cmd := New()
+	assert.Equal(t, "restore [archive]", cmd.Use)
+	assert.True(t, cmd.DisableAutoGenTag)
+	for _, name := range []string{"addr", "storage"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+}
+
+func TestRunWithStorage(t *testing.T) {
+	path := writeArchive(t)
+	storage := graph.NewMockStorage()
+	o := &options{
+		storage:     "sqlite:///minefield.db",
+		openStorage: func(string) (graph.Storage, error) { return storage, nil },
+	}
+	cmd := New()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	require.NoError(t, o.Run(cmd, []string{path}))
+	assert.Contains(t, out.String(), "Restored 1 nodes")
+
+	node, err := storage.GetNode(1)
+	require.NoError(t, err)
+	assert.Equal(t, "pkg:generic/a@1.0.0", node.Name)
+}
+
+func TestRunWithServer(t *testing.T) {
+	path := writeArchive(t)
+	client := &fakeAdminServiceClient{}
+	o := &options{adminServiceClient: client}
+	cmd := New()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetContext(context.Background())
+	require.NoError(t, o.Run(cmd, []string{path}))
+
+	archive, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, archive, client.restored)
+
+	// Corrupt archives are rejected before reaching the server
+	corrupt := filepath.Join(t.TempDir(), "corrupt.tar.gz")
+	require.NoError(t, os.WriteFile(corrupt, []byte("not an archive"), 0o644))
+	client.restored = nil
+	assert.Error(t, o.Run(cmd, []string{corrupt}))
+	assert.Nil(t, client.restored)
+}
@@ -0,0 +1,128 @@
+package annotate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"connectrpc.com/connect"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/spf13/cobra"
+)
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+type options struct {
+	addr string // Address of the minefield server
+
+	annotationServiceClient apiv1connect.AnnotationServiceClient
+}
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+}
+
+func (o *options) client() apiv1connect.AnnotationServiceClient {
+	if o.annotationServiceClient == nil {
+		o.annotationServiceClient = apiv1connect.NewAnnotationServiceClient(
+			http.DefaultClient,
+			o.addr,
+		)
+	}
+	return o.annotationServiceClient
+}
+
+// set attaches every key=value pair in args[1:] to the node named args[0].
+func (o *options) set(cmd *cobra.Command, args []string) error {
+	for _, pair := range args[1:] {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid annotation %q: must be key=value", pair)
+		}
+		req := connect.NewRequest(&apiv1.SetAnnotationRequest{Name: args[0], Key: key, Value: value})
+		if _, err := o.client().SetAnnotation(ctx(cmd), req); err != nil {
+			return fmt.Errorf("failed to set annotation %s: %w", key, err)
+		}
+	}
+	cmd.Printf("Annotated %s\n", args[0])
+	return nil
+}
+
+// remove removes every key in args[1:] from the node named args[0].
+func (o *options) remove(cmd *cobra.Command, args []string) error {
+	for _, key := range args[1:] {
+		req := connect.NewRequest(&apiv1.RemoveAnnotationRequest{Name: args[0], Key: key})
+		if _, err := o.client().RemoveAnnotation(ctx(cmd), req); err != nil {
+			return fmt.Errorf("failed to remove annotation %s: %w", key, err)
+		}
+	}
+	cmd.Printf("Removed %d annotations from %s\n", len(args)-1, args[0])
+	return nil
+}
+
+// get prints the annotations of the node named args[0] as sorted key=value lines.
+func (o *options) get(cmd *cobra.Command, args []string) error {
+	res, err := o.client().GetAnnotations(ctx(cmd), connect.NewRequest(&apiv1.GetAnnotationsRequest{Name: args[0]}))
+	if err != nil {
+		return fmt.Errorf("failed to get annotations: %w", err)
+	}
+	keys := make([]string, 0, len(res.Msg.Annotations))
+	for key := range res.Msg.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cmd.Printf("%s=%s\n", key, res.Msg.Annotations[key])
+	}
+	return nil
+}
+
+func ctx(cmd *cobra.Command) context.Context {
+	if cmd.Context() != nil {
+		return cmd.Context()
+	}
+	return context.Background()
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:   "annotate",
+		Short: "Attach key/value annotations, such as owner=team-payments, to nodes",
+		Long: `Attach key/value annotations, such as owner=team-payments, to nodes.
+
+Annotations can be used to filter queries, for example:
+  minefield query custom "dependents library pkg:generic/lib@1.0.0 where tag.owner = \"team-payments\""`,
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:               "set [node name] [key=value...]",
+		Short:             "Set annotations on a node",
+		Args:              cobra.MinimumNArgs(2),
+		RunE:              o.set,
+		DisableAutoGenTag: true,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:               "remove [node name] [key...]",
+		Short:             "Remove annotations from a node",
+		Args:              cobra.MinimumNArgs(2),
+		RunE:              o.remove,
+		DisableAutoGenTag: true,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:               "get [node name]",
+		Short:             "Print the annotations of a node",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.get,
+		DisableAutoGenTag: true,
+	})
+	return cmd
+}
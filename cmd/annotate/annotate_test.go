@@ -0,0 +1,77 @@
+package annotate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeAnnotationServiceClient keeps annotations of a single node in memory.
+type fakeAnnotationServiceClient struct {
+	annotations map[string]string
+}
+
+func (f *fakeAnnotationServiceClient) SetAnnotation(_ context.Context, req *connect.Request[apiv1.SetAnnotationRequest]) (*connect.Response[emptypb.Empty], error) {
+	f.annotations[req.Msg.Key] = req.Msg.Value
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (f *fakeAnnotationServiceClient) RemoveAnnotation(_ context.Context, req *connect.Request[apiv1.RemoveAnnotationRequest]) (*connect.Response[emptypb.Empty], error) {
+	delete(f.annotations, req.Msg.Key)
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (f *fakeAnnotationServiceClient) GetAnnotations(context.Context, *connect.Request[apiv1.GetAnnotationsRequest]) (*connect.Response[apiv1.GetAnnotationsResponse], error) {
+	return connect.NewResponse(&apiv1.GetAnnotationsResponse{Annotations: f.annotations}), nil
+}
+
+func TestAnnotate(t *testing.T) {
+	client := &fakeAnnotationServiceClient{annotations: map[string]string{}}
+	run := func(args ...string) (string, error) {
+		cmd := New()
+		o := &options{annotationServiceClient: client}
+		out := &bytes.Buffer{}
+		cmd.SetOut(out)
+		var err error
+		switch args[0] {
+		case "set":
+			err = o.set(cmd, args[1:])
+		case "remove":
+			err = o.remove(cmd, args[1:])
+		case "get":
+			err = o.get(cmd, args[1:])
+		}
+		return out.String(), err
+	}
+
+	_, err := run("set", "pkg:generic/lib@1.0.0", "owner=team-payments", "internal=true")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team-payments", "internal": "true"}, client.annotations)
+
+	_, err = run("remove", "pkg:generic/lib@1.0.0", "internal")
+	require.NoError(t, err)
+
+	out, err := run("get", "pkg:generic/lib@1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "owner=team-payments\n", out)
+
+	_, err = run("set", "pkg:generic/lib@1.0.0", "owner")
+	assert.ErrorContains(t, err, "must be key=value")
+}
+
+func TestNew(t *testing.T) {
+	cmd := New()
+	assert.Equal(t, "annotate", cmd.Use)
+	assert.NotNil(t, cmd.PersistentFlags().Lookup("addr"))
+	var names []string
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"set", "remove", "get"}, names)
+}
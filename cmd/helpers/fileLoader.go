@@ -145,3 +145,11 @@ func processZipFile(filePath string) ([]Data, error) {
 
 	return LoadDataFromPath(tempDir)
 }
+
+// ReadInput reads a file, or stdin when the path is "-".
+func ReadInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
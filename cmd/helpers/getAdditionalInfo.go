@@ -51,9 +51,14 @@ func ComputeAdditionalInfo(node *apiv1.Node) string {
 						}
 					}
 				}
+				var details []string
+				if cvss := ingest.HighestCVSS(vulnerability); cvss != nil {
+					details = append(details, fmt.Sprintf("Severity: %s (CVSS %s: %.1f)", strings.ToUpper(cvss.Rating), cvss.Version, cvss.BaseScore))
+				}
 				if len(fixedInfo) > 0 {
-					additionalInfo = "Affected Package PURL (Package URL) : Fixed Version\n\n" + strings.Join(fixedInfo, "\n")
+					details = append(details, "Affected Package PURL (Package URL) : Fixed Version\n\n"+strings.Join(fixedInfo, "\n"))
 				}
+				additionalInfo = strings.Join(details, "\n\n")
 			}
 		}
 	}
@@ -67,6 +67,35 @@ func TestComputeAdditionalInfo(t *testing.T) {
 			},
 			expected: "Affected Package PURL (Package URL) : Fixed Version\n\npkg:npm/example@1.0.0 : 1.0.1",
 		},
+		{
+			name: "Vulnerability with severity",
+			input: &apiv1.Node{
+				Type: tools.VulnerabilityType,
+				Metadata: mustMarshal(ingest.Vulnerability{
+					Severity: []ingest.Severity{
+						{
+							Type:  ingest.SeverityTypeCVSSv3,
+							Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
+						},
+					},
+					Affected: []ingest.Affected{
+						{
+							Package: ingest.Package{
+								Purl: "pkg:npm/example@1.0.0",
+							},
+							Ranges: []ingest.Range{
+								{
+									Events: []ingest.Event{
+										{Fixed: "1.0.1"},
+									},
+								},
+							},
+						},
+					},
+				}),
+			},
+			expected: "Severity: HIGH (CVSS 3.1: 7.5)\n\nAffected Package PURL (Package URL) : Fixed Version\n\npkg:npm/example@1.0.0 : 1.0.1",
+		},
 		{
 			name: "Node with nil metadata",
 			input: &apiv1.Node{
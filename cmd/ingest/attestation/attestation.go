@@ -0,0 +1,64 @@
+package attestation
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/bitbomdev/minefield/cmd/helpers"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	addr                string // Address of the minefield server
+	ingestServiceClient apiv1connect.IngestServiceClient
+}
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+}
+
+func (o *options) Run(_ *cobra.Command, args []string) error {
+	if o.ingestServiceClient == nil {
+		o.ingestServiceClient = apiv1connect.NewIngestServiceClient(
+			http.DefaultClient,
+			o.addr,
+		)
+	}
+	data, err := helpers.ReadInput(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read attestation: %w", err)
+	}
+
+	req := connect.NewRequest(&apiv1.IngestAttestationRequest{
+		Attestation: data,
+	})
+	res, err := o.ingestServiceClient.IngestAttestation(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("failed to ingest attestation: %w", err)
+	}
+
+	fmt.Printf("Ingested %d in-toto statements\n", res.Msg.Ingested)
+	return nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "attestation [path to DSSE envelopes of in-toto statements]",
+		Short:             "Ingest SLSA provenance and SBOM attestations, adding the builds of artifacts with their builders, source repositories and materials",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,29 @@
+package attestation
+
+import (
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	cmd := New()
+
+	if cmd.Use != "attestation [path to DSSE envelopes of in-toto statements]" {
+		t.Errorf("expected Use to be 'attestation [path to DSSE envelopes of in-toto statements]', got %s", cmd.Use)
+	}
+
+	if cmd.Args == nil || cmd.Args(nil, []string{"arg1"}) != nil {
+		t.Errorf("expected Args to be cobra.ExactArgs(1)")
+	}
+
+	if cmd.Flags().Lookup("addr") == nil {
+		t.Errorf("expected addr flag to be set")
+	}
+
+	if cmd.DisableAutoGenTag != true {
+		t.Errorf("expected DisableAutoGenTag to be true")
+	}
+
+	if cmd.RunE == nil {
+		t.Errorf("expected RunE to be set")
+	}
+}
@@ -0,0 +1,64 @@
+package epss
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"connectrpc.com/connect"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	addr                string // Address of the minefield server
+	ingestServiceClient apiv1connect.IngestServiceClient
+}
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+}
+
+func (o *options) Run(_ *cobra.Command, args []string) error {
+	if o.ingestServiceClient == nil {
+		o.ingestServiceClient = apiv1connect.NewIngestServiceClient(
+			http.DefaultClient,
+			o.addr,
+		)
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read EPSS scores: %w", err)
+	}
+
+	req := connect.NewRequest(&apiv1.IngestEPSSRequest{
+		Epss: data,
+	})
+	res, err := o.ingestServiceClient.IngestEPSS(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("failed to ingest EPSS scores: %w", err)
+	}
+
+	fmt.Printf("Ingested %d EPSS scores\n", res.Msg.Ingested)
+	return nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "epss [path to epss scores csv]",
+		Short:             "Ingest the EPSS scores CSV, gzipped or not, into the epss fields of vulnerabilities",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,29 @@
+package epss
+
+import (
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	cmd := New()
+
+	if cmd.Use != "epss [path to epss scores csv]" {
+		t.Errorf("expected Use to be 'epss [path to epss scores csv]', got %s", cmd.Use)
+	}
+
+	if cmd.Args == nil || cmd.Args(nil, []string{"arg1"}) != nil {
+		t.Errorf("expected Args to be cobra.ExactArgs(1)")
+	}
+
+	if cmd.Flags().Lookup("addr") == nil {
+		t.Errorf("expected addr flag to be set")
+	}
+
+	if cmd.DisableAutoGenTag != true {
+		t.Errorf("expected DisableAutoGenTag to be true")
+	}
+
+	if cmd.RunE == nil {
+		t.Errorf("expected RunE to be set")
+	}
+}
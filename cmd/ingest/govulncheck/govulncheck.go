@@ -0,0 +1,63 @@
+package govulncheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"github.com/bitbomdev/minefield/cmd/helpers"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	addr                string // Address of the minefield server
+	ingestServiceClient apiv1connect.IngestServiceClient
+}
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+}
+
+func (o *options) Run(_ *cobra.Command, args []string) error {
+	if o.ingestServiceClient == nil {
+		o.ingestServiceClient = apiv1connect.NewIngestServiceClient(
+			http.DefaultClient,
+			o.addr,
+		)
+	}
+	data, err := helpers.ReadInput(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read govulncheck output: %w", err)
+	}
+
+	req := connect.NewRequest(&apiv1.IngestGovulncheckRequest{
+		Output: data,
+	})
+	if _, err := o.ingestServiceClient.IngestGovulncheck(context.Background(), req); err != nil {
+		return fmt.Errorf("failed to ingest govulncheck output: %w", err)
+	}
+
+	fmt.Println("Govulncheck output ingested successfully")
+	return nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "govulncheck [path to govulncheck -json output]",
+		Short:             "Ingest the output of govulncheck -json, or - for stdin, marking which vulnerabilities are reachable",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
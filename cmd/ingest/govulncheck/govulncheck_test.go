@@ -0,0 +1,29 @@
+package govulncheck
+
+import (
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	cmd := New()
+
+	if cmd.Use != "govulncheck [path to govulncheck -json output]" {
+		t.Errorf("expected Use to be 'govulncheck [path to govulncheck -json output]', got %s", cmd.Use)
+	}
+
+	if cmd.Args == nil || cmd.Args(nil, []string{"arg1"}) != nil {
+		t.Errorf("expected Args to be cobra.ExactArgs(1)")
+	}
+
+	if cmd.Flags().Lookup("addr") == nil {
+		t.Errorf("expected addr flag to be set")
+	}
+
+	if cmd.DisableAutoGenTag != true {
+		t.Errorf("expected DisableAutoGenTag to be true")
+	}
+
+	if cmd.RunE == nil {
+		t.Errorf("expected RunE to be set")
+	}
+}
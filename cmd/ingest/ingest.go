@@ -1,9 +1,16 @@
 package ingest
 
 import (
+	"github.com/bitbomdev/minefield/cmd/ingest/attestation"
+	"github.com/bitbomdev/minefield/cmd/ingest/epss"
+	"github.com/bitbomdev/minefield/cmd/ingest/gomodgraph"
+	"github.com/bitbomdev/minefield/cmd/ingest/govulncheck"
+	"github.com/bitbomdev/minefield/cmd/ingest/kev"
+	"github.com/bitbomdev/minefield/cmd/ingest/lockfile"
 	"github.com/bitbomdev/minefield/cmd/ingest/osv"
 	"github.com/bitbomdev/minefield/cmd/ingest/sbom"
 	"github.com/bitbomdev/minefield/cmd/ingest/scorecard"
+	"github.com/bitbomdev/minefield/cmd/ingest/vex"
 	"github.com/spf13/cobra"
 )
 
@@ -23,5 +30,12 @@ func New() *cobra.Command {
 	cmd.AddCommand(osv.New())
 	cmd.AddCommand(sbom.New())
 	cmd.AddCommand(scorecard.New())
+	cmd.AddCommand(vex.New())
+	cmd.AddCommand(lockfile.New())
+	cmd.AddCommand(gomodgraph.New())
+	cmd.AddCommand(govulncheck.New())
+	cmd.AddCommand(epss.New())
+	cmd.AddCommand(kev.New())
+	cmd.AddCommand(attestation.New())
 	return cmd
 }
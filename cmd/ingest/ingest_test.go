@@ -23,6 +23,13 @@ func TestNewCommand(t *testing.T) {
 		"osv [path to vulnerability file/dir]",
 		"sbom [path to sbom file/dir]",
 		"scorecard [path to scorecard file/dir]",
+		"vex [path to vex file/dir]",
+		"lockfile [path to lockfile/dir]",
+		"gomodgraph [path to go mod graph output]",
+		"govulncheck [path to govulncheck -json output]",
+		"epss [path to epss scores csv]",
+		"kev [path to known exploited vulnerabilities json]",
+		"attestation [path to DSSE envelopes of in-toto statements]",
 	}
 	assert.ElementsMatch(t, expectedSubcommands, subcommandUses, "Subcommands should match expected list")
 }
@@ -0,0 +1,64 @@
+package kev
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"connectrpc.com/connect"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	addr                string // Address of the minefield server
+	ingestServiceClient apiv1connect.IngestServiceClient
+}
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+}
+
+func (o *options) Run(_ *cobra.Command, args []string) error {
+	if o.ingestServiceClient == nil {
+		o.ingestServiceClient = apiv1connect.NewIngestServiceClient(
+			http.DefaultClient,
+			o.addr,
+		)
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read KEV catalog: %w", err)
+	}
+
+	req := connect.NewRequest(&apiv1.IngestKEVRequest{
+		Kev: data,
+	})
+	res, err := o.ingestServiceClient.IngestKEV(context.Background(), req)
+	if err != nil {
+		return fmt.Errorf("failed to ingest KEV catalog: %w", err)
+	}
+
+	fmt.Printf("Ingested %d known exploited vulnerabilities\n", res.Msg.Ingested)
+	return nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "kev [path to known exploited vulnerabilities json]",
+		Short:             "Ingest the CISA Known Exploited Vulnerabilities catalog into the kev fields of vulnerabilities",
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,126 @@
+package lockfile
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"connectrpc.com/connect"
+	"github.com/bitbomdev/minefield/cmd/helpers"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/bitbomdev/minefield/pkg/tools/ingest"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	addr                string // Address of the minefield server
+	ingestServiceClient apiv1connect.IngestServiceClient
+}
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+// skippedDirs hold installed dependencies, whose own lockfiles aren't part of the project.
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+}
+
+func (o *options) Run(_ *cobra.Command, args []string) error {
+	if o.ingestServiceClient == nil {
+		o.ingestServiceClient = apiv1connect.NewIngestServiceClient(
+			http.DefaultClient,
+			o.addr,
+		)
+	}
+	paths, err := findLockfiles(args[0])
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no lockfiles found in %s", args[0])
+	}
+
+	for index, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read lockfile %s: %w", path, err)
+		}
+		req := connect.NewRequest(&apiv1.IngestLockfileRequest{
+			Filename: filepath.Base(path),
+			Lockfile: data,
+		})
+		if _, err := o.ingestServiceClient.IngestLockfile(context.Background(), req); err != nil {
+			return fmt.Errorf("failed to ingest lockfile %s: %w", path, err)
+		}
+		// Clear the line by overwriting with spaces
+		fmt.Printf("\r\033[1;36m%-80s\033[0m", " ")
+		fmt.Printf("\r\033[1;36mIngested %d/%d lockfiles\033[0m | \033[1;34m%s\033[0m", index+1, len(paths), helpers.TruncateString(path, 50))
+	}
+
+	fmt.Println("\nLockfiles ingested successfully")
+	return nil
+}
+
+// findLockfiles returns the path itself when it's a file, and the supported lockfiles under it when it's a
+// directory.
+func findLockfiles(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("error accessing path %s: %w", root, err)
+	}
+	if !info.IsDir() {
+		if !ingest.IsLockfile(root) {
+			return nil, fmt.Errorf("unsupported lockfile %s", root)
+		}
+		return []string{root}, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != root && skippedDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ingest.IsLockfile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lockfiles in %s: %w", root, err)
+	}
+	return paths, nil
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:   "lockfile [path to lockfile/dir]",
+		Short: "Ingest lockfiles of projects that can't produce an SBOM",
+		Long: `Ingest go.mod, go.sum, package-lock.json, yarn.lock, Cargo.lock, poetry.lock, Gemfile.lock and
+requirements.txt files into library nodes, with edges for the dependencies they record. Directories are searched
+for lockfiles, skipping node_modules and vendor.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
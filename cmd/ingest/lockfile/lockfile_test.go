@@ -0,0 +1,46 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cmd := New()
+	assert.Equal(t, "lockfile [path to lockfile/dir]", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("addr"))
+	assert.NoError(t, cmd.Args(cmd, []string{"go.mod"}))
+	assert.Error(t, cmd.Args(cmd, nil))
+	assert.True(t, cmd.DisableAutoGenTag)
+}
+
+func TestFindLockfiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{
+		"go.mod",
+		"README.md",
+		"web/package-lock.json",
+		"web/node_modules/debug/package-lock.json",
+		"vendor/github.com/google/uuid/go.mod",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte("{}"), 0o644))
+	}
+
+	paths, err := findLockfiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "go.mod"), filepath.Join(dir, "web/package-lock.json")}, paths)
+
+	paths, err = findLockfiles(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "go.mod")}, paths)
+
+	_, err = findLockfiles(filepath.Join(dir, "README.md"))
+	assert.Error(t, err)
+	_, err = findLockfiles(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
 
 	"connectrpc.com/connect"
 	"github.com/bitbomdev/minefield/cmd/helpers"
@@ -14,16 +16,20 @@ import (
 
 type options struct {
 	addr                string // Address of the minefield server
+	batchSize           int    // Number of advisories sent per request
 	ingestServiceClient apiv1connect.IngestServiceClient
 }
 
 const (
-	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+	DefaultAddr      = "http://localhost:8089" // Default address of the minefield server
+	DefaultBatchSize = 500                     // Default number of advisories sent per request
 )
 
 func (o *options) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+	cmd.Flags().IntVar(&o.batchSize, "batch-size", DefaultBatchSize, "Number of vulnerabilities sent to the server per request")
 }
+
 func (o *options) Run(_ *cobra.Command, args []string) error {
 	if o.ingestServiceClient == nil {
 		o.ingestServiceClient = apiv1connect.NewIngestServiceClient(
@@ -31,22 +37,48 @@ func (o *options) Run(_ *cobra.Command, args []string) error {
 			o.addr,
 		)
 	}
+	if o.batchSize <= 0 {
+		return fmt.Errorf("batch size must be positive, got %d", o.batchSize)
+	}
 	vulnsPath := args[0]
+
+	// A zip archive, such as the all.zip of an OSV ecosystem, is ingested by the server in one go
+	if filepath.Ext(vulnsPath) == ".zip" {
+		archive, err := os.ReadFile(vulnsPath)
+		if err != nil {
+			return fmt.Errorf("failed to read vulnerability archive: %w", err)
+		}
+		req := connect.NewRequest(&apiv1.IngestVulnerabilitiesRequest{
+			Archive: archive,
+		})
+		res, err := o.ingestServiceClient.IngestVulnerabilities(context.Background(), req)
+		if err != nil {
+			return fmt.Errorf("failed to ingest vulnerabilities: %w", err)
+		}
+		fmt.Printf("Ingested %d vulnerabilities from %s\n", res.Msg.Ingested, helpers.TruncateString(vulnsPath, 50))
+		return nil
+	}
+
 	// Ingest vulnerabilities
 	result, err := helpers.LoadDataFromPath(vulnsPath)
 	if err != nil {
 		return fmt.Errorf("failed to load vulnerabilities: %w", err)
 	}
-	for index, data := range result {
-		req := connect.NewRequest(&apiv1.IngestVulnerabilityRequest{
-			Vulnerability: data.Data,
+	for start := 0; start < len(result); start += o.batchSize {
+		batch := result[start:min(start+o.batchSize, len(result))]
+		vulnerabilities := make([][]byte, len(batch))
+		for i, data := range batch {
+			vulnerabilities[i] = data.Data
+		}
+		req := connect.NewRequest(&apiv1.IngestVulnerabilitiesRequest{
+			Vulnerabilities: vulnerabilities,
 		})
-		if _, err := o.ingestServiceClient.IngestVulnerability(context.Background(), req); err != nil {
+		if _, err := o.ingestServiceClient.IngestVulnerabilities(context.Background(), req); err != nil {
 			return fmt.Errorf("failed to ingest vulnerabilities: %w", err)
 		}
 		// Clear the line by overwriting with spaces
 		fmt.Printf("\r\033[1;36m%-80s\033[0m", " ")
-		fmt.Printf("\r\033[K\033[1;36mIngested %d/%d vulnerabilities\033[0m | \033[1;34mCurrent: %s\033[0m", index+1, len(result), helpers.TruncateString(data.Path, 50))
+		fmt.Printf("\r\033[K\033[1;36mIngested %d/%d vulnerabilities\033[0m | \033[1;34mCurrent: %s\033[0m", start+len(batch), len(result), helpers.TruncateString(batch[len(batch)-1].Path, 50))
 	}
 	fmt.Println("\nVulnerabilities ingested successfully")
 	return nil
@@ -1,10 +1,19 @@
 package osv
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/stretchr/testify/assert"
+	"connectrpc.com/connect"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 func TestNew(t *testing.T) {
@@ -18,7 +27,7 @@ func TestNew(t *testing.T) {
 			name:          "creates command with correct configuration",
 			wantUse:       "osv [path to vulnerability file/dir]",
 			wantShort:     "Graph vulnerability data into the graph, and connect it to existing library nodes",
-			wantFlagCount: 1, // Should have the "addr" flag
+			wantFlagCount: 2, // Should have the "addr" and "batch-size" flags
 		},
 	}
 
@@ -45,6 +54,98 @@ func TestNew(t *testing.T) {
 			assert.NotNil(t, addrFlag)
 			assert.Equal(t, "string", addrFlag.Value.Type())
 			assert.Equal(t, DefaultAddr, addrFlag.DefValue)
+
+			batchSizeFlag := flags.Lookup("batch-size")
+			assert.NotNil(t, batchSizeFlag)
+			assert.Equal(t, "int", batchSizeFlag.Value.Type())
+			assert.Equal(t, "500", batchSizeFlag.DefValue)
 		})
 	}
 }
+
+type mockIngestServiceClient struct {
+	requests []*apiv1.IngestVulnerabilitiesRequest
+	err      error
+}
+
+func (m *mockIngestServiceClient) IngestSBOM(ctx context.Context, req *connect.Request[apiv1.IngestSBOMRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockIngestServiceClient) IngestVulnerability(ctx context.Context, req *connect.Request[apiv1.IngestVulnerabilityRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockIngestServiceClient) IngestVulnerabilities(ctx context.Context, req *connect.Request[apiv1.IngestVulnerabilitiesRequest]) (*connect.Response[apiv1.IngestVulnerabilitiesResponse], error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.requests = append(m.requests, req.Msg)
+	return connect.NewResponse(&apiv1.IngestVulnerabilitiesResponse{Ingested: int32(len(req.Msg.Vulnerabilities))}), nil
+}
+
+func (m *mockIngestServiceClient) IngestScorecard(ctx context.Context, req *connect.Request[apiv1.IngestScorecardRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockIngestServiceClient) IngestVEX(ctx context.Context, req *connect.Request[apiv1.IngestVEXRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockIngestServiceClient) IngestLockfile(ctx context.Context, req *connect.Request[apiv1.IngestLockfileRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockIngestServiceClient) IngestGoModGraph(ctx context.Context, req *connect.Request[apiv1.IngestGoModGraphRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockIngestServiceClient) IngestGovulncheck(ctx context.Context, req *connect.Request[apiv1.IngestGovulncheckRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockIngestServiceClient) IngestAttestation(ctx context.Context, req *connect.Request[apiv1.IngestAttestationRequest]) (*connect.Response[apiv1.IngestAttestationResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockIngestServiceClient) IngestEPSS(ctx context.Context, req *connect.Request[apiv1.IngestEPSSRequest]) (*connect.Response[apiv1.IngestEPSSResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockIngestServiceClient) IngestKEV(ctx context.Context, req *connect.Request[apiv1.IngestKEVRequest]) (*connect.Response[apiv1.IngestKEVResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestRun(t *testing.T) {
+	vulnsDir := "../../../testdata/osv-vulns"
+
+	t.Run("sends the advisories in batches", func(t *testing.T) {
+		client := &mockIngestServiceClient{}
+		o := &options{batchSize: 2, ingestServiceClient: client}
+		require.NoError(t, o.Run(&cobra.Command{}, []string{vulnsDir}))
+		require.Len(t, client.requests, 2)
+		assert.Len(t, client.requests[0].Vulnerabilities, 2)
+		assert.Len(t, client.requests[1].Vulnerabilities, 1)
+	})
+
+	t.Run("sends a zip archive as is", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "all.zip")
+		require.NoError(t, os.WriteFile(archivePath, []byte("zip data"), 0o600))
+		client := &mockIngestServiceClient{}
+		o := &options{batchSize: DefaultBatchSize, ingestServiceClient: client}
+		require.NoError(t, o.Run(&cobra.Command{}, []string{archivePath}))
+		require.Len(t, client.requests, 1)
+		assert.Equal(t, []byte("zip data"), client.requests[0].Archive)
+		assert.Empty(t, client.requests[0].Vulnerabilities)
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		o := &options{batchSize: DefaultBatchSize, ingestServiceClient: &mockIngestServiceClient{err: errors.New("client error")}}
+		assert.EqualError(t, o.Run(&cobra.Command{}, []string{vulnsDir}), "failed to ingest vulnerabilities: client error")
+	})
+
+	t.Run("invalid batch size", func(t *testing.T) {
+		o := &options{batchSize: 0, ingestServiceClient: &mockIngestServiceClient{}}
+		assert.Error(t, o.Run(&cobra.Command{}, []string{vulnsDir}))
+	})
+}
@@ -13,7 +13,9 @@ import (
 )
 
 type options struct {
-	addr string // Address of the minefield server
+	addr     string // Address of the minefield server
+	replace  bool   // Replace the previously ingested version of each SBOM
+	identity string // Identity of the SBOM when replacing
 
 	ingestServiceClient apiv1connect.IngestServiceClient
 }
@@ -24,6 +26,8 @@ const (
 
 func (o *options) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+	cmd.Flags().BoolVar(&o.replace, "replace", false, "Replace the previously ingested version of each SBOM, removing dependencies it no longer declares")
+	cmd.Flags().StringVar(&o.identity, "identity", "", "Identity tying versions of the SBOM together, defaults to the root package URLs without versions")
 }
 
 func (o *options) Run(_ *cobra.Command, args []string) error {
@@ -39,10 +43,15 @@ func (o *options) Run(_ *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to ingest SBOM: %w", err)
 	}
+	if o.identity != "" && len(result) > 1 {
+		return fmt.Errorf("--identity can only be used with a single SBOM, got %d", len(result))
+	}
 
 	for index, data := range result {
 		req := connect.NewRequest(&apiv1.IngestSBOMRequest{
-			Sbom: data.Data,
+			Sbom:     data.Data,
+			Replace:  o.replace,
+			Identity: o.identity,
 		})
 		if _, err := o.ingestServiceClient.IngestSBOM(context.Background(), req); err != nil {
 			return fmt.Errorf("failed to ingest SBOM: %w", err)
@@ -27,3 +27,19 @@ func TestNew(t *testing.T) {
 		t.Errorf("expected RunE to be set")
 	}
 }
+
+func TestNew_ReplaceFlags(t *testing.T) {
+	cmd := New()
+	if err := cmd.ParseFlags([]string{"--replace", "--identity=pkg:golang/example.com/app"}); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+
+	replace, err := cmd.Flags().GetBool("replace")
+	if err != nil || !replace {
+		t.Errorf("expected replace to be true, got %v (%v)", replace, err)
+	}
+	identity, err := cmd.Flags().GetString("identity")
+	if err != nil || identity != "pkg:golang/example.com/app" {
+		t.Errorf("expected identity to be set, got %q (%v)", identity, err)
+	}
+}
@@ -23,6 +23,7 @@ type options struct {
 	saveQuery string
 	addr      string
 	output    string
+	sortBy    string
 	client    apiv1connect.LeaderboardServiceClient
 }
 
@@ -33,6 +34,7 @@ func (o *options) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&o.showInfo, "show-info", true, "display the info column")
 	cmd.Flags().StringVarP(&o.addr, "addr", "a", "http://localhost:8089", "Address of the Minefield server")
 	cmd.Flags().StringVarP(&o.output, "output", "o", "table", "Output format (table or json)")
+	cmd.Flags().StringVar(&o.sortBy, "sort-by", "", "Node field to sort by, highest first, such as epss, kev or scorecard.Maintained, instead of the size of the output")
 }
 
 // Run executes the custom command.
@@ -53,6 +55,7 @@ func (o *options) Run(cmd *cobra.Command, args []string) error {
 	// Create and send the request
 	req := connect.NewRequest(&apiv1.CustomLeaderboardRequest{
 		Script: script,
+		SortBy: o.sortBy,
 	})
 	res, err := o.client.CustomLeaderboard(ctx, req)
 	if err != nil {
@@ -99,6 +102,9 @@ func (o *options) renderTable(w io.Writer, res *connect.Response[apiv1.CustomLea
 	table.SetAutoWrapText(false)
 	table.SetRowLine(true)
 	headers := []string{"Name", "Type", "ID", "Output"}
+	if o.sortBy != "" {
+		headers = append(headers, o.sortBy)
+	}
 	if showInfo {
 		headers = append(headers, "Info")
 	}
@@ -124,6 +130,9 @@ func (o *options) renderTable(w io.Writer, res *connect.Response[apiv1.CustomLea
 			strconv.Itoa(int(q.Node.Id)),
 			output,
 		}
+		if o.sortBy != "" {
+			row = append(row, q.SortValue)
+		}
 
 		// If showInfo is true, compute the additionalInfo and append it
 		if showInfo {
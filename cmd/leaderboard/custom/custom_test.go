@@ -25,6 +25,7 @@ func TestRenderTable(t *testing.T) {
 		showInfo       bool
 		maxOutput      int
 		all            bool
+		sortBy         string
 		output         string
 		expectedOutput []string
 		wantErr        bool
@@ -87,6 +88,23 @@ func TestRenderTable(t *testing.T) {
 				"test1", "type1", "1", "1",
 			},
 		},
+		{
+			name: "table sorted by a field",
+			queries: []*apiv1.Query{
+				{
+					Node:      &apiv1.Node{Name: "CVE-2024-1", Type: "vuln", Id: 1},
+					Output:    []uint32{1},
+					SortValue: "0.97565",
+				},
+			},
+			showInfo:  false,
+			maxOutput: 10,
+			sortBy:    "epss",
+			expectedOutput: []string{
+				"NAME", "TYPE", "ID", "OUTPUT", "EPSS",
+				"CVE-2024-1", "vuln", "1", "0.97565",
+			},
+		},
 		{
 			name:      "no queries",
 			queries:   []*apiv1.Query{},
@@ -101,7 +119,7 @@ func TestRenderTable(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup
-			o := &options{}
+			o := &options{sortBy: tt.sortBy}
 			buf := &bytes.Buffer{}
 
 			// Create response
@@ -34,14 +34,17 @@ If an '@' is used in a package name, even if a version is not included, leave it
 
 If a user asks for vulnerablities for a package they mean dependencies of type vuln, for a query, and if we want to know what a vuln affects, dependents of type library.
 
-Globsearch queries can be used to find anything that might exist in a node, not only names of nodes, so for types of packages, if it is inside of a purl you can find it, versions, ecosystem, etc. For vulns you can do globsearches like '*GHSA*', '*CVE*', etc, depending on what the user asks.
+Globsearch queries match the names of nodes, so for types of packages, if it is inside of a purl you can find it, versions, ecosystem, etc. For vulns you can do globsearches like '*GHSA*', '*CVE*', etc, depending on what the user asks.
 
 Try to surrond a globsearch pattern with as much glob as you can, be as general as possible.
 
+Search queries match words inside the metadata of nodes, such as vulnerability summaries, license strings or supplier names. Only output the words to search for, every word has to be present in a node for it to match.
+
 
 If this is a leaderboard query, you should prefix your answer with 'leaderboard:'.
 If this is a regular query, you should prefix your answer with 'query:'.
 If this is a globsearch query, you should prefix your answer with 'globsearch:'.
+If this is a search query, you should prefix your answer with 'search:'.
 
 
 Context information:
@@ -271,6 +274,40 @@ func (o *options) Run(cmd *cobra.Command, args []string) error {
 						}
 					}
 				}
+			} else if strings.HasPrefix(strings.TrimSpace(script), "search:") {
+				// Remove the "search:" prefix
+				text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(script), "search:"))
+				fmt.Printf("\nAssistant: I'll help you with that. I'm going to search for:\n\"%s\"\n", text)
+
+				req := connect.NewRequest(&apiv1.SearchMetadataRequest{Query: text, Limit: int32(o.maxOutput)})
+				res, err := o.graphServiceClient.SearchMetadata(cmd.Context(), req)
+
+				if err != nil {
+					queryResult = fmt.Sprintf("Query failed: %v", err)
+				} else if len(res.Msg.Results) == 0 {
+					queryResult = "No results found"
+				} else {
+					nodes := make([]*apiv1.Node, len(res.Msg.Results))
+					for i, result := range res.Msg.Results {
+						nodes[i] = result.Node
+					}
+					switch o.output {
+					case "json":
+						jsonOutput, err := helpers.FormatNodeJSON(nodes)
+						if err != nil {
+							queryResult = fmt.Sprintf("Failed to format JSON: %v", err)
+						} else {
+							queryResult = string(jsonOutput)
+						}
+					case "table":
+						err = formatTableGlobSearch(&buf, nodes, o.maxOutput, o.showInfo)
+						if err != nil {
+							queryResult = fmt.Sprintf("Failed to format table: %v", err)
+						} else {
+							queryResult = buf.String()
+						}
+					}
+				}
 			} else {
 				queryResult = "Sorry the query failed, please try again."
 			}
@@ -0,0 +1,74 @@
+package licenses
+
+import (
+	"fmt"
+	"net/http"
+
+	"connectrpc.com/connect"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	deny []string // SPDX licenses that are not allowed
+	addr string   // Address of the minefield server
+
+	policyServiceClient apiv1connect.PolicyServiceClient
+}
+
+const (
+	DefaultAddr = "http://localhost:8089" // Default address of the minefield server
+)
+
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&o.deny, "deny", nil, "SPDX license that is not allowed, such as GPL-3.0-only (can be repeated or comma separated)")
+	cmd.Flags().StringVar(&o.addr, "addr", DefaultAddr, "Address of the minefield server")
+}
+
+func (o *options) Run(cmd *cobra.Command, args []string) error {
+	if len(o.deny) == 0 {
+		return fmt.Errorf("at least one --deny license is required")
+	}
+	if o.policyServiceClient == nil {
+		o.policyServiceClient = apiv1connect.NewPolicyServiceClient(
+			http.DefaultClient,
+			o.addr,
+		)
+	}
+
+	req := connect.NewRequest(&apiv1.CheckLicensesRequest{Deny: o.deny, Roots: args})
+	res, err := o.policyServiceClient.CheckLicenses(cmd.Context(), req)
+	if err != nil {
+		return fmt.Errorf("failed to check licenses: %w", err)
+	}
+
+	violations := res.Msg.Violations
+	if len(violations) == 0 {
+		cmd.Println("No denied licenses found")
+		return nil
+	}
+	for _, violation := range violations {
+		cmd.Printf("%s: %s is licensed %s (%s)\n", violation.Root, violation.Library, violation.License, violation.Expression)
+	}
+	return fmt.Errorf("found %d libraries with denied licenses", len(violations))
+}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:   "licenses [root node name...]",
+		Short: "Report dependencies whose licenses are denied",
+		Long: `Report the libraries among the transitive dependencies of the given nodes, or of every ingested SBOM, that
+can't be used without a denied license. A library licensed "MIT OR GPL-3.0-only" passes when only GPL-3.0-only
+is denied. The command fails when a violation is found, so it can gate CI.
+
+Example:
+  minefield policy licenses --deny GPL-3.0-only --deny AGPL-3.0-only pkg:golang/example.com/app@v1.0.0`,
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+
+	return cmd
+}
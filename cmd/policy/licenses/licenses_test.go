@@ -0,0 +1,55 @@
+package licenses
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	service "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePolicyServiceClient struct {
+	request    *service.CheckLicensesRequest
+	violations []*service.LicenseViolation
+}
+
+func (f *fakePolicyServiceClient) CheckLicenses(_ context.Context, req *connect.Request[service.CheckLicensesRequest]) (*connect.Response[service.CheckLicensesResponse], error) {
+	f.request = req.Msg
+	return connect.NewResponse(&service.CheckLicensesResponse{Violations: f.violations}), nil
+}
+
+func TestNew(t *testing.T) {
+	cmd := New()
+	assert.Equal(t, "licenses [root node name...]", cmd.Use)
+	assert.True(t, cmd.DisableAutoGenTag)
+	for _, name := range []string{"deny", "addr"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), "missing flag %s", name)
+	}
+}
+
+func TestRun(t *testing.T) {
+	client := &fakePolicyServiceClient{}
+	o := &options{deny: []string{"GPL-3.0-only"}, policyServiceClient: client}
+	cmd := New()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetContext(context.Background())
+
+	require.NoError(t, o.Run(cmd, []string{"pkg:npm/app@1.0.0"}))
+	assert.Equal(t, []string{"GPL-3.0-only"}, client.request.Deny)
+	assert.Equal(t, []string{"pkg:npm/app@1.0.0"}, client.request.Roots)
+	assert.Contains(t, out.String(), "No denied licenses found")
+
+	client.violations = []*service.LicenseViolation{{Root: "pkg:npm/app@1.0.0", Library: "pkg:npm/b@1.0.0", License: "GPL-3.0-only", Expression: "Apache-2.0 AND GPL-3.0-only"}}
+	out.Reset()
+	assert.EqualError(t, o.Run(cmd, nil), "found 1 libraries with denied licenses")
+	assert.Contains(t, out.String(), "pkg:npm/app@1.0.0: pkg:npm/b@1.0.0 is licensed GPL-3.0-only (Apache-2.0 AND GPL-3.0-only)")
+}
+
+func TestRun_NoDeny(t *testing.T) {
+	o := &options{policyServiceClient: &fakePolicyServiceClient{}}
+	assert.Error(t, o.Run(New(), nil))
+}
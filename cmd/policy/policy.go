@@ -0,0 +1,25 @@
+package policy
+
+import (
+	"github.com/bitbomdev/minefield/cmd/policy/licenses"
+	"github.com/spf13/cobra"
+)
+
+type options struct{}
+
+func (o *options) AddFlags(_ *cobra.Command) {}
+
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "policy",
+		Short:             "Check the graph against policies, such as denied licenses",
+		SilenceUsage:      true,
+		DisableAutoGenTag: true,
+	}
+
+	o.AddFlags(cmd)
+
+	cmd.AddCommand(licenses.New())
+	return cmd
+}
@@ -51,6 +51,8 @@ type mockGraphServiceClient struct {
 	GetNodeByNameFunc  func(ctx context.Context, req *connect.Request[apiv1.GetNodeByNameRequest]) (*connect.Response[apiv1.GetNodeByNameResponse], error)
 	AddNodeFunc        func(ctx context.Context, req *connect.Request[apiv1.AddNodeRequest]) (*connect.Response[apiv1.AddNodeResponse], error)
 	SetDependencyFunc  func(ctx context.Context, req *connect.Request[apiv1.SetDependencyRequest]) (*connect.Response[emptypb.Empty], error)
+	SearchMetadataFunc func(ctx context.Context, req *connect.Request[apiv1.SearchMetadataRequest]) (*connect.Response[apiv1.SearchMetadataResponse], error)
+	SearchNodesFunc    func(ctx context.Context, req *connect.Request[apiv1.SearchNodesRequest]) (*connect.Response[apiv1.SearchNodesResponse], error)
 }
 
 func (m *mockGraphServiceClient) GetNodesByGlob(ctx context.Context, req *connect.Request[apiv1.GetNodesByGlobRequest]) (*connect.Response[apiv1.GetNodesByGlobResponse], error) {
@@ -73,6 +75,14 @@ func (m *mockGraphServiceClient) SetDependency(ctx context.Context, req *connect
 	return m.SetDependencyFunc(ctx, req)
 }
 
+func (m *mockGraphServiceClient) SearchMetadata(ctx context.Context, req *connect.Request[apiv1.SearchMetadataRequest]) (*connect.Response[apiv1.SearchMetadataResponse], error) {
+	return m.SearchMetadataFunc(ctx, req)
+}
+
+func (m *mockGraphServiceClient) SearchNodes(ctx context.Context, req *connect.Request[apiv1.SearchNodesRequest]) (*connect.Response[apiv1.SearchNodesResponse], error) {
+	return m.SearchNodesFunc(ctx, req)
+}
+
 func TestRun(t *testing.T) {
 	tests := []struct {
 		name                string
@@ -10,6 +10,7 @@ import (
 	"github.com/bitbomdev/minefield/cmd/helpers"
 	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
 	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/bitbomdev/minefield/pkg/graph"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -18,6 +19,13 @@ type options struct {
 	maxOutput          int
 	addr               string
 	output             string
+	regex              bool
+	purlType           string
+	purlNamespace      string
+	purlName           string
+	versionRange       string
+	qualifiers         map[string]string
+	pageSize           int
 	graphServiceClient apiv1connect.GraphServiceClient
 }
 
@@ -26,13 +34,41 @@ func (o *options) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().IntVar(&o.maxOutput, "max-output", 10, "maximum number of results to display")
 	cmd.Flags().StringVar(&o.addr, "addr", "http://localhost:8089", "address of the minefield server")
 	cmd.Flags().StringVar(&o.output, "output", "table", "output format (table or json)")
+	cmd.Flags().BoolVar(&o.regex, "regex", false, "treat the pattern as a regular expression instead of a glob")
+	cmd.Flags().StringVar(&o.purlType, "type", "", "only match purls of this type, such as npm or golang")
+	cmd.Flags().StringVar(&o.purlNamespace, "namespace", "", "only match purls with this namespace")
+	cmd.Flags().StringVar(&o.purlName, "name", "", "only match purls with this name")
+	cmd.Flags().StringVar(&o.versionRange, "version-range", "", "only match purls with a version in this semver range, such as \">=1.2.0, <2.0.0\"")
+	cmd.Flags().StringToStringVar(&o.qualifiers, "qualifier", nil, "only match purls with this qualifier, such as arch=amd64 (can be repeated)")
+	cmd.Flags().IntVar(&o.pageSize, "page-size", 100, "number of nodes to request from the server at a time")
 }
 
 // Run executes the globsearch command with the provided arguments.
 func (o *options) Run(cmd *cobra.Command, args []string) error {
-	pattern := args[0]
-	if pattern == "" {
-		return fmt.Errorf("pattern is required")
+	pattern := ""
+	if len(args) > 0 {
+		pattern = args[0]
+	}
+	hasPurlFilter := o.purlType != "" || o.purlNamespace != "" || o.purlName != "" || o.versionRange != "" || len(o.qualifiers) > 0
+	if pattern == "" && !hasPurlFilter {
+		return fmt.Errorf("pattern or purl filter is required")
+	}
+
+	req := &apiv1.SearchNodesRequest{PageSize: int32(o.pageSize)}
+	if pattern != "" {
+		req.Regex = pattern
+		if !o.regex {
+			req.Regex = graph.GlobToRegex(pattern)
+		}
+	}
+	if hasPurlFilter {
+		req.Purl = &apiv1.PurlFilter{
+			Type:         o.purlType,
+			Namespace:    o.purlNamespace,
+			Name:         o.purlName,
+			VersionRange: o.versionRange,
+			Qualifiers:   o.qualifiers,
+		}
 	}
 
 	// Initialize client if not injected (for testing)
@@ -43,30 +79,41 @@ func (o *options) Run(cmd *cobra.Command, args []string) error {
 		)
 	}
 
-	// Query nodes matching pattern
-	res, err := o.graphServiceClient.GetNodesByGlob(
-		cmd.Context(),
-		connect.NewRequest(&apiv1.GetNodesByGlobRequest{Pattern: pattern}),
-	)
-	if err != nil {
-		return fmt.Errorf("query failed: %w", err)
+	// Page through the matching nodes until there are enough to display
+	var nodes []*apiv1.Node
+	for len(nodes) < o.maxOutput {
+		res, err := o.graphServiceClient.SearchNodes(cmd.Context(), connect.NewRequest(req))
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		nodes = append(nodes, res.Msg.Nodes...)
+		if res.Msg.NextPageToken == "" {
+			break
+		}
+		req.PageToken = res.Msg.NextPageToken
+	}
+	if len(nodes) > o.maxOutput {
+		nodes = nodes[:o.maxOutput]
 	}
 
-	if len(res.Msg.Nodes) == 0 {
+	if len(nodes) == 0 {
+		if pattern == "" {
+			return fmt.Errorf("no nodes found matching the purl filter")
+		}
 		return fmt.Errorf("no nodes found matching pattern: %s", pattern)
 	}
 
 	// Format and display results
 	switch o.output {
 	case "json":
-		jsonOutput, err := helpers.FormatNodeJSON(res.Msg.Nodes)
+		jsonOutput, err := helpers.FormatNodeJSON(nodes)
 		if err != nil {
 			return fmt.Errorf("failed to format nodes as JSON: %w", err)
 		}
 		cmd.Println(string(jsonOutput))
 		return nil
 	case "table":
-		return formatTable(cmd.OutOrStdout(), res.Msg.Nodes, o.maxOutput)
+		return formatTable(cmd.OutOrStdout(), nodes, o.maxOutput)
 	default:
 		return fmt.Errorf("unknown output format: %s", o.output)
 	}
@@ -100,8 +147,8 @@ func New() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:               "globsearch [pattern]",
 		Short:             "Search for nodes by glob pattern",
-		Long:              "Search for nodes in the graph using a glob pattern",
-		Args:              cobra.ExactArgs(1),
+		Long:              "Search for nodes in the graph by name using a glob pattern, where '*' matches any run of characters and '?' matches a single character, or a regular expression with --regex. The purl flags narrow the search to package URLs with the given type, namespace, name, version range and qualifiers.",
+		Args:              cobra.MaximumNArgs(1),
 		RunE:              o.Run,
 		DisableAutoGenTag: true,
 	}
@@ -165,6 +165,8 @@ type mockGraphServiceClient struct {
 	GetNodeFunc        func(ctx context.Context, req *connect.Request[apiv1.GetNodeRequest]) (*connect.Response[apiv1.GetNodeResponse], error)
 	GetNodeByNameFunc  func(ctx context.Context, req *connect.Request[apiv1.GetNodeByNameRequest]) (*connect.Response[apiv1.GetNodeByNameResponse], error)
 	SetDependencyFunc  func(ctx context.Context, req *connect.Request[apiv1.SetDependencyRequest]) (*connect.Response[emptypb.Empty], error)
+	SearchMetadataFunc func(ctx context.Context, req *connect.Request[apiv1.SearchMetadataRequest]) (*connect.Response[apiv1.SearchMetadataResponse], error)
+	SearchNodesFunc    func(ctx context.Context, req *connect.Request[apiv1.SearchNodesRequest]) (*connect.Response[apiv1.SearchNodesResponse], error)
 	AddNodeFunc        func(ctx context.Context, req *connect.Request[apiv1.AddNodeRequest]) (*connect.Response[apiv1.AddNodeResponse], error)
 }
 
@@ -187,65 +189,106 @@ func (m *mockGraphServiceClient) AddNode(ctx context.Context, req *connect.Reque
 func (m *mockGraphServiceClient) SetDependency(ctx context.Context, req *connect.Request[apiv1.SetDependencyRequest]) (*connect.Response[emptypb.Empty], error) {
 	return m.SetDependencyFunc(ctx, req)
 }
+
+func (m *mockGraphServiceClient) SearchMetadata(ctx context.Context, req *connect.Request[apiv1.SearchMetadataRequest]) (*connect.Response[apiv1.SearchMetadataResponse], error) {
+	return m.SearchMetadataFunc(ctx, req)
+}
+
+func (m *mockGraphServiceClient) SearchNodes(ctx context.Context, req *connect.Request[apiv1.SearchNodesRequest]) (*connect.Response[apiv1.SearchNodesResponse], error) {
+	return m.SearchNodesFunc(ctx, req)
+}
+
 func TestRun(t *testing.T) {
 	tests := []struct {
 		name                string
-		pattern             string
+		args                []string
 		output              string
 		maxOutput           int
-		mockResponse        *apiv1.GetNodesByGlobResponse
+		regex               bool
+		purlType            string
+		versionRange        string
+		mockResponse        *apiv1.SearchNodesResponse
 		mockError           error
-		expectError         bool
+		expectedRegex       string
 		expectedErrorString string
 	}{
 		{
 			name:      "valid response with nodes",
-			pattern:   "node*",
+			args:      []string{"node*"},
 			output:    "json",
 			maxOutput: 10,
-			mockResponse: &apiv1.GetNodesByGlobResponse{
+			mockResponse: &apiv1.SearchNodesResponse{
 				Nodes: []*apiv1.Node{
 					{Name: "node1", Type: "type1", Id: 1},
 					{Name: "node2", Type: "type2", Id: 2},
 				},
 			},
+			expectedRegex: `^node.*$`,
 		},
 		{
 			name:      "metadata",
-			pattern:   "node*",
+			args:      []string{"node?"},
 			output:    "json",
 			maxOutput: 10,
-			mockResponse: &apiv1.GetNodesByGlobResponse{
+			mockResponse: &apiv1.SearchNodesResponse{
 				Nodes: []*apiv1.Node{
 					{Name: "node1", Type: "type1", Id: 1, Metadata: []byte(`{"field": "value1"}`)},
 				},
 			},
+			expectedRegex: `^node.$`,
+		},
+		{
+			name:      "regex pattern is sent as is",
+			args:      []string{`^pkg:npm/.*@4\.`},
+			output:    "table",
+			maxOutput: 10,
+			regex:     true,
+			mockResponse: &apiv1.SearchNodesResponse{
+				Nodes: []*apiv1.Node{{Name: "pkg:npm/lodash@4.17.21", Type: "library", Id: 1}},
+			},
+			expectedRegex: `^pkg:npm/.*@4\.`,
+		},
+		{
+			name:         "purl filter without a pattern",
+			output:       "table",
+			maxOutput:    10,
+			purlType:     "npm",
+			versionRange: ">=4.0.0",
+			mockResponse: &apiv1.SearchNodesResponse{
+				Nodes: []*apiv1.Node{{Name: "pkg:npm/lodash@4.17.21", Type: "library", Id: 1}},
+			},
+		},
+		{
+			name:                "no pattern or purl filter",
+			output:              "table",
+			maxOutput:           10,
+			expectedErrorString: "pattern or purl filter is required",
 		},
 		{
 			name:                "no nodes found",
-			pattern:             "unknown*",
+			args:                []string{"unknown*"},
 			output:              "table",
 			maxOutput:           10,
-			mockResponse:        &apiv1.GetNodesByGlobResponse{Nodes: []*apiv1.Node{}},
-			expectError:         true,
+			mockResponse:        &apiv1.SearchNodesResponse{Nodes: []*apiv1.Node{}},
+			expectedRegex:       `^unknown.*$`,
 			expectedErrorString: "no nodes found matching pattern: unknown*",
 		},
 		{
 			name:                "client error",
-			pattern:             "error*",
+			args:                []string{"error*"},
 			output:              "json",
 			maxOutput:           10,
 			mockError:           errors.New("client error"),
-			expectError:         true,
+			expectedRegex:       `^error.*$`,
 			expectedErrorString: "query failed: client error",
 		},
 		{
 			name:                "unknown output format",
-			pattern:             "node*",
+			args:                []string{"node*"},
 			output:              "unknown",
 			maxOutput:           10,
-			mockResponse:        &apiv1.GetNodesByGlobResponse{Nodes: []*apiv1.Node{{Name: "node1", Type: "type1", Id: 1}}},
-			expectError:         true,
+			mockResponse:        &apiv1.SearchNodesResponse{Nodes: []*apiv1.Node{{Name: "node1", Type: "type1", Id: 1}}},
+			expectedRegex:       `^node.*$`,
 			expectedErrorString: "unknown output format: unknown",
 		},
 	}
@@ -253,7 +296,14 @@ func TestRun(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := &mockGraphServiceClient{
-				GetNodesByGlobFunc: func(ctx context.Context, req *connect.Request[apiv1.GetNodesByGlobRequest]) (*connect.Response[apiv1.GetNodesByGlobResponse], error) {
+				SearchNodesFunc: func(ctx context.Context, req *connect.Request[apiv1.SearchNodesRequest]) (*connect.Response[apiv1.SearchNodesResponse], error) {
+					assert.Equal(t, tt.expectedRegex, req.Msg.Regex)
+					if tt.purlType != "" {
+						assert.Equal(t, tt.purlType, req.Msg.Purl.Type)
+						assert.Equal(t, tt.versionRange, req.Msg.Purl.VersionRange)
+					} else {
+						assert.Nil(t, req.Msg.Purl)
+					}
 					if tt.mockError != nil {
 						return nil, tt.mockError
 					}
@@ -265,6 +315,9 @@ func TestRun(t *testing.T) {
 				addr:               "http://localhost:8089",
 				output:             tt.output,
 				maxOutput:          tt.maxOutput,
+				regex:              tt.regex,
+				purlType:           tt.purlType,
+				versionRange:       tt.versionRange,
 				graphServiceClient: mockClient,
 			}
 
@@ -273,11 +326,9 @@ func TestRun(t *testing.T) {
 			cmd.SetOut(io.Discard) // Discard output during testing
 			cmd.SetContext(context.Background())
 
-			args := []string{tt.pattern}
-
-			err := o.Run(cmd, args)
+			err := o.Run(cmd, tt.args)
 
-			if tt.expectError {
+			if tt.expectedErrorString != "" {
 				assert.Error(t, err)
 				assert.Equal(t, tt.expectedErrorString, err.Error())
 			} else {
@@ -286,3 +337,35 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRun_Pagination(t *testing.T) {
+	pages := map[string]*apiv1.SearchNodesResponse{
+		"": {
+			Nodes:         []*apiv1.Node{{Name: "node1", Id: 1}, {Name: "node2", Id: 2}},
+			NextPageToken: "page2",
+		},
+		"page2": {
+			Nodes:         []*apiv1.Node{{Name: "node3", Id: 3}, {Name: "node4", Id: 4}},
+			NextPageToken: "page3",
+		},
+	}
+	var tokens []string
+	mockClient := &mockGraphServiceClient{
+		SearchNodesFunc: func(ctx context.Context, req *connect.Request[apiv1.SearchNodesRequest]) (*connect.Response[apiv1.SearchNodesResponse], error) {
+			tokens = append(tokens, req.Msg.PageToken)
+			assert.Equal(t, int32(2), req.Msg.PageSize)
+			return connect.NewResponse(pages[req.Msg.PageToken]), nil
+		},
+	}
+
+	o := &options{output: "table", maxOutput: 3, pageSize: 2, graphServiceClient: mockClient}
+	buf := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	cmd.SetContext(context.Background())
+
+	assert.NoError(t, o.Run(cmd, []string{"node*"}))
+	assert.DeepEqual(t, []string{"", "page2"}, tokens)
+	assert.True(t, strings.Contains(buf.String(), "node3"))
+	assert.False(t, strings.Contains(buf.String(), "node4"))
+}
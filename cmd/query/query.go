@@ -4,6 +4,7 @@ import (
 	"github.com/bitbomdev/minefield/cmd/query/custom"
 	"github.com/bitbomdev/minefield/cmd/query/getMetadata"
 	"github.com/bitbomdev/minefield/cmd/query/globsearch"
+	"github.com/bitbomdev/minefield/cmd/query/search"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +20,7 @@ func New() *cobra.Command {
 	cmd.AddCommand(custom.New())
 	cmd.AddCommand(getMetadata.New())
 	cmd.AddCommand(globsearch.New())
+	cmd.AddCommand(search.New())
 
 	return cmd
 }
@@ -0,0 +1,115 @@
+package search
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/bitbomdev/minefield/cmd/helpers"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/bitbomdev/minefield/gen/api/v1/apiv1connect"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+type options struct {
+	maxOutput          int
+	addr               string
+	output             string
+	graphServiceClient apiv1connect.GraphServiceClient
+}
+
+// AddFlags adds command-line flags to the provided cobra command.
+func (o *options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&o.maxOutput, "max-output", 10, "maximum number of results to display")
+	cmd.Flags().StringVar(&o.addr, "addr", "http://localhost:8089", "address of the minefield server")
+	cmd.Flags().StringVar(&o.output, "output", "table", "output format (table or json)")
+}
+
+// Run executes the search command with the provided arguments.
+func (o *options) Run(cmd *cobra.Command, args []string) error {
+	query := strings.Join(args, " ")
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("search text is required")
+	}
+
+	// Initialize client if not injected (for testing)
+	if o.graphServiceClient == nil {
+		o.graphServiceClient = apiv1connect.NewGraphServiceClient(
+			http.DefaultClient,
+			o.addr,
+		)
+	}
+
+	res, err := o.graphServiceClient.SearchMetadata(
+		cmd.Context(),
+		connect.NewRequest(&apiv1.SearchMetadataRequest{Query: query, Limit: int32(o.maxOutput)}),
+	)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	if len(res.Msg.Results) == 0 {
+		return fmt.Errorf("no nodes found matching: %s", query)
+	}
+
+	// Format and display results
+	switch o.output {
+	case "json":
+		nodes := make([]*apiv1.Node, len(res.Msg.Results))
+		for i, result := range res.Msg.Results {
+			nodes[i] = result.Node
+		}
+		jsonOutput, err := helpers.FormatNodeJSON(nodes)
+		if err != nil {
+			return fmt.Errorf("failed to format nodes as JSON: %w", err)
+		}
+		cmd.Println(string(jsonOutput))
+		return nil
+	case "table":
+		return formatTable(cmd.OutOrStdout(), res.Msg.Results, o.maxOutput)
+	default:
+		return fmt.Errorf("unknown output format: %s", o.output)
+	}
+}
+
+// formatTable formats the ranked results into a table and writes it to the provided writer.
+func formatTable(w io.Writer, results []*apiv1.SearchResult, maxOutput int) error {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Type", "ID", "Score"})
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+
+	for i, result := range results {
+		if i >= maxOutput {
+			break
+		}
+		table.Append([]string{
+			result.Node.Name,
+			result.Node.Type,
+			strconv.FormatUint(uint64(result.Node.Id), 10),
+			strconv.FormatFloat(result.Score, 'f', 2, 64),
+		})
+	}
+
+	table.Render()
+	return nil
+}
+
+// New returns a new cobra command for search.
+func New() *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:               "search [text]",
+		Short:             "Search node metadata",
+		Long:              "Search the names, types and metadata of nodes, such as vulnerability summaries, license strings or supplier names. Nodes containing every word of the text are returned, best matches first.",
+		Args:              cobra.MinimumNArgs(1),
+		RunE:              o.Run,
+		DisableAutoGenTag: true,
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,166 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	apiv1 "github.com/bitbomdev/minefield/gen/api/v1"
+	"github.com/spf13/cobra"
+	"github.com/zeebo/assert"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestFormatTable(t *testing.T) {
+	results := []*apiv1.SearchResult{
+		{Node: &apiv1.Node{Name: "node1", Type: "type1", Id: 1}, Score: 2.5},
+		{Node: &apiv1.Node{Name: "node2", Type: "type2", Id: 2}, Score: 1.25},
+		{Node: &apiv1.Node{Name: "node3", Type: "type3", Id: 3}, Score: 0.5},
+	}
+
+	buf := &bytes.Buffer{}
+	err := formatTable(buf, results, 2)
+	assert.NoError(t, err)
+
+	got := buf.String()
+	for _, want := range []string{"SCORE", "node1", "2.50", "node2", "1.25"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatTable() output doesn't contain %q\nGot:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "node3") {
+		t.Errorf("formatTable() output should respect maxOutput\nGot:\n%s", got)
+	}
+}
+
+type mockGraphServiceClient struct {
+	SearchMetadataFunc func(ctx context.Context, req *connect.Request[apiv1.SearchMetadataRequest]) (*connect.Response[apiv1.SearchMetadataResponse], error)
+}
+
+func (m *mockGraphServiceClient) GetNodesByGlob(ctx context.Context, req *connect.Request[apiv1.GetNodesByGlobRequest]) (*connect.Response[apiv1.GetNodesByGlobResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockGraphServiceClient) GetNode(ctx context.Context, req *connect.Request[apiv1.GetNodeRequest]) (*connect.Response[apiv1.GetNodeResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockGraphServiceClient) GetNodeByName(ctx context.Context, req *connect.Request[apiv1.GetNodeByNameRequest]) (*connect.Response[apiv1.GetNodeByNameResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockGraphServiceClient) AddNode(ctx context.Context, req *connect.Request[apiv1.AddNodeRequest]) (*connect.Response[apiv1.AddNodeResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockGraphServiceClient) SetDependency(ctx context.Context, req *connect.Request[apiv1.SetDependencyRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockGraphServiceClient) SearchNodes(ctx context.Context, req *connect.Request[apiv1.SearchNodesRequest]) (*connect.Response[apiv1.SearchNodesResponse], error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockGraphServiceClient) SearchMetadata(ctx context.Context, req *connect.Request[apiv1.SearchMetadataRequest]) (*connect.Response[apiv1.SearchMetadataResponse], error) {
+	return m.SearchMetadataFunc(ctx, req)
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name                string
+		args                []string
+		output              string
+		mockResponse        *apiv1.SearchMetadataResponse
+		mockError           error
+		expectedQuery       string
+		expectedErrorString string
+	}{
+		{
+			name:   "joins the arguments into one query",
+			args:   []string{"remote", "code"},
+			output: "table",
+			mockResponse: &apiv1.SearchMetadataResponse{Results: []*apiv1.SearchResult{
+				{Node: &apiv1.Node{Name: "GHSA-1234", Type: "vuln", Id: 1}, Score: 1},
+			}},
+			expectedQuery: "remote code",
+		},
+		{
+			name:   "json output",
+			args:   []string{"remote"},
+			output: "json",
+			mockResponse: &apiv1.SearchMetadataResponse{Results: []*apiv1.SearchResult{
+				{Node: &apiv1.Node{Name: "GHSA-1234", Type: "vuln", Id: 1, Metadata: []byte(`{"summary": "Remote code execution"}`)}, Score: 1},
+			}},
+			expectedQuery: "remote",
+		},
+		{
+			name:                "no results",
+			args:                []string{"missing"},
+			output:              "table",
+			mockResponse:        &apiv1.SearchMetadataResponse{},
+			expectedQuery:       "missing",
+			expectedErrorString: "no nodes found matching: missing",
+		},
+		{
+			name:                "client error",
+			args:                []string{"remote"},
+			output:              "table",
+			mockError:           errors.New("client error"),
+			expectedQuery:       "remote",
+			expectedErrorString: "search failed: client error",
+		},
+		{
+			name:                "empty query",
+			args:                []string{" "},
+			output:              "table",
+			expectedErrorString: "search text is required",
+		},
+		{
+			name:   "unknown output format",
+			args:   []string{"remote"},
+			output: "unknown",
+			mockResponse: &apiv1.SearchMetadataResponse{Results: []*apiv1.SearchResult{
+				{Node: &apiv1.Node{Name: "GHSA-1234", Type: "vuln", Id: 1}, Score: 1},
+			}},
+			expectedQuery:       "remote",
+			expectedErrorString: "unknown output format: unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockGraphServiceClient{
+				SearchMetadataFunc: func(ctx context.Context, req *connect.Request[apiv1.SearchMetadataRequest]) (*connect.Response[apiv1.SearchMetadataResponse], error) {
+					assert.Equal(t, tt.expectedQuery, req.Msg.Query)
+					assert.Equal(t, int32(10), req.Msg.Limit)
+					if tt.mockError != nil {
+						return nil, tt.mockError
+					}
+					return connect.NewResponse(tt.mockResponse), nil
+				},
+			}
+
+			o := &options{
+				maxOutput:          10,
+				output:             tt.output,
+				graphServiceClient: mockClient,
+			}
+
+			cmd := &cobra.Command{}
+			cmd.SetOut(io.Discard)
+			cmd.SetContext(context.Background())
+
+			err := o.Run(cmd, tt.args)
+			if tt.expectedErrorString != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedErrorString, err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
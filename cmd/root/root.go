@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/bitbomdev/minefield/cmd/admin"
+	"github.com/bitbomdev/minefield/cmd/annotate"
 	"github.com/bitbomdev/minefield/cmd/cache"
 	"github.com/bitbomdev/minefield/cmd/ingest"
 	"github.com/bitbomdev/minefield/cmd/leaderboard"
+	"github.com/bitbomdev/minefield/cmd/policy"
 	"github.com/bitbomdev/minefield/cmd/query"
 	"github.com/bitbomdev/minefield/cmd/server"
 	llm "github.com/bitbomdev/minefield/cmd/llm"
@@ -56,5 +59,8 @@ func New() *cobra.Command {
 	rootCmd.AddCommand(leaderboard.New())
 	rootCmd.AddCommand(server.New())
 	rootCmd.AddCommand(llm.New())
+	rootCmd.AddCommand(admin.New())
+	rootCmd.AddCommand(annotate.New())
+	rootCmd.AddCommand(policy.New())
 	return rootCmd
 }
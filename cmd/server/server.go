@@ -25,16 +25,18 @@ import (
 )
 
 type options struct {
-	storage      graph.Storage
-	concurrency  int32
-	addr         string
-	StorageType  string
-	StorageAddr  string
-	StoragePath  string
-	UseInMemory  bool
-	CORS         []string
-	UseOpenAILLM bool
-	VectorDBPath string
+	storage       graph.Storage
+	concurrency   int32
+	addr          string
+	StorageType   string
+	StorageAddr   string
+	StoragePath   string
+	UseInMemory   bool
+	UpgradeSchema bool
+	CacheSize     int
+	CORS          []string
+	UseOpenAILLM  bool
+	VectorDBPath  string
 }
 
 const (
@@ -51,6 +53,8 @@ func (o *options) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&o.StorageAddr, "storage-addr", "localhost:6379", "Address for redis storage backend")
 	cmd.Flags().StringVar(&o.StoragePath, "storage-path", "", "Path to the SQLite database file")
 	cmd.Flags().BoolVar(&o.UseInMemory, "use-in-memory", true, "Use in-memory SQLite database")
+	cmd.Flags().BoolVar(&o.UpgradeSchema, "upgrade-schema", false, "Upgrade the storage schema if it is older than this version of minefield expects")
+	cmd.Flags().IntVar(&o.CacheSize, "storage-cache-size", 0, "Number of decoded nodes and node caches to keep in an in-process LRU in front of the storage (0 disables it; only use it when this server is the only writer)")
 	cmd.Flags().StringSliceVar(
 		&o.CORS,
 		"cors",
@@ -79,6 +83,18 @@ func (o *options) Run(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
+	applied, err := storages.EnsureSchema(o.storage, o.UpgradeSchema)
+	if err != nil {
+		return fmt.Errorf("failed to check storage schema: %w", err)
+	}
+	for _, migration := range applied {
+		log.Printf("Applied schema migration %s", migration)
+	}
+
+	if o.CacheSize > 0 {
+		o.storage = storages.NewCachingStorage(o.storage, o.CacheSize)
+	}
+
 	server, err := o.setupServer()
 	if err != nil {
 		return fmt.Errorf("failed to setup server: %w", err)
@@ -101,6 +117,10 @@ func (o *options) PersistentPreRunE(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("storage-addr is required when using Redis (format: host:port)")
 	}
 
+	if o.CacheSize < 0 {
+		return fmt.Errorf("storage-cache-size must not be negative")
+	}
+
 	return nil
 }
 
@@ -128,6 +148,12 @@ func (o *options) setupServer() (*http.Server, error) {
 	mux.Handle(path, handler)
 	path, handler = apiv1connect.NewIngestServiceHandler(newService)
 	mux.Handle(path, handler)
+	path, handler = apiv1connect.NewAnnotationServiceHandler(newService)
+	mux.Handle(path, handler)
+	path, handler = apiv1connect.NewAdminServiceHandler(newService)
+	mux.Handle(path, handler)
+	path, handler = apiv1connect.NewPolicyServiceHandler(newService)
+	mux.Handle(path, handler)
 
 	server := &http.Server{
 		Addr:    serviceAddr,
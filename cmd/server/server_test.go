@@ -174,6 +174,16 @@ func TestOptions_PersistentPreRunE(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Negative CacheSize",
+			options: &options{
+				StorageType: sqliteStorageType,
+				StoragePath: "/path/to/sqlite.db",
+				CacheSize:   -1,
+			},
+			wantErr:      true,
+			errorMessage: "storage-cache-size must not be negative",
+		},
 		{
 			name: "Unsupported StorageType",
 			options: &options{
@@ -32,6 +32,12 @@ const (
 	GraphServiceName = "api.v1.GraphService"
 	// IngestServiceName is the fully-qualified name of the IngestService service.
 	IngestServiceName = "api.v1.IngestService"
+	// AnnotationServiceName is the fully-qualified name of the AnnotationService service.
+	AnnotationServiceName = "api.v1.AnnotationService"
+	// AdminServiceName is the fully-qualified name of the AdminService service.
+	AdminServiceName = "api.v1.AdminService"
+	// PolicyServiceName is the fully-qualified name of the PolicyService service.
+	PolicyServiceName = "api.v1.PolicyService"
 	// HealthServiceName is the fully-qualified name of the HealthService service.
 	HealthServiceName = "api.v1.HealthService"
 )
@@ -64,6 +70,12 @@ const (
 	// GraphServiceGetNodeByNameProcedure is the fully-qualified name of the GraphService's
 	// GetNodeByName RPC.
 	GraphServiceGetNodeByNameProcedure = "/api.v1.GraphService/GetNodeByName"
+	// GraphServiceSearchMetadataProcedure is the fully-qualified name of the GraphService's
+	// SearchMetadata RPC.
+	GraphServiceSearchMetadataProcedure = "/api.v1.GraphService/SearchMetadata"
+	// GraphServiceSearchNodesProcedure is the fully-qualified name of the GraphService's SearchNodes
+	// RPC.
+	GraphServiceSearchNodesProcedure = "/api.v1.GraphService/SearchNodes"
 	// GraphServiceAddNodeProcedure is the fully-qualified name of the GraphService's AddNode RPC.
 	GraphServiceAddNodeProcedure = "/api.v1.GraphService/AddNode"
 	// GraphServiceSetDependencyProcedure is the fully-qualified name of the GraphService's
@@ -75,9 +87,49 @@ const (
 	// IngestServiceIngestVulnerabilityProcedure is the fully-qualified name of the IngestService's
 	// IngestVulnerability RPC.
 	IngestServiceIngestVulnerabilityProcedure = "/api.v1.IngestService/IngestVulnerability"
+	// IngestServiceIngestVulnerabilitiesProcedure is the fully-qualified name of the IngestService's
+	// IngestVulnerabilities RPC.
+	IngestServiceIngestVulnerabilitiesProcedure = "/api.v1.IngestService/IngestVulnerabilities"
 	// IngestServiceIngestScorecardProcedure is the fully-qualified name of the IngestService's
 	// IngestScorecard RPC.
 	IngestServiceIngestScorecardProcedure = "/api.v1.IngestService/IngestScorecard"
+	// IngestServiceIngestVEXProcedure is the fully-qualified name of the IngestService's IngestVEX RPC.
+	IngestServiceIngestVEXProcedure = "/api.v1.IngestService/IngestVEX"
+	// IngestServiceIngestLockfileProcedure is the fully-qualified name of the IngestService's
+	// IngestLockfile RPC.
+	IngestServiceIngestLockfileProcedure = "/api.v1.IngestService/IngestLockfile"
+	// IngestServiceIngestGoModGraphProcedure is the fully-qualified name of the IngestService's
+	// IngestGoModGraph RPC.
+	IngestServiceIngestGoModGraphProcedure = "/api.v1.IngestService/IngestGoModGraph"
+	// IngestServiceIngestGovulncheckProcedure is the fully-qualified name of the IngestService's
+	// IngestGovulncheck RPC.
+	IngestServiceIngestGovulncheckProcedure = "/api.v1.IngestService/IngestGovulncheck"
+	// IngestServiceIngestEPSSProcedure is the fully-qualified name of the IngestService's IngestEPSS
+	// RPC.
+	IngestServiceIngestEPSSProcedure = "/api.v1.IngestService/IngestEPSS"
+	// IngestServiceIngestKEVProcedure is the fully-qualified name of the IngestService's IngestKEV RPC.
+	IngestServiceIngestKEVProcedure = "/api.v1.IngestService/IngestKEV"
+	// IngestServiceIngestAttestationProcedure is the fully-qualified name of the IngestService's
+	// IngestAttestation RPC.
+	IngestServiceIngestAttestationProcedure = "/api.v1.IngestService/IngestAttestation"
+	// AnnotationServiceSetAnnotationProcedure is the fully-qualified name of the AnnotationService's
+	// SetAnnotation RPC.
+	AnnotationServiceSetAnnotationProcedure = "/api.v1.AnnotationService/SetAnnotation"
+	// AnnotationServiceRemoveAnnotationProcedure is the fully-qualified name of the AnnotationService's
+	// RemoveAnnotation RPC.
+	AnnotationServiceRemoveAnnotationProcedure = "/api.v1.AnnotationService/RemoveAnnotation"
+	// AnnotationServiceGetAnnotationsProcedure is the fully-qualified name of the AnnotationService's
+	// GetAnnotations RPC.
+	AnnotationServiceGetAnnotationsProcedure = "/api.v1.AnnotationService/GetAnnotations"
+	// AdminServiceBackupProcedure is the fully-qualified name of the AdminService's Backup RPC.
+	AdminServiceBackupProcedure = "/api.v1.AdminService/Backup"
+	// AdminServiceRestoreProcedure is the fully-qualified name of the AdminService's Restore RPC.
+	AdminServiceRestoreProcedure = "/api.v1.AdminService/Restore"
+	// AdminServiceDedupeProcedure is the fully-qualified name of the AdminService's Dedupe RPC.
+	AdminServiceDedupeProcedure = "/api.v1.AdminService/Dedupe"
+	// PolicyServiceCheckLicensesProcedure is the fully-qualified name of the PolicyService's
+	// CheckLicenses RPC.
+	PolicyServiceCheckLicensesProcedure = "/api.v1.PolicyService/CheckLicenses"
 	// HealthServiceCheckProcedure is the fully-qualified name of the HealthService's Check RPC.
 	HealthServiceCheckProcedure = "/api.v1.HealthService/Check"
 )
@@ -96,12 +148,32 @@ var (
 	graphServiceGetNodeMethodDescriptor                 = graphServiceServiceDescriptor.Methods().ByName("GetNode")
 	graphServiceGetNodesByGlobMethodDescriptor          = graphServiceServiceDescriptor.Methods().ByName("GetNodesByGlob")
 	graphServiceGetNodeByNameMethodDescriptor           = graphServiceServiceDescriptor.Methods().ByName("GetNodeByName")
+	graphServiceSearchMetadataMethodDescriptor          = graphServiceServiceDescriptor.Methods().ByName("SearchMetadata")
+	graphServiceSearchNodesMethodDescriptor             = graphServiceServiceDescriptor.Methods().ByName("SearchNodes")
 	graphServiceAddNodeMethodDescriptor                 = graphServiceServiceDescriptor.Methods().ByName("AddNode")
 	graphServiceSetDependencyMethodDescriptor           = graphServiceServiceDescriptor.Methods().ByName("SetDependency")
 	ingestServiceServiceDescriptor                      = v1.File_api_v1_service_proto.Services().ByName("IngestService")
 	ingestServiceIngestSBOMMethodDescriptor             = ingestServiceServiceDescriptor.Methods().ByName("IngestSBOM")
 	ingestServiceIngestVulnerabilityMethodDescriptor    = ingestServiceServiceDescriptor.Methods().ByName("IngestVulnerability")
+	ingestServiceIngestVulnerabilitiesMethodDescriptor  = ingestServiceServiceDescriptor.Methods().ByName("IngestVulnerabilities")
 	ingestServiceIngestScorecardMethodDescriptor        = ingestServiceServiceDescriptor.Methods().ByName("IngestScorecard")
+	ingestServiceIngestVEXMethodDescriptor              = ingestServiceServiceDescriptor.Methods().ByName("IngestVEX")
+	ingestServiceIngestLockfileMethodDescriptor         = ingestServiceServiceDescriptor.Methods().ByName("IngestLockfile")
+	ingestServiceIngestGoModGraphMethodDescriptor       = ingestServiceServiceDescriptor.Methods().ByName("IngestGoModGraph")
+	ingestServiceIngestGovulncheckMethodDescriptor      = ingestServiceServiceDescriptor.Methods().ByName("IngestGovulncheck")
+	ingestServiceIngestEPSSMethodDescriptor             = ingestServiceServiceDescriptor.Methods().ByName("IngestEPSS")
+	ingestServiceIngestKEVMethodDescriptor              = ingestServiceServiceDescriptor.Methods().ByName("IngestKEV")
+	ingestServiceIngestAttestationMethodDescriptor      = ingestServiceServiceDescriptor.Methods().ByName("IngestAttestation")
+	annotationServiceServiceDescriptor                  = v1.File_api_v1_service_proto.Services().ByName("AnnotationService")
+	annotationServiceSetAnnotationMethodDescriptor      = annotationServiceServiceDescriptor.Methods().ByName("SetAnnotation")
+	annotationServiceRemoveAnnotationMethodDescriptor   = annotationServiceServiceDescriptor.Methods().ByName("RemoveAnnotation")
+	annotationServiceGetAnnotationsMethodDescriptor     = annotationServiceServiceDescriptor.Methods().ByName("GetAnnotations")
+	adminServiceServiceDescriptor                       = v1.File_api_v1_service_proto.Services().ByName("AdminService")
+	adminServiceBackupMethodDescriptor                  = adminServiceServiceDescriptor.Methods().ByName("Backup")
+	adminServiceRestoreMethodDescriptor                 = adminServiceServiceDescriptor.Methods().ByName("Restore")
+	adminServiceDedupeMethodDescriptor                  = adminServiceServiceDescriptor.Methods().ByName("Dedupe")
+	policyServiceServiceDescriptor                      = v1.File_api_v1_service_proto.Services().ByName("PolicyService")
+	policyServiceCheckLicensesMethodDescriptor          = policyServiceServiceDescriptor.Methods().ByName("CheckLicenses")
 	healthServiceServiceDescriptor                      = v1.File_api_v1_service_proto.Services().ByName("HealthService")
 	healthServiceCheckMethodDescriptor                  = healthServiceServiceDescriptor.Methods().ByName("Check")
 )
@@ -367,6 +439,8 @@ type GraphServiceClient interface {
 	GetNode(context.Context, *connect.Request[v1.GetNodeRequest]) (*connect.Response[v1.GetNodeResponse], error)
 	GetNodesByGlob(context.Context, *connect.Request[v1.GetNodesByGlobRequest]) (*connect.Response[v1.GetNodesByGlobResponse], error)
 	GetNodeByName(context.Context, *connect.Request[v1.GetNodeByNameRequest]) (*connect.Response[v1.GetNodeByNameResponse], error)
+	SearchMetadata(context.Context, *connect.Request[v1.SearchMetadataRequest]) (*connect.Response[v1.SearchMetadataResponse], error)
+	SearchNodes(context.Context, *connect.Request[v1.SearchNodesRequest]) (*connect.Response[v1.SearchNodesResponse], error)
 	AddNode(context.Context, *connect.Request[v1.AddNodeRequest]) (*connect.Response[v1.AddNodeResponse], error)
 	SetDependency(context.Context, *connect.Request[v1.SetDependencyRequest]) (*connect.Response[emptypb.Empty], error)
 }
@@ -399,6 +473,18 @@ func NewGraphServiceClient(httpClient connect.HTTPClient, baseURL string, opts .
 			connect.WithSchema(graphServiceGetNodeByNameMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		searchMetadata: connect.NewClient[v1.SearchMetadataRequest, v1.SearchMetadataResponse](
+			httpClient,
+			baseURL+GraphServiceSearchMetadataProcedure,
+			connect.WithSchema(graphServiceSearchMetadataMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		searchNodes: connect.NewClient[v1.SearchNodesRequest, v1.SearchNodesResponse](
+			httpClient,
+			baseURL+GraphServiceSearchNodesProcedure,
+			connect.WithSchema(graphServiceSearchNodesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 		addNode: connect.NewClient[v1.AddNodeRequest, v1.AddNodeResponse](
 			httpClient,
 			baseURL+GraphServiceAddNodeProcedure,
@@ -419,6 +505,8 @@ type graphServiceClient struct {
 	getNode        *connect.Client[v1.GetNodeRequest, v1.GetNodeResponse]
 	getNodesByGlob *connect.Client[v1.GetNodesByGlobRequest, v1.GetNodesByGlobResponse]
 	getNodeByName  *connect.Client[v1.GetNodeByNameRequest, v1.GetNodeByNameResponse]
+	searchMetadata *connect.Client[v1.SearchMetadataRequest, v1.SearchMetadataResponse]
+	searchNodes    *connect.Client[v1.SearchNodesRequest, v1.SearchNodesResponse]
 	addNode        *connect.Client[v1.AddNodeRequest, v1.AddNodeResponse]
 	setDependency  *connect.Client[v1.SetDependencyRequest, emptypb.Empty]
 }
@@ -438,6 +526,16 @@ func (c *graphServiceClient) GetNodeByName(ctx context.Context, req *connect.Req
 	return c.getNodeByName.CallUnary(ctx, req)
 }
 
+// SearchMetadata calls api.v1.GraphService.SearchMetadata.
+func (c *graphServiceClient) SearchMetadata(ctx context.Context, req *connect.Request[v1.SearchMetadataRequest]) (*connect.Response[v1.SearchMetadataResponse], error) {
+	return c.searchMetadata.CallUnary(ctx, req)
+}
+
+// SearchNodes calls api.v1.GraphService.SearchNodes.
+func (c *graphServiceClient) SearchNodes(ctx context.Context, req *connect.Request[v1.SearchNodesRequest]) (*connect.Response[v1.SearchNodesResponse], error) {
+	return c.searchNodes.CallUnary(ctx, req)
+}
+
 // AddNode calls api.v1.GraphService.AddNode.
 func (c *graphServiceClient) AddNode(ctx context.Context, req *connect.Request[v1.AddNodeRequest]) (*connect.Response[v1.AddNodeResponse], error) {
 	return c.addNode.CallUnary(ctx, req)
@@ -453,6 +551,8 @@ type GraphServiceHandler interface {
 	GetNode(context.Context, *connect.Request[v1.GetNodeRequest]) (*connect.Response[v1.GetNodeResponse], error)
 	GetNodesByGlob(context.Context, *connect.Request[v1.GetNodesByGlobRequest]) (*connect.Response[v1.GetNodesByGlobResponse], error)
 	GetNodeByName(context.Context, *connect.Request[v1.GetNodeByNameRequest]) (*connect.Response[v1.GetNodeByNameResponse], error)
+	SearchMetadata(context.Context, *connect.Request[v1.SearchMetadataRequest]) (*connect.Response[v1.SearchMetadataResponse], error)
+	SearchNodes(context.Context, *connect.Request[v1.SearchNodesRequest]) (*connect.Response[v1.SearchNodesResponse], error)
 	AddNode(context.Context, *connect.Request[v1.AddNodeRequest]) (*connect.Response[v1.AddNodeResponse], error)
 	SetDependency(context.Context, *connect.Request[v1.SetDependencyRequest]) (*connect.Response[emptypb.Empty], error)
 }
@@ -481,6 +581,18 @@ func NewGraphServiceHandler(svc GraphServiceHandler, opts ...connect.HandlerOpti
 		connect.WithSchema(graphServiceGetNodeByNameMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	graphServiceSearchMetadataHandler := connect.NewUnaryHandler(
+		GraphServiceSearchMetadataProcedure,
+		svc.SearchMetadata,
+		connect.WithSchema(graphServiceSearchMetadataMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	graphServiceSearchNodesHandler := connect.NewUnaryHandler(
+		GraphServiceSearchNodesProcedure,
+		svc.SearchNodes,
+		connect.WithSchema(graphServiceSearchNodesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	graphServiceAddNodeHandler := connect.NewUnaryHandler(
 		GraphServiceAddNodeProcedure,
 		svc.AddNode,
@@ -501,6 +613,10 @@ func NewGraphServiceHandler(svc GraphServiceHandler, opts ...connect.HandlerOpti
 			graphServiceGetNodesByGlobHandler.ServeHTTP(w, r)
 		case GraphServiceGetNodeByNameProcedure:
 			graphServiceGetNodeByNameHandler.ServeHTTP(w, r)
+		case GraphServiceSearchMetadataProcedure:
+			graphServiceSearchMetadataHandler.ServeHTTP(w, r)
+		case GraphServiceSearchNodesProcedure:
+			graphServiceSearchNodesHandler.ServeHTTP(w, r)
 		case GraphServiceAddNodeProcedure:
 			graphServiceAddNodeHandler.ServeHTTP(w, r)
 		case GraphServiceSetDependencyProcedure:
@@ -526,6 +642,14 @@ func (UnimplementedGraphServiceHandler) GetNodeByName(context.Context, *connect.
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.GraphService.GetNodeByName is not implemented"))
 }
 
+func (UnimplementedGraphServiceHandler) SearchMetadata(context.Context, *connect.Request[v1.SearchMetadataRequest]) (*connect.Response[v1.SearchMetadataResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.GraphService.SearchMetadata is not implemented"))
+}
+
+func (UnimplementedGraphServiceHandler) SearchNodes(context.Context, *connect.Request[v1.SearchNodesRequest]) (*connect.Response[v1.SearchNodesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.GraphService.SearchNodes is not implemented"))
+}
+
 func (UnimplementedGraphServiceHandler) AddNode(context.Context, *connect.Request[v1.AddNodeRequest]) (*connect.Response[v1.AddNodeResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.GraphService.AddNode is not implemented"))
 }
@@ -538,7 +662,15 @@ func (UnimplementedGraphServiceHandler) SetDependency(context.Context, *connect.
 type IngestServiceClient interface {
 	IngestSBOM(context.Context, *connect.Request[v1.IngestSBOMRequest]) (*connect.Response[emptypb.Empty], error)
 	IngestVulnerability(context.Context, *connect.Request[v1.IngestVulnerabilityRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestVulnerabilities(context.Context, *connect.Request[v1.IngestVulnerabilitiesRequest]) (*connect.Response[v1.IngestVulnerabilitiesResponse], error)
 	IngestScorecard(context.Context, *connect.Request[v1.IngestScorecardRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestVEX(context.Context, *connect.Request[v1.IngestVEXRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestLockfile(context.Context, *connect.Request[v1.IngestLockfileRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestGoModGraph(context.Context, *connect.Request[v1.IngestGoModGraphRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestGovulncheck(context.Context, *connect.Request[v1.IngestGovulncheckRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestEPSS(context.Context, *connect.Request[v1.IngestEPSSRequest]) (*connect.Response[v1.IngestEPSSResponse], error)
+	IngestKEV(context.Context, *connect.Request[v1.IngestKEVRequest]) (*connect.Response[v1.IngestKEVResponse], error)
+	IngestAttestation(context.Context, *connect.Request[v1.IngestAttestationRequest]) (*connect.Response[v1.IngestAttestationResponse], error)
 }
 
 // NewIngestServiceClient constructs a client for the api.v1.IngestService service. By default, it
@@ -563,20 +695,76 @@ func NewIngestServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(ingestServiceIngestVulnerabilityMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		ingestVulnerabilities: connect.NewClient[v1.IngestVulnerabilitiesRequest, v1.IngestVulnerabilitiesResponse](
+			httpClient,
+			baseURL+IngestServiceIngestVulnerabilitiesProcedure,
+			connect.WithSchema(ingestServiceIngestVulnerabilitiesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 		ingestScorecard: connect.NewClient[v1.IngestScorecardRequest, emptypb.Empty](
 			httpClient,
 			baseURL+IngestServiceIngestScorecardProcedure,
 			connect.WithSchema(ingestServiceIngestScorecardMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		ingestVEX: connect.NewClient[v1.IngestVEXRequest, emptypb.Empty](
+			httpClient,
+			baseURL+IngestServiceIngestVEXProcedure,
+			connect.WithSchema(ingestServiceIngestVEXMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		ingestLockfile: connect.NewClient[v1.IngestLockfileRequest, emptypb.Empty](
+			httpClient,
+			baseURL+IngestServiceIngestLockfileProcedure,
+			connect.WithSchema(ingestServiceIngestLockfileMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		ingestGoModGraph: connect.NewClient[v1.IngestGoModGraphRequest, emptypb.Empty](
+			httpClient,
+			baseURL+IngestServiceIngestGoModGraphProcedure,
+			connect.WithSchema(ingestServiceIngestGoModGraphMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		ingestGovulncheck: connect.NewClient[v1.IngestGovulncheckRequest, emptypb.Empty](
+			httpClient,
+			baseURL+IngestServiceIngestGovulncheckProcedure,
+			connect.WithSchema(ingestServiceIngestGovulncheckMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		ingestEPSS: connect.NewClient[v1.IngestEPSSRequest, v1.IngestEPSSResponse](
+			httpClient,
+			baseURL+IngestServiceIngestEPSSProcedure,
+			connect.WithSchema(ingestServiceIngestEPSSMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		ingestKEV: connect.NewClient[v1.IngestKEVRequest, v1.IngestKEVResponse](
+			httpClient,
+			baseURL+IngestServiceIngestKEVProcedure,
+			connect.WithSchema(ingestServiceIngestKEVMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		ingestAttestation: connect.NewClient[v1.IngestAttestationRequest, v1.IngestAttestationResponse](
+			httpClient,
+			baseURL+IngestServiceIngestAttestationProcedure,
+			connect.WithSchema(ingestServiceIngestAttestationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // ingestServiceClient implements IngestServiceClient.
 type ingestServiceClient struct {
-	ingestSBOM          *connect.Client[v1.IngestSBOMRequest, emptypb.Empty]
-	ingestVulnerability *connect.Client[v1.IngestVulnerabilityRequest, emptypb.Empty]
-	ingestScorecard     *connect.Client[v1.IngestScorecardRequest, emptypb.Empty]
+	ingestSBOM            *connect.Client[v1.IngestSBOMRequest, emptypb.Empty]
+	ingestVulnerability   *connect.Client[v1.IngestVulnerabilityRequest, emptypb.Empty]
+	ingestVulnerabilities *connect.Client[v1.IngestVulnerabilitiesRequest, v1.IngestVulnerabilitiesResponse]
+	ingestScorecard       *connect.Client[v1.IngestScorecardRequest, emptypb.Empty]
+	ingestVEX             *connect.Client[v1.IngestVEXRequest, emptypb.Empty]
+	ingestLockfile        *connect.Client[v1.IngestLockfileRequest, emptypb.Empty]
+	ingestGoModGraph      *connect.Client[v1.IngestGoModGraphRequest, emptypb.Empty]
+	ingestGovulncheck     *connect.Client[v1.IngestGovulncheckRequest, emptypb.Empty]
+	ingestEPSS            *connect.Client[v1.IngestEPSSRequest, v1.IngestEPSSResponse]
+	ingestKEV             *connect.Client[v1.IngestKEVRequest, v1.IngestKEVResponse]
+	ingestAttestation     *connect.Client[v1.IngestAttestationRequest, v1.IngestAttestationResponse]
 }
 
 // IngestSBOM calls api.v1.IngestService.IngestSBOM.
@@ -589,16 +777,64 @@ func (c *ingestServiceClient) IngestVulnerability(ctx context.Context, req *conn
 	return c.ingestVulnerability.CallUnary(ctx, req)
 }
 
+// IngestVulnerabilities calls api.v1.IngestService.IngestVulnerabilities.
+func (c *ingestServiceClient) IngestVulnerabilities(ctx context.Context, req *connect.Request[v1.IngestVulnerabilitiesRequest]) (*connect.Response[v1.IngestVulnerabilitiesResponse], error) {
+	return c.ingestVulnerabilities.CallUnary(ctx, req)
+}
+
 // IngestScorecard calls api.v1.IngestService.IngestScorecard.
 func (c *ingestServiceClient) IngestScorecard(ctx context.Context, req *connect.Request[v1.IngestScorecardRequest]) (*connect.Response[emptypb.Empty], error) {
 	return c.ingestScorecard.CallUnary(ctx, req)
 }
 
+// IngestVEX calls api.v1.IngestService.IngestVEX.
+func (c *ingestServiceClient) IngestVEX(ctx context.Context, req *connect.Request[v1.IngestVEXRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.ingestVEX.CallUnary(ctx, req)
+}
+
+// IngestLockfile calls api.v1.IngestService.IngestLockfile.
+func (c *ingestServiceClient) IngestLockfile(ctx context.Context, req *connect.Request[v1.IngestLockfileRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.ingestLockfile.CallUnary(ctx, req)
+}
+
+// IngestGoModGraph calls api.v1.IngestService.IngestGoModGraph.
+func (c *ingestServiceClient) IngestGoModGraph(ctx context.Context, req *connect.Request[v1.IngestGoModGraphRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.ingestGoModGraph.CallUnary(ctx, req)
+}
+
+// IngestGovulncheck calls api.v1.IngestService.IngestGovulncheck.
+func (c *ingestServiceClient) IngestGovulncheck(ctx context.Context, req *connect.Request[v1.IngestGovulncheckRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.ingestGovulncheck.CallUnary(ctx, req)
+}
+
+// IngestEPSS calls api.v1.IngestService.IngestEPSS.
+func (c *ingestServiceClient) IngestEPSS(ctx context.Context, req *connect.Request[v1.IngestEPSSRequest]) (*connect.Response[v1.IngestEPSSResponse], error) {
+	return c.ingestEPSS.CallUnary(ctx, req)
+}
+
+// IngestKEV calls api.v1.IngestService.IngestKEV.
+func (c *ingestServiceClient) IngestKEV(ctx context.Context, req *connect.Request[v1.IngestKEVRequest]) (*connect.Response[v1.IngestKEVResponse], error) {
+	return c.ingestKEV.CallUnary(ctx, req)
+}
+
+// IngestAttestation calls api.v1.IngestService.IngestAttestation.
+func (c *ingestServiceClient) IngestAttestation(ctx context.Context, req *connect.Request[v1.IngestAttestationRequest]) (*connect.Response[v1.IngestAttestationResponse], error) {
+	return c.ingestAttestation.CallUnary(ctx, req)
+}
+
 // IngestServiceHandler is an implementation of the api.v1.IngestService service.
 type IngestServiceHandler interface {
 	IngestSBOM(context.Context, *connect.Request[v1.IngestSBOMRequest]) (*connect.Response[emptypb.Empty], error)
 	IngestVulnerability(context.Context, *connect.Request[v1.IngestVulnerabilityRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestVulnerabilities(context.Context, *connect.Request[v1.IngestVulnerabilitiesRequest]) (*connect.Response[v1.IngestVulnerabilitiesResponse], error)
 	IngestScorecard(context.Context, *connect.Request[v1.IngestScorecardRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestVEX(context.Context, *connect.Request[v1.IngestVEXRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestLockfile(context.Context, *connect.Request[v1.IngestLockfileRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestGoModGraph(context.Context, *connect.Request[v1.IngestGoModGraphRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestGovulncheck(context.Context, *connect.Request[v1.IngestGovulncheckRequest]) (*connect.Response[emptypb.Empty], error)
+	IngestEPSS(context.Context, *connect.Request[v1.IngestEPSSRequest]) (*connect.Response[v1.IngestEPSSResponse], error)
+	IngestKEV(context.Context, *connect.Request[v1.IngestKEVRequest]) (*connect.Response[v1.IngestKEVResponse], error)
+	IngestAttestation(context.Context, *connect.Request[v1.IngestAttestationRequest]) (*connect.Response[v1.IngestAttestationResponse], error)
 }
 
 // NewIngestServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -619,20 +855,84 @@ func NewIngestServiceHandler(svc IngestServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(ingestServiceIngestVulnerabilityMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	ingestServiceIngestVulnerabilitiesHandler := connect.NewUnaryHandler(
+		IngestServiceIngestVulnerabilitiesProcedure,
+		svc.IngestVulnerabilities,
+		connect.WithSchema(ingestServiceIngestVulnerabilitiesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	ingestServiceIngestScorecardHandler := connect.NewUnaryHandler(
 		IngestServiceIngestScorecardProcedure,
 		svc.IngestScorecard,
 		connect.WithSchema(ingestServiceIngestScorecardMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	ingestServiceIngestVEXHandler := connect.NewUnaryHandler(
+		IngestServiceIngestVEXProcedure,
+		svc.IngestVEX,
+		connect.WithSchema(ingestServiceIngestVEXMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ingestServiceIngestLockfileHandler := connect.NewUnaryHandler(
+		IngestServiceIngestLockfileProcedure,
+		svc.IngestLockfile,
+		connect.WithSchema(ingestServiceIngestLockfileMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ingestServiceIngestGoModGraphHandler := connect.NewUnaryHandler(
+		IngestServiceIngestGoModGraphProcedure,
+		svc.IngestGoModGraph,
+		connect.WithSchema(ingestServiceIngestGoModGraphMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ingestServiceIngestGovulncheckHandler := connect.NewUnaryHandler(
+		IngestServiceIngestGovulncheckProcedure,
+		svc.IngestGovulncheck,
+		connect.WithSchema(ingestServiceIngestGovulncheckMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ingestServiceIngestEPSSHandler := connect.NewUnaryHandler(
+		IngestServiceIngestEPSSProcedure,
+		svc.IngestEPSS,
+		connect.WithSchema(ingestServiceIngestEPSSMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ingestServiceIngestKEVHandler := connect.NewUnaryHandler(
+		IngestServiceIngestKEVProcedure,
+		svc.IngestKEV,
+		connect.WithSchema(ingestServiceIngestKEVMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	ingestServiceIngestAttestationHandler := connect.NewUnaryHandler(
+		IngestServiceIngestAttestationProcedure,
+		svc.IngestAttestation,
+		connect.WithSchema(ingestServiceIngestAttestationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/api.v1.IngestService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case IngestServiceIngestSBOMProcedure:
 			ingestServiceIngestSBOMHandler.ServeHTTP(w, r)
 		case IngestServiceIngestVulnerabilityProcedure:
 			ingestServiceIngestVulnerabilityHandler.ServeHTTP(w, r)
+		case IngestServiceIngestVulnerabilitiesProcedure:
+			ingestServiceIngestVulnerabilitiesHandler.ServeHTTP(w, r)
 		case IngestServiceIngestScorecardProcedure:
 			ingestServiceIngestScorecardHandler.ServeHTTP(w, r)
+		case IngestServiceIngestVEXProcedure:
+			ingestServiceIngestVEXHandler.ServeHTTP(w, r)
+		case IngestServiceIngestLockfileProcedure:
+			ingestServiceIngestLockfileHandler.ServeHTTP(w, r)
+		case IngestServiceIngestGoModGraphProcedure:
+			ingestServiceIngestGoModGraphHandler.ServeHTTP(w, r)
+		case IngestServiceIngestGovulncheckProcedure:
+			ingestServiceIngestGovulncheckHandler.ServeHTTP(w, r)
+		case IngestServiceIngestEPSSProcedure:
+			ingestServiceIngestEPSSHandler.ServeHTTP(w, r)
+		case IngestServiceIngestKEVProcedure:
+			ingestServiceIngestKEVHandler.ServeHTTP(w, r)
+		case IngestServiceIngestAttestationProcedure:
+			ingestServiceIngestAttestationHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -650,10 +950,350 @@ func (UnimplementedIngestServiceHandler) IngestVulnerability(context.Context, *c
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestVulnerability is not implemented"))
 }
 
+func (UnimplementedIngestServiceHandler) IngestVulnerabilities(context.Context, *connect.Request[v1.IngestVulnerabilitiesRequest]) (*connect.Response[v1.IngestVulnerabilitiesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestVulnerabilities is not implemented"))
+}
+
 func (UnimplementedIngestServiceHandler) IngestScorecard(context.Context, *connect.Request[v1.IngestScorecardRequest]) (*connect.Response[emptypb.Empty], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestScorecard is not implemented"))
 }
 
+func (UnimplementedIngestServiceHandler) IngestVEX(context.Context, *connect.Request[v1.IngestVEXRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestVEX is not implemented"))
+}
+
+func (UnimplementedIngestServiceHandler) IngestLockfile(context.Context, *connect.Request[v1.IngestLockfileRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestLockfile is not implemented"))
+}
+
+func (UnimplementedIngestServiceHandler) IngestGoModGraph(context.Context, *connect.Request[v1.IngestGoModGraphRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestGoModGraph is not implemented"))
+}
+
+func (UnimplementedIngestServiceHandler) IngestGovulncheck(context.Context, *connect.Request[v1.IngestGovulncheckRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestGovulncheck is not implemented"))
+}
+
+func (UnimplementedIngestServiceHandler) IngestEPSS(context.Context, *connect.Request[v1.IngestEPSSRequest]) (*connect.Response[v1.IngestEPSSResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestEPSS is not implemented"))
+}
+
+func (UnimplementedIngestServiceHandler) IngestKEV(context.Context, *connect.Request[v1.IngestKEVRequest]) (*connect.Response[v1.IngestKEVResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestKEV is not implemented"))
+}
+
+func (UnimplementedIngestServiceHandler) IngestAttestation(context.Context, *connect.Request[v1.IngestAttestationRequest]) (*connect.Response[v1.IngestAttestationResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.IngestService.IngestAttestation is not implemented"))
+}
+
+// AnnotationServiceClient is a client for the api.v1.AnnotationService service.
+type AnnotationServiceClient interface {
+	SetAnnotation(context.Context, *connect.Request[v1.SetAnnotationRequest]) (*connect.Response[emptypb.Empty], error)
+	RemoveAnnotation(context.Context, *connect.Request[v1.RemoveAnnotationRequest]) (*connect.Response[emptypb.Empty], error)
+	GetAnnotations(context.Context, *connect.Request[v1.GetAnnotationsRequest]) (*connect.Response[v1.GetAnnotationsResponse], error)
+}
+
+// NewAnnotationServiceClient constructs a client for the api.v1.AnnotationService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewAnnotationServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) AnnotationServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &annotationServiceClient{
+		setAnnotation: connect.NewClient[v1.SetAnnotationRequest, emptypb.Empty](
+			httpClient,
+			baseURL+AnnotationServiceSetAnnotationProcedure,
+			connect.WithSchema(annotationServiceSetAnnotationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		removeAnnotation: connect.NewClient[v1.RemoveAnnotationRequest, emptypb.Empty](
+			httpClient,
+			baseURL+AnnotationServiceRemoveAnnotationProcedure,
+			connect.WithSchema(annotationServiceRemoveAnnotationMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getAnnotations: connect.NewClient[v1.GetAnnotationsRequest, v1.GetAnnotationsResponse](
+			httpClient,
+			baseURL+AnnotationServiceGetAnnotationsProcedure,
+			connect.WithSchema(annotationServiceGetAnnotationsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// annotationServiceClient implements AnnotationServiceClient.
+type annotationServiceClient struct {
+	setAnnotation    *connect.Client[v1.SetAnnotationRequest, emptypb.Empty]
+	removeAnnotation *connect.Client[v1.RemoveAnnotationRequest, emptypb.Empty]
+	getAnnotations   *connect.Client[v1.GetAnnotationsRequest, v1.GetAnnotationsResponse]
+}
+
+// SetAnnotation calls api.v1.AnnotationService.SetAnnotation.
+func (c *annotationServiceClient) SetAnnotation(ctx context.Context, req *connect.Request[v1.SetAnnotationRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.setAnnotation.CallUnary(ctx, req)
+}
+
+// RemoveAnnotation calls api.v1.AnnotationService.RemoveAnnotation.
+func (c *annotationServiceClient) RemoveAnnotation(ctx context.Context, req *connect.Request[v1.RemoveAnnotationRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.removeAnnotation.CallUnary(ctx, req)
+}
+
+// GetAnnotations calls api.v1.AnnotationService.GetAnnotations.
+func (c *annotationServiceClient) GetAnnotations(ctx context.Context, req *connect.Request[v1.GetAnnotationsRequest]) (*connect.Response[v1.GetAnnotationsResponse], error) {
+	return c.getAnnotations.CallUnary(ctx, req)
+}
+
+// AnnotationServiceHandler is an implementation of the api.v1.AnnotationService service.
+type AnnotationServiceHandler interface {
+	SetAnnotation(context.Context, *connect.Request[v1.SetAnnotationRequest]) (*connect.Response[emptypb.Empty], error)
+	RemoveAnnotation(context.Context, *connect.Request[v1.RemoveAnnotationRequest]) (*connect.Response[emptypb.Empty], error)
+	GetAnnotations(context.Context, *connect.Request[v1.GetAnnotationsRequest]) (*connect.Response[v1.GetAnnotationsResponse], error)
+}
+
+// NewAnnotationServiceHandler builds an HTTP handler from the service implementation. It returns
+// the path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewAnnotationServiceHandler(svc AnnotationServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	annotationServiceSetAnnotationHandler := connect.NewUnaryHandler(
+		AnnotationServiceSetAnnotationProcedure,
+		svc.SetAnnotation,
+		connect.WithSchema(annotationServiceSetAnnotationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	annotationServiceRemoveAnnotationHandler := connect.NewUnaryHandler(
+		AnnotationServiceRemoveAnnotationProcedure,
+		svc.RemoveAnnotation,
+		connect.WithSchema(annotationServiceRemoveAnnotationMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	annotationServiceGetAnnotationsHandler := connect.NewUnaryHandler(
+		AnnotationServiceGetAnnotationsProcedure,
+		svc.GetAnnotations,
+		connect.WithSchema(annotationServiceGetAnnotationsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/api.v1.AnnotationService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case AnnotationServiceSetAnnotationProcedure:
+			annotationServiceSetAnnotationHandler.ServeHTTP(w, r)
+		case AnnotationServiceRemoveAnnotationProcedure:
+			annotationServiceRemoveAnnotationHandler.ServeHTTP(w, r)
+		case AnnotationServiceGetAnnotationsProcedure:
+			annotationServiceGetAnnotationsHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedAnnotationServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedAnnotationServiceHandler struct{}
+
+func (UnimplementedAnnotationServiceHandler) SetAnnotation(context.Context, *connect.Request[v1.SetAnnotationRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.AnnotationService.SetAnnotation is not implemented"))
+}
+
+func (UnimplementedAnnotationServiceHandler) RemoveAnnotation(context.Context, *connect.Request[v1.RemoveAnnotationRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.AnnotationService.RemoveAnnotation is not implemented"))
+}
+
+func (UnimplementedAnnotationServiceHandler) GetAnnotations(context.Context, *connect.Request[v1.GetAnnotationsRequest]) (*connect.Response[v1.GetAnnotationsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.AnnotationService.GetAnnotations is not implemented"))
+}
+
+// AdminServiceClient is a client for the api.v1.AdminService service.
+type AdminServiceClient interface {
+	Backup(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[v1.BackupResponse], error)
+	Restore(context.Context, *connect.Request[v1.RestoreRequest]) (*connect.Response[v1.RestoreResponse], error)
+	Dedupe(context.Context, *connect.Request[v1.DedupeRequest]) (*connect.Response[v1.DedupeResponse], error)
+}
+
+// NewAdminServiceClient constructs a client for the api.v1.AdminService service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewAdminServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) AdminServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &adminServiceClient{
+		backup: connect.NewClient[emptypb.Empty, v1.BackupResponse](
+			httpClient,
+			baseURL+AdminServiceBackupProcedure,
+			connect.WithSchema(adminServiceBackupMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		restore: connect.NewClient[v1.RestoreRequest, v1.RestoreResponse](
+			httpClient,
+			baseURL+AdminServiceRestoreProcedure,
+			connect.WithSchema(adminServiceRestoreMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		dedupe: connect.NewClient[v1.DedupeRequest, v1.DedupeResponse](
+			httpClient,
+			baseURL+AdminServiceDedupeProcedure,
+			connect.WithSchema(adminServiceDedupeMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// adminServiceClient implements AdminServiceClient.
+type adminServiceClient struct {
+	backup  *connect.Client[emptypb.Empty, v1.BackupResponse]
+	restore *connect.Client[v1.RestoreRequest, v1.RestoreResponse]
+	dedupe  *connect.Client[v1.DedupeRequest, v1.DedupeResponse]
+}
+
+// Backup calls api.v1.AdminService.Backup.
+func (c *adminServiceClient) Backup(ctx context.Context, req *connect.Request[emptypb.Empty]) (*connect.Response[v1.BackupResponse], error) {
+	return c.backup.CallUnary(ctx, req)
+}
+
+// Restore calls api.v1.AdminService.Restore.
+func (c *adminServiceClient) Restore(ctx context.Context, req *connect.Request[v1.RestoreRequest]) (*connect.Response[v1.RestoreResponse], error) {
+	return c.restore.CallUnary(ctx, req)
+}
+
+// Dedupe calls api.v1.AdminService.Dedupe.
+func (c *adminServiceClient) Dedupe(ctx context.Context, req *connect.Request[v1.DedupeRequest]) (*connect.Response[v1.DedupeResponse], error) {
+	return c.dedupe.CallUnary(ctx, req)
+}
+
+// AdminServiceHandler is an implementation of the api.v1.AdminService service.
+type AdminServiceHandler interface {
+	Backup(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[v1.BackupResponse], error)
+	Restore(context.Context, *connect.Request[v1.RestoreRequest]) (*connect.Response[v1.RestoreResponse], error)
+	Dedupe(context.Context, *connect.Request[v1.DedupeRequest]) (*connect.Response[v1.DedupeResponse], error)
+}
+
+// NewAdminServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewAdminServiceHandler(svc AdminServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	adminServiceBackupHandler := connect.NewUnaryHandler(
+		AdminServiceBackupProcedure,
+		svc.Backup,
+		connect.WithSchema(adminServiceBackupMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	adminServiceRestoreHandler := connect.NewUnaryHandler(
+		AdminServiceRestoreProcedure,
+		svc.Restore,
+		connect.WithSchema(adminServiceRestoreMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	adminServiceDedupeHandler := connect.NewUnaryHandler(
+		AdminServiceDedupeProcedure,
+		svc.Dedupe,
+		connect.WithSchema(adminServiceDedupeMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/api.v1.AdminService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case AdminServiceBackupProcedure:
+			adminServiceBackupHandler.ServeHTTP(w, r)
+		case AdminServiceRestoreProcedure:
+			adminServiceRestoreHandler.ServeHTTP(w, r)
+		case AdminServiceDedupeProcedure:
+			adminServiceDedupeHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedAdminServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedAdminServiceHandler struct{}
+
+func (UnimplementedAdminServiceHandler) Backup(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[v1.BackupResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.AdminService.Backup is not implemented"))
+}
+
+func (UnimplementedAdminServiceHandler) Restore(context.Context, *connect.Request[v1.RestoreRequest]) (*connect.Response[v1.RestoreResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.AdminService.Restore is not implemented"))
+}
+
+func (UnimplementedAdminServiceHandler) Dedupe(context.Context, *connect.Request[v1.DedupeRequest]) (*connect.Response[v1.DedupeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.AdminService.Dedupe is not implemented"))
+}
+
+// PolicyServiceClient is a client for the api.v1.PolicyService service.
+type PolicyServiceClient interface {
+	CheckLicenses(context.Context, *connect.Request[v1.CheckLicensesRequest]) (*connect.Response[v1.CheckLicensesResponse], error)
+}
+
+// NewPolicyServiceClient constructs a client for the api.v1.PolicyService service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewPolicyServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) PolicyServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &policyServiceClient{
+		checkLicenses: connect.NewClient[v1.CheckLicensesRequest, v1.CheckLicensesResponse](
+			httpClient,
+			baseURL+PolicyServiceCheckLicensesProcedure,
+			connect.WithSchema(policyServiceCheckLicensesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// policyServiceClient implements PolicyServiceClient.
+type policyServiceClient struct {
+	checkLicenses *connect.Client[v1.CheckLicensesRequest, v1.CheckLicensesResponse]
+}
+
+// CheckLicenses calls api.v1.PolicyService.CheckLicenses.
+func (c *policyServiceClient) CheckLicenses(ctx context.Context, req *connect.Request[v1.CheckLicensesRequest]) (*connect.Response[v1.CheckLicensesResponse], error) {
+	return c.checkLicenses.CallUnary(ctx, req)
+}
+
+// PolicyServiceHandler is an implementation of the api.v1.PolicyService service.
+type PolicyServiceHandler interface {
+	CheckLicenses(context.Context, *connect.Request[v1.CheckLicensesRequest]) (*connect.Response[v1.CheckLicensesResponse], error)
+}
+
+// NewPolicyServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewPolicyServiceHandler(svc PolicyServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	policyServiceCheckLicensesHandler := connect.NewUnaryHandler(
+		PolicyServiceCheckLicensesProcedure,
+		svc.CheckLicenses,
+		connect.WithSchema(policyServiceCheckLicensesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/api.v1.PolicyService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case PolicyServiceCheckLicensesProcedure:
+			policyServiceCheckLicensesHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedPolicyServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedPolicyServiceHandler struct{}
+
+func (UnimplementedPolicyServiceHandler) CheckLicenses(context.Context, *connect.Request[v1.CheckLicensesRequest]) (*connect.Response[v1.CheckLicensesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("api.v1.PolicyService.CheckLicenses is not implemented"))
+}
+
 // HealthServiceClient is a client for the api.v1.HealthService service.
 type HealthServiceClient interface {
 	Check(context.Context, *connect.Request[emptypb.Empty]) (*connect.Response[v1.HealthCheckResponse], error)
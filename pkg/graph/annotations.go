@@ -0,0 +1,86 @@
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// AnnotationTag is the custom data tag annotations are stored under, keyed by node name.
+const AnnotationTag = "annotations"
+
+// annotationKeyPattern restricts keys to what the query language can refer to as tag.<key>.
+var annotationKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_\-]*$`)
+
+// ValidateAnnotationKey checks that key can be used as an annotation key.
+func ValidateAnnotationKey(key string) error {
+	if !annotationKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid annotation key %q: must start with a letter and contain only letters, digits, '_' and '-'", key)
+	}
+	return nil
+}
+
+// SetAnnotation attaches a key/value annotation, such as owner=team-payments, to the node with the given name.
+func SetAnnotation(storage Storage, name, key, value string) error {
+	if err := ValidateAnnotationKey(key); err != nil {
+		return err
+	}
+	name = CanonicalName(name)
+	if _, err := storage.NameToID(name); err != nil {
+		return fmt.Errorf("failed to find node %s: %w", name, err)
+	}
+	if err := storage.AddOrUpdateCustomData(AnnotationTag, name, key, []byte(value)); err != nil {
+		return fmt.Errorf("failed to save annotation: %w", err)
+	}
+	return nil
+}
+
+// RemoveAnnotation removes an annotation from the node with the given name.
+func RemoveAnnotation(storage Storage, name, key string) error {
+	if err := storage.DeleteCustomData(AnnotationTag, CanonicalName(name), key); err != nil {
+		return fmt.Errorf("failed to remove annotation: %w", err)
+	}
+	return nil
+}
+
+// GetAnnotations returns every annotation of the node with the given name.
+func GetAnnotations(storage Storage, name string) (map[string]string, error) {
+	data, err := storage.GetCustomData(AnnotationTag, CanonicalName(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get annotations: %w", err)
+	}
+	annotations := make(map[string]string, len(data))
+	for key, value := range data {
+		annotations[key] = string(value)
+	}
+	return annotations, nil
+}
+
+// annotationLookup memoizes annotations while queries are evaluated, since the same node is usually checked by many
+// filters.
+type annotationLookup struct {
+	storage Storage
+	mu      sync.RWMutex
+	byName  map[string]map[string]string
+}
+
+func newAnnotationLookup(storage Storage) *annotationLookup {
+	return &annotationLookup{storage: storage, byName: make(map[string]map[string]string)}
+}
+
+func (a *annotationLookup) get(name string) (map[string]string, error) {
+	a.mu.RLock()
+	annotations, ok := a.byName[name]
+	a.mu.RUnlock()
+	if ok {
+		return annotations, nil
+	}
+	annotations, err := GetAnnotations(a.storage, name)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	a.byName[name] = annotations
+	a.mu.Unlock()
+	return annotations, nil
+}
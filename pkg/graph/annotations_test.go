@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotations(t *testing.T) {
+	storage := NewMockStorage()
+	node, err := AddNode(storage, "library", nil, "pkg:generic/lib@1.0.0")
+	require.NoError(t, err)
+
+	annotations, err := GetAnnotations(storage, node.Name)
+	require.NoError(t, err)
+	assert.Empty(t, annotations)
+
+	require.NoError(t, SetAnnotation(storage, node.Name, "owner", "team-payments"))
+	require.NoError(t, SetAnnotation(storage, node.Name, "internal", "true"))
+	require.NoError(t, SetAnnotation(storage, node.Name, "owner", "team-infra"))
+	annotations, err = GetAnnotations(storage, node.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team-infra", "internal": "true"}, annotations)
+
+	require.NoError(t, RemoveAnnotation(storage, node.Name, "internal"))
+	annotations, err = GetAnnotations(storage, node.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team-infra"}, annotations)
+}
+
+func TestSetAnnotationValidation(t *testing.T) {
+	storage := NewMockStorage()
+	node, err := AddNode(storage, "library", nil, "pkg:generic/lib@1.0.0")
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "1owner", "team.owner", "owner name"} {
+		assert.Error(t, SetAnnotation(storage, node.Name, key, "value"), "key %q", key)
+	}
+	assert.ErrorContains(t, SetAnnotation(storage, "pkg:generic/missing@1.0.0", "owner", "value"), "failed to find node")
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/RoaringBitmap/roaring"
 	"github.com/bitbomdev/minefield/pkg/utils"
@@ -26,7 +27,7 @@ func Cache(storage Storage) error {
 		return fmt.Errorf("error getting all nodes: %w", err)
 	}
 
-	scc := findCycles(len(keys), allNodes)
+	scc := findCycles(allNodes)
 
 	cachedChildren, err := buildCache(uncachedNodes, ChildrenDirection, scc, allNodes)
 	if err != nil {
@@ -70,7 +71,7 @@ func Cache(storage Storage) error {
 	return storage.ClearCacheStack()
 }
 
-func findCycles(numOfNodes int, allNodes map[uint32]*Node) map[uint32]uint32 {
+func findCycles(allNodes map[uint32]*Node) map[uint32]uint32 {
 	var stack []uint32
 	var tarjanDFS func(nodeID uint32)
 
@@ -110,9 +111,15 @@ func findCycles(numOfNodes int, allNodes map[uint32]*Node) map[uint32]uint32 {
 		}
 	}
 
-	for id := 1; id < numOfNodes+1; id++ {
-		if _, visited := nodeToTarjanID[uint32(id)]; !visited {
-			tarjanDFS(uint32(id))
+	// IDs have gaps once nodes are removed, so walk the nodes that exist in ID order
+	ids := make([]uint32, 0, len(allNodes))
+	for id := range allNodes {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		if _, visited := nodeToTarjanID[id]; !visited {
+			tarjanDFS(id)
 		}
 	}
 
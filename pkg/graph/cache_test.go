@@ -21,7 +21,7 @@ func Test_findCycles(t *testing.T) {
 	allNodes, err := storage.GetNodes([]uint32{node1.ID, node2.ID})
 	assert.NoError(t, err)
 
-	got := findCycles(2, allNodes)
+	got := findCycles(allNodes)
 	assert.Equal(t, map[uint32]uint32{1: 1, 2: 2}, got)
 }
 
@@ -44,7 +44,7 @@ func Test_findCycles_With_Cycles(t *testing.T) {
 	allNodes, err := storage.GetNodes([]uint32{node1.ID, node2.ID, node3.ID})
 	assert.NoError(t, err)
 
-	got := findCycles(3, allNodes)
+	got := findCycles(allNodes)
 
 	assert.Equal(t, map[uint32]uint32{1: 1, 2: 1, 3: 1}, got)
 }
@@ -0,0 +1,207 @@
+package graph
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/goccy/go-json"
+)
+
+// EdgeAttributeTag is the custom data tag edge attributes are stored under, keyed by the name of the node the edge
+// points to and then by the name of the node it starts from. Attributes don't require the edge, or its nodes, to
+// exist yet, so they can be recorded before the graph catches up.
+const EdgeAttributeTag = "edge_attributes"
+
+// EdgeAttributeTargetsTag is the custom data tag listing the names of the nodes that edges with attributes point to,
+// so that queries only read the attributes of those nodes, and none at all on graphs without any.
+const EdgeAttributeTargetsTag = "edge_attribute_targets"
+
+// edgeAttributeTargetsKey is the single key of EdgeAttributeTargetsTag, the names being its data keys.
+const edgeAttributeTargetsKey = "nodes"
+
+// VEXStatusAttribute is the edge attribute holding the VEX status of the vulnerability an edge points to, for the
+// node it starts from.
+const VEXStatusAttribute = "vex_status"
+
+// VEX statuses, see https://github.com/openvex/spec.
+const (
+	VEXStatusNotAffected        = "not_affected"
+	VEXStatusAffected           = "affected"
+	VEXStatusFixed              = "fixed"
+	VEXStatusUnderInvestigation = "under_investigation"
+)
+
+// IsSuppressingVEXStatus reports whether a VEX status says the vulnerability doesn't affect the product.
+func IsSuppressingVEXStatus(status string) bool {
+	return status == VEXStatusNotAffected || status == VEXStatusFixed
+}
+
+// SetEdgeAttribute sets an attribute of the edge from one node to another, by name.
+func SetEdgeAttribute(storage Storage, from, to, key, value string) error {
+	attributes, err := GetEdgeAttributes(storage, to)
+	if err != nil {
+		return err
+	}
+	edge := attributes[from]
+	if edge == nil {
+		edge = map[string]string{}
+	}
+	edge[key] = value
+	data, err := json.Marshal(edge)
+	if err != nil {
+		return fmt.Errorf("failed to marshal edge attributes: %w", err)
+	}
+	if err := storage.AddOrUpdateCustomData(EdgeAttributeTag, to, from, data); err != nil {
+		return fmt.Errorf("failed to save edge attribute: %w", err)
+	}
+	return addEdgeAttributeTarget(storage, to)
+}
+
+func addEdgeAttributeTarget(storage Storage, to string) error {
+	if err := storage.AddOrUpdateCustomData(EdgeAttributeTargetsTag, edgeAttributeTargetsKey, to, nil); err != nil {
+		return fmt.Errorf("failed to record the edge attributes of %s: %w", to, err)
+	}
+	return nil
+}
+
+// IndexEdgeAttributes lists the nodes edges with attributes point to under EdgeAttributeTargetsTag, for graphs whose
+// edge attributes were set before the list existed.
+func IndexEdgeAttributes(storage Storage) error {
+	keys, err := storage.GetCustomDataKeys()
+	if err != nil {
+		return fmt.Errorf("failed to get custom data keys: %w", err)
+	}
+	for _, key := range keys {
+		if key.Tag != EdgeAttributeTag {
+			continue
+		}
+		if err := addEdgeAttributeTarget(storage, key.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetEdgeAttributes returns the attributes of every edge pointing to the node with the given name, keyed by the name
+// of the node the edge starts from.
+func GetEdgeAttributes(storage Storage, to string) (map[string]map[string]string, error) {
+	data, err := storage.GetCustomData(EdgeAttributeTag, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edge attributes: %w", err)
+	}
+	attributes := make(map[string]map[string]string, len(data))
+	for from, value := range data {
+		var edge map[string]string
+		if err := json.Unmarshal(value, &edge); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the attributes of edge %s -> %s: %w", from, to, err)
+		}
+		attributes[from] = edge
+	}
+	return attributes, nil
+}
+
+// edgeAttributeLookup memoizes edge attributes while queries are evaluated. It only reads the attributes of the nodes
+// listed under EdgeAttributeTargetsTag, which it reads once.
+type edgeAttributeLookup struct {
+	storage Storage
+	mu      sync.RWMutex
+	targets map[string][]byte
+	byName  map[string]map[string]map[string]string
+}
+
+func newEdgeAttributeLookup(storage Storage) *edgeAttributeLookup {
+	return &edgeAttributeLookup{storage: storage, byName: make(map[string]map[string]map[string]string)}
+}
+
+// any reports whether any edge has attributes.
+func (e *edgeAttributeLookup) any() (bool, error) {
+	targets, err := e.getTargets()
+	if err != nil {
+		return false, err
+	}
+	return len(targets) > 0, nil
+}
+
+func (e *edgeAttributeLookup) getTargets() (map[string][]byte, error) {
+	e.mu.RLock()
+	targets := e.targets
+	e.mu.RUnlock()
+	if targets != nil {
+		return targets, nil
+	}
+	targets, err := e.storage.GetCustomData(EdgeAttributeTargetsTag, edgeAttributeTargetsKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the nodes with edge attributes: %w", err)
+	}
+	if targets == nil {
+		targets = map[string][]byte{}
+	}
+	e.mu.Lock()
+	e.targets = targets
+	e.mu.Unlock()
+	return targets, nil
+}
+
+func (e *edgeAttributeLookup) get(to string) (map[string]map[string]string, error) {
+	targets, err := e.getTargets()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := targets[to]; !ok {
+		return nil, nil
+	}
+	e.mu.RLock()
+	attributes, ok := e.byName[to]
+	e.mu.RUnlock()
+	if ok {
+		return attributes, nil
+	}
+	attributes, err = GetEdgeAttributes(e.storage, to)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.byName[to] = attributes
+	e.mu.Unlock()
+	return attributes, nil
+}
+
+// removeSuppressed drops the dependencies of root that VEX statements say don't affect it: the ones root itself has
+// a suppressing statement for, and the ones every node depending on them directly, within root's dependencies, has
+// a suppressing statement for.
+func removeSuppressed(bm *roaring.Bitmap, root uint32, rootDependencies *roaring.Bitmap, nodes map[uint32]*Node, edgeAttributes *edgeAttributeLookup) (*roaring.Bitmap, error) {
+	rootNode := nodes[root]
+	kept := roaring.New()
+	for _, id := range bm.ToArray() {
+		node := nodes[id]
+		attributes, err := edgeAttributes.get(node.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(attributes) == 0 {
+			kept.Add(id)
+			continue
+		}
+		if rootNode != nil && IsSuppressingVEXStatus(attributes[rootNode.Name][VEXStatusAttribute]) {
+			continue
+		}
+
+		suppressed := false
+		for _, parent := range node.Parents.ToArray() {
+			if parent != root && (rootDependencies == nil || !rootDependencies.Contains(parent)) {
+				continue
+			}
+			parentNode := nodes[parent]
+			if parentNode == nil || !IsSuppressingVEXStatus(attributes[parentNode.Name][VEXStatusAttribute]) {
+				suppressed = false
+				break
+			}
+			suppressed = true
+		}
+		if !suppressed {
+			kept.Add(id)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdgeAttributes(t *testing.T) {
+	storage := NewMockStorage()
+
+	attributes, err := GetEdgeAttributes(storage, "CVE-2024-1")
+	require.NoError(t, err)
+	assert.Empty(t, attributes)
+
+	require.NoError(t, SetEdgeAttribute(storage, "pkg:npm/a@1.0.0", "CVE-2024-1", VEXStatusAttribute, VEXStatusNotAffected))
+	require.NoError(t, SetEdgeAttribute(storage, "pkg:npm/a@1.0.0", "CVE-2024-1", "note", "reviewed"))
+	require.NoError(t, SetEdgeAttribute(storage, "pkg:npm/b@1.0.0", "CVE-2024-1", VEXStatusAttribute, VEXStatusAffected))
+
+	attributes, err = GetEdgeAttributes(storage, "CVE-2024-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"pkg:npm/a@1.0.0": {VEXStatusAttribute: VEXStatusNotAffected, "note": "reviewed"},
+		"pkg:npm/b@1.0.0": {VEXStatusAttribute: VEXStatusAffected},
+	}, attributes)
+}
+
+func TestParseAndExecute_SuppressedVulnerabilities(t *testing.T) {
+	storage := NewMockStorage()
+	add := func(_type, name string) *Node {
+		node, err := AddNode(storage, _type, nil, name)
+		require.NoError(t, err)
+		return node
+	}
+	app := add("library", "pkg:npm/app@1.0.0")
+	libA := add("library", "pkg:npm/a@1.0.0")
+	libB := add("library", "pkg:npm/b@1.0.0")
+	shared := add("vuln", "CVE-2024-1")
+	onlyA := add("vuln", "CVE-2024-2")
+	for _, edge := range [][2]*Node{{app, libA}, {app, libB}, {libA, shared}, {libB, shared}, {libA, onlyA}} {
+		require.NoError(t, edge[0].SetDependency(storage, edge[1]))
+	}
+	require.NoError(t, Cache(storage))
+
+	query := func(script string) *roaring.Bitmap {
+		t.Helper()
+		keys, err := storage.GetAllKeys()
+		require.NoError(t, err)
+		nodes, err := storage.GetNodes(keys)
+		require.NoError(t, err)
+		caches, err := storage.GetCaches(keys)
+		require.NoError(t, err)
+		result, err := ParseAndExecute(script, storage, "", nodes, caches, true)
+		require.NoError(t, err)
+		return result
+	}
+
+	assert.Equal(t, []uint32{shared.ID, onlyA.ID}, query("dependencies vuln pkg:npm/app@1.0.0").ToArray())
+
+	// A vulnerability stays while one of the libraries depending on it is still affected
+	require.NoError(t, SetEdgeAttribute(storage, libA.Name, shared.Name, VEXStatusAttribute, VEXStatusNotAffected))
+	require.NoError(t, SetEdgeAttribute(storage, libA.Name, onlyA.Name, VEXStatusAttribute, VEXStatusFixed))
+	assert.Equal(t, []uint32{shared.ID}, query("dependencies vuln pkg:npm/app@1.0.0").ToArray())
+
+	require.NoError(t, SetEdgeAttribute(storage, libB.Name, shared.Name, VEXStatusAttribute, VEXStatusUnderInvestigation))
+	assert.Equal(t, []uint32{shared.ID}, query("dependencies vuln pkg:npm/app@1.0.0").ToArray())
+	require.NoError(t, SetEdgeAttribute(storage, libB.Name, shared.Name, VEXStatusAttribute, VEXStatusNotAffected))
+	assert.True(t, query("dependencies vuln pkg:npm/app@1.0.0").IsEmpty())
+
+	// Suppressed vulnerabilities can be asked for
+	assert.Equal(t, []uint32{shared.ID, onlyA.ID}, query("dependencies vuln pkg:npm/app@1.0.0 with suppressed").ToArray())
+
+	// A statement about the product suppresses the vulnerability for queries about it only
+	require.NoError(t, SetEdgeAttribute(storage, libB.Name, shared.Name, VEXStatusAttribute, VEXStatusAffected))
+	require.NoError(t, SetEdgeAttribute(storage, app.Name, shared.Name, VEXStatusAttribute, VEXStatusNotAffected))
+	assert.True(t, query("dependencies vuln pkg:npm/app@1.0.0").IsEmpty())
+	assert.Equal(t, []uint32{shared.ID}, query("dependencies vuln pkg:npm/b@1.0.0").ToArray())
+
+	// Filters still apply after the modifier
+	require.NoError(t, SetAnnotation(storage, onlyA.Name, "severity", "high"))
+	assert.Equal(t, []uint32{onlyA.ID}, query(`dependencies vuln pkg:npm/app@1.0.0 with suppressed where tag.severity = "high"`).ToArray())
+}
+
+// customDataCountingStorage counts the custom data records read.
+type customDataCountingStorage struct {
+	Storage
+	reads int
+}
+
+func (s *customDataCountingStorage) GetCustomData(tag, key string) (map[string][]byte, error) {
+	s.reads++
+	return s.Storage.GetCustomData(tag, key)
+}
+
+func TestParseAndExecute_SuppressionReads(t *testing.T) {
+	storage := NewMockStorage()
+	add := func(_type, name string) *Node {
+		node, err := AddNode(storage, _type, nil, name)
+		require.NoError(t, err)
+		return node
+	}
+	app := add("library", "pkg:npm/app@1.0.0")
+	lib := add("library", "pkg:npm/a@1.0.0")
+	vuln := add("vuln", "CVE-2024-1")
+	other := add("vuln", "CVE-2024-2")
+	for _, edge := range [][2]*Node{{app, lib}, {lib, vuln}, {lib, other}} {
+		require.NoError(t, edge[0].SetDependency(storage, edge[1]))
+	}
+	require.NoError(t, Cache(storage))
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	nodes, err := storage.GetNodes(keys)
+	require.NoError(t, err)
+	caches, err := storage.GetCaches(keys)
+	require.NoError(t, err)
+
+	// Runs the query for every node, sharing the lookups as a leaderboard does, and returns the records read
+	leaderboard := func() int {
+		t.Helper()
+		counting := &customDataCountingStorage{Storage: storage}
+		lookups := NewQueryLookups(counting)
+		for _, node := range nodes {
+			_, err := ParseAndExecuteWithLookups("dependencies vuln", counting, node.Name, nodes, caches, true, lookups)
+			require.NoError(t, err)
+		}
+		return counting.reads
+	}
+
+	// Without edge attributes only the list of nodes having some is read
+	assert.Equal(t, 1, leaderboard())
+
+	// With some, only the attributes of the nodes in that list are read, once
+	require.NoError(t, SetEdgeAttribute(storage, app.Name, vuln.Name, VEXStatusAttribute, VEXStatusNotAffected))
+	assert.Equal(t, 2, leaderboard())
+	result, err := ParseAndExecute("dependencies vuln pkg:npm/app@1.0.0", storage, "", nodes, caches, true)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{other.ID}, result.ToArray())
+}
+
+func TestIndexEdgeAttributes(t *testing.T) {
+	storage := NewMockStorage()
+	// Simulate attributes set before the nodes with edge attributes were listed
+	require.NoError(t, storage.AddOrUpdateCustomData(EdgeAttributeTag, "CVE-2024-1", "pkg:npm/a@1.0.0", []byte(`{"vex_status": "not_affected"}`)))
+
+	require.NoError(t, IndexEdgeAttributes(storage))
+	targets, err := storage.GetCustomData(EdgeAttributeTargetsTag, edgeAttributeTargetsKey)
+	require.NoError(t, err)
+	assert.Len(t, targets, 1)
+	assert.Contains(t, targets, "CVE-2024-1")
+}
+
+func TestParseAndExecute_EdgeFilters(t *testing.T) {
+	storage := NewMockStorage()
+	add := func(_type, name string) *Node {
+		node, err := AddNode(storage, _type, nil, name)
+		require.NoError(t, err)
+		return node
+	}
+	app := add("library", "pkg:golang/example.com/app")
+	text := add("library", "pkg:golang/golang.org/x/text@v0.3.7")
+	net := add("library", "pkg:golang/golang.org/x/net@v0.10.0")
+	reachable := add("vuln", "GO-2022-1059")
+	unreachable := add("vuln", "GO-2023-2102")
+	unknown := add("vuln", "GO-2024-0001")
+	for _, edge := range [][2]*Node{{app, text}, {app, net}, {text, reachable}, {net, unreachable}, {net, unknown}} {
+		require.NoError(t, edge[0].SetDependency(storage, edge[1]))
+	}
+	require.NoError(t, SetEdgeAttribute(storage, text.Name, reachable.Name, "reachable", "true"))
+	require.NoError(t, SetEdgeAttribute(storage, net.Name, unreachable.Name, "reachable", "false"))
+	// An edge from outside the queried dependencies doesn't count
+	require.NoError(t, SetEdgeAttribute(storage, "pkg:golang/example.com/other", unreachable.Name, "reachable", "true"))
+	require.NoError(t, Cache(storage))
+
+	query := func(script string) (*roaring.Bitmap, error) {
+		t.Helper()
+		keys, err := storage.GetAllKeys()
+		require.NoError(t, err)
+		nodes, err := storage.GetNodes(keys)
+		require.NoError(t, err)
+		caches, err := storage.GetCaches(keys)
+		require.NoError(t, err)
+		return ParseAndExecute(script, storage, "", nodes, caches, true)
+	}
+
+	result, err := query("dependencies vuln pkg:golang/example.com/app where edge.reachable = true")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{reachable.ID}, result.ToArray())
+
+	result, err = query(`dependencies vuln pkg:golang/example.com/app where edge.reachable != "true"`)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{unreachable.ID, unknown.ID}, result.ToArray())
+
+	result, err = query("dependencies vuln pkg:golang/golang.org/x/net@v0.10.0 where edge.reachable = false")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{unreachable.ID}, result.ToArray())
+
+	_, err = query("dependencies vuln pkg:golang/example.com/app where reachable = true")
+	assert.Error(t, err)
+}
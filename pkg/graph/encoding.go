@@ -0,0 +1,270 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/goccy/go-json"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Every stored record starts with a format tag so readers can tell encodings apart.
+// Legacy records are plain JSON objects and therefore always start with '{'.
+const (
+	FormatTagJSON     byte = '{'
+	FormatTagBinaryV1 byte = 0x01
+)
+
+// metadataCodec describes how the metadata bytes inside a binary record are stored.
+type metadataCodec uint64
+
+const (
+	metadataCodecJSON metadataCodec = iota
+	metadataCodecZstdJSON
+)
+
+// metadataCompressionThreshold is the size in bytes above which metadata is zstd compressed.
+const metadataCompressionThreshold = 512
+
+// Field numbers of the protobuf framed binary records.
+const (
+	nodeFieldID            protowire.Number = 1
+	nodeFieldType          protowire.Number = 2
+	nodeFieldName          protowire.Number = 3
+	nodeFieldChildren      protowire.Number = 4
+	nodeFieldParents       protowire.Number = 5
+	nodeFieldMetadataCodec protowire.Number = 6
+	nodeFieldMetadata      protowire.Number = 7
+
+	cacheFieldID          protowire.Number = 1
+	cacheFieldAllParents  protowire.Number = 2
+	cacheFieldAllChildren protowire.Number = 3
+)
+
+var (
+	ErrEmptyRecord      = errors.New("record is empty")
+	ErrUnknownFormatTag = errors.New("unknown record format tag")
+	ErrMalformedRecord  = errors.New("malformed binary record")
+	zstdEncoder, _      = zstd.NewWriter(nil)
+	zstdDecoder, _      = zstd.NewReader(nil)
+)
+
+// MarshalBinary encodes the node as a tagged binary record.
+// The bitmaps are stored as raw roaring portable bytes and the metadata as JSON, compressed with zstd once it grows
+// past metadataCompressionThreshold.
+func (n *Node) MarshalBinary() ([]byte, error) {
+	childData, err := n.Children.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert child bitmap to bytes: %w", err)
+	}
+	parentData, err := n.Parents.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert parent bitmap to bytes: %w", err)
+	}
+	metadata, err := json.Marshal(n.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal node metadata: %w", err)
+	}
+	codec := metadataCodecJSON
+	if len(metadata) > metadataCompressionThreshold {
+		metadata = zstdEncoder.EncodeAll(metadata, nil)
+		codec = metadataCodecZstdJSON
+	}
+
+	b := make([]byte, 0, 1+len(n.Type)+len(n.Name)+len(childData)+len(parentData)+len(metadata)+32)
+	b = append(b, FormatTagBinaryV1)
+	b = protowire.AppendTag(b, nodeFieldID, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(n.ID))
+	b = protowire.AppendTag(b, nodeFieldType, protowire.BytesType)
+	b = protowire.AppendString(b, n.Type)
+	b = protowire.AppendTag(b, nodeFieldName, protowire.BytesType)
+	b = protowire.AppendString(b, n.Name)
+	b = protowire.AppendTag(b, nodeFieldChildren, protowire.BytesType)
+	b = protowire.AppendBytes(b, childData)
+	b = protowire.AppendTag(b, nodeFieldParents, protowire.BytesType)
+	b = protowire.AppendBytes(b, parentData)
+	b = protowire.AppendTag(b, nodeFieldMetadataCodec, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(codec))
+	b = protowire.AppendTag(b, nodeFieldMetadata, protowire.BytesType)
+	b = protowire.AppendBytes(b, metadata)
+	return b, nil
+}
+
+// UnmarshalBinary decodes a node record written by MarshalBinary.
+// Legacy JSON records are detected by their format tag and decoded with UnmarshalJSON.
+func (n *Node) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return ErrEmptyRecord
+	}
+	switch data[0] {
+	case FormatTagJSON:
+		return n.UnmarshalJSON(data)
+	case FormatTagBinaryV1:
+	default:
+		return fmt.Errorf("%w: 0x%02x", ErrUnknownFormatTag, data[0])
+	}
+
+	var (
+		childData, parentData, metadata []byte
+		codec                           metadataCodec
+	)
+	*n = Node{}
+	err := consumeFields(data[1:], func(num protowire.Number, typ protowire.Type, value uint64, raw []byte) error {
+		switch {
+		case num == nodeFieldID && typ == protowire.VarintType:
+			n.ID = uint32(value)
+		case num == nodeFieldType && typ == protowire.BytesType:
+			n.Type = string(raw)
+		case num == nodeFieldName && typ == protowire.BytesType:
+			n.Name = string(raw)
+		case num == nodeFieldChildren && typ == protowire.BytesType:
+			childData = raw
+		case num == nodeFieldParents && typ == protowire.BytesType:
+			parentData = raw
+		case num == nodeFieldMetadataCodec && typ == protowire.VarintType:
+			codec = metadataCodec(value)
+		case num == nodeFieldMetadata && typ == protowire.BytesType:
+			metadata = raw
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to decode node record: %w", err)
+	}
+
+	n.Children = roaring.New()
+	n.Parents = roaring.New()
+	if len(childData) > 0 {
+		if _, err := n.Children.FromBuffer(childData); err != nil {
+			return fmt.Errorf("failed to convert child data from buffer: %w", err)
+		}
+	}
+	if len(parentData) > 0 {
+		if _, err := n.Parents.FromBuffer(parentData); err != nil {
+			return fmt.Errorf("failed to convert parent data from buffer: %w", err)
+		}
+	}
+
+	switch codec {
+	case metadataCodecJSON:
+	case metadataCodecZstdJSON:
+		metadata, err = zstdDecoder.DecodeAll(metadata, nil)
+		if err != nil {
+			return fmt.Errorf("failed to decompress node metadata: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown metadata codec %d", ErrMalformedRecord, codec)
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &n.Metadata); err != nil {
+			return fmt.Errorf("failed to unmarshal node metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// MarshalBinary encodes the node cache as a tagged binary record.
+func (nc *NodeCache) MarshalBinary() ([]byte, error) {
+	allParentsData, err := nc.AllParents.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert AllParents bitmap to bytes: %w", err)
+	}
+	allChildrenData, err := nc.AllChildren.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert AllChildren bitmap to bytes: %w", err)
+	}
+
+	b := make([]byte, 0, 1+len(allParentsData)+len(allChildrenData)+16)
+	b = append(b, FormatTagBinaryV1)
+	b = protowire.AppendTag(b, cacheFieldID, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(nc.ID))
+	b = protowire.AppendTag(b, cacheFieldAllParents, protowire.BytesType)
+	b = protowire.AppendBytes(b, allParentsData)
+	b = protowire.AppendTag(b, cacheFieldAllChildren, protowire.BytesType)
+	b = protowire.AppendBytes(b, allChildrenData)
+	return b, nil
+}
+
+// UnmarshalBinary decodes a node cache record written by MarshalBinary.
+// Legacy JSON records are detected by their format tag and decoded with UnmarshalJSON.
+func (nc *NodeCache) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return ErrEmptyRecord
+	}
+	switch data[0] {
+	case FormatTagJSON:
+		return nc.UnmarshalJSON(data)
+	case FormatTagBinaryV1:
+	default:
+		return fmt.Errorf("%w: 0x%02x", ErrUnknownFormatTag, data[0])
+	}
+
+	var allParentsData, allChildrenData []byte
+	*nc = NodeCache{}
+	err := consumeFields(data[1:], func(num protowire.Number, typ protowire.Type, value uint64, raw []byte) error {
+		switch {
+		case num == cacheFieldID && typ == protowire.VarintType:
+			nc.ID = uint32(value)
+		case num == cacheFieldAllParents && typ == protowire.BytesType:
+			allParentsData = raw
+		case num == cacheFieldAllChildren && typ == protowire.BytesType:
+			allChildrenData = raw
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to decode cache record: %w", err)
+	}
+
+	nc.AllParents = roaring.New()
+	nc.AllChildren = roaring.New()
+	if len(allParentsData) > 0 {
+		if _, err := nc.AllParents.FromBuffer(allParentsData); err != nil {
+			return fmt.Errorf("failed to convert AllParents data from buffer: %w", err)
+		}
+	}
+	if len(allChildrenData) > 0 {
+		if _, err := nc.AllChildren.FromBuffer(allChildrenData); err != nil {
+			return fmt.Errorf("failed to convert AllChildren data from buffer: %w", err)
+		}
+	}
+	return nil
+}
+
+// consumeFields walks the protobuf framed fields in b and calls fn for every varint or length delimited field.
+// Fields of other wire types are skipped so that newer writers can add fields without breaking older readers.
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, value uint64, raw []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("%w: %v", ErrMalformedRecord, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		var (
+			value uint64
+			raw   []byte
+		)
+		switch typ {
+		case protowire.VarintType:
+			value, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			raw, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("%w: %v", ErrMalformedRecord, protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if typ == protowire.VarintType || typ == protowire.BytesType {
+			if err := fn(num, typ, value, raw); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeBinaryRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata any
+	}{
+		{name: "nil metadata", metadata: nil},
+		{name: "small metadata", metadata: map[string]any{"key": "value"}},
+		{name: "compressed metadata", metadata: map[string]any{"summary": strings.Repeat("log4j ", 500)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &Node{
+				ID:       7,
+				Type:     "library",
+				Name:     "pkg:golang/example.com/foo@v1.0.0",
+				Metadata: tt.metadata,
+				Children: roaring.BitmapOf(1, 2, 3),
+				Parents:  roaring.BitmapOf(100000),
+			}
+			data, err := node.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, FormatTagBinaryV1, data[0])
+
+			var decoded Node
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			assert.Equal(t, node.ID, decoded.ID)
+			assert.Equal(t, node.Type, decoded.Type)
+			assert.Equal(t, node.Name, decoded.Name)
+			assert.Equal(t, node.Metadata, decoded.Metadata)
+			assert.True(t, node.Children.Equals(decoded.Children))
+			assert.True(t, node.Parents.Equals(decoded.Parents))
+		})
+	}
+}
+
+func TestNodeBinaryIsSmallerThanJSON(t *testing.T) {
+	children := roaring.New()
+	children.AddRange(1, 5000)
+	node := &Node{ID: 1, Type: "library", Name: "name", Children: children, Parents: roaring.New()}
+
+	binaryData, err := node.MarshalBinary()
+	require.NoError(t, err)
+	jsonData, err := node.MarshalJSON()
+	require.NoError(t, err)
+	assert.Less(t, len(binaryData), len(jsonData))
+}
+
+func TestNodeUnmarshalBinaryReadsLegacyJSON(t *testing.T) {
+	node := &Node{
+		ID:       3,
+		Type:     "vuln",
+		Name:     "GHSA-xxxx",
+		Metadata: "metadata",
+		Children: roaring.BitmapOf(4),
+		Parents:  roaring.BitmapOf(5, 6),
+	}
+	data, err := node.MarshalJSON()
+	require.NoError(t, err)
+
+	var decoded Node
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, node.ID, decoded.ID)
+	assert.Equal(t, node.Name, decoded.Name)
+	assert.Equal(t, node.Metadata, decoded.Metadata)
+	assert.True(t, node.Parents.Equals(decoded.Parents))
+}
+
+func TestNodeUnmarshalBinaryErrors(t *testing.T) {
+	var node Node
+	assert.ErrorIs(t, node.UnmarshalBinary(nil), ErrEmptyRecord)
+	assert.ErrorIs(t, node.UnmarshalBinary([]byte{0x7f, 0x00}), ErrUnknownFormatTag)
+	assert.ErrorIs(t, node.UnmarshalBinary([]byte{FormatTagBinaryV1, 0x0a, 0xff}), ErrMalformedRecord)
+}
+
+func TestNodeCacheBinaryRoundTrip(t *testing.T) {
+	cache := NewNodeCache(9, roaring.BitmapOf(1, 9), roaring.BitmapOf(9, 10, 11))
+	data, err := cache.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded NodeCache
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, cache.ID, decoded.ID)
+	assert.True(t, cache.AllParents.Equals(decoded.AllParents))
+	assert.True(t, cache.AllChildren.Equals(decoded.AllChildren))
+
+	legacy, err := cache.MarshalJSON()
+	require.NoError(t, err)
+	var fromLegacy NodeCache
+	require.NoError(t, fromLegacy.UnmarshalBinary(legacy))
+	assert.Equal(t, cache.ID, fromLegacy.ID)
+	assert.True(t, cache.AllChildren.Equals(fromLegacy.AllChildren))
+}
@@ -0,0 +1,248 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldTag is the custom data tag node fields are stored under, keyed by node name. Fields are typed values
+// ingesters derive for a node, such as the EPSS score of a vulnerability, which queries filter on by name, as in
+// `where kev = true`, and leaderboards sort by. Like edge attributes, they don't require the node to exist yet.
+const FieldTag = "fields"
+
+// Fields of vuln nodes.
+const (
+	// KEVField is true for vulnerabilities in the CISA Known Exploited Vulnerabilities catalog.
+	KEVField = "kev"
+	// KEVDateAddedField is the date, as YYYY-MM-DD, the vulnerability was added to the KEV catalog.
+	KEVDateAddedField = "kev_date_added"
+	// KEVDueDateField is the date, as YYYY-MM-DD, CISA requires federal agencies to have remediated it by.
+	KEVDueDateField = "kev_due_date"
+	// KEVRansomwareField is "Known" when the vulnerability is known to be used in ransomware campaigns.
+	KEVRansomwareField = "kev_ransomware"
+	// EPSSField is the EPSS probability, from 0 to 1, that the vulnerability is exploited in the next 30 days.
+	EPSSField = "epss"
+	// EPSSPercentileField is the share of scored vulnerabilities with a lower or equal EPSS score.
+	EPSSPercentileField = "epss_percentile"
+	// EPSSDateField is the date of the EPSS scores.
+	EPSSDateField = "epss_date"
+	// SeverityField is the highest qualitative CVSS rating of the vulnerability and its aliases.
+	SeverityField = "severity"
+	// CVSSScoreField is the score of the CVSS vector the severity comes from.
+	CVSSScoreField = "cvss_score"
+	// CVSSVectorField is the CVSS vector the severity comes from.
+	CVSSVectorField = "cvss_vector"
+)
+
+// Fields of scorecard, repository and library nodes, which are given the fields of the scorecard of their source
+// repository.
+const (
+	// ScorecardScoreField is the aggregate score, from 0 to 10, of the OpenSSF Scorecard of the repository.
+	ScorecardScoreField = "scorecard_score"
+	// ScorecardCheckFieldPrefix prefixes the name of a Scorecard check, such as Code-Review or Maintained, in the
+	// field holding its score from 0 to 10. Checks Scorecard couldn't score, which it gives -1, have no field.
+	ScorecardCheckFieldPrefix = "scorecard."
+)
+
+// Fields of build and artifact nodes.
+const (
+	// UnpinnedMaterialsField is the number of materials of a build, or of the build of an artifact, that the
+	// provenance doesn't give a digest for, so that what was built from them can't be reproduced or verified.
+	UnpinnedMaterialsField = "unpinned_materials"
+)
+
+// FieldType decides how the values of a field compare.
+type FieldType int
+
+const (
+	// FieldString values compare as strings, which orders dates written as YYYY-MM-DD.
+	FieldString FieldType = iota
+	// FieldNumber values compare as numbers.
+	FieldNumber
+	// FieldBool values are "true" or "false", and can only be compared with = and !=.
+	FieldBool
+	// FieldSeverity values are qualitative severity ratings, compared case-insensitively in the order of
+	// SeverityRatings.
+	FieldSeverity
+)
+
+// SeverityRatings are the qualitative severity ratings of CVSS, from the lowest.
+var SeverityRatings = []string{"none", "low", "medium", "high", "critical"}
+
+// fieldTypes are the known fields.
+var fieldTypes = map[string]FieldType{
+	KEVField:               FieldBool,
+	KEVDateAddedField:      FieldString,
+	KEVDueDateField:        FieldString,
+	KEVRansomwareField:     FieldString,
+	EPSSField:              FieldNumber,
+	EPSSPercentileField:    FieldNumber,
+	EPSSDateField:          FieldString,
+	SeverityField:          FieldSeverity,
+	CVSSScoreField:         FieldNumber,
+	CVSSVectorField:        FieldString,
+	ScorecardScoreField:    FieldNumber,
+	UnpinnedMaterialsField: FieldNumber,
+}
+
+// ScorecardCheckField returns the name of the field holding the score of a Scorecard check.
+func ScorecardCheckField(check string) string {
+	return ScorecardCheckFieldPrefix + check
+}
+
+// LookupField returns the type of a field, and whether it is a known field.
+func LookupField(key string) (FieldType, bool) {
+	if check, ok := strings.CutPrefix(key, ScorecardCheckFieldPrefix); ok && check != "" {
+		return FieldNumber, true
+	}
+	fieldType, ok := fieldTypes[key]
+	return fieldType, ok
+}
+
+// Fields returns the names of the known fields, sorted, with the Scorecard check fields as scorecard.<check>.
+func Fields() []string {
+	keys := make([]string, 0, len(fieldTypes)+1)
+	for key := range fieldTypes {
+		keys = append(keys, key)
+	}
+	keys = append(keys, ScorecardCheckField("<check>"))
+	sort.Strings(keys)
+	return keys
+}
+
+// SetField sets a field of the node with the given name, checking that the value has the type of the field.
+func SetField(storage Storage, name, key, value string) error {
+	fieldType, ok := LookupField(key)
+	if !ok {
+		return fmt.Errorf("unknown field %q", key)
+	}
+	if _, err := parseFieldValue(fieldType, value); err != nil {
+		return fmt.Errorf("invalid value for field %s: %w", key, err)
+	}
+	if err := storage.AddOrUpdateCustomData(FieldTag, name, key, []byte(value)); err != nil {
+		return fmt.Errorf("failed to save field: %w", err)
+	}
+	return nil
+}
+
+// DeleteField removes a field of the node with the given name.
+func DeleteField(storage Storage, name, key string) error {
+	if err := storage.DeleteCustomData(FieldTag, name, key); err != nil {
+		return fmt.Errorf("failed to delete field: %w", err)
+	}
+	return nil
+}
+
+// GetFields returns every field of the node with the given name.
+func GetFields(storage Storage, name string) (map[string]string, error) {
+	data, err := storage.GetCustomData(FieldTag, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fields: %w", err)
+	}
+	fields := make(map[string]string, len(data))
+	for key, value := range data {
+		fields[key] = string(value)
+	}
+	return fields, nil
+}
+
+// CompareFieldValues compares two values of a field, returning -1, 0 or 1. Values that don't have the type of the
+// field sort before the ones that do.
+func CompareFieldValues(key, a, b string) int {
+	fieldType, _ := LookupField(key)
+	parsedA, errA := parseFieldValue(fieldType, a)
+	parsedB, errB := parseFieldValue(fieldType, b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return compareParsed(parsedA, parsedB)
+}
+
+// parseFieldValue parses a value of a field of the given type, into a float64, a bool, a string, or the rank of a
+// severity rating as a float64.
+func parseFieldValue(fieldType FieldType, value string) (any, error) {
+	switch fieldType {
+	case FieldNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return number, nil
+	case FieldBool:
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", value)
+		}
+		return boolean, nil
+	case FieldSeverity:
+		for rank, rating := range SeverityRatings {
+			if strings.EqualFold(value, rating) {
+				return float64(rank), nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not one of %s", value, strings.Join(SeverityRatings, ", "))
+	default:
+		return value, nil
+	}
+}
+
+func compareParsed(a, b any) int {
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case bool:
+		b := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case b:
+			return -1
+		}
+		return 1
+	default:
+		return strings.Compare(a.(string), b.(string))
+	}
+}
+
+// fieldLookup memoizes fields while queries are evaluated.
+type fieldLookup struct {
+	storage Storage
+	mu      sync.RWMutex
+	byName  map[string]map[string]string
+}
+
+func newFieldLookup(storage Storage) *fieldLookup {
+	return &fieldLookup{storage: storage, byName: make(map[string]map[string]string)}
+}
+
+func (f *fieldLookup) get(name string) (map[string]string, error) {
+	f.mu.RLock()
+	fields, ok := f.byName[name]
+	f.mu.RUnlock()
+	if ok {
+		return fields, nil
+	}
+	fields, err := GetFields(f.storage, name)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.byName[name] = fields
+	f.mu.Unlock()
+	return fields, nil
+}
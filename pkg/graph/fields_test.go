@@ -0,0 +1,128 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFields(t *testing.T) {
+	storage := NewMockStorage()
+
+	fields, err := GetFields(storage, "CVE-2024-1")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	// Fields don't need the node to exist
+	require.NoError(t, SetField(storage, "CVE-2024-1", EPSSField, "0.5"))
+	require.NoError(t, SetField(storage, "CVE-2024-1", KEVField, "true"))
+	require.NoError(t, SetField(storage, "CVE-2024-1", EPSSField, "0.75"))
+	fields, err = GetFields(storage, "CVE-2024-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{EPSSField: "0.75", KEVField: "true"}, fields)
+
+	assert.ErrorContains(t, SetField(storage, "CVE-2024-1", "owner", "team-payments"), "unknown field")
+	assert.Error(t, SetField(storage, "CVE-2024-1", EPSSField, "high"))
+	assert.Error(t, SetField(storage, "CVE-2024-1", KEVField, "yes"))
+
+	require.NoError(t, SetField(storage, "pkg:npm/app@1.0.0", ScorecardCheckField("Code-Review"), "7"))
+	assert.Error(t, SetField(storage, "pkg:npm/app@1.0.0", ScorecardCheckField("Code-Review"), "high"))
+	assert.ErrorContains(t, SetField(storage, "pkg:npm/app@1.0.0", ScorecardCheckFieldPrefix, "7"), "unknown field")
+	require.NoError(t, DeleteField(storage, "pkg:npm/app@1.0.0", ScorecardCheckField("Code-Review")))
+	fields, err = GetFields(storage, "pkg:npm/app@1.0.0")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestCompareFieldValues(t *testing.T) {
+	assert.Equal(t, 1, CompareFieldValues(EPSSField, "0.5", "0.25"))
+	assert.Equal(t, -1, CompareFieldValues(EPSSField, "0.5", "10"))
+	assert.Equal(t, 0, CompareFieldValues(EPSSField, "0.50", "0.5"))
+	assert.Equal(t, -1, CompareFieldValues(EPSSField, "", "0"))
+	assert.Equal(t, 1, CompareFieldValues(KEVField, "true", "false"))
+	assert.Equal(t, -1, CompareFieldValues(KEVDueDateField, "2024-01-05", "2024-02-01"))
+	assert.Equal(t, 1, CompareFieldValues(SeverityField, "critical", "HIGH"))
+	assert.Equal(t, -1, CompareFieldValues(SeverityField, "none", "low"))
+}
+
+func TestParseAndExecute_FieldFilters(t *testing.T) {
+	storage := NewMockStorage()
+	add := func(_type, name string) *Node {
+		node, err := AddNode(storage, _type, nil, name)
+		require.NoError(t, err)
+		return node
+	}
+	app := add("library", "pkg:npm/app@1.0.0")
+	exploited := add("vuln", "CVE-2024-1")
+	likely := add("vuln", "CVE-2024-2")
+	unscored := add("vuln", "CVE-2024-3")
+	for _, vuln := range []*Node{exploited, likely, unscored} {
+		require.NoError(t, app.SetDependency(storage, vuln))
+	}
+	require.NoError(t, SetField(storage, exploited.Name, KEVField, "true"))
+	require.NoError(t, SetField(storage, exploited.Name, EPSSField, "0.1"))
+	require.NoError(t, SetField(storage, likely.Name, EPSSField, "0.9"))
+	require.NoError(t, SetField(storage, exploited.Name, SeverityField, "critical"))
+	require.NoError(t, SetField(storage, likely.Name, SeverityField, "medium"))
+	unmaintained := add("library", "pkg:npm/unmaintained@1.0.0")
+	reviewed := add("library", "pkg:npm/reviewed@1.0.0")
+	for _, library := range []*Node{unmaintained, reviewed} {
+		require.NoError(t, app.SetDependency(storage, library))
+	}
+	require.NoError(t, SetField(storage, unmaintained.Name, ScorecardCheckField("Maintained"), "0"))
+	require.NoError(t, SetField(storage, unmaintained.Name, ScorecardCheckField("Code-Review"), "0"))
+	require.NoError(t, SetField(storage, reviewed.Name, ScorecardCheckField("Maintained"), "10"))
+	require.NoError(t, SetField(storage, reviewed.Name, ScorecardCheckField("Code-Review"), "8"))
+	require.NoError(t, Cache(storage))
+
+	query := func(script string) (*roaring.Bitmap, error) {
+		t.Helper()
+		keys, err := storage.GetAllKeys()
+		require.NoError(t, err)
+		nodes, err := storage.GetNodes(keys)
+		require.NoError(t, err)
+		caches, err := storage.GetCaches(keys)
+		require.NoError(t, err)
+		return ParseAndExecute(script, storage, "", nodes, caches, true)
+	}
+
+	tests := []struct {
+		script string
+		want   []uint32
+	}{
+		{"dependencies vuln pkg:npm/app@1.0.0 where kev = true", []uint32{exploited.ID}},
+		{"dependencies vuln pkg:npm/app@1.0.0 where kev != true", []uint32{likely.ID, unscored.ID}},
+		{"dependencies vuln pkg:npm/app@1.0.0 where kev=true", []uint32{exploited.ID}},
+		{"dependencies vuln pkg:npm/app@1.0.0 where epss>=0.5", []uint32{likely.ID}},
+		{"dependencies vuln pkg:npm/app@1.0.0 where epss >= 0.5", []uint32{likely.ID}},
+		{"dependencies vuln pkg:npm/app@1.0.0 where epss < 0.5", []uint32{exploited.ID}},
+		{`dependencies vuln pkg:npm/app@1.0.0 where epss > "0.05" where epss <= 1`, []uint32{exploited.ID, likely.ID}},
+		{"dependencies vuln pkg:npm/app@1.0.0 where severity >= high", []uint32{exploited.ID}},
+		{"dependencies vuln pkg:npm/app@1.0.0 where severity > NONE", []uint32{exploited.ID, likely.ID}},
+		{"dependencies library pkg:npm/app@1.0.0 where scorecard.Maintained < 3", []uint32{unmaintained.ID}},
+		{"dependencies library pkg:npm/app@1.0.0 where scorecard.Code-Review = 0", []uint32{unmaintained.ID}},
+		{"dependencies library pkg:npm/app@1.0.0 where scorecard.Code-Review >= 5", []uint32{reviewed.ID}},
+		{"dependencies vuln pkg:npm/app@1.0.0 where epss > 0.5 or dependencies vuln pkg:npm/app@1.0.0 where kev = true", []uint32{exploited.ID, likely.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			result, err := query(tt.script)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result.ToArray())
+		})
+	}
+
+	for _, script := range []string{
+		"dependencies vuln pkg:npm/app@1.0.0 where unknown = 1",
+		"dependencies vuln pkg:npm/app@1.0.0 where kev > false",
+		"dependencies vuln pkg:npm/app@1.0.0 where kev = yes",
+		"dependencies vuln pkg:npm/app@1.0.0 where epss > high",
+		"dependencies vuln pkg:npm/app@1.0.0 where severity > severe",
+		"dependencies library pkg:npm/app@1.0.0 where scorecard.Maintained > yes",
+	} {
+		_, err := query(script)
+		assert.Error(t, err, script)
+	}
+}
@@ -150,8 +150,9 @@ func (n *Node) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// AddNode becomes generic in terms of metadata
+// AddNode becomes generic in terms of metadata. The node is named after the canonical form of name, see CanonicalName.
 func AddNode(storage Storage, _type string, metadata any, name string) (*Node, error) {
+	name = CanonicalName(name)
 	var ID uint32
 	if id, err := storage.NameToID(name); err == nil {
 		return storage.GetNode(id)
@@ -211,6 +212,50 @@ func (n *Node) SetDependency(storage Storage, neighbor *Node) error {
 	return nil
 }
 
+// RemoveDependency removes the edge from n to neighbor. Both nodes are saved, which marks them dirty for Cache.
+func (n *Node) RemoveDependency(storage Storage, neighbor *Node) error {
+	if n == nil || neighbor == nil {
+		return fmt.Errorf("cannot remove dependency of nil node")
+	}
+	if storage == nil {
+		return fmt.Errorf("storages cannot be nil")
+	}
+
+	n.Children.Remove(neighbor.ID)
+	neighbor.Parents.Remove(n.ID)
+
+	if err := storage.SaveNode(n); err != nil {
+		return fmt.Errorf("failed to save node: %w", err)
+	}
+	if err := storage.SaveNode(neighbor); err != nil {
+		return fmt.Errorf("failed to save neighbor node: %w", err)
+	}
+	return nil
+}
+
+// RemoveNode removes every edge of the node and then the node itself.
+func RemoveNode(storage Storage, id uint32) error {
+	node, err := storage.GetNode(id)
+	if err != nil {
+		return fmt.Errorf("failed to get node %d: %w", id, err)
+	}
+	neighbors, err := storage.GetNodes(roaring.Or(node.Children, node.Parents).ToArray())
+	if err != nil {
+		return fmt.Errorf("failed to get neighbors of node %d: %w", id, err)
+	}
+	for _, neighbor := range neighbors {
+		neighbor.Parents.Remove(id)
+		neighbor.Children.Remove(id)
+		if err := storage.SaveNode(neighbor); err != nil {
+			return fmt.Errorf("failed to save neighbor node: %w", err)
+		}
+	}
+	if err := storage.DeleteNode(id); err != nil {
+		return fmt.Errorf("failed to delete node %d: %w", id, err)
+	}
+	return nil
+}
+
 func (n *Node) queryBitmap(storage Storage, direction Direction) (*roaring.Bitmap, error) {
 	if n == nil {
 		return nil, fmt.Errorf("cannot query bitmap of nil node")
@@ -47,6 +47,55 @@ func TestSetDependent(t *testing.T) {
 	assert.Contains(t, node2.Parents.ToArray(), node1.ID, "Expected node2 to have node1 as parent dependency")
 }
 
+func TestRemoveDependency(t *testing.T) {
+	storage := NewMockStorage()
+	node1, err := AddNode(storage, "type1", "metadata1", "name1")
+	assert.NoError(t, err)
+	node2, err := AddNode(storage, "type2", "metadata2", "name2")
+	assert.NoError(t, err)
+	assert.NoError(t, node1.SetDependency(storage, node2))
+	assert.NoError(t, storage.ClearCacheStack())
+
+	assert.NoError(t, node1.RemoveDependency(storage, node2))
+	assert.True(t, node1.Children.IsEmpty())
+	assert.True(t, node2.Parents.IsEmpty())
+
+	toBeCached, err := storage.ToBeCached()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []uint32{node1.ID, node2.ID}, toBeCached, "Expected both nodes to be marked dirty")
+}
+
+func TestRemoveNode(t *testing.T) {
+	storage := NewMockStorage()
+	node1, err := AddNode(storage, "type1", "metadata1", "name1")
+	assert.NoError(t, err)
+	node2, err := AddNode(storage, "type2", "metadata2", "name2")
+	assert.NoError(t, err)
+	node3, err := AddNode(storage, "type3", "metadata3", "name3")
+	assert.NoError(t, err)
+	assert.NoError(t, node1.SetDependency(storage, node2))
+	assert.NoError(t, node2.SetDependency(storage, node3))
+
+	assert.NoError(t, RemoveNode(storage, node2.ID))
+	_, err = storage.NameToID("name2")
+	assert.Error(t, err)
+	_, err = storage.GetNode(node2.ID)
+	assert.Error(t, err)
+
+	node1, err = storage.GetNode(node1.ID)
+	assert.NoError(t, err)
+	assert.True(t, node1.Children.IsEmpty())
+	node3, err = storage.GetNode(node3.ID)
+	assert.NoError(t, err)
+	assert.True(t, node3.Parents.IsEmpty())
+
+	// The cache can be rebuilt with a gap in the IDs
+	assert.NoError(t, Cache(storage))
+	dependents, err := node3.QueryDependents(storage)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{node3.ID}, dependents.ToArray())
+}
+
 func TestQueryDependentsAndDependenciesNoCache(t *testing.T) {
 	tests := []struct {
 		name             string
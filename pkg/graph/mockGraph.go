@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	"github.com/RoaringBitmap/roaring"
@@ -19,19 +20,25 @@ type MockStorage struct {
 	mu           sync.Mutex
 	idCounter    uint32
 	fullyCached  bool
-	db           map[string]map[string][]byte
+	db           map[CustomDataKey]map[string][]byte
+	searchIndex  *InvertedIndex
 
 	// Error injection fields
 	SaveNodeErr              error
 	GetNodeErr               error
+	DeleteNodeErr            error
 	GetNodesByGlobErr        error
+	SearchMetadataErr        error
 	GetAllKeysErr            error
+	GetAllNamesErr           error
 	SaveCacheErr             error
 	ToBeCachedErr            error
 	AddNodeToCachedStackErr  error
 	ClearCacheStackErr       error
 	GetCacheErr              error
 	GenerateIDErr            error
+	GetIDCounterErr          error
+	SetIDCounterErr          error
 	NameToIDErr              error
 	GetNodesErr              error
 	SaveCachesErr            error
@@ -39,6 +46,8 @@ type MockStorage struct {
 	RemoveAllCachesErr       error
 	AddOrUpdateCustomDataErr error
 	GetCustomDataErr         error
+	GetCustomDataKeysErr     error
+	DeleteCustomDataErr      error
 }
 
 func NewMockStorage() *MockStorage {
@@ -48,7 +57,8 @@ func NewMockStorage() *MockStorage {
 		dependents:   make(map[uint32]*roaring.Bitmap),
 		nameToID:     make(map[string]uint32),
 		idCounter:    0,
-		db:           make(map[string]map[string][]byte),
+		db:           make(map[CustomDataKey]map[string][]byte),
+		searchIndex:  NewInvertedIndex(),
 	}
 }
 
@@ -61,6 +71,32 @@ func (m *MockStorage) SaveNode(node *Node) error {
 	m.nameToID[node.Name] = node.ID
 	m.nodes[node.ID] = node
 	m.toBeCached = append(m.toBeCached, node.ID)
+	m.searchIndex.Index(node)
+	return nil
+}
+
+func (m *MockStorage) DeleteNode(id uint32) error {
+	if m.DeleteNodeErr != nil {
+		return m.DeleteNodeErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, exists := m.nodes[id]
+	if !exists {
+		return fmt.Errorf("node %v not found", id)
+	}
+	delete(m.nameToID, node.Name)
+	delete(m.nodes, id)
+	delete(m.cache, id)
+	toBeCached := m.toBeCached[:0]
+	for _, cached := range m.toBeCached {
+		if cached != id {
+			toBeCached = append(toBeCached, cached)
+		}
+	}
+	m.toBeCached = toBeCached
+	m.searchIndex.Remove(id)
 	return nil
 }
 
@@ -97,6 +133,24 @@ func (m *MockStorage) GetNodesByGlob(pattern string) ([]*Node, error) {
 	return nodes, nil
 }
 
+func (m *MockStorage) SearchMetadata(query string, limit int) ([]SearchResult, error) {
+	if m.SearchMetadataErr != nil {
+		return nil, m.SearchMetadataErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ranked, err := m.searchIndex.Search(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(ranked))
+	for _, hit := range ranked {
+		results = append(results, SearchResult{Node: m.nodes[hit.ID], Score: hit.Score})
+	}
+	return results, nil
+}
+
 func (m *MockStorage) GetAllKeys() ([]uint32, error) {
 	if m.GetAllKeysErr != nil {
 		return nil, m.GetAllKeysErr
@@ -112,6 +166,20 @@ func (m *MockStorage) GetAllKeys() ([]uint32, error) {
 	return keys, nil
 }
 
+func (m *MockStorage) GetAllNames() (map[string]uint32, error) {
+	if m.GetAllNamesErr != nil {
+		return nil, m.GetAllNamesErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make(map[string]uint32, len(m.nameToID))
+	for name, id := range m.nameToID {
+		names[name] = id
+	}
+	return names, nil
+}
+
 func (m *MockStorage) SaveCache(cache *NodeCache) error {
 	if m.SaveCacheErr != nil {
 		return m.SaveCacheErr
@@ -172,6 +240,27 @@ func (m *MockStorage) GenerateID() (uint32, error) {
 	return m.idCounter, nil
 }
 
+func (m *MockStorage) GetIDCounter() (uint32, error) {
+	if m.GetIDCounterErr != nil {
+		return 0, m.GetIDCounterErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.idCounter, nil
+}
+
+func (m *MockStorage) SetIDCounter(id uint32) error {
+	if m.SetIDCounterErr != nil {
+		return m.SetIDCounterErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id > m.idCounter {
+		m.idCounter = id
+	}
+	return nil
+}
+
 func (m *MockStorage) NameToID(name string) (uint32, error) {
 	if m.NameToIDErr != nil {
 		return 0, m.NameToIDErr
@@ -261,7 +350,7 @@ func (m *MockStorage) AddOrUpdateCustomData(tag, key, dataKey string, data []byt
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	fullKey := fmt.Sprintf("%s:%s", tag, key)
+	fullKey := CustomDataKey{Tag: tag, Key: key}
 	if m.db[fullKey] == nil {
 		m.db[fullKey] = make(map[string][]byte)
 	}
@@ -275,10 +364,43 @@ func (m *MockStorage) GetCustomData(tag, key string) (map[string][]byte, error)
 	}
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	fullKey := fmt.Sprintf("%s:%s", tag, key)
-	data, exists := m.db[fullKey]
-	if !exists {
-		return nil, fmt.Errorf("no data found for tag: %s, key: %s", tag, key)
+	// Like the real backends, a missing record is returned as an empty map
+	data := make(map[string][]byte, len(m.db[CustomDataKey{Tag: tag, Key: key}]))
+	for dataKey, value := range m.db[CustomDataKey{Tag: tag, Key: key}] {
+		data[dataKey] = value
 	}
 	return data, nil
 }
+
+func (m *MockStorage) DeleteCustomData(tag, key, dataKey string) error {
+	if m.DeleteCustomDataErr != nil {
+		return m.DeleteCustomDataErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fullKey := CustomDataKey{Tag: tag, Key: key}
+	delete(m.db[fullKey], dataKey)
+	if len(m.db[fullKey]) == 0 {
+		delete(m.db, fullKey)
+	}
+	return nil
+}
+
+func (m *MockStorage) GetCustomDataKeys() ([]CustomDataKey, error) {
+	if m.GetCustomDataKeysErr != nil {
+		return nil, m.GetCustomDataKeysErr
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]CustomDataKey, 0, len(m.db))
+	for k := range m.db {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Tag != keys[j].Tag {
+			return keys[i].Tag < keys[j].Tag
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	return keys, nil
+}
@@ -0,0 +1,198 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// purlPrefix is the scheme every package URL starts with.
+const purlPrefix = "pkg:"
+
+// CanonicalName returns the name a node is stored under. Package URLs are normalized, so that percent-encoding, the
+// case of the type or the order of qualifiers don't make two nodes of one package, as pkg:npm/@scope/x and
+// pkg:npm/%40scope/x would be. Any other name, or a package URL that doesn't parse, is returned as is.
+func CanonicalName(name string) string {
+	if !strings.HasPrefix(name, purlPrefix) {
+		return name
+	}
+	purl, err := packageurl.FromString(name)
+	if err != nil {
+		return name
+	}
+	return purl.ToString()
+}
+
+// NameMerge is a node whose name wasn't canonical, merged into the node of its canonical name.
+type NameMerge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CanonicalizeNames merges every node whose name isn't canonical, stored before names were normalized, into the node
+// of its canonical name, which is added when needed. The edges of the node move to the canonical node, and so do the
+// custom data records keyed by its name, such as annotations, fields and edge attributes, unless the canonical node
+// already has them. Names stored inside custom data values, such as the libraries an advisory was linked to, are left
+// as is, and canonicalized by whatever reads them. It returns the merges, sorted by name. Nodes are only saved, so
+// the graph needs caching again.
+func CanonicalizeNames(storage Storage) ([]NameMerge, error) {
+	names, err := storage.GetAllNames()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node names: %w", err)
+	}
+
+	var merges []NameMerge
+	for name := range names {
+		if canonical := CanonicalName(name); canonical != name {
+			merges = append(merges, NameMerge{From: name, To: canonical})
+		}
+	}
+	sort.Slice(merges, func(i, j int) bool {
+		return merges[i].From < merges[j].From
+	})
+
+	renames := make(map[string]string, len(merges))
+	for _, merge := range merges {
+		if err := mergeNode(storage, names[merge.From], merge.To); err != nil {
+			return nil, fmt.Errorf("failed to merge %s into %s: %w", merge.From, merge.To, err)
+		}
+		renames[merge.From] = merge.To
+	}
+	if err := renameCustomData(storage, renames); err != nil {
+		return nil, err
+	}
+	return merges, nil
+}
+
+// MergeNode merges the node with the given name into the node named into, which is added when needed. The edges of
+// the node move to the other node, and so do its annotations, fields and the attributes of the edges pointing to it,
+// unless the other node already has them. Nodes are only saved, so the graph needs caching again.
+func MergeNode(storage Storage, name, into string) error {
+	id, err := storage.NameToID(name)
+	if err != nil {
+		return fmt.Errorf("failed to find node %s: %w", name, err)
+	}
+	if err := mergeNode(storage, id, into); err != nil {
+		return err
+	}
+	for _, tag := range []string{AnnotationTag, FieldTag, EdgeAttributeTag} {
+		if err := moveCustomData(storage, tag, name, into); err != nil {
+			return err
+		}
+	}
+	attributes, err := GetEdgeAttributes(storage, into)
+	if err != nil {
+		return err
+	}
+	if len(attributes) > 0 {
+		return addEdgeAttributeTarget(storage, into)
+	}
+	return nil
+}
+
+// moveCustomData moves the custom data of one key to another, keeping the data the other key already has.
+func moveCustomData(storage Storage, tag, from, to string) error {
+	data, err := storage.GetCustomData(tag, from)
+	if err != nil {
+		return fmt.Errorf("failed to get custom data %s/%s: %w", tag, from, err)
+	}
+	existing, err := storage.GetCustomData(tag, to)
+	if err != nil {
+		return fmt.Errorf("failed to get custom data %s/%s: %w", tag, to, err)
+	}
+	for dataKey, value := range data {
+		if _, ok := existing[dataKey]; !ok {
+			if err := storage.AddOrUpdateCustomData(tag, to, dataKey, value); err != nil {
+				return fmt.Errorf("failed to save custom data %s/%s: %w", tag, to, err)
+			}
+		}
+		if err := storage.DeleteCustomData(tag, from, dataKey); err != nil {
+			return fmt.Errorf("failed to remove custom data %s/%s: %w", tag, from, err)
+		}
+	}
+	return nil
+}
+
+// mergeNode moves the edges of a node to the node with the given name and removes it.
+func mergeNode(storage Storage, id uint32, name string) error {
+	node, err := storage.GetNode(id)
+	if err != nil {
+		return fmt.Errorf("failed to get node %d: %w", id, err)
+	}
+	target, err := AddNode(storage, node.Type, node.Metadata, name)
+	if err != nil {
+		return fmt.Errorf("failed to add node %s: %w", name, err)
+	}
+
+	for _, childID := range node.Children.ToArray() {
+		if childID == target.ID {
+			continue
+		}
+		child, err := storage.GetNode(childID)
+		if err != nil {
+			return fmt.Errorf("failed to get node %d: %w", childID, err)
+		}
+		if err := target.SetDependency(storage, child); err != nil {
+			return fmt.Errorf("failed to add edge %s -> %s: %w", target.Name, child.Name, err)
+		}
+	}
+	for _, parentID := range node.Parents.ToArray() {
+		if parentID == target.ID {
+			continue
+		}
+		parent, err := storage.GetNode(parentID)
+		if err != nil {
+			return fmt.Errorf("failed to get node %d: %w", parentID, err)
+		}
+		if err := parent.SetDependency(storage, target); err != nil {
+			return fmt.Errorf("failed to add edge %s -> %s: %w", parent.Name, target.Name, err)
+		}
+	}
+	return RemoveNode(storage, id)
+}
+
+// renameCustomData moves the custom data keyed by a renamed node, either as key or as data key, to its new name.
+// Data the new name already has is kept.
+func renameCustomData(storage Storage, renames map[string]string) error {
+	if len(renames) == 0 {
+		return nil
+	}
+	keys, err := storage.GetCustomDataKeys()
+	if err != nil {
+		return fmt.Errorf("failed to get custom data keys: %w", err)
+	}
+	for _, key := range keys {
+		data, err := storage.GetCustomData(key.Tag, key.Key)
+		if err != nil {
+			return fmt.Errorf("failed to get custom data %s/%s: %w", key.Tag, key.Key, err)
+		}
+		newKey, renamed := renames[key.Key]
+		if !renamed {
+			newKey = key.Key
+		}
+		existing, err := storage.GetCustomData(key.Tag, newKey)
+		if err != nil {
+			return fmt.Errorf("failed to get custom data %s/%s: %w", key.Tag, newKey, err)
+		}
+		for dataKey, value := range data {
+			newDataKey, ok := renames[dataKey]
+			if !ok {
+				if !renamed {
+					continue
+				}
+				newDataKey = dataKey
+			}
+			if _, ok := existing[newDataKey]; !ok {
+				if err := storage.AddOrUpdateCustomData(key.Tag, newKey, newDataKey, value); err != nil {
+					return fmt.Errorf("failed to save custom data %s/%s: %w", key.Tag, newKey, err)
+				}
+			}
+			if err := storage.DeleteCustomData(key.Tag, key.Key, dataKey); err != nil {
+				return fmt.Errorf("failed to remove custom data %s/%s: %w", key.Tag, key.Key, err)
+			}
+		}
+	}
+	return nil
+}
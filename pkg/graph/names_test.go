@@ -0,0 +1,166 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalName(t *testing.T) {
+	for name, want := range map[string]string{
+		"pkg:npm/@scope/x@1.0.0":                                    "pkg:npm/%40scope/x@1.0.0",
+		"pkg:npm/%40scope/x@1.0.0":                                  "pkg:npm/%40scope/x@1.0.0",
+		"pkg:maven/org.example/lib@1.0?type=jar&classifier=sources": "pkg:maven/org.example/lib@1.0?classifier=sources&type=jar",
+		"pkg:NPM/left-pad@1.3.0":                                    "pkg:npm/left-pad@1.3.0",
+		"pkg:pypi/Django_Rest@3.0":                                  "pkg:pypi/django-rest@3.0",
+		"pkg:golang/github.com/google/uuid@v1.6.0":                  "pkg:golang/github.com/google/uuid@v1.6.0",
+		"pkg:not-a-purl":                                            "pkg:not-a-purl",
+		"CVE-2024-3094":                                             "CVE-2024-3094",
+		"":                                                          "",
+	} {
+		assert.Equal(t, want, CanonicalName(name), name)
+	}
+}
+
+func TestAddNode_CanonicalName(t *testing.T) {
+	storage := NewMockStorage()
+	node, err := AddNode(storage, "application", nil, "pkg:npm/@scope/x@1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "pkg:npm/%40scope/x@1.0.0", node.Name)
+
+	again, err := AddNode(storage, "application", nil, "pkg:npm/%40scope/x@1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, node.ID, again.ID)
+
+	// Annotations and queries find the node however its package URL is written
+	require.NoError(t, SetAnnotation(storage, "pkg:npm/@scope/x@1.0.0", "owner", "team-payments"))
+	annotations, err := GetAnnotations(storage, node.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team-payments"}, annotations)
+
+	dependency, err := AddNode(storage, "library", nil, "pkg:npm/y@2.0.0")
+	require.NoError(t, err)
+	require.NoError(t, node.SetDependency(storage, dependency))
+	require.NoError(t, Cache(storage))
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	nodes, err := storage.GetNodes(keys)
+	require.NoError(t, err)
+	caches, err := storage.GetCaches(keys)
+	require.NoError(t, err)
+	for _, script := range []string{"dependencies library pkg:npm/@scope/x@1.0.0", "dependencies library pkg:npm/%40scope/x@1.0.0"} {
+		result, err := ParseAndExecute(script, storage, "", nodes, caches, true)
+		require.NoError(t, err, script)
+		assert.Equal(t, []uint32{dependency.ID}, result.ToArray(), script)
+	}
+}
+
+func TestCanonicalizeNames(t *testing.T) {
+	storage := NewMockStorage()
+	// Nodes stored before names were canonical are saved directly, since AddNode would canonicalize their names
+	saveNode := func(name string) *Node {
+		t.Helper()
+		id, err := storage.GenerateID()
+		require.NoError(t, err)
+		node := &Node{ID: id, Type: "library", Name: name, Children: roaring.New(), Parents: roaring.New()}
+		require.NoError(t, storage.SaveNode(node))
+		return node
+	}
+	canonical, err := AddNode(storage, "library", nil, "pkg:maven/org.example/lib@1.0?classifier=sources&type=jar")
+	require.NoError(t, err)
+	unsorted := saveNode("pkg:maven/org.example/lib@1.0?type=jar&classifier=sources")
+	encoded := saveNode("pkg:npm/@scope/x@1.0.0")
+	app, err := AddNode(storage, "application", nil, "pkg:generic/app@1.0.0")
+	require.NoError(t, err)
+	vuln, err := AddNode(storage, "vuln", nil, "CVE-2024-0001")
+	require.NoError(t, err)
+
+	require.NoError(t, app.SetDependency(storage, unsorted))
+	require.NoError(t, app.SetDependency(storage, encoded))
+	require.NoError(t, unsorted.SetDependency(storage, vuln))
+	require.NoError(t, storage.AddOrUpdateCustomData(AnnotationTag, unsorted.Name, "owner", []byte("team-infra")))
+	require.NoError(t, SetAnnotation(storage, canonical.Name, "owner", "team-payments"))
+	require.NoError(t, storage.AddOrUpdateCustomData(AnnotationTag, encoded.Name, "owner", []byte("team-web")))
+	require.NoError(t, SetEdgeAttribute(storage, unsorted.Name, vuln.Name, VEXStatusAttribute, VEXStatusNotAffected))
+
+	merges, err := CanonicalizeNames(storage)
+	require.NoError(t, err)
+	assert.Equal(t, []NameMerge{
+		{From: "pkg:maven/org.example/lib@1.0?type=jar&classifier=sources", To: canonical.Name},
+		{From: "pkg:npm/@scope/x@1.0.0", To: "pkg:npm/%40scope/x@1.0.0"},
+	}, merges)
+
+	names, err := storage.GetAllNames()
+	require.NoError(t, err)
+	assert.Len(t, names, 4)
+	assert.NotContains(t, names, unsorted.Name)
+	assert.NotContains(t, names, encoded.Name)
+	renamedID, ok := names["pkg:npm/%40scope/x@1.0.0"]
+	require.True(t, ok)
+
+	// The edges of the merged nodes moved to the canonical ones
+	app, err = storage.GetNode(app.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint32{canonical.ID, renamedID}, app.Children.ToArray())
+	canonical, err = storage.GetNode(canonical.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{vuln.ID}, canonical.Children.ToArray())
+
+	// So did their custom data, without overwriting that of the canonical node
+	annotations, err := GetAnnotations(storage, canonical.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team-payments"}, annotations)
+	annotations, err = GetAnnotations(storage, "pkg:npm/%40scope/x@1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team-web"}, annotations)
+	attributes, err := GetEdgeAttributes(storage, vuln.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{canonical.Name: {VEXStatusAttribute: VEXStatusNotAffected}}, attributes)
+	keys, err := storage.GetCustomDataKeys()
+	require.NoError(t, err)
+	for _, key := range keys {
+		assert.NotEqual(t, "pkg:maven/org.example/lib@1.0?type=jar&classifier=sources", key.Key)
+	}
+
+	// Running it again changes nothing
+	merges, err = CanonicalizeNames(storage)
+	require.NoError(t, err)
+	assert.Empty(t, merges)
+}
+
+func TestMergeNode(t *testing.T) {
+	storage := NewMockStorage()
+	library, err := AddNode(storage, "library", nil, "pkg:pypi/django@4.2.0")
+	require.NoError(t, err)
+	from, err := AddNode(storage, "vuln", nil, "PYSEC-1")
+	require.NoError(t, err)
+	into, err := AddNode(storage, "vuln", nil, "GHSA-1")
+	require.NoError(t, err)
+	require.NoError(t, library.SetDependency(storage, from))
+	require.NoError(t, SetAnnotation(storage, from.Name, "owner", "team-infra"))
+	require.NoError(t, SetAnnotation(storage, into.Name, "owner", "team-payments"))
+	require.NoError(t, SetField(storage, from.Name, KEVField, "true"))
+	require.NoError(t, SetEdgeAttribute(storage, library.Name, from.Name, VEXStatusAttribute, VEXStatusNotAffected))
+
+	require.NoError(t, MergeNode(storage, from.Name, into.Name))
+	_, err = storage.NameToID(from.Name)
+	assert.Error(t, err)
+	into, err = storage.GetNode(into.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{library.ID}, into.Parents.ToArray())
+
+	annotations, err := GetAnnotations(storage, into.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team-payments"}, annotations)
+	fields, err := GetFields(storage, into.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{KEVField: "true"}, fields)
+	attributes, err := GetEdgeAttributes(storage, into.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{library.Name: {VEXStatusAttribute: VEXStatusNotAffected}}, attributes)
+	fields, err = GetFields(storage, from.Name)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
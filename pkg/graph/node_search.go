@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/package-url/packageurl-go"
+)
+
+const (
+	// DefaultPageSize is the number of nodes SearchNodes returns when no page size is given.
+	DefaultPageSize = 100
+	// MaxPageSize is the largest page SearchNodes returns.
+	MaxPageSize = 1000
+)
+
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// PurlFilter matches nodes whose names are package URLs. Empty fields match anything.
+type PurlFilter struct {
+	Type      string
+	Namespace string
+	Name      string
+	// VersionRange is a semver constraint such as ">=1.2.0, <2.0.0". Versions that are not semver never match it.
+	VersionRange string
+	// Qualifiers that must all be present with the given values.
+	Qualifiers map[string]string
+}
+
+func (f *PurlFilter) empty() bool {
+	return f == nil || (f.Type == "" && f.Namespace == "" && f.Name == "" && f.VersionRange == "" && len(f.Qualifiers) == 0)
+}
+
+// NodeQuery selects nodes by name. A node has to match both the regex and the purl filter when both are set, and
+// an empty query matches every node.
+type NodeQuery struct {
+	// Regex is an RE2 regular expression matched against any part of the node name, use ^ and $ to anchor it.
+	Regex string
+	Purl  *PurlFilter
+}
+
+// nodeMatcher is a compiled NodeQuery.
+type nodeMatcher struct {
+	regex      *regexp.Regexp
+	purl       *PurlFilter
+	constraint *semver.Constraints
+}
+
+func compileNodeQuery(query NodeQuery) (*nodeMatcher, error) {
+	m := &nodeMatcher{}
+	if query.Regex != "" {
+		regex, err := regexp.Compile(query.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		m.regex = regex
+	}
+	if !query.Purl.empty() {
+		m.purl = query.Purl
+		if query.Purl.VersionRange != "" {
+			constraint, err := semver.NewConstraint(query.Purl.VersionRange)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version range %q: %w", query.Purl.VersionRange, err)
+			}
+			m.constraint = constraint
+		}
+	}
+	return m, nil
+}
+
+func (m *nodeMatcher) matches(name string) bool {
+	if m.regex != nil && !m.regex.MatchString(name) {
+		return false
+	}
+	if m.purl == nil {
+		return true
+	}
+	purl, err := packageurl.FromString(name)
+	if err != nil {
+		return false
+	}
+	// The purl spec makes types case insensitive
+	if m.purl.Type != "" && !strings.EqualFold(m.purl.Type, purl.Type) {
+		return false
+	}
+	if m.purl.Namespace != "" && m.purl.Namespace != purl.Namespace {
+		return false
+	}
+	if m.purl.Name != "" && m.purl.Name != purl.Name {
+		return false
+	}
+	if m.constraint != nil {
+		version, err := semver.NewVersion(purl.Version)
+		if err != nil || !m.constraint.Check(version) {
+			return false
+		}
+	}
+	if len(m.purl.Qualifiers) > 0 {
+		qualifiers := purl.Qualifiers.Map()
+		for key, value := range m.purl.Qualifiers {
+			if qualifiers[key] != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SearchNodes returns one page of the nodes matching query, ordered by name. The returned page token is passed
+// back to get the next page and is empty on the last page. Matching happens here rather than in the backend, so
+// every storage returns the same results.
+func SearchNodes(storage Storage, query NodeQuery, pageSize int, pageToken string) ([]*Node, string, error) {
+	matcher, err := compileNodeQuery(query)
+	if err != nil {
+		return nil, "", err
+	}
+	after, err := decodePageToken(pageToken)
+	if err != nil {
+		return nil, "", err
+	}
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	} else if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	nameToID, err := storage.GetAllNames()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get node names: %w", err)
+	}
+	names := make([]string, 0, len(nameToID))
+	for name := range nameToID {
+		if pageToken == "" || name > after {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var matched []string
+	hasMore := false
+	for _, name := range names {
+		if !matcher.matches(name) {
+			continue
+		}
+		if len(matched) == pageSize {
+			hasMore = true
+			break
+		}
+		matched = append(matched, name)
+	}
+
+	ids := make([]uint32, len(matched))
+	for i, name := range matched {
+		ids[i] = nameToID[name]
+	}
+	nodes, err := storage.GetNodes(ids)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get nodes: %w", err)
+	}
+	page := make([]*Node, 0, len(matched))
+	for _, id := range ids {
+		if node, ok := nodes[id]; ok {
+			page = append(page, node)
+		}
+	}
+
+	nextPageToken := ""
+	if hasMore {
+		nextPageToken = base64.RawURLEncoding.EncodeToString([]byte(matched[len(matched)-1]))
+	}
+	return page, nextPageToken, nil
+}
+
+// decodePageToken returns the name of the last node of the previous page.
+func decodePageToken(pageToken string) (string, error) {
+	if pageToken == "" {
+		return "", nil
+	}
+	name, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+	return string(name), nil
+}
+
+// GlobToRegex converts a glob pattern, where '*' matches any run of characters and '?' matches a single character,
+// into an anchored regex for NodeQuery.
+func GlobToRegex(pattern string) string {
+	var sb strings.Builder
+	sb.WriteByte('^')
+	for _, char := range pattern {
+		switch char {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteByte('.')
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	sb.WriteByte('$')
+	return sb.String()
+}
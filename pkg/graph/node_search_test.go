@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSearchNodes(t *testing.T) Storage {
+	storage := NewMockStorage()
+	for _, name := range []string{
+		"pkg:npm/lodash@4.17.21",
+		"pkg:npm/lodash@3.10.1",
+		"pkg:npm/%40babel/core@7.24.0",
+		"pkg:golang/github.com/google/uuid@v1.6.0",
+		"pkg:deb/debian/curl@7.88.1?arch=amd64&distro=debian-12",
+		"pkg:deb/debian/curl@7.88.1?arch=arm64&distro=debian-12",
+		"GHSA-29mw-wpgm-hmr9",
+	} {
+		_, err := AddNode(storage, "library", nil, name)
+		require.NoError(t, err)
+	}
+	return storage
+}
+
+func nodeNames(nodes []*Node) []string {
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Name
+	}
+	return names
+}
+
+func TestSearchNodes(t *testing.T) {
+	storage := setupSearchNodes(t)
+	tests := []struct {
+		name  string
+		query NodeQuery
+		want  []string
+	}{
+		{
+			name:  "regex",
+			query: NodeQuery{Regex: `^GHSA-`},
+			want:  []string{"GHSA-29mw-wpgm-hmr9"},
+		},
+		{
+			name:  "glob",
+			query: NodeQuery{Regex: GlobToRegex("pkg:npm/lodash@*")},
+			want:  []string{"pkg:npm/lodash@3.10.1", "pkg:npm/lodash@4.17.21"},
+		},
+		{
+			name:  "purl type and namespace",
+			query: NodeQuery{Purl: &PurlFilter{Type: "NPM", Namespace: "@babel"}},
+			want:  []string{"pkg:npm/%40babel/core@7.24.0"},
+		},
+		{
+			name:  "purl version range",
+			query: NodeQuery{Purl: &PurlFilter{Name: "lodash", VersionRange: ">=4.0.0, <5.0.0"}},
+			want:  []string{"pkg:npm/lodash@4.17.21"},
+		},
+		{
+			name:  "purl version range with a v prefix",
+			query: NodeQuery{Purl: &PurlFilter{Type: "golang", VersionRange: "^1.2"}},
+			want:  []string{"pkg:golang/github.com/google/uuid@v1.6.0"},
+		},
+		{
+			name:  "purl qualifiers",
+			query: NodeQuery{Purl: &PurlFilter{Type: "deb", Qualifiers: map[string]string{"arch": "arm64"}}},
+			want:  []string{"pkg:deb/debian/curl@7.88.1?arch=arm64&distro=debian-12"},
+		},
+		{
+			name:  "regex and purl together",
+			query: NodeQuery{Regex: `3\.`, Purl: &PurlFilter{Name: "lodash"}},
+			want:  []string{"pkg:npm/lodash@3.10.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, nextPageToken, err := SearchNodes(storage, tt.query, 0, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, nodeNames(nodes))
+			assert.Empty(t, nextPageToken)
+		})
+	}
+}
+
+func TestSearchNodes_Pagination(t *testing.T) {
+	storage := setupSearchNodes(t)
+
+	var names []string
+	pageToken := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 10)
+		nodes, nextPageToken, err := SearchNodes(storage, NodeQuery{Regex: "^pkg:"}, 2, pageToken)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(nodes), 2)
+		names = append(names, nodeNames(nodes)...)
+		if nextPageToken == "" {
+			break
+		}
+		pageToken = nextPageToken
+	}
+	all, _, err := SearchNodes(storage, NodeQuery{Regex: "^pkg:"}, 0, "")
+	require.NoError(t, err)
+	assert.Equal(t, nodeNames(all), names)
+	assert.Len(t, names, 6)
+}
+
+func TestSearchNodes_Errors(t *testing.T) {
+	storage := setupSearchNodes(t)
+
+	_, _, err := SearchNodes(storage, NodeQuery{Regex: "("}, 0, "")
+	assert.ErrorContains(t, err, "invalid regex")
+
+	_, _, err = SearchNodes(storage, NodeQuery{Purl: &PurlFilter{VersionRange: "not a range"}}, 0, "")
+	assert.ErrorContains(t, err, "invalid version range")
+
+	_, _, err = SearchNodes(storage, NodeQuery{}, 0, "!!")
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+}
+
+func TestGlobToRegex(t *testing.T) {
+	assert.Equal(t, `^pkg:npm/.*@4\.1.$`, GlobToRegex("pkg:npm/*@4.1?"))
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/RoaringBitmap/roaring"
 	"github.com/alecthomas/participle/v2"
@@ -14,6 +15,14 @@ const (
 	or           = "or"
 	and          = "and"
 	xor          = "xor"
+	equals       = "="
+	notEquals    = "!="
+	less         = "<"
+	lessEqual    = "<="
+	greater      = ">"
+	greaterEqual = ">="
+	tagPrefix    = "tag."
+	edgePrefix   = "edge."
 )
 
 // Define the grammar using Go structs and Participle tags
@@ -32,13 +41,32 @@ type Query struct {
 	QueryType string  `@Ident`  // For example "dependencies" or "dependents"
 	NodeType  string  `@Ident`  // For example "library" or "vulns"
 	NodeName  *string `@Ident?` // NodeName is now optional // The purl being inputted
+	// WithSuppressed keeps the dependencies VEX statements say don't affect the node, which are left out by default
+	WithSuppressed bool      `@("with" "suppressed")?`
+	Filters        []*Filter `("where" @@)*` // For example where tag.owner = "team-payments", all filters must match
+}
+
+// Filter keeps only the nodes whose field matches the value. tag.<key> matches the annotations of a node, and
+// edge.<key> the attributes of the edges into it, from the queried node or its dependencies for dependencies queries.
+// A node matches edge.<key> = value when one of those edges has the value, and != when none does. Any other field is
+// one of the typed node fields, such as kev or epss, which also compare with <, <=, > and >=. A node without the
+// annotation or field only matches !=.
+type Filter struct {
+	Field string `@Ident`                     // For example "tag.owner", "edge.reachable" or "epss"
+	Op    string `@Compare`                   // "=", "!=", "<", "<=", ">" or ">="
+	Value string `@(String | Ident | Number)` // For example "team-payments", true or 0.5
 }
 
 var (
 	simpleLexer = lexer.MustSimple([]lexer.SimpleRule{
-		{"Operator", `\b(?:and|or|xor)\b`},           // Prioritize operators
-		{"Ident", `[a-zA-Z][a-zA-Z0-9:/._@?=&+\-]*`}, // Updated to handle colons, slashes, dots, underscores, hyphens, and @
+		{"Operator", `\b(?:and|or|xor)\b`}, // Prioritize operators
+		{"Keyword", `\b(?:where|with)\b`},  // Keywords must not be mistaken for node names
+		// Handles colons, slashes, dots, underscores, hyphens, @ and percent-encoding. = is only part of the qualifiers
+		// of a package URL, after ?, so that filters such as tag.owner=x don't need spaces around the operator.
+		{"Ident", `[a-zA-Z][a-zA-Z0-9:/._@&+%\-]*(?:\?[a-zA-Z0-9:/._@?=&+%\-]*)?`},
 		{"String", `"(?:\\.|[^"])*"`},
+		{"Number", `[0-9]+(?:\.[0-9]+)?`},
+		{"Compare", `!=|<=|>=|=|<|>`},
 		{"Whitespace", `[ \t\n\r]+`},
 		{"LBracket", `\[`},
 		{"RBracket", `\]`},
@@ -48,11 +76,18 @@ var (
 	parser = participle.MustBuild[Expression](
 		participle.Lexer(simpleLexer),
 		participle.Elide("Whitespace"),
+		participle.Unquote("String"),
 	)
 )
 
 // ParseAndExecute parses and executes a script using the given storage backend.
 func ParseAndExecute(script string, storage Storage, defaultNodeName string, nodes map[uint32]*Node, caches map[uint32]*NodeCache, isCached bool) (*roaring.Bitmap, error) {
+	return ParseAndExecuteWithLookups(script, storage, defaultNodeName, nodes, caches, isCached, NewQueryLookups(storage))
+}
+
+// ParseAndExecuteWithLookups parses and executes a script like ParseAndExecute, reading custom data through lookups
+// that can be shared by the scripts run against one graph.
+func ParseAndExecuteWithLookups(script string, storage Storage, defaultNodeName string, nodes map[uint32]*Node, caches map[uint32]*NodeCache, isCached bool, lookups *QueryLookups) (*roaring.Bitmap, error) {
 	nameToIDs := make(map[string]uint32, len(nodes))
 	for _, node := range nodes {
 		if node == nil {
@@ -65,6 +100,8 @@ func ParseAndExecute(script string, storage Storage, defaultNodeName string, nod
 	if err != nil {
 		return nil, fmt.Errorf("Failed to parse expression: %v", err)
 	}
+	canonicalizeNodeNames(expression)
+	defaultNodeName = CanonicalName(defaultNodeName)
 
 	// Collect all packages for batch querying
 	dependenciesToQuery, dependentsToQuery := collectPackages(expression, defaultNodeName)
@@ -105,7 +142,7 @@ func ParseAndExecute(script string, storage Storage, defaultNodeName string, nod
 	}
 
 	// Iterate through the parsed structure
-	bm, err := iterateExpression(expression, dependenciesForID, dependentsForID, nameToIDs, nodes, defaultNodeName)
+	bm, err := iterateExpression(expression, dependenciesForID, dependentsForID, nameToIDs, nodes, defaultNodeName, lookups)
 	if err != nil {
 		return nil, fmt.Errorf("failed to iterate expression: %v", err)
 	}
@@ -117,6 +154,38 @@ func ParseAndExecute(script string, storage Storage, defaultNodeName string, nod
 	return bm, nil
 }
 
+// QueryLookups memoize the custom data filters and VEX suppression read while queries are evaluated. They are safe
+// for concurrent use, so the queries of a leaderboard share one instead of reading the same data for every node.
+type QueryLookups struct {
+	annotations    *annotationLookup
+	edgeAttributes *edgeAttributeLookup
+	fields         *fieldLookup
+}
+
+// NewQueryLookups returns lookups reading custom data from storage.
+func NewQueryLookups(storage Storage) *QueryLookups {
+	return &QueryLookups{
+		annotations:    newAnnotationLookup(storage),
+		edgeAttributes: newEdgeAttributeLookup(storage),
+		fields:         newFieldLookup(storage),
+	}
+}
+
+// canonicalizeNodeNames replaces the node names of the queries of an expression with their canonical form, so that a
+// package URL matches its node however it is written.
+func canonicalizeNodeNames(expr *Expression) {
+	for ; expr != nil; expr = expr.Right {
+		if expr.Left == nil {
+			continue
+		}
+		if query := expr.Left.Query; query != nil && query.NodeName != nil {
+			name := CanonicalName(*query.NodeName)
+			query.NodeName = &name
+		}
+		canonicalizeNodeNames(expr.Left.Expression)
+	}
+}
+
 type purlData struct {
 	purl  string
 	_type string
@@ -168,18 +237,18 @@ func collectPackagesFromTerm(term *Term, dependenciesToQuery, dependentsToQuery
 }
 
 // iterateExpression iterates through the expression and returns the result
-func iterateExpression(expr *Expression, dependenciesForID, dependentsForID map[uint32]*roaring.Bitmap, nameToIDs map[string]uint32, nodes map[uint32]*Node, defaultNodeName string) (*roaring.Bitmap, error) {
+func iterateExpression(expr *Expression, dependenciesForID, dependentsForID map[uint32]*roaring.Bitmap, nameToIDs map[string]uint32, nodes map[uint32]*Node, defaultNodeName string, lookups *QueryLookups) (*roaring.Bitmap, error) {
 	if expr == nil {
 		return nil, nil
 	}
 
-	bm, err := iterateTerm(expr.Left, dependenciesForID, dependentsForID, nameToIDs, nodes, defaultNodeName)
+	bm, err := iterateTerm(expr.Left, dependenciesForID, dependentsForID, nameToIDs, nodes, defaultNodeName, lookups)
 	if err != nil {
 		return nil, err
 	}
 
 	if expr.Op != nil {
-		bm2, err := iterateExpression(expr.Right, dependenciesForID, dependentsForID, nameToIDs, nodes, defaultNodeName)
+		bm2, err := iterateExpression(expr.Right, dependenciesForID, dependentsForID, nameToIDs, nodes, defaultNodeName, lookups)
 
 		if err != nil {
 			return nil, err
@@ -200,7 +269,7 @@ func iterateExpression(expr *Expression, dependenciesForID, dependentsForID map[
 	return bm, nil
 }
 
-func iterateTerm(term *Term, dependenciesForID, dependentsForID map[uint32]*roaring.Bitmap, nameToIDs map[string]uint32, nodes map[uint32]*Node, defaultNodeName string) (*roaring.Bitmap, error) {
+func iterateTerm(term *Term, dependenciesForID, dependentsForID map[uint32]*roaring.Bitmap, nameToIDs map[string]uint32, nodes map[uint32]*Node, defaultNodeName string, lookups *QueryLookups) (*roaring.Bitmap, error) {
 	if term == nil {
 		return nil, nil
 	}
@@ -222,6 +291,19 @@ func iterateTerm(term *Term, dependenciesForID, dependentsForID map[uint32]*roar
 					bm.Add(depId)
 				}
 			}
+			if !term.Query.WithSuppressed {
+				hasAttributes, err := lookups.edgeAttributes.any()
+				if err != nil {
+					return nil, err
+				}
+				if hasAttributes {
+					unsuppressed, err := removeSuppressed(bm, id, dependenciesForID[id], nodes, lookups.edgeAttributes)
+					if err != nil {
+						return nil, err
+					}
+					bm = unsuppressed
+				}
+			}
 		case dependents:
 			for _, depId := range dependentsForID[id].ToArray() {
 				if nodes[depId] != nil && nodes[depId].Type == term.Query.NodeType {
@@ -231,10 +313,23 @@ func iterateTerm(term *Term, dependenciesForID, dependentsForID map[uint32]*roar
 		default:
 			return nil, fmt.Errorf("unknown query: %s", term.Query.QueryType)
 		}
+
+		if len(term.Query.Filters) > 0 {
+			var scope *roaring.Bitmap
+			if term.Query.QueryType == dependencies {
+				scope = dependenciesForID[id].Clone()
+				scope.Add(id)
+			}
+			filtered, err := applyFilters(bm, term.Query.Filters, nodes, scope, lookups)
+			if err != nil {
+				return nil, err
+			}
+			bm = filtered
+		}
 	}
 
 	if term.Expression != nil {
-		_, err := iterateExpression(term.Expression, dependenciesForID, dependentsForID, nameToIDs, nodes, defaultNodeName)
+		_, err := iterateExpression(term.Expression, dependenciesForID, dependentsForID, nameToIDs, nodes, defaultNodeName, lookups)
 		if err != nil {
 			return nil, err
 		}
@@ -242,3 +337,118 @@ func iterateTerm(term *Term, dependenciesForID, dependentsForID map[uint32]*roar
 
 	return bm, nil
 }
+
+// applyFilters returns the IDs in bm whose nodes match every filter. Edge filters only look at edges from the nodes in
+// scope, or from any node when scope is nil.
+func applyFilters(bm *roaring.Bitmap, filters []*Filter, nodes map[uint32]*Node, scope *roaring.Bitmap, lookups *QueryLookups) (*roaring.Bitmap, error) {
+	filtered := roaring.New()
+	for _, id := range bm.ToArray() {
+		node := nodes[id]
+		if node == nil {
+			continue
+		}
+		matches := true
+		for _, filter := range filters {
+			ok, err := filter.matches(node, nodes, scope, lookups)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered.Add(id)
+		}
+	}
+	return filtered, nil
+}
+
+func (f *Filter) matches(node *Node, nodes map[uint32]*Node, scope *roaring.Bitmap, lookups *QueryLookups) (bool, error) {
+	if key, ok := strings.CutPrefix(f.Field, edgePrefix); ok {
+		return f.matchesEdges(node, key, nodes, scope, lookups.edgeAttributes)
+	}
+	if key, ok := strings.CutPrefix(f.Field, tagPrefix); ok {
+		if err := ValidateAnnotationKey(key); err != nil {
+			return false, err
+		}
+		nodeAnnotations, err := lookups.annotations.get(node.Name)
+		if err != nil {
+			return false, err
+		}
+		value, exists := nodeAnnotations[key]
+		return f.compare(FieldString, value, exists)
+	}
+	fieldType, ok := LookupField(f.Field)
+	if !ok {
+		return false, fmt.Errorf("unknown filter field %q: only %s<key>, %s<key> and the fields %s are supported", f.Field, tagPrefix, edgePrefix, strings.Join(Fields(), ", "))
+	}
+	nodeFields, err := lookups.fields.get(node.Name)
+	if err != nil {
+		return false, err
+	}
+	value, exists := nodeFields[f.Field]
+	return f.compare(fieldType, value, exists)
+}
+
+// compare reports whether a value of the filtered field matches the filter. A missing value, or one that doesn't
+// have the type of the field, only matches !=.
+func (f *Filter) compare(fieldType FieldType, value string, exists bool) (bool, error) {
+	want, err := parseFieldValue(fieldType, f.Value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for filter field %s: %w", f.Field, err)
+	}
+	if fieldType == FieldBool && f.Op != equals && f.Op != notEquals {
+		return false, fmt.Errorf("filter field %s can only be compared with %s and %s", f.Field, equals, notEquals)
+	}
+	if !exists {
+		return f.Op == notEquals, nil
+	}
+	got, err := parseFieldValue(fieldType, value)
+	if err != nil {
+		return f.Op == notEquals, nil
+	}
+	c := compareParsed(got, want)
+	switch f.Op {
+	case equals:
+		return c == 0, nil
+	case notEquals:
+		return c != 0, nil
+	case less:
+		return c < 0, nil
+	case lessEqual:
+		return c <= 0, nil
+	case greater:
+		return c > 0, nil
+	case greaterEqual:
+		return c >= 0, nil
+	default:
+		return false, fmt.Errorf("unknown filter operator: %s", f.Op)
+	}
+}
+
+func (f *Filter) matchesEdges(node *Node, key string, nodes map[uint32]*Node, scope *roaring.Bitmap, edgeAttributes *edgeAttributeLookup) (bool, error) {
+	if f.Op != equals && f.Op != notEquals {
+		return false, fmt.Errorf("unknown filter operator: %s", f.Op)
+	}
+	attributes, err := edgeAttributes.get(node.Name)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for _, parent := range node.Parents.ToArray() {
+		if scope != nil && !scope.Contains(parent) {
+			continue
+		}
+		parentNode := nodes[parent]
+		if parentNode == nil {
+			continue
+		}
+		if value, ok := attributes[parentNode.Name][key]; ok && value == f.Value {
+			found = true
+			break
+		}
+	}
+	return found == (f.Op == equals), nil
+}
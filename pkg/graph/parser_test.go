@@ -61,6 +61,17 @@ func TestParseAndExecute(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// Annotate the libraries for the where filters.
+	for _, annotation := range []struct{ name, key, value string }{
+		{node1.Name, "owner", "team-payments"},
+		{node1.Name, "approved", "true"},
+		{node2.Name, "owner", "team-infra"},
+	} {
+		if err := SetAnnotation(storage, annotation.name, annotation.key, annotation.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
 	tests := []struct {
 		name            string
 		script          string
@@ -118,6 +129,42 @@ func TestParseAndExecute(t *testing.T) {
 			want:            roaring.BitmapOf(nodeCamel.ID),
 			defaultNodeName: "",
 		},
+		{
+			name:            "Dependents filtered by annotation",
+			script:          `dependents PACKAGE pkg:generic/dep1@1.0.0 where tag.owner = "team-payments"`,
+			want:            roaring.BitmapOf(node1.ID),
+			defaultNodeName: "",
+		},
+		{
+			name:            "Dependents filtered by missing or different annotation",
+			script:          `dependents PACKAGE pkg:generic/dep1@1.0.0 where tag.owner != "team-payments"`,
+			want:            roaring.BitmapOf(node2.ID, node3.ID),
+			defaultNodeName: "",
+		},
+		{
+			name:            "Filter without spaces around the operator",
+			script:          `dependents PACKAGE pkg:generic/dep1@1.0.0 where tag.owner="team-payments" where tag.approved=true`,
+			want:            roaring.BitmapOf(node1.ID),
+			defaultNodeName: "",
+		},
+		{
+			name:            "Multiple filters must all match",
+			script:          `dependents PACKAGE pkg:generic/dep1@1.0.0 where tag.owner = "team-payments" where tag.approved = true`,
+			want:            roaring.BitmapOf(node1.ID),
+			defaultNodeName: "",
+		},
+		{
+			name:            "Filtered query combined with OR",
+			script:          `dependents PACKAGE pkg:generic/dep1@1.0.0 where tag.owner = "team-infra" or dependencies PACKAGE pkg:generic/dep1@1.0.0`,
+			want:            roaring.BitmapOf(node2.ID, node3.ID, node4.ID),
+			defaultNodeName: "",
+		},
+		{
+			name:            "Unknown filter field",
+			script:          `dependents PACKAGE pkg:generic/dep1@1.0.0 where owner = "team-payments"`,
+			wantErr:         true,
+			defaultNodeName: "",
+		},
 		{
 			name:            "Invalid query with qualifier",
 			script:          "invalid PACKAGE pkg:maven/org.apache.camel.quarkus/camel-quarkus-cassandraql@3.18.0-SNAPSHOT?type=jar",
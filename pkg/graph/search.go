@@ -0,0 +1,193 @@
+package graph
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-json"
+)
+
+// DefaultSearchLimit is the number of results returned when no limit is given.
+const DefaultSearchLimit = 50
+
+var ErrEmptySearchQuery = errors.New("search query has no searchable terms")
+
+// SearchResult is a node matched by a metadata search, with higher scores ranking first.
+type SearchResult struct {
+	Node  *Node
+	Score float64
+}
+
+// ScoredID is the ID of a matched node and its score.
+type ScoredID struct {
+	ID    uint32
+	Score float64
+}
+
+// SearchText returns the text of a node that metadata search matches against: its name, its type and every string
+// value in its metadata. Metadata keys are left out, since they are shared by every node of a type.
+func SearchText(node *Node) string {
+	var sb strings.Builder
+	sb.WriteString(node.Name)
+	sb.WriteByte(' ')
+	sb.WriteString(node.Type)
+	if node.Metadata != nil {
+		// Round trip through JSON so structs and maps are walked the same way
+		data, err := json.Marshal(node.Metadata)
+		if err == nil {
+			var value any
+			if err := json.Unmarshal(data, &value); err == nil {
+				appendStrings(&sb, value)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func appendStrings(sb *strings.Builder, value any) {
+	switch v := value.(type) {
+	case string:
+		sb.WriteByte(' ')
+		sb.WriteString(v)
+	case []any:
+		for _, item := range v {
+			appendStrings(sb, item)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			appendStrings(sb, v[key])
+		}
+	}
+}
+
+// Tokenize splits text into lower case terms on anything that is not a letter or a digit, the same way the
+// SQLite unicode61 tokenizer does.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// QueryTerms returns the unique terms of a search query.
+func QueryTerms(query string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, term := range Tokenize(query) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// TermFrequencies returns how often each term occurs in the search text of a node.
+func TermFrequencies(node *Node) map[string]int {
+	frequencies := map[string]int{}
+	for _, term := range Tokenize(SearchText(node)) {
+		frequencies[term]++
+	}
+	return frequencies
+}
+
+// RankTFIDF ranks the documents that contain every query term by TF-IDF and returns the best limit of them.
+// postings holds, for each query term, the term frequency in every document containing it.
+func RankTFIDF(postings map[string]map[uint32]int, terms []string, totalDocs, limit int) []ScoredID {
+	if len(terms) == 0 {
+		return nil
+	}
+	scores := map[uint32]float64{}
+	for i, term := range terms {
+		docs := postings[term]
+		if len(docs) == 0 {
+			return nil
+		}
+		idf := math.Log(1 + float64(totalDocs)/float64(len(docs)))
+		next := make(map[uint32]float64, len(docs))
+		for id, tf := range docs {
+			// Every term has to match, so only documents that matched all earlier terms are kept
+			if _, ok := scores[id]; i > 0 && !ok {
+				continue
+			}
+			next[id] = scores[id] + (1+math.Log(float64(tf)))*idf
+		}
+		scores = next
+	}
+
+	ranked := make([]ScoredID, 0, len(scores))
+	for id, score := range scores {
+		ranked = append(ranked, ScoredID{ID: id, Score: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// InvertedIndex is an in-memory inverted index from terms to the nodes containing them.
+// It is not safe for concurrent use.
+type InvertedIndex struct {
+	postings map[string]map[uint32]int
+	docs     map[uint32]map[string]int
+}
+
+func NewInvertedIndex() *InvertedIndex {
+	return &InvertedIndex{
+		postings: map[string]map[uint32]int{},
+		docs:     map[uint32]map[string]int{},
+	}
+}
+
+// Index replaces the indexed terms of the node.
+func (ix *InvertedIndex) Index(node *Node) {
+	ix.Remove(node.ID)
+	frequencies := TermFrequencies(node)
+	ix.docs[node.ID] = frequencies
+	for term, tf := range frequencies {
+		if ix.postings[term] == nil {
+			ix.postings[term] = map[uint32]int{}
+		}
+		ix.postings[term][node.ID] = tf
+	}
+}
+
+// Remove drops the node from the index.
+func (ix *InvertedIndex) Remove(id uint32) {
+	for term := range ix.docs[id] {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	delete(ix.docs, id)
+}
+
+// Search returns the IDs of the best limit nodes matching every term of the query.
+func (ix *InvertedIndex) Search(query string, limit int) ([]ScoredID, error) {
+	terms := QueryTerms(query)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+	postings := make(map[string]map[uint32]int, len(terms))
+	for _, term := range terms {
+		postings[term] = ix.postings[term]
+	}
+	return RankTFIDF(postings, terms, len(ix.docs), limit), nil
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchText(t *testing.T) {
+	node := &Node{
+		ID:   1,
+		Name: "pkg:golang/example.com/lib@v1.0.0",
+		Type: "library",
+		Metadata: map[string]any{
+			"supplier": "Acme Corp",
+			"licenses": []any{"MIT", "Apache-2.0"},
+			"version":  1,
+		},
+	}
+	assert.Equal(t, "pkg:golang/example.com/lib@v1.0.0 library MIT Apache-2.0 Acme Corp", SearchText(node))
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"pkg", "npm", "lodash", "4", "17", "21"}, Tokenize("pkg:npm/Lodash@4.17.21"))
+	assert.Empty(t, Tokenize(" -- "))
+	assert.Equal(t, []string{"remote", "code"}, QueryTerms("Remote code remote"))
+}
+
+func TestRankTFIDF(t *testing.T) {
+	postings := map[string]map[uint32]int{
+		"remote": {1: 1, 2: 3, 3: 1},
+		"code":   {1: 1, 2: 1},
+	}
+	ranked := RankTFIDF(postings, []string{"remote", "code"}, 4, 0)
+	require.Len(t, ranked, 2, "only documents with every term match")
+	assert.Equal(t, uint32(2), ranked[0].ID, "a higher term frequency ranks first")
+	assert.Equal(t, uint32(1), ranked[1].ID)
+
+	assert.Len(t, RankTFIDF(postings, []string{"remote"}, 4, 1), 1)
+	assert.Empty(t, RankTFIDF(postings, []string{"remote", "missing"}, 4, 0))
+}
+
+func TestInvertedIndex(t *testing.T) {
+	ix := NewInvertedIndex()
+	ix.Index(&Node{ID: 1, Name: "vuln1", Type: "vuln", Metadata: map[string]any{"summary": "Remote code execution"}})
+	ix.Index(&Node{ID: 2, Name: "vuln2", Type: "vuln", Metadata: map[string]any{"summary": "Denial of service"}})
+
+	ranked, err := ix.Search("REMOTE code", 10)
+	require.NoError(t, err)
+	require.Len(t, ranked, 1)
+	assert.Equal(t, uint32(1), ranked[0].ID)
+
+	// Reindexing a node drops its old terms
+	ix.Index(&Node{ID: 1, Name: "vuln1", Type: "vuln", Metadata: map[string]any{"summary": "Path traversal"}})
+	ranked, err = ix.Search("remote", 10)
+	require.NoError(t, err)
+	assert.Empty(t, ranked)
+
+	ranked, err = ix.Search("vuln", 10)
+	require.NoError(t, err)
+	assert.Len(t, ranked, 2)
+
+	_, err = ix.Search("?!", 10)
+	assert.ErrorIs(t, err, ErrEmptySearchQuery)
+}
+
+func TestMockStorageSearchMetadata(t *testing.T) {
+	storage := NewMockStorage()
+	node, err := AddNode(storage, "vuln", map[string]any{"summary": "Prototype pollution in lodash"}, "GHSA-1234")
+	require.NoError(t, err)
+	_, err = AddNode(storage, "library", nil, "pkg:npm/lodash@4.17.20")
+	require.NoError(t, err)
+
+	results, err := storage.SearchMetadata("prototype pollution", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, node.ID, results[0].Node.ID)
+	assert.Greater(t, results[0].Score, 0.0)
+}
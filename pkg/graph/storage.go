@@ -6,8 +6,16 @@ type Storage interface {
 	SaveNode(node *Node) error
 	GetNode(id uint32) (*Node, error)
 	GetNodes(ids []uint32) (map[uint32]*Node, error)
+	// DeleteNode removes a node along with its name mapping, cache and search entry. The node must no longer have
+	// any edges, use RemoveNode to remove them first.
+	DeleteNode(id uint32) error
 	GetNodesByGlob(pattern string) ([]*Node, error)
+	// SearchMetadata returns up to limit nodes whose name, type or metadata contain every term of query,
+	// best matches first.
+	SearchMetadata(query string, limit int) ([]SearchResult, error)
 	GetAllKeys() ([]uint32, error)
+	// GetAllNames returns the ID of every node, keyed by node name.
+	GetAllNames() (map[string]uint32, error)
 	SaveCache(cache *NodeCache) error
 	SaveCaches(cache []*NodeCache) error
 	RemoveAllCaches() error
@@ -17,6 +25,16 @@ type Storage interface {
 	GetCaches(ids []uint32) (map[uint32]*NodeCache, error)
 	ClearCacheStack() error
 	GenerateID() (uint32, error)
+	GetIDCounter() (uint32, error)
+	SetIDCounter(id uint32) error
 	GetCustomData(tag, key string) (map[string][]byte, error)
+	GetCustomDataKeys() ([]CustomDataKey, error)
 	AddOrUpdateCustomData(tag, key string, datakey string, data []byte) error
+	DeleteCustomData(tag, key string, datakey string) error
+}
+
+// CustomDataKey identifies a custom data record, which holds a map of data keys to values.
+type CustomDataKey struct {
+	Tag string `json:"tag"`
+	Key string `json:"key"`
 }
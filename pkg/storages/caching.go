@@ -0,0 +1,284 @@
+package storages
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+)
+
+// CachingStorage is a graph.Storage decorator that keeps an in-process LRU of decoded nodes and node caches in
+// front of another backend. Reads are served from the LRU when possible and writes go straight to the backend,
+// invalidating the affected entries. Writes made by other processes sharing the backend are not seen until the
+// entry is evicted, so only enable it when this process is the only writer.
+type CachingStorage struct {
+	graph.Storage
+	nodes  *lru[*graph.Node]
+	caches *lru[*graph.NodeCache]
+}
+
+// CacheStats reports the hits and misses of a CachingStorage.
+type CacheStats struct {
+	NodeHits    uint64
+	NodeMisses  uint64
+	CacheHits   uint64
+	CacheMisses uint64
+}
+
+// NewCachingStorage wraps backend with LRUs holding up to size nodes and size node caches.
+func NewCachingStorage(backend graph.Storage, size int) *CachingStorage {
+	return &CachingStorage{
+		Storage: backend,
+		nodes:   newLRU[*graph.Node](size),
+		caches:  newLRU[*graph.NodeCache](size),
+	}
+}
+
+// Stats returns the hit and miss counts since the storage was created.
+func (c *CachingStorage) Stats() CacheStats {
+	nodeHits, nodeMisses := c.nodes.stats()
+	cacheHits, cacheMisses := c.caches.stats()
+	return CacheStats{NodeHits: nodeHits, NodeMisses: nodeMisses, CacheHits: cacheHits, CacheMisses: cacheMisses}
+}
+
+func (c *CachingStorage) SaveNode(node *graph.Node) error {
+	err := c.Storage.SaveNode(node)
+	if node != nil {
+		c.nodes.remove(node.ID)
+	}
+	return err
+}
+
+func (c *CachingStorage) DeleteNode(id uint32) error {
+	err := c.Storage.DeleteNode(id)
+	c.nodes.remove(id)
+	c.caches.remove(id)
+	return err
+}
+
+// GetNode returns a copy of the node, so callers that change it without saving do not corrupt the LRU.
+func (c *CachingStorage) GetNode(id uint32) (*graph.Node, error) {
+	node, generation, ok := c.nodes.get(id)
+	if ok {
+		return cloneNode(node), nil
+	}
+	node, err := c.Storage.GetNode(id)
+	if err != nil {
+		return nil, err
+	}
+	if node != nil {
+		c.nodes.add(id, cloneNode(node), generation)
+	}
+	return node, nil
+}
+
+func (c *CachingStorage) GetNodes(ids []uint32) (map[uint32]*graph.Node, error) {
+	result := make(map[uint32]*graph.Node, len(ids))
+	var missing []uint32
+	generation := c.nodes.generation()
+	for _, id := range ids {
+		if node, _, ok := c.nodes.get(id); ok {
+			result[id] = cloneNode(node)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	nodes, err := c.Storage.GetNodes(missing)
+	if err != nil {
+		return nil, err
+	}
+	for id, node := range nodes {
+		if node == nil {
+			continue
+		}
+		c.nodes.add(id, cloneNode(node), generation)
+		result[id] = node
+	}
+	return result, nil
+}
+
+func (c *CachingStorage) SaveCache(cache *graph.NodeCache) error {
+	err := c.Storage.SaveCache(cache)
+	if cache != nil {
+		c.caches.remove(cache.ID)
+	}
+	return err
+}
+
+func (c *CachingStorage) SaveCaches(caches []*graph.NodeCache) error {
+	err := c.Storage.SaveCaches(caches)
+	for _, cache := range caches {
+		if cache != nil {
+			c.caches.remove(cache.ID)
+		}
+	}
+	return err
+}
+
+func (c *CachingStorage) RemoveAllCaches() error {
+	err := c.Storage.RemoveAllCaches()
+	c.caches.purge()
+	return err
+}
+
+// GetCache returns a copy of the node cache, so callers that change it do not corrupt the LRU.
+func (c *CachingStorage) GetCache(id uint32) (*graph.NodeCache, error) {
+	cache, generation, ok := c.caches.get(id)
+	if ok {
+		return cloneNodeCache(cache), nil
+	}
+	cache, err := c.Storage.GetCache(id)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		c.caches.add(id, cloneNodeCache(cache), generation)
+	}
+	return cache, nil
+}
+
+func (c *CachingStorage) GetCaches(ids []uint32) (map[uint32]*graph.NodeCache, error) {
+	result := make(map[uint32]*graph.NodeCache, len(ids))
+	var missing []uint32
+	generation := c.caches.generation()
+	for _, id := range ids {
+		if cache, _, ok := c.caches.get(id); ok {
+			result[id] = cloneNodeCache(cache)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	caches, err := c.Storage.GetCaches(missing)
+	if err != nil {
+		return nil, err
+	}
+	for id, cache := range caches {
+		if cache == nil {
+			continue
+		}
+		c.caches.add(id, cloneNodeCache(cache), generation)
+		result[id] = cache
+	}
+	return result, nil
+}
+
+func cloneNode(node *graph.Node) *graph.Node {
+	clone := *node
+	if node.Children != nil {
+		clone.Children = node.Children.Clone()
+	}
+	if node.Parents != nil {
+		clone.Parents = node.Parents.Clone()
+	}
+	return &clone
+}
+
+func cloneNodeCache(cache *graph.NodeCache) *graph.NodeCache {
+	clone := *cache
+	if cache.AllChildren != nil {
+		clone.AllChildren = cache.AllChildren.Clone()
+	}
+	if cache.AllParents != nil {
+		clone.AllParents = cache.AllParents.Clone()
+	}
+	return &clone
+}
+
+// lru is a size bounded, least recently used map from IDs to values that is safe for concurrent use.
+// Every invalidation bumps its generation, and values read from the backend are only added if no invalidation
+// happened since the read started, so a read racing with a write cannot put a stale value back.
+type lru[V any] struct {
+	mu      sync.Mutex
+	size    int
+	gen     uint64
+	order   *list.List
+	entries map[uint32]*list.Element
+	hits    uint64
+	misses  uint64
+}
+
+type lruEntry[V any] struct {
+	id    uint32
+	value V
+}
+
+func newLRU[V any](size int) *lru[V] {
+	return &lru[V]{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[uint32]*list.Element),
+	}
+}
+
+// get returns the value for id, along with the current generation to pass to add on a miss.
+func (l *lru[V]) get(id uint32) (V, uint64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.entries[id]; ok {
+		l.hits++
+		l.order.MoveToFront(elem)
+		return elem.Value.(*lruEntry[V]).value, l.gen, true
+	}
+	l.misses++
+	var zero V
+	return zero, l.gen, false
+}
+
+func (l *lru[V]) generation() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.gen
+}
+
+// add stores value for id unless an invalidation happened after generation was read.
+func (l *lru[V]) add(id uint32, value V, generation uint64) {
+	if l.size <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if generation != l.gen {
+		return
+	}
+	if elem, ok := l.entries[id]; ok {
+		elem.Value.(*lruEntry[V]).value = value
+		l.order.MoveToFront(elem)
+		return
+	}
+	l.entries[id] = l.order.PushFront(&lruEntry[V]{id: id, value: value})
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry[V]).id)
+	}
+}
+
+func (l *lru[V]) remove(id uint32) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gen++
+	if elem, ok := l.entries[id]; ok {
+		l.order.Remove(elem)
+		delete(l.entries, id)
+	}
+}
+
+func (l *lru[V]) purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.gen++
+	l.order.Init()
+	l.entries = make(map[uint32]*list.Element)
+}
+
+func (l *lru[V]) stats() (hits, misses uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.hits, l.misses
+}
@@ -0,0 +1,154 @@
+package storages
+
+import (
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStorage counts the reads that reach the backend.
+type countingStorage struct {
+	graph.Storage
+	nodeReads  int
+	cacheReads int
+}
+
+func (c *countingStorage) GetNode(id uint32) (*graph.Node, error) {
+	c.nodeReads++
+	return c.Storage.GetNode(id)
+}
+
+func (c *countingStorage) GetNodes(ids []uint32) (map[uint32]*graph.Node, error) {
+	c.nodeReads += len(ids)
+	return c.Storage.GetNodes(ids)
+}
+
+func (c *countingStorage) GetCache(id uint32) (*graph.NodeCache, error) {
+	c.cacheReads++
+	return c.Storage.GetCache(id)
+}
+
+func (c *countingStorage) GetCaches(ids []uint32) (map[uint32]*graph.NodeCache, error) {
+	c.cacheReads += len(ids)
+	return c.Storage.GetCaches(ids)
+}
+
+func setupCachingStorage(t *testing.T, size int) (*CachingStorage, *countingStorage) {
+	t.Helper()
+	backend := &countingStorage{Storage: graph.NewMockStorage()}
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := graph.AddNode(backend, "library", nil, name)
+		require.NoError(t, err)
+	}
+	return NewCachingStorage(backend, size), backend
+}
+
+func TestCachingStorage_GetNode(t *testing.T) {
+	s, backend := setupCachingStorage(t, 10)
+
+	node, err := s.GetNode(1)
+	require.NoError(t, err)
+	assert.Equal(t, "a", node.Name)
+	_, err = s.GetNode(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, backend.nodeReads)
+
+	// Changing a returned node without saving it does not leak into the LRU
+	node.Children.Add(42)
+	node, err = s.GetNode(1)
+	require.NoError(t, err)
+	assert.False(t, node.Children.Contains(42))
+
+	// Saving invalidates the entry
+	node.Name = "renamed"
+	require.NoError(t, s.SaveNode(node))
+	node, err = s.GetNode(1)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", node.Name)
+	assert.Equal(t, 2, backend.nodeReads)
+
+	assert.Equal(t, CacheStats{NodeHits: 2, NodeMisses: 2}, s.Stats())
+}
+
+func TestCachingStorage_GetNodes(t *testing.T) {
+	s, backend := setupCachingStorage(t, 10)
+
+	_, err := s.GetNode(1)
+	require.NoError(t, err)
+	nodes, err := s.GetNodes([]uint32{1, 2, 3})
+	require.NoError(t, err)
+	assert.Len(t, nodes, 3)
+	// Only the two uncached nodes are read from the backend
+	assert.Equal(t, 3, backend.nodeReads)
+
+	nodes, err = s.GetNodes([]uint32{1, 2, 3})
+	require.NoError(t, err)
+	assert.Len(t, nodes, 3)
+	assert.Equal(t, 3, backend.nodeReads)
+}
+
+func TestCachingStorage_Eviction(t *testing.T) {
+	s, backend := setupCachingStorage(t, 2)
+
+	for _, id := range []uint32{1, 2, 3} {
+		_, err := s.GetNode(id)
+		require.NoError(t, err)
+	}
+	// Node 1 was the least recently used and has been evicted
+	_, err := s.GetNode(1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, backend.nodeReads)
+	_, err = s.GetNode(3)
+	require.NoError(t, err)
+	assert.Equal(t, 4, backend.nodeReads)
+}
+
+func TestCachingStorage_Caches(t *testing.T) {
+	s, backend := setupCachingStorage(t, 10)
+	require.NoError(t, s.SaveCache(graph.NewNodeCache(1, roaring.New(), roaring.BitmapOf(2))))
+
+	cache, err := s.GetCache(1)
+	require.NoError(t, err)
+	assert.True(t, cache.AllChildren.Contains(2))
+	caches, err := s.GetCaches([]uint32{1})
+	require.NoError(t, err)
+	assert.Contains(t, caches, uint32(1))
+	assert.Equal(t, 1, backend.cacheReads)
+
+	require.NoError(t, s.SaveCaches([]*graph.NodeCache{graph.NewNodeCache(1, roaring.New(), roaring.BitmapOf(3))}))
+	cache, err = s.GetCache(1)
+	require.NoError(t, err)
+	assert.True(t, cache.AllChildren.Contains(3))
+	assert.Equal(t, 2, backend.cacheReads)
+
+	// Removing all caches purges the LRU, so the next read goes to the backend
+	require.NoError(t, s.RemoveAllCaches())
+	_, _ = s.GetCache(1)
+	assert.Equal(t, 3, backend.cacheReads)
+}
+
+func TestCachingStorage_StaleReadIsNotCached(t *testing.T) {
+	l := newLRU[string](10)
+	_, generation, ok := l.get(1)
+	assert.False(t, ok)
+
+	// A write invalidates the entry while the read is still in flight
+	l.remove(1)
+	l.add(1, "stale", generation)
+	_, _, ok = l.get(1)
+	assert.False(t, ok)
+}
+
+func TestCachingStorage_DeleteNode(t *testing.T) {
+	s, _ := setupCachingStorage(t, 10)
+
+	_, err := s.GetNode(1)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteNode(1))
+
+	_, err = s.GetNode(1)
+	assert.Error(t, err, "a deleted node is not served from the LRU")
+}
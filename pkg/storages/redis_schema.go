@@ -0,0 +1,152 @@
+package storages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools/ingest"
+	"github.com/go-redis/redis/v8"
+)
+
+const scanBatchSize = 500
+
+// redisSchemaMigrations is the ordered registry of Redis schema migrations. Append new migrations to the end.
+var redisSchemaMigrations = []SchemaMigration[*RedisStorage]{
+	{
+		Version:     1,
+		Description: "re-encode JSON node and cache records in the binary record format",
+		Up: func(r *RedisStorage) error {
+			if err := r.reencodeRecords(NodeKeyPrefix, reencodeNode); err != nil {
+				return err
+			}
+			return r.reencodeRecords(CacheKeyPrefix, reencodeCache)
+		},
+	},
+	{
+		Version:     2,
+		Description: "index existing custom data records",
+		Up: func(r *RedisStorage) error {
+			return r.indexCustomData()
+		},
+	},
+	{
+		Version:     3,
+		Description: "build the metadata search index",
+		Up: func(r *RedisStorage) error {
+			return r.reindexSearch()
+		},
+	},
+	{
+		Version:     4,
+		Description: "index library nodes by the package they are a version of",
+		Up: func(r *RedisStorage) error {
+			return ingest.IndexLibraries(r)
+		},
+	},
+	{
+		Version:     5,
+		Description: "record the advisories of existing vuln nodes from their OSV metadata",
+		Up: func(r *RedisStorage) error {
+			return ingest.RebuildAdvisories(r)
+		},
+	},
+	{
+		Version:     6,
+		Description: "list the nodes with edge attributes",
+		Up: func(r *RedisStorage) error {
+			return graph.IndexEdgeAttributes(r)
+		},
+	},
+}
+
+// SchemaVersion returns the schema version recorded in the database, or zero if none is recorded.
+func (r *RedisStorage) SchemaVersion() (int, error) {
+	version, err := r.Client.Get(context.Background(), SchemaVersionKey).Int()
+	if err == redis.Nil {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
+
+// LatestSchemaVersion returns the schema version this version of minefield writes.
+func (r *RedisStorage) LatestSchemaVersion() int {
+	return latestVersion(redisSchemaMigrations)
+}
+
+// UpgradeSchema applies every pending Redis schema migration in order.
+func (r *RedisStorage) UpgradeSchema() ([]string, error) {
+	version, err := r.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	return applyMigrations(r, version, redisSchemaMigrations, r.setSchemaVersion)
+}
+
+func (r *RedisStorage) setSchemaVersion(version int) error {
+	return r.Client.Set(context.Background(), SchemaVersionKey, version, 0).Err()
+}
+
+// stampSchemaVersion records the latest schema version in a database that holds no data yet, so new databases
+// never need to be upgraded.
+func (r *RedisStorage) stampSchemaVersion() error {
+	size, err := r.Client.DBSize(context.Background()).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get database size: %w", err)
+	}
+	if size > 0 {
+		return nil
+	}
+	return r.setSchemaVersion(r.LatestSchemaVersion())
+}
+
+// reencodeRecords rewrites every record under prefix with reencode, skipping records it returns nil for.
+func (r *RedisStorage) reencodeRecords(prefix string, reencode func([]byte) ([]byte, error)) error {
+	ctx := context.Background()
+	iter := r.Client.Scan(ctx, 0, prefix+"*", scanBatchSize).Iterator()
+	for iter.Next(ctx) {
+		redisKey := iter.Val()
+		value, err := r.Client.Get(ctx, redisKey).Bytes()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get %s: %w", redisKey, err)
+		}
+		data, err := reencode(value)
+		if err != nil {
+			return fmt.Errorf("failed to re-encode %s: %w", redisKey, err)
+		}
+		if data == nil {
+			continue
+		}
+		if err := r.Client.Set(ctx, redisKey, data, 0).Err(); err != nil {
+			return fmt.Errorf("failed to update %s: %w", redisKey, err)
+		}
+	}
+	return iter.Err()
+}
+
+// indexCustomData adds every custom data hash to the custom data index. Custom data is the only data stored in
+// hashes, under "<tag>:<key>".
+func (r *RedisStorage) indexCustomData() error {
+	ctx := context.Background()
+	iter := r.Client.ScanType(ctx, 0, "*", scanBatchSize, "hash").Iterator()
+	for iter.Next(ctx) {
+		tag, key, ok := strings.Cut(iter.Val(), ":")
+		if !ok {
+			continue
+		}
+		member, err := json.Marshal(graph.CustomDataKey{Tag: tag, Key: key})
+		if err != nil {
+			return fmt.Errorf("failed to marshal custom data key: %w", err)
+		}
+		if err := r.Client.SAdd(ctx, CustomDataKeys, member).Err(); err != nil {
+			return fmt.Errorf("failed to index %s: %w", iter.Val(), err)
+		}
+	}
+	return iter.Err()
+}
@@ -0,0 +1,139 @@
+package storages
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/utils"
+	"github.com/go-redis/redis/v8"
+)
+
+// indexNode replaces the search entry of a node. Each term is a sorted set of the nodes containing it, scored by
+// term frequency, and each node remembers its terms so they can be removed when it changes.
+func (r *RedisStorage) indexNode(node *graph.Node) error {
+	ctx := context.Background()
+	docKey := fmt.Sprintf("%s%d", SearchDocPrefix, node.ID)
+	member := utils.Uint32ToStr(node.ID)
+
+	oldTerms, err := r.Client.SMembers(ctx, docKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get indexed terms: %w", err)
+	}
+	frequencies := graph.TermFrequencies(node)
+
+	pipe := r.Client.TxPipeline()
+	for _, term := range oldTerms {
+		if _, ok := frequencies[term]; !ok {
+			pipe.ZRem(ctx, SearchTermPrefix+term, member)
+		}
+	}
+	pipe.Del(ctx, docKey)
+	terms := make([]interface{}, 0, len(frequencies))
+	for term, frequency := range frequencies {
+		pipe.ZAdd(ctx, SearchTermPrefix+term, &redis.Z{Score: float64(frequency), Member: member})
+		terms = append(terms, term)
+	}
+	if len(terms) > 0 {
+		pipe.SAdd(ctx, docKey, terms...)
+	}
+	pipe.SAdd(ctx, SearchDocsKey, member)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	return nil
+}
+
+// unindexNode removes a node from the search index.
+func (r *RedisStorage) unindexNode(id uint32) error {
+	ctx := context.Background()
+	docKey := fmt.Sprintf("%s%d", SearchDocPrefix, id)
+	member := utils.Uint32ToStr(id)
+
+	terms, err := r.Client.SMembers(ctx, docKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get indexed terms: %w", err)
+	}
+	pipe := r.Client.TxPipeline()
+	for _, term := range terms {
+		pipe.ZRem(ctx, SearchTermPrefix+term, member)
+	}
+	pipe.Del(ctx, docKey)
+	pipe.SRem(ctx, SearchDocsKey, member)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	return nil
+}
+
+// SearchMetadata returns up to limit nodes whose name, type or metadata contain every term of query, ranked by
+// TF-IDF.
+func (r *RedisStorage) SearchMetadata(query string, limit int) ([]graph.SearchResult, error) {
+	terms := graph.QueryTerms(query)
+	if len(terms) == 0 {
+		return nil, graph.ErrEmptySearchQuery
+	}
+	ctx := context.Background()
+
+	pipe := r.Client.Pipeline()
+	postingCmds := make(map[string]*redis.ZSliceCmd, len(terms))
+	for _, term := range terms {
+		postingCmds[term] = pipe.ZRangeWithScores(ctx, SearchTermPrefix+term, 0, -1)
+	}
+	totalCmd := pipe.SCard(ctx, SearchDocsKey)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to search metadata: %w", err)
+	}
+
+	postings := make(map[string]map[uint32]int, len(terms))
+	for term, cmd := range postingCmds {
+		postings[term] = map[uint32]int{}
+		for _, z := range cmd.Val() {
+			memberStr, _ := z.Member.(string)
+			id, err := utils.StrToUint32(memberStr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse search index member: %w", err)
+			}
+			postings[term][id] = int(z.Score)
+		}
+	}
+	ranked := graph.RankTFIDF(postings, terms, int(totalCmd.Val()), limit)
+
+	ids := make([]uint32, len(ranked))
+	for i, hit := range ranked {
+		ids[i] = hit.ID
+	}
+	nodes, err := r.GetNodes(ids)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]graph.SearchResult, 0, len(ranked))
+	for _, hit := range ranked {
+		if node, ok := nodes[hit.ID]; ok && node != nil {
+			results = append(results, graph.SearchResult{Node: node, Score: hit.Score})
+		}
+	}
+	return results, nil
+}
+
+// reindexSearch indexes every stored node for metadata search.
+func (r *RedisStorage) reindexSearch() error {
+	ctx := context.Background()
+	iter := r.Client.Scan(ctx, 0, NodeKeyPrefix+"*", scanBatchSize).Iterator()
+	for iter.Next(ctx) {
+		data, err := r.Client.Get(ctx, iter.Val()).Bytes()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get %s: %w", iter.Val(), err)
+		}
+		var node graph.Node
+		if err := node.UnmarshalBinary(data); err != nil {
+			return fmt.Errorf("failed to unmarshal %s: %w", iter.Val(), err)
+		}
+		if err := r.indexNode(&node); err != nil {
+			return fmt.Errorf("failed to index %s: %w", iter.Val(), err)
+		}
+	}
+	return iter.Err()
+}
@@ -2,7 +2,9 @@ package storages
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -22,7 +24,11 @@ func NewRedisStorage(addr string) (graph.Storage, error) {
 	if err := rdb.Ping(context.Background()).Err(); err != nil {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
-	return &RedisStorage{Client: rdb}, nil
+	storage := &RedisStorage{Client: rdb}
+	if err := storage.stampSchemaVersion(); err != nil {
+		return nil, fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return storage, nil
 }
 
 func (r *RedisStorage) GenerateID() (uint32, error) {
@@ -33,8 +39,41 @@ func (r *RedisStorage) GenerateID() (uint32, error) {
 	return utils.IntToUint32(int(id))
 }
 
+func (r *RedisStorage) GetIDCounter() (uint32, error) {
+	id, err := r.Client.Get(context.Background(), IDCounterKey).Int()
+	if err == redis.Nil {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to get ID counter: %w", err)
+	}
+	return utils.IntToUint32(id)
+}
+
+// SetIDCounter moves the ID counter forward so that the next generated ID is greater than id.
+// The counter never moves backwards, since that would hand out IDs that are already in use.
+func (r *RedisStorage) SetIDCounter(id uint32) error {
+	ctx := context.Background()
+	return r.Client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, IDCounterKey).Int64()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to get ID counter: %w", err)
+		}
+		if int64(id) <= current {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, IDCounterKey, id, 0)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set ID counter: %w", err)
+		}
+		return nil
+	}, IDCounterKey)
+}
+
 func (r *RedisStorage) SaveNode(node *graph.Node) error {
-	data, err := node.MarshalJSON()
+	data, err := node.MarshalBinary()
 	if err != nil {
 		return fmt.Errorf("failed to marshal node: %w", err)
 	}
@@ -48,6 +87,31 @@ func (r *RedisStorage) SaveNode(node *graph.Node) error {
 	if err := r.AddNodeToCachedStack(node.ID); err != nil {
 		return fmt.Errorf("failed to add node ID to %s set: %w", CacheStackKey, err)
 	}
+	if err := r.indexNode(node); err != nil {
+		return fmt.Errorf("failed to index node for search: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisStorage) DeleteNode(id uint32) error {
+	ctx := context.Background()
+	node, err := r.GetNode(id)
+	if err != nil {
+		return err
+	}
+	pipe := r.Client.TxPipeline()
+	pipe.Del(ctx,
+		fmt.Sprintf("%s%d", NodeKeyPrefix, id),
+		fmt.Sprintf("%s%s", NameToIDKey, node.Name),
+		fmt.Sprintf("%s%d", CacheKeyPrefix, id),
+	)
+	pipe.LRem(ctx, CacheStackKey, 0, utils.Uint32ToStr(id))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete node %d: %w", id, err)
+	}
+	if err := r.unindexNode(id); err != nil {
+		return fmt.Errorf("failed to remove node %d from the search index: %w", id, err)
+	}
 	return nil
 }
 
@@ -71,7 +135,7 @@ func (r *RedisStorage) GetNode(id uint32) (*graph.Node, error) {
 		return nil, fmt.Errorf("failed to get node data for ID %d: %w", id, err)
 	}
 	var node graph.Node
-	if err := node.UnmarshalJSON([]byte(data)); err != nil {
+	if err := node.UnmarshalBinary([]byte(data)); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal node data: %w", err)
 	}
 	return &node, nil
@@ -121,9 +185,55 @@ func (r *RedisStorage) GetAllKeys() ([]uint32, error) {
 	return result, nil
 }
 
+func (r *RedisStorage) GetAllNames() (map[string]uint32, error) {
+	ctx := context.Background()
+	names := make(map[string]uint32)
+	var keys []string
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		values, err := r.Client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get name-to-ID mappings: %w", err)
+		}
+		for i, value := range values {
+			// The mapping was removed between the scan and the read
+			str, ok := value.(string)
+			if !ok {
+				continue
+			}
+			id, err := strconv.ParseUint(str, 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid ID format for key %s: %w", keys[i], err)
+			}
+			names[strings.TrimPrefix(keys[i], NameToIDKey)] = uint32(id)
+		}
+		keys = keys[:0]
+		return nil
+	}
+
+	iter := r.Client.Scan(ctx, 0, NameToIDKey+"*", scanBatchSize).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == scanBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan name-to-ID mappings: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
 func (r *RedisStorage) SaveCache(cache *graph.NodeCache) error {
 	ctx := context.Background()
-	data, err := cache.MarshalJSON()
+	data, err := cache.MarshalBinary()
 	if err != nil {
 		return fmt.Errorf("failed to marshal cache: %w", err)
 	}
@@ -174,7 +284,7 @@ func (r *RedisStorage) GetCache(nodeID uint32) (*graph.NodeCache, error) {
 		return nil, fmt.Errorf("failed to get cache for node %d: %w", nodeID, err)
 	}
 	var cache graph.NodeCache
-	if err := cache.UnmarshalJSON([]byte(data)); err != nil {
+	if err := cache.UnmarshalBinary([]byte(data)); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal cache data: %w", err)
 	}
 	return &cache, nil
@@ -204,7 +314,7 @@ func (r *RedisStorage) GetNodes(ids []uint32) (map[uint32]*graph.Node, error) {
 		}
 
 		var node graph.Node
-		if err := node.UnmarshalJSON([]byte(data)); err != nil {
+		if err := node.UnmarshalBinary([]byte(data)); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal node data: %w", err)
 		}
 		nodes[ids[i]] = &node
@@ -218,7 +328,7 @@ func (r *RedisStorage) SaveCaches(caches []*graph.NodeCache) error {
 	pipe := r.Client.Pipeline()
 
 	for _, cache := range caches {
-		data, err := cache.MarshalJSON()
+		data, err := cache.MarshalBinary()
 		if err != nil {
 			return fmt.Errorf("failed to marshal cache: %w", err)
 		}
@@ -256,7 +366,7 @@ func (r *RedisStorage) GetCaches(ids []uint32) (map[uint32]*graph.NodeCache, err
 		}
 
 		var cache graph.NodeCache
-		if err := cache.UnmarshalJSON([]byte(data)); err != nil {
+		if err := cache.UnmarshalBinary([]byte(data)); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal cache data: %w", err)
 		}
 		caches[ids[i]] = &cache
@@ -306,16 +416,81 @@ func (r *RedisStorage) RemoveAllCaches() error {
 func (r *RedisStorage) AddOrUpdateCustomData(tag, key string, datakey string, data []byte) error {
 	ctx := context.Background()
 	redisKey := fmt.Sprintf("%s:%s", tag, key)
-
-	// Use HSet to add or update the field in the hash
-	err := r.Client.HSet(ctx, redisKey, datakey, data).Err()
+	indexMember, err := json.Marshal(graph.CustomDataKey{Tag: tag, Key: key})
 	if err != nil {
+		return fmt.Errorf("failed to marshal custom data key: %w", err)
+	}
+
+	// Use HSet to add or update the field in the hash, and remember the hash in the custom data index
+	pipe := r.Client.TxPipeline()
+	pipe.HSet(ctx, redisKey, datakey, data)
+	pipe.SAdd(ctx, CustomDataKeys, indexMember)
+	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("failed to set hash field: %w", err)
 	}
 
 	return nil
 }
 
+// DeleteCustomData removes a single data key of a custom data record, and drops the record from the custom data
+// index once it has no data keys left.
+func (r *RedisStorage) DeleteCustomData(tag, key string, datakey string) error {
+	ctx := context.Background()
+	redisKey := fmt.Sprintf("%s:%s", tag, key)
+	indexMember, err := json.Marshal(graph.CustomDataKey{Tag: tag, Key: key})
+	if err != nil {
+		return fmt.Errorf("failed to marshal custom data key: %w", err)
+	}
+
+	return r.Client.Watch(ctx, func(tx *redis.Tx) error {
+		remaining, err := tx.HLen(ctx, redisKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get hash length: %w", err)
+		}
+		exists, err := tx.HExists(ctx, redisKey, datakey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to check hash field: %w", err)
+		}
+		if !exists {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HDel(ctx, redisKey, datakey)
+			if remaining == 1 {
+				pipe.SRem(ctx, CustomDataKeys, indexMember)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete hash field: %w", err)
+		}
+		return nil
+	}, redisKey)
+}
+
+// GetCustomDataKeys gets the tag and key of every custom data record.
+func (r *RedisStorage) GetCustomDataKeys() ([]graph.CustomDataKey, error) {
+	members, err := r.Client.SMembers(context.Background(), CustomDataKeys).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom data keys: %w", err)
+	}
+	keys := make([]graph.CustomDataKey, 0, len(members))
+	for _, member := range members {
+		var key graph.CustomDataKey
+		if err := json.Unmarshal([]byte(member), &key); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal custom data key %s: %w", member, err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Tag != keys[j].Tag {
+			return keys[i].Tag < keys[j].Tag
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	return keys, nil
+}
+
 // GetCustomData gets data from the database.
 func (r *RedisStorage) GetCustomData(tag, key string) (map[string][]byte, error) {
 	ctx := context.Background()
@@ -228,6 +228,39 @@ func TestAddAndGetDataToDB(t *testing.T) {
 	t2, err := json.Marshal("test_data2")
 	assert.NoError(t, err)
 	assert.Contains(t, string(t2), string(data["test_data2"]))
+
+	keys, err := r.GetCustomDataKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []graph.CustomDataKey{{Tag: "test_tag", Key: "test_key1"}}, keys)
+
+	// Deleting the last data key drops the record from the index
+	assert.NoError(t, r.DeleteCustomData("test_tag", "test_key1", "test_data1"))
+	assert.NoError(t, r.DeleteCustomData("test_tag", "test_key1", "test_data2"))
+	keys, err = r.GetCustomDataKeys()
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestIDCounter(t *testing.T) {
+	if _, ok := os.LookupEnv("e2e"); !ok {
+		t.Skip("E2E tests are not enabled")
+	}
+	r, err := SetupRedisTestDB(context.Background())
+	assert.NoError(t, err)
+	counter, err := r.GetIDCounter()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), counter)
+
+	assert.NoError(t, r.SetIDCounter(41))
+	id, err := r.GenerateID()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(42), id)
+
+	// The counter never moves backwards
+	assert.NoError(t, r.SetIDCounter(5))
+	counter, err = r.GetIDCounter()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(42), counter)
 }
 
 func TestGetNodesByGlob(t *testing.T) {
@@ -267,3 +300,112 @@ func TestGetNodesByGlob(t *testing.T) {
 	_, err = r.GetNodesByGlob("test_*")
 	assert.Error(t, err)
 }
+
+func TestRedisEnsureSchema(t *testing.T) {
+	if _, ok := os.LookupEnv("e2e"); !ok {
+		t.Skip("E2E tests are not enabled")
+	}
+	r, err := SetupRedisTestDB(context.Background())
+	assert.NoError(t, err)
+
+	// Simulate a database written before schema versions, custom data indexing and the binary encoding existed
+	node := &graph.Node{ID: 1, Name: "legacy_node", Children: roaring.New(), Parents: roaring.New()}
+	data, err := node.MarshalJSON()
+	assert.NoError(t, err)
+	assert.NoError(t, r.Client.Set(context.Background(), NodeKeyPrefix+"1", data, 0).Err())
+	assert.NoError(t, r.Client.HSet(context.Background(), "owner:legacy_node", "team", "payments").Err())
+
+	_, err = EnsureSchema(r, false)
+	assert.ErrorIs(t, err, ErrSchemaOutdated)
+
+	applied, err := EnsureSchema(r, true)
+	assert.NoError(t, err)
+	assert.Len(t, applied, r.LatestSchemaVersion())
+
+	raw, err := r.Client.Get(context.Background(), NodeKeyPrefix+"1").Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, graph.FormatTagBinaryV1, raw[0])
+
+	keys, err := r.GetCustomDataKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []graph.CustomDataKey{{Tag: "owner", Key: "legacy_node"}}, keys)
+}
+
+func TestRedisSearchMetadata(t *testing.T) {
+	if _, ok := os.LookupEnv("e2e"); !ok {
+		t.Skip("E2E tests are not enabled")
+	}
+	r, err := SetupRedisTestDB(context.Background())
+	assert.NoError(t, err)
+
+	vuln := &graph.Node{ID: 1, Name: "GHSA-1234", Type: "vuln", Metadata: map[string]any{"summary": "Remote code execution in parser"}, Children: roaring.New(), Parents: roaring.New()}
+	other := &graph.Node{ID: 2, Name: "GHSA-5678", Type: "vuln", Metadata: map[string]any{"summary": "Denial of service in parser"}, Children: roaring.New(), Parents: roaring.New()}
+	assert.NoError(t, r.SaveNode(vuln))
+	assert.NoError(t, r.SaveNode(other))
+
+	results, err := r.SearchMetadata("remote CODE", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, vuln.ID, results[0].Node.ID)
+	}
+
+	vuln.Metadata = map[string]any{"summary": "Path traversal"}
+	assert.NoError(t, r.SaveNode(vuln))
+	results, err = r.SearchMetadata("remote", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = r.SearchMetadata("parser", 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestRedisGetAllNames(t *testing.T) {
+	if _, ok := os.LookupEnv("e2e"); !ok {
+		t.Skip("E2E tests are not enabled")
+	}
+	r, err := SetupRedisTestDB(context.Background())
+	assert.NoError(t, err)
+
+	node1 := &graph.Node{ID: 1, Name: "pkg:npm/lodash@4.17.21", Children: roaring.New(), Parents: roaring.New()}
+	node2 := &graph.Node{ID: 2, Name: "pkg:npm/lodash@3.10.1", Children: roaring.New(), Parents: roaring.New()}
+	assert.NoError(t, r.SaveNode(node1))
+	assert.NoError(t, r.SaveNode(node2))
+
+	names, err := r.GetAllNames()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint32{node1.Name: 1, node2.Name: 2}, names)
+
+	nodes, _, err := graph.SearchNodes(r, graph.NodeQuery{Purl: &graph.PurlFilter{Type: "npm", VersionRange: "~4.17"}}, 0, "")
+	assert.NoError(t, err)
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, node1.Name, nodes[0].Name)
+	}
+}
+
+func TestRedisDeleteNode(t *testing.T) {
+	if _, ok := os.LookupEnv("e2e"); !ok {
+		t.Skip("E2E tests are not enabled")
+	}
+	r, err := SetupRedisTestDB(context.Background())
+	assert.NoError(t, err)
+
+	node := &graph.Node{ID: 1, Name: "GHSA-1234", Type: "vuln", Metadata: map[string]any{"summary": "Remote code execution"}, Children: roaring.New(), Parents: roaring.New()}
+	assert.NoError(t, r.SaveNode(node))
+	assert.NoError(t, r.SaveCache(&graph.NodeCache{ID: 1, AllParents: roaring.New(), AllChildren: roaring.New()}))
+
+	assert.NoError(t, r.DeleteNode(1))
+
+	_, err = r.GetNode(1)
+	assert.Error(t, err)
+	_, err = r.NameToID(node.Name)
+	assert.Error(t, err)
+	_, err = r.GetCache(1)
+	assert.Error(t, err)
+	toBeCached, err := r.ToBeCached()
+	assert.NoError(t, err)
+	assert.NotContains(t, toBeCached, uint32(1))
+	results, err := r.SearchMetadata("remote", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,120 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+)
+
+var (
+	ErrSchemaOutdated = errors.New("storage schema is outdated")
+	ErrSchemaTooNew   = errors.New("storage schema is newer than this version of minefield supports")
+)
+
+// SchemaMigration upgrades a storage backend from schema version Version-1 to Version.
+type SchemaMigration[S any] struct {
+	Version     int
+	Description string
+	Up          func(s S) error
+}
+
+// SchemaMigrator is implemented by storage backends that keep track of their schema version.
+// A version of zero means the database was written before schema versions were introduced.
+type SchemaMigrator interface {
+	SchemaVersion() (int, error)
+	LatestSchemaVersion() int
+	// UpgradeSchema applies every pending migration in order and returns their descriptions.
+	UpgradeSchema() ([]string, error)
+}
+
+// EnsureSchema checks that the schema of the storage matches this version of minefield.
+// An outdated schema is upgraded when upgrade is true and rejected otherwise, and a schema that is newer than
+// the latest known migration is always rejected. It returns the descriptions of the migrations that were applied.
+func EnsureSchema(storage graph.Storage, upgrade bool) ([]string, error) {
+	migrator, ok := storage.(SchemaMigrator)
+	if !ok {
+		return nil, nil
+	}
+	version, err := migrator.SchemaVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	latest := migrator.LatestSchemaVersion()
+	switch {
+	case version == latest:
+		return nil, nil
+	case version > latest:
+		return nil, fmt.Errorf("%w: database is at version %d, latest known version is %d", ErrSchemaTooNew, version, latest)
+	case !upgrade:
+		return nil, fmt.Errorf("%w: database is at version %d, latest version is %d; restart with --upgrade-schema to upgrade it", ErrSchemaOutdated, version, latest)
+	}
+	return migrator.UpgradeSchema()
+}
+
+// latestVersion returns the version of the last migration in the registry.
+func latestVersion[S any](migrations []SchemaMigration[S]) int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// applyMigrations runs every migration newer than current in order, recording the new version after each one so
+// an interrupted upgrade continues where it stopped.
+func applyMigrations[S any](s S, current int, migrations []SchemaMigration[S], setVersion func(int) error) ([]string, error) {
+	if err := validateMigrations(migrations); err != nil {
+		return nil, err
+	}
+	var applied []string
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := m.Up(s); err != nil {
+			return applied, fmt.Errorf("failed to apply schema migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		if err := setVersion(m.Version); err != nil {
+			return applied, fmt.Errorf("failed to record schema version %d: %w", m.Version, err)
+		}
+		applied = append(applied, fmt.Sprintf("%d: %s", m.Version, m.Description))
+	}
+	return applied, nil
+}
+
+// validateMigrations checks that the registry numbers its migrations 1, 2, 3, ... without gaps.
+func validateMigrations[S any](migrations []SchemaMigration[S]) error {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return fmt.Errorf("schema migration %q has version %d, expected %d", m.Description, m.Version, i+1)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("schema migration %d has no Up function", m.Version)
+		}
+	}
+	return nil
+}
+
+// reencodeNode rewrites a node record in the current binary encoding, returning nil if it already uses it.
+func reencodeNode(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != graph.FormatTagJSON {
+		return nil, nil
+	}
+	var node graph.Node
+	if err := node.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return node.MarshalBinary()
+}
+
+// reencodeCache rewrites a cache record in the current binary encoding, returning nil if it already uses it.
+func reencodeCache(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != graph.FormatTagJSON {
+		return nil, nil
+	}
+	var cache graph.NodeCache
+	if err := cache.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return cache.MarshalBinary()
+}
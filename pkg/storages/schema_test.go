@@ -0,0 +1,199 @@
+package storages
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/bitbomdev/minefield/pkg/tools/ingest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLSchemaVersion_NewDatabase(t *testing.T) {
+	s, err := SetupSQLTestDB(filepath.Join(t.TempDir(), "minefield.db"))
+	require.NoError(t, err)
+
+	version, err := s.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, s.LatestSchemaVersion(), version)
+
+	applied, err := EnsureSchema(s, false)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestSQLEnsureSchema_LegacyDatabase(t *testing.T) {
+	s, err := SetupSQLTestDB(filepath.Join(t.TempDir(), "minefield.db"))
+	require.NoError(t, err)
+
+	// Simulate a database written before schema versions and the binary encoding existed
+	node := &graph.Node{ID: 1, Name: "legacy_node", Children: roaring.BitmapOf(2), Parents: roaring.New()}
+	data, err := node.MarshalJSON()
+	require.NoError(t, err)
+	require.NoError(t, s.DB.Save(&KVStore{Key: NodeKeyPrefix + "1", Value: data}).Error)
+	require.NoError(t, s.DB.Where("1 = 1").Delete(&SchemaVersionRecord{}).Error)
+
+	_, err = EnsureSchema(s, false)
+	assert.ErrorIs(t, err, ErrSchemaOutdated)
+
+	applied, err := EnsureSchema(s, true)
+	require.NoError(t, err)
+	assert.Len(t, applied, s.LatestSchemaVersion())
+
+	version, err := s.SchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, s.LatestSchemaVersion(), version)
+
+	var kv KVStore
+	require.NoError(t, s.DB.First(&kv, key, NodeKeyPrefix+"1").Error)
+	assert.Equal(t, graph.FormatTagBinaryV1, kv.Value[0])
+
+	savedNode, err := s.GetNode(1)
+	require.NoError(t, err)
+	assert.Equal(t, node.Name, savedNode.Name)
+	assert.True(t, node.Children.Equals(savedNode.Children))
+}
+
+func TestSQLEnsureSchema_TooNew(t *testing.T) {
+	s, err := SetupSQLTestDB(filepath.Join(t.TempDir(), "minefield.db"))
+	require.NoError(t, err)
+	require.NoError(t, s.setSchemaVersion(s.LatestSchemaVersion()+1))
+
+	_, err = EnsureSchema(s, true)
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+}
+
+func TestEnsureSchema_UnversionedStorage(t *testing.T) {
+	applied, err := EnsureSchema(graph.NewMockStorage(), false)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestApplyMigrations(t *testing.T) {
+	var ran []int
+	version := 1
+	step := func(v int) SchemaMigration[*int] {
+		return SchemaMigration[*int]{Version: v, Description: "step", Up: func(*int) error {
+			ran = append(ran, v)
+			if v == 3 {
+				return errors.New("boom")
+			}
+			return nil
+		}}
+	}
+	setVersion := func(v int) error {
+		version = v
+		return nil
+	}
+
+	applied, err := applyMigrations(&version, version, []SchemaMigration[*int]{step(1), step(2), step(3)}, setVersion)
+	assert.ErrorContains(t, err, "failed to apply schema migration 3")
+	assert.Equal(t, []string{"2: step"}, applied)
+	assert.Equal(t, []int{2, 3}, ran)
+	assert.Equal(t, 2, version, "the version is recorded after every successful migration")
+
+	_, err = applyMigrations(&version, 0, []SchemaMigration[*int]{step(1), step(3)}, setVersion)
+	assert.ErrorContains(t, err, "has version 3, expected 2")
+}
+
+func TestMigrationRegistries(t *testing.T) {
+	assert.NoError(t, validateMigrations(sqlSchemaMigrations))
+	assert.NoError(t, validateMigrations(redisSchemaMigrations))
+}
+
+func TestSQLEnsureSchema_IndexesExistingNodes(t *testing.T) {
+	s, err := SetupSQLTestDB(filepath.Join(t.TempDir(), "minefield.db"))
+	require.NoError(t, err)
+
+	// Simulate a node saved before the metadata search index existed
+	node := &graph.Node{ID: 1, Name: "GHSA-1234", Type: "vuln", Metadata: map[string]any{"summary": "Remote code execution"}, Children: roaring.New(), Parents: roaring.New()}
+	data, err := node.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, s.DB.Save(&KVStore{Key: NodeKeyPrefix + "1", Value: data}).Error)
+	require.NoError(t, s.setSchemaVersion(1))
+
+	applied, err := EnsureSchema(s, true)
+	require.NoError(t, err)
+	assert.Len(t, applied, s.LatestSchemaVersion()-1)
+
+	results, err := s.SearchMetadata("remote execution", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, node.Name, results[0].Node.Name)
+}
+
+func TestSQLEnsureSchema_IndexesLibraries(t *testing.T) {
+	s, err := SetupSQLTestDB(filepath.Join(t.TempDir(), "minefield.db"))
+	require.NoError(t, err)
+
+	// Simulate a library ingested before library nodes were indexed by package
+	_, err = graph.AddNode(s, tools.LibraryType, nil, "pkg:pypi/django@4.2.0")
+	require.NoError(t, err)
+	require.NoError(t, s.setSchemaVersion(2))
+
+	applied, err := EnsureSchema(s, true)
+	require.NoError(t, err)
+	assert.Len(t, applied, s.LatestSchemaVersion()-2)
+
+	libraries, err := s.GetCustomData(ingest.OSVLibrariesTag, "PyPI/django")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"pkg:pypi/django@4.2.0": nil}, libraries)
+}
+
+func TestSQLEnsureSchema_RebuildsAdvisories(t *testing.T) {
+	s, err := SetupSQLTestDB(filepath.Join(t.TempDir(), "minefield.db"))
+	require.NoError(t, err)
+
+	// Simulate a vuln node ingested before advisories were recorded, which kept its OSV record as metadata
+	library, err := graph.AddNode(s, tools.LibraryType, nil, "pkg:pypi/django@4.1.0")
+	require.NoError(t, err)
+	vuln, err := graph.AddNode(s, tools.VulnerabilityType, []byte(`{"id": "PYSEC-1", "affected": [{"package": {"ecosystem": "PyPI", "name": "django"}}]}`), "PYSEC-1")
+	require.NoError(t, err)
+	require.NoError(t, library.SetDependency(s, vuln))
+	require.NoError(t, s.setSchemaVersion(3))
+
+	applied, err := EnsureSchema(s, true)
+	require.NoError(t, err)
+	assert.Len(t, applied, s.LatestSchemaVersion()-3)
+
+	advisory, err := s.GetCustomData(ingest.OSVAdvisoriesTag, "PYSEC-1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `["pkg:pypi/django@4.1.0"]`, string(advisory["libraries"]))
+	packages, err := s.GetCustomData(ingest.OSVPackagesTag, "PyPI/django")
+	require.NoError(t, err)
+	assert.Contains(t, packages, "PYSEC-1")
+}
+
+func TestSQLSearch_ReindexesOnBackendChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "minefield.db")
+	s, err := SetupSQLTestDB(path)
+	require.NoError(t, err)
+	node := &graph.Node{ID: 1, Name: "GHSA-1234", Type: "vuln", Metadata: map[string]any{"summary": "Remote code execution"}, Children: roaring.New(), Parents: roaring.New()}
+	require.NoError(t, s.SaveNode(node))
+
+	// Simulate a database indexed by a build with the other search backend, which left the current index empty
+	other := searchBackendFTS5
+	if s.fts5 {
+		other = searchBackendTerms
+	}
+	require.NoError(t, s.DB.Save(&SearchBackendRecord{ID: searchBackendRowID, Backend: other}).Error)
+	require.NoError(t, s.unindexNode(s.DB, node.ID))
+	results, err := s.SearchMetadata("remote", 10)
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	s, err = SetupSQLTestDB(path)
+	require.NoError(t, err)
+	results, err = s.SearchMetadata("remote", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, node.Name, results[0].Node.Name)
+
+	var record SearchBackendRecord
+	require.NoError(t, s.DB.First(&record, searchBackendRowID).Error)
+	assert.Equal(t, s.searchBackend(), record.Backend)
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/utils"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -28,7 +29,7 @@ const (
 // KVStore represents the key-value storage table.
 type KVStore struct {
 	Key       string    `gorm:"primaryKey;uniqueIndex"`
-	Value     string    `gorm:"type:text"`
+	Value     []byte    `gorm:"type:blob"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 }
@@ -43,9 +44,20 @@ type GlobalCounter struct {
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 }
 
+// CustomData represents a single data key of a custom data record.
+type CustomData struct {
+	Tag       string    `gorm:"primaryKey"`
+	Key       string    `gorm:"primaryKey"`
+	DataKey   string    `gorm:"primaryKey"`
+	Value     []byte    `gorm:"type:blob"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
 // SQLStorage represents the storage backed by a SQL database.
 type SQLStorage struct {
 	DB *gorm.DB
+
+	fts5 bool // Whether metadata search uses FTS5, see setupSearch
 }
 
 // NewSQLStorage initializes a new SQLStorage with a SQLite database.
@@ -73,13 +85,20 @@ func NewSQLStorage(dsn string, useInMemory bool) (*SQLStorage, error) {
 	if err := storage.Migrate(); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
+	if err := storage.stampSchemaVersion(); err != nil {
+		return nil, fmt.Errorf("failed to record schema version: %w", err)
+	}
 
 	return storage, nil
 }
 
-// Migrate performs the database migrations for SQLStorage.
+// Migrate creates or updates the tables of SQLStorage. Changes to the data itself are done by the schema migrations
+// in sql_schema.go.
 func (s *SQLStorage) Migrate() error {
-	return s.DB.AutoMigrate(&KVStore{}, &CacheStack{}, &GlobalCounter{})
+	if err := s.DB.AutoMigrate(&KVStore{}, &CacheStack{}, &GlobalCounter{}, &CustomData{}, &SchemaVersionRecord{}); err != nil {
+		return err
+	}
+	return s.setupSearch()
 }
 
 // NameToID converts a node name to its corresponding ID.
@@ -88,7 +107,7 @@ func (s *SQLStorage) NameToID(name string) (uint32, error) {
 	if err := s.DB.First(&kv, key, NameToIDKey+name).Error; err != nil {
 		return 0, fmt.Errorf("failed to get name-to-ID mapping: %w", err)
 	}
-	id, err := strconv.ParseUint(kv.Value, 10, 32)
+	id, err := strconv.ParseUint(string(kv.Value), 10, 32)
 	if err != nil {
 		return 0, fmt.Errorf("failed to convert ID to integer: %w", err)
 	}
@@ -104,8 +123,8 @@ func (s *SQLStorage) SaveNode(node *graph.Node) error {
 		return fmt.Errorf("node cannot be nil")
 	}
 
-	// Marshal the node to JSON
-	data, err := node.MarshalJSON()
+	// Encode the node as a binary record
+	data, err := node.MarshalBinary()
 	if err != nil {
 		return fmt.Errorf("failed to marshal node: %w", err)
 	}
@@ -119,7 +138,7 @@ func (s *SQLStorage) SaveNode(node *graph.Node) error {
 		// Save the node data
 		kvNode := KVStore{
 			Key:   nodeKey,
-			Value: string(data),
+			Value: data,
 		}
 		if err := tx.Save(&kvNode).Error; err != nil {
 			return fmt.Errorf("failed to save node data: %w", err)
@@ -128,7 +147,7 @@ func (s *SQLStorage) SaveNode(node *graph.Node) error {
 		// Save the name-to-ID mapping
 		kvMapping := KVStore{
 			Key:   nameToIDKey,
-			Value: fmt.Sprintf("%d", node.ID),
+			Value: []byte(utils.Uint32ToStr(node.ID)),
 		}
 		if err := tx.Save(&kvMapping).Error; err != nil {
 			return fmt.Errorf("failed to save name-to-ID mapping: %w", err)
@@ -146,6 +165,36 @@ func (s *SQLStorage) SaveNode(node *graph.Node) error {
 			return fmt.Errorf("failed to add node ID to cache stack: %w", err)
 		}
 
+		// Keep the metadata search index up to date
+		if err := s.indexNode(tx, node); err != nil {
+			return fmt.Errorf("failed to index node for search: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteNode removes a node, its name-to-ID mapping, its cache and its search entry in one transaction.
+func (s *SQLStorage) DeleteNode(id uint32) error {
+	node, err := s.GetNode(id)
+	if err != nil {
+		return err
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		keys := []string{
+			fmt.Sprintf("%s%d", NodeKeyPrefix, id),
+			fmt.Sprintf("%s%s", NameToIDKey, node.Name),
+			fmt.Sprintf("%s%d", CacheKeyPrefix, id),
+		}
+		if err := tx.Where("key IN ?", keys).Delete(&KVStore{}).Error; err != nil {
+			return fmt.Errorf("failed to delete node %d: %w", id, err)
+		}
+		if err := tx.Delete(&CacheStack{}, id).Error; err != nil {
+			return fmt.Errorf("failed to remove node %d from the cache stack: %w", id, err)
+		}
+		if err := s.unindexNode(tx, id); err != nil {
+			return fmt.Errorf("failed to remove node %d from the search index: %w", id, err)
+		}
 		return nil
 	})
 }
@@ -160,7 +209,7 @@ func (s *SQLStorage) GetNode(id uint32) (*graph.Node, error) {
 	}
 
 	var node graph.Node
-	if err := node.UnmarshalJSON([]byte(kvNode.Value)); err != nil {
+	if err := node.UnmarshalBinary(kvNode.Value); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal node data: %w", err)
 	}
 
@@ -177,7 +226,7 @@ func (s *SQLStorage) GetNodes(ids []uint32) (map[uint32]*graph.Node, error) {
 	nodes := make(map[uint32]*graph.Node)
 	for _, kvNode := range kvNodes {
 		var node graph.Node
-		if err := node.UnmarshalJSON([]byte(kvNode.Value)); err != nil {
+		if err := node.UnmarshalBinary(kvNode.Value); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal node: %w", err)
 		}
 		idStr := strings.TrimPrefix(kvNode.Key, NodeKeyPrefix)
@@ -208,7 +257,7 @@ func (s *SQLStorage) GetNodesByGlob(pattern string) ([]*graph.Node, error) {
 	// Extract IDs from the mappings
 	ids := make([]uint32, 0, len(mappings))
 	for _, mapping := range mappings {
-		id, err := strconv.ParseUint(mapping.Value, 10, 32)
+		id, err := strconv.ParseUint(string(mapping.Value), 10, 32)
 		if err != nil {
 			return nil, fmt.Errorf("invalid ID format for key %s: %w", mapping.Key, err)
 		}
@@ -225,7 +274,7 @@ func (s *SQLStorage) GetNodesByGlob(pattern string) ([]*graph.Node, error) {
 	}
 	for _, node := range nodes {
 		var graphNode graph.Node
-		if err := graphNode.UnmarshalJSON([]byte(node.Value)); err != nil {
+		if err := graphNode.UnmarshalBinary(node.Value); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal node data: %w", err)
 		}
 		resultNodes = append(resultNodes, &graphNode)
@@ -246,30 +295,47 @@ func generateNodeKeys(ids []uint32) []string {
 // GetAllKeys retrieves all node IDs.
 func (s *SQLStorage) GetAllKeys() ([]uint32, error) {
 	var kvNodes []KVStore
-	if err := s.DB.Where(KeyLike, NodeKeyPrefix+"%").Find(&kvNodes).Error; err != nil {
+	if err := s.DB.Select("key").Where(KeyLike, NodeKeyPrefix+"%").Find(&kvNodes).Error; err != nil {
 		return nil, fmt.Errorf("failed to get all node IDs: %w", err)
 	}
 	ids := make([]uint32, len(kvNodes))
 	for i, kvNode := range kvNodes {
-		var graphNode graph.Node
-		if err := graphNode.UnmarshalJSON([]byte(kvNode.Value)); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal node data: %w", err)
+		id, err := utils.StrToUint32(strings.TrimPrefix(kvNode.Key, NodeKeyPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node ID from key %s: %w", kvNode.Key, err)
 		}
-		ids[i] = graphNode.ID
+		ids[i] = id
 	}
 	return ids, nil
 }
 
+// GetAllNames returns the ID of every node, keyed by node name.
+func (s *SQLStorage) GetAllNames() (map[string]uint32, error) {
+	var mappings []KVStore
+	if err := s.DB.Where(KeyLike, NameToIDKey+"%").Find(&mappings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get name-to-ID mappings: %w", err)
+	}
+	names := make(map[string]uint32, len(mappings))
+	for _, mapping := range mappings {
+		id, err := utils.StrToUint32(string(mapping.Value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID format for key %s: %w", mapping.Key, err)
+		}
+		names[strings.TrimPrefix(mapping.Key, NameToIDKey)] = id
+	}
+	return names, nil
+}
+
 // SaveCache saves a node cache.
 func (s *SQLStorage) SaveCache(cache *graph.NodeCache) error {
 	cacheKey := fmt.Sprintf("%s%d", CacheKeyPrefix, cache.ID)
-	data, err := cache.MarshalJSON()
+	data, err := cache.MarshalBinary()
 	if err != nil {
 		return fmt.Errorf("failed to marshal cache: %w", err)
 	}
 	kvCache := KVStore{
 		Key:   cacheKey,
-		Value: string(data),
+		Value: data,
 	}
 	if err := s.DB.Save(&kvCache).Error; err != nil {
 		return fmt.Errorf("failed to save cache: %w", err)
@@ -292,13 +358,13 @@ func (s *SQLStorage) SaveCaches(caches []*graph.NodeCache) error {
 		kvCaches := make([]KVStore, len(batch))
 		for j, cache := range batch {
 			cacheKey := fmt.Sprintf("%s%d", CacheKeyPrefix, cache.ID)
-			data, err := cache.MarshalJSON()
+			data, err := cache.MarshalBinary()
 			if err != nil {
 				return fmt.Errorf("failed to marshal cache: %w", err)
 			}
 			kvCaches[j] = KVStore{
 				Key:   cacheKey,
-				Value: string(data),
+				Value: data,
 			}
 		}
 
@@ -353,7 +419,7 @@ func (s *SQLStorage) GetCache(id uint32) (*graph.NodeCache, error) {
 		return nil, fmt.Errorf("failed to get cache: %w", err)
 	}
 	var cache graph.NodeCache
-	if err := cache.UnmarshalJSON([]byte(kvCache.Value)); err != nil {
+	if err := cache.UnmarshalBinary(kvCache.Value); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal cache: %w", err)
 	}
 	return &cache, nil
@@ -372,7 +438,7 @@ func (s *SQLStorage) GetCaches(ids []uint32) (map[uint32]*graph.NodeCache, error
 	caches := make(map[uint32]*graph.NodeCache, len(kvCaches))
 	for _, kvCache := range kvCaches {
 		var cache graph.NodeCache
-		if err := cache.UnmarshalJSON([]byte(kvCache.Value)); err != nil {
+		if err := cache.UnmarshalBinary(kvCache.Value); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal cache: %w", err)
 		}
 		idStr := strings.TrimPrefix(kvCache.Key, CacheKeyPrefix)
@@ -405,16 +471,73 @@ func (s *SQLStorage) GenerateID() (uint32, error) {
 	return counter.ID, nil
 }
 
+// GetIDCounter returns the last ID handed out by GenerateID.
+func (s *SQLStorage) GetIDCounter() (uint32, error) {
+	var counter uint32
+	if err := s.DB.Model(&GlobalCounter{}).Select("COALESCE(MAX(id), 0)").Scan(&counter).Error; err != nil {
+		return 0, fmt.Errorf("failed to get ID counter: %w", err)
+	}
+	return counter, nil
+}
+
+// SetIDCounter moves the ID counter forward so that the next generated ID is greater than id.
+// The counter never moves backwards, since that would hand out IDs that are already in use.
+func (s *SQLStorage) SetIDCounter(id uint32) error {
+	current, err := s.GetIDCounter()
+	if err != nil {
+		return err
+	}
+	if id <= current {
+		return nil
+	}
+	if err := s.DB.Create(&GlobalCounter{ID: id}).Error; err != nil {
+		return fmt.Errorf("failed to set ID counter: %w", err)
+	}
+	return nil
+}
+
 // GetCustomData retrieves custom data based on tag and key.
 func (s *SQLStorage) GetCustomData(tag, key string) (map[string][]byte, error) {
-	// TODO: Implement using GORM
-	return nil, fmt.Errorf("not implemented")
+	var rows []CustomData
+	if err := s.DB.Where("tag = ? AND key = ?", tag, key).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get custom data: %w", err)
+	}
+	result := make(map[string][]byte, len(rows))
+	for _, row := range rows {
+		result[row.DataKey] = row.Value
+	}
+	return result, nil
+}
+
+// GetCustomDataKeys retrieves the tag and key of every custom data record.
+func (s *SQLStorage) GetCustomDataKeys() ([]graph.CustomDataKey, error) {
+	var keys []graph.CustomDataKey
+	if err := s.DB.Model(&CustomData{}).Distinct("tag", "key").Order("tag, key").Scan(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to get custom data keys: %w", err)
+	}
+	return keys, nil
 }
 
 // AddOrUpdateCustomData adds or updates custom data based on tag, key, and data key.
 func (s *SQLStorage) AddOrUpdateCustomData(tag, key string, dataKey string, data []byte) error {
-	// TODO: Implement using GORM
-	return fmt.Errorf("not implemented")
+	row := CustomData{
+		Tag:     tag,
+		Key:     key,
+		DataKey: dataKey,
+		Value:   data,
+	}
+	if err := s.DB.Save(&row).Error; err != nil {
+		return fmt.Errorf("failed to save custom data: %w", err)
+	}
+	return nil
+}
+
+// DeleteCustomData removes a single data key of a custom data record.
+func (s *SQLStorage) DeleteCustomData(tag, key string, dataKey string) error {
+	if err := s.DB.Where("tag = ? AND key = ? AND data_key = ?", tag, key, dataKey).Delete(&CustomData{}).Error; err != nil {
+		return fmt.Errorf("failed to delete custom data: %w", err)
+	}
+	return nil
 }
 
 // convertGlobToSQLPattern converts a glob pattern to a SQL LIKE pattern.
@@ -0,0 +1,137 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools/ingest"
+	"gorm.io/gorm"
+)
+
+// SchemaVersionRecord is the single row holding the schema version of a SQL database.
+type SchemaVersionRecord struct {
+	ID        uint `gorm:"primaryKey"`
+	Version   int
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (SchemaVersionRecord) TableName() string {
+	return "schema_versions"
+}
+
+const schemaVersionRowID = 1
+
+// sqlSchemaMigrations is the ordered registry of SQL schema migrations. Append new migrations to the end.
+var sqlSchemaMigrations = []SchemaMigration[*SQLStorage]{
+	{
+		Version:     1,
+		Description: "re-encode JSON node and cache records in the binary record format",
+		Up: func(s *SQLStorage) error {
+			if err := s.reencodeRecords(NodeKeyPrefix, reencodeNode); err != nil {
+				return err
+			}
+			return s.reencodeRecords(CacheKeyPrefix, reencodeCache)
+		},
+	},
+	{
+		Version:     2,
+		Description: "build the metadata search index",
+		Up: func(s *SQLStorage) error {
+			return s.reindexSearch()
+		},
+	},
+	{
+		Version:     3,
+		Description: "index library nodes by the package they are a version of",
+		Up: func(s *SQLStorage) error {
+			return ingest.IndexLibraries(s)
+		},
+	},
+	{
+		Version:     4,
+		Description: "record the advisories of existing vuln nodes from their OSV metadata",
+		Up: func(s *SQLStorage) error {
+			return ingest.RebuildAdvisories(s)
+		},
+	},
+	{
+		Version:     5,
+		Description: "list the nodes with edge attributes",
+		Up: func(s *SQLStorage) error {
+			return graph.IndexEdgeAttributes(s)
+		},
+	},
+}
+
+// SchemaVersion returns the schema version recorded in the database, or zero if none is recorded.
+func (s *SQLStorage) SchemaVersion() (int, error) {
+	var record SchemaVersionRecord
+	err := s.DB.First(&record, schemaVersionRowID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return record.Version, nil
+}
+
+// LatestSchemaVersion returns the schema version this version of minefield writes.
+func (s *SQLStorage) LatestSchemaVersion() int {
+	return latestVersion(sqlSchemaMigrations)
+}
+
+// UpgradeSchema applies every pending SQL schema migration in order.
+func (s *SQLStorage) UpgradeSchema() ([]string, error) {
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	return applyMigrations(s, version, sqlSchemaMigrations, s.setSchemaVersion)
+}
+
+func (s *SQLStorage) setSchemaVersion(version int) error {
+	return s.DB.Save(&SchemaVersionRecord{ID: schemaVersionRowID, Version: version}).Error
+}
+
+// stampSchemaVersion records the latest schema version in a database that holds no data yet, so new databases
+// never need to be upgraded.
+func (s *SQLStorage) stampSchemaVersion() error {
+	version, err := s.SchemaVersion()
+	if err != nil || version != 0 {
+		return err
+	}
+	var records, customData int64
+	if err := s.DB.Model(&KVStore{}).Count(&records).Error; err != nil {
+		return fmt.Errorf("failed to count records: %w", err)
+	}
+	if err := s.DB.Model(&CustomData{}).Count(&customData).Error; err != nil {
+		return fmt.Errorf("failed to count custom data: %w", err)
+	}
+	if records > 0 || customData > 0 {
+		return nil
+	}
+	return s.setSchemaVersion(s.LatestSchemaVersion())
+}
+
+// reencodeRecords rewrites every record under prefix with reencode, skipping records it returns nil for.
+func (s *SQLStorage) reencodeRecords(prefix string, reencode func([]byte) ([]byte, error)) error {
+	var batch []KVStore
+	return s.DB.Where(KeyLike, prefix+"%").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, kv := range batch {
+			data, err := reencode(kv.Value)
+			if err != nil {
+				return fmt.Errorf("failed to re-encode %s: %w", kv.Key, err)
+			}
+			if data == nil {
+				continue
+			}
+			if err := s.DB.Model(&KVStore{}).Where(key, kv.Key).Update("value", data).Error; err != nil {
+				return fmt.Errorf("failed to update %s: %w", kv.Key, err)
+			}
+		}
+		return nil
+	}).Error
+}
@@ -0,0 +1,235 @@
+package storages
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"gorm.io/gorm"
+)
+
+// ftsTable is the FTS5 table holding the search text of every node, with the node ID as rowid.
+const ftsTable = "node_search"
+
+// SearchTerm is a row of the inverted index used for metadata search when SQLite is built without FTS5.
+type SearchTerm struct {
+	Term      string `gorm:"primaryKey"`
+	NodeID    uint32 `gorm:"primaryKey;index"`
+	Frequency int
+}
+
+// Search backends, as recorded in SearchBackendRecord.
+const (
+	searchBackendFTS5  = "fts5"
+	searchBackendTerms = "search_terms"
+)
+
+// searchIndexSchemaVersion is the schema version whose migration first builds the metadata search index.
+const searchIndexSchemaVersion = 2
+
+// SearchBackendRecord is the single row holding the search backend that built the metadata search index of a SQL
+// database.
+type SearchBackendRecord struct {
+	ID        uint `gorm:"primaryKey"`
+	Backend   string
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (SearchBackendRecord) TableName() string {
+	return "search_backends"
+}
+
+const searchBackendRowID = 1
+
+// setupSearch picks the metadata search implementation. FTS5 is only available when minefield is built with the
+// sqlite_fts5 tag, otherwise the search_terms inverted index is used. A database indexed by the other implementation,
+// because it was written by a build with different tags, is reindexed.
+func (s *SQLStorage) setupSearch() error {
+	var enabled int
+	if err := s.DB.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled).Error; err != nil {
+		return fmt.Errorf("failed to check for FTS5 support: %w", err)
+	}
+	s.fts5 = enabled == 1
+	if s.fts5 {
+		if err := s.DB.Exec(fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(body, tokenize='unicode61')", ftsTable)).Error; err != nil {
+			return err
+		}
+	} else if err := s.DB.AutoMigrate(&SearchTerm{}); err != nil {
+		return err
+	}
+	if err := s.DB.AutoMigrate(&SearchBackendRecord{}); err != nil {
+		return err
+	}
+	return s.syncSearchBackend()
+}
+
+// searchBackend returns the name of the metadata search implementation in use.
+func (s *SQLStorage) searchBackend() string {
+	if s.fts5 {
+		return searchBackendFTS5
+	}
+	return searchBackendTerms
+}
+
+// syncSearchBackend reindexes the nodes when the search backend that indexed them isn't the one in use. Databases
+// the search index migration hasn't run on yet are left to it.
+func (s *SQLStorage) syncSearchBackend() error {
+	var record SearchBackendRecord
+	err := s.DB.First(&record, searchBackendRowID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get the search backend: %w", err)
+	}
+	if record.Backend == s.searchBackend() {
+		return nil
+	}
+
+	var nodes int64
+	if err := s.DB.Model(&KVStore{}).Where(KeyLike, NodeKeyPrefix+"%").Count(&nodes).Error; err != nil {
+		return fmt.Errorf("failed to count nodes: %w", err)
+	}
+	if nodes == 0 {
+		return s.setSearchBackend()
+	}
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version < searchIndexSchemaVersion {
+		return nil
+	}
+	if err := s.reindexSearch(); err != nil {
+		return fmt.Errorf("failed to reindex metadata search with %s: %w", s.searchBackend(), err)
+	}
+	return nil
+}
+
+func (s *SQLStorage) setSearchBackend() error {
+	if err := s.DB.Save(&SearchBackendRecord{ID: searchBackendRowID, Backend: s.searchBackend()}).Error; err != nil {
+		return fmt.Errorf("failed to record the search backend: %w", err)
+	}
+	return nil
+}
+
+// indexNode replaces the search entry of a node within the transaction tx.
+func (s *SQLStorage) indexNode(tx *gorm.DB, node *graph.Node) error {
+	if err := s.unindexNode(tx, node.ID); err != nil {
+		return err
+	}
+	if s.fts5 {
+		return tx.Exec(fmt.Sprintf("INSERT INTO %s(rowid, body) VALUES (?, ?)", ftsTable), node.ID, graph.SearchText(node)).Error
+	}
+
+	frequencies := graph.TermFrequencies(node)
+	terms := make([]SearchTerm, 0, len(frequencies))
+	for term, frequency := range frequencies {
+		terms = append(terms, SearchTerm{Term: term, NodeID: node.ID, Frequency: frequency})
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(terms, 100).Error
+}
+
+// unindexNode removes the search entry of a node within the transaction tx.
+func (s *SQLStorage) unindexNode(tx *gorm.DB, id uint32) error {
+	if s.fts5 {
+		return tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE rowid = ?", ftsTable), id).Error
+	}
+	return tx.Where("node_id = ?", id).Delete(&SearchTerm{}).Error
+}
+
+// SearchMetadata returns up to limit nodes whose name, type or metadata contain every term of query, ranked by
+// BM25 with FTS5 and by TF-IDF otherwise.
+func (s *SQLStorage) SearchMetadata(query string, limit int) ([]graph.SearchResult, error) {
+	terms := graph.QueryTerms(query)
+	if len(terms) == 0 {
+		return nil, graph.ErrEmptySearchQuery
+	}
+	if limit <= 0 {
+		limit = graph.DefaultSearchLimit
+	}
+
+	var ranked []graph.ScoredID
+	if s.fts5 {
+		// Quote every term so user input is never parsed as FTS5 query syntax
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = `"` + term + `"`
+		}
+		err := s.DB.Raw(fmt.Sprintf("SELECT rowid AS id, -bm25(%[1]s) AS score FROM %[1]s WHERE %[1]s MATCH ? ORDER BY bm25(%[1]s) LIMIT ?", ftsTable),
+			strings.Join(quoted, " "), limit).Scan(&ranked).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to search metadata: %w", err)
+		}
+	} else {
+		var rows []SearchTerm
+		if err := s.DB.Where("term IN ?", terms).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to search metadata: %w", err)
+		}
+		var totalDocs int64
+		if err := s.DB.Model(&SearchTerm{}).Distinct("node_id").Count(&totalDocs).Error; err != nil {
+			return nil, fmt.Errorf("failed to count indexed nodes: %w", err)
+		}
+		postings := make(map[string]map[uint32]int, len(terms))
+		for _, row := range rows {
+			if postings[row.Term] == nil {
+				postings[row.Term] = map[uint32]int{}
+			}
+			postings[row.Term][row.NodeID] = row.Frequency
+		}
+		ranked = graph.RankTFIDF(postings, terms, int(totalDocs), limit)
+	}
+
+	return s.searchResults(ranked)
+}
+
+func (s *SQLStorage) searchResults(ranked []graph.ScoredID) ([]graph.SearchResult, error) {
+	ids := make([]uint32, len(ranked))
+	for i, hit := range ranked {
+		ids[i] = hit.ID
+	}
+	nodes, err := s.GetNodes(ids)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]graph.SearchResult, 0, len(ranked))
+	for _, hit := range ranked {
+		if node, ok := nodes[hit.ID]; ok {
+			results = append(results, graph.SearchResult{Node: node, Score: hit.Score})
+		}
+	}
+	return results, nil
+}
+
+// reindexSearch rebuilds the metadata search index from every stored node, and records the backend that built it.
+func (s *SQLStorage) reindexSearch() error {
+	if s.fts5 {
+		if err := s.DB.Exec(fmt.Sprintf("DELETE FROM %s", ftsTable)).Error; err != nil {
+			return err
+		}
+	} else if err := s.DB.Where("1 = 1").Delete(&SearchTerm{}).Error; err != nil {
+		return err
+	}
+
+	var batch []KVStore
+	err := s.DB.Where(KeyLike, NodeKeyPrefix+"%").FindInBatches(&batch, 500, func(_ *gorm.DB, _ int) error {
+		return s.DB.Transaction(func(tx *gorm.DB) error {
+			for _, kv := range batch {
+				var node graph.Node
+				if err := node.UnmarshalBinary(kv.Value); err != nil {
+					return fmt.Errorf("failed to unmarshal %s: %w", kv.Key, err)
+				}
+				if err := s.indexNode(tx, &node); err != nil {
+					return fmt.Errorf("failed to index %s: %w", kv.Key, err)
+				}
+			}
+			return nil
+		})
+	}).Error
+	if err != nil {
+		return err
+	}
+	return s.setSearchBackend()
+}
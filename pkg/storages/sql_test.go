@@ -100,6 +100,24 @@ func TestSQLSaveNode(t *testing.T) {
 	assert.Equal(t, node.ID, savedNode.ID)
 	assert.Equal(t, node.Name, savedNode.Name)
 }
+
+// TestSQLGetNode_LegacyJSON tests that nodes written in the legacy JSON encoding can still be read.
+func TestSQLGetNode_LegacyJSON(t *testing.T) {
+	s, err := SetupSQLTestDB("file::memory:")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	node := &graph.Node{ID: 1, Name: "legacy_node", Children: roaring.BitmapOf(2), Parents: roaring.New()}
+	data, err := node.MarshalJSON()
+	assert.NoError(t, err)
+	assert.NoError(t, s.DB.Save(&KVStore{Key: NodeKeyPrefix + "1", Value: data}).Error)
+
+	savedNode, err := s.GetNode(node.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, node.Name, savedNode.Name)
+	assert.True(t, node.Children.Equals(savedNode.Children))
+}
+
 func TestSQLGetNodes(t *testing.T) {
 	s, err := SetupSQLTestDB("file::memory:")
 	if err != nil {
@@ -266,7 +284,43 @@ func TestSQLAddAndGetDataToDB(t *testing.T) {
 		t.Fatalf("Setup failed: %v", err)
 	}
 	err = s.AddOrUpdateCustomData("test_tag", "test_key1", "test_data1", []byte("test_data1"))
-	assert.Error(t, err)
+	assert.NoError(t, err)
+	err = s.AddOrUpdateCustomData("test_tag", "test_key1", "test_data2", []byte("test_data2"))
+	assert.NoError(t, err)
+	err = s.AddOrUpdateCustomData("test_tag", "test_key1", "test_data2", []byte("updated"))
+	assert.NoError(t, err)
+
+	data, err := s.GetCustomData("test_tag", "test_key1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"test_data1": []byte("test_data1"),
+		"test_data2": []byte("updated"),
+	}, data)
+
+	keys, err := s.GetCustomDataKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []graph.CustomDataKey{{Tag: "test_tag", Key: "test_key1"}}, keys)
+}
+
+func TestSQLIDCounter(t *testing.T) {
+	s, err := SetupSQLTestDB("file::memory:")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	counter, err := s.GetIDCounter()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), counter)
+
+	assert.NoError(t, s.SetIDCounter(41))
+	id, err := s.GenerateID()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(42), id)
+
+	// The counter never moves backwards
+	assert.NoError(t, s.SetIDCounter(5))
+	counter, err = s.GetIDCounter()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(42), counter)
 }
 
 func TestSQLGetAllKeysByGlob(t *testing.T) {
@@ -291,3 +345,113 @@ func TestSQLGetAllKeysByGlob(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(nodes))
 }
+
+func TestSQLDeleteCustomData(t *testing.T) {
+	s, err := SetupSQLTestDB("file::memory:")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	assert.NoError(t, s.AddOrUpdateCustomData("test_tag", "test_key1", "test_data1", []byte("test_data1")))
+	assert.NoError(t, s.AddOrUpdateCustomData("test_tag", "test_key1", "test_data2", []byte("test_data2")))
+
+	assert.NoError(t, s.DeleteCustomData("test_tag", "test_key1", "test_data1"))
+	data, err := s.GetCustomData("test_tag", "test_key1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"test_data2": []byte("test_data2")}, data)
+
+	// Once the last data key is gone the record no longer shows up
+	assert.NoError(t, s.DeleteCustomData("test_tag", "test_key1", "test_data2"))
+	keys, err := s.GetCustomDataKeys()
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// Deleting a missing data key is not an error
+	assert.NoError(t, s.DeleteCustomData("test_tag", "test_key1", "missing"))
+}
+
+func TestSQLSearchMetadata(t *testing.T) {
+	s, err := SetupSQLTestDB("file::memory:")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	vuln := &graph.Node{ID: 1, Name: "GHSA-1234", Type: "vuln", Metadata: map[string]any{"summary": "Remote code execution in parser"}, Children: roaring.New(), Parents: roaring.New()}
+	other := &graph.Node{ID: 2, Name: "GHSA-5678", Type: "vuln", Metadata: map[string]any{"summary": "Denial of service in parser"}, Children: roaring.New(), Parents: roaring.New()}
+	assert.NoError(t, s.SaveNode(vuln))
+	assert.NoError(t, s.SaveNode(other))
+
+	results, err := s.SearchMetadata("remote CODE", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, vuln.ID, results[0].Node.ID)
+		assert.Greater(t, results[0].Score, 0.0)
+	}
+
+	results, err = s.SearchMetadata("parser", 1)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// Saving a node again replaces its indexed text
+	vuln.Metadata = map[string]any{"summary": "Path traversal"}
+	assert.NoError(t, s.SaveNode(vuln))
+	results, err = s.SearchMetadata("remote", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	// Quotes and FTS5 operators are searched for as plain words
+	results, err = s.SearchMetadata(`"path" OR NEAR(`, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	_, err = s.SearchMetadata("  ", 10)
+	assert.ErrorIs(t, err, graph.ErrEmptySearchQuery)
+}
+
+func TestSQLGetAllNames(t *testing.T) {
+	s, err := SetupSQLTestDB("file::memory:")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	node1 := &graph.Node{ID: 1, Name: "pkg:npm/lodash@4.17.21", Children: roaring.New(), Parents: roaring.New()}
+	node2 := &graph.Node{ID: 2, Name: "name_with_underscore", Children: roaring.New(), Parents: roaring.New()}
+	assert.NoError(t, s.SaveNode(node1))
+	assert.NoError(t, s.SaveNode(node2))
+
+	names, err := s.GetAllNames()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint32{node1.Name: 1, node2.Name: 2}, names)
+
+	// SearchNodes matches the same nodes as the in-memory storage
+	nodes, _, err := graph.SearchNodes(s, graph.NodeQuery{Purl: &graph.PurlFilter{Type: "npm", VersionRange: "~4.17"}}, 0, "")
+	assert.NoError(t, err)
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, node1.Name, nodes[0].Name)
+	}
+}
+
+func TestSQLDeleteNode(t *testing.T) {
+	s, err := SetupSQLTestDB("file::memory:")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	node := &graph.Node{ID: 1, Name: "GHSA-1234", Type: "vuln", Metadata: map[string]any{"summary": "Remote code execution"}, Children: roaring.New(), Parents: roaring.New()}
+	assert.NoError(t, s.SaveNode(node))
+	assert.NoError(t, s.SaveCache(&graph.NodeCache{ID: 1, AllParents: roaring.New(), AllChildren: roaring.New()}))
+
+	assert.NoError(t, s.DeleteNode(1))
+
+	_, err = s.GetNode(1)
+	assert.Error(t, err)
+	_, err = s.NameToID(node.Name)
+	assert.Error(t, err)
+	cache, err := s.GetCache(1)
+	assert.NoError(t, err)
+	assert.Nil(t, cache)
+	toBeCached, err := s.ToBeCached()
+	assert.NoError(t, err)
+	assert.NotContains(t, toBeCached, uint32(1))
+	results, err := s.SearchMetadata("remote", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	assert.Error(t, s.DeleteNode(1), "deleting a missing node fails")
+}
@@ -14,6 +14,13 @@ const (
 	CacheKeyPrefix = "cache:"
 	IDCounterKey   = "id_counter"
 	CacheStackKey  = "to_be_cached"
+	CustomDataKeys = "custom_data_keys"
+	// SchemaVersionKey holds the schema version of a Redis database.
+	SchemaVersionKey = "schema_version"
+	// Metadata search index: a sorted set of nodes per term, the terms of each node, and the set of indexed nodes.
+	SearchTermPrefix = "search:term:"
+	SearchDocPrefix  = "search:doc:"
+	SearchDocsKey    = "search:docs"
 )
 
 // SetupSQLTestDB initializes a new SQLStorage with the given DSN.
@@ -0,0 +1,33 @@
+package storages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+)
+
+const (
+	SQLiteURIPrefix = "sqlite://"
+	RedisURIPrefix  = "redis://"
+	inMemoryPath    = ":memory:"
+)
+
+// NewStorageFromURI opens the storage backend described by uri.
+// Supported forms are sqlite:///path/to/db.sqlite, sqlite://:memory: and redis://host:port.
+func NewStorageFromURI(uri string) (graph.Storage, error) {
+	if path, ok := strings.CutPrefix(uri, SQLiteURIPrefix); ok {
+		if path == "" {
+			return nil, fmt.Errorf("storage URI %q is missing the SQLite database path", uri)
+		}
+		return NewSQLStorage(path, path == inMemoryPath)
+	}
+	if addr, ok := strings.CutPrefix(uri, RedisURIPrefix); ok {
+		addr = strings.TrimSuffix(addr, "/")
+		if addr == "" {
+			return nil, fmt.Errorf("storage URI %q is missing the Redis address", uri)
+		}
+		return NewRedisStorage(addr)
+	}
+	return nil, fmt.Errorf("unknown storage URI %q: must start with %s or %s", uri, SQLiteURIPrefix, RedisURIPrefix)
+}
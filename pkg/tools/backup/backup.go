@@ -0,0 +1,376 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// FormatVersion is the version of the archive layout written by Write.
+const FormatVersion = 1
+
+// Files inside the archive. The manifest is written first so readers know what to expect.
+const (
+	ManifestFile   = "manifest.json"
+	NodesFile      = "nodes.bin"
+	CachesFile     = "caches.bin"
+	CacheStackFile = "cache_stack.json"
+	CustomDataFile = "custom_data.json"
+)
+
+const batchSize = 500
+
+var (
+	ErrChecksumMismatch   = errors.New("archive checksum mismatch")
+	ErrUnsupportedVersion = errors.New("unsupported archive format version")
+)
+
+// Manifest describes the contents of an archive.
+type Manifest struct {
+	FormatVersion int                 `json:"formatVersion"`
+	CreatedAt     time.Time           `json:"createdAt"`
+	IDCounter     uint32              `json:"idCounter"`
+	Nodes         int                 `json:"nodes"`
+	Caches        int                 `json:"caches"`
+	CacheStack    int                 `json:"cacheStack"`
+	CustomData    int                 `json:"customData"`
+	Files         map[string]FileInfo `json:"files"`
+}
+
+// FileInfo is the size and SHA-256 checksum of a file inside the archive.
+type FileInfo struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// CustomDataRecord is a custom data record as stored in the archive.
+type CustomDataRecord struct {
+	Tag  string            `json:"tag"`
+	Key  string            `json:"key"`
+	Data map[string][]byte `json:"data"`
+}
+
+// Write writes a point-in-time archive of the storage to w.
+// The archive is a gzip compressed tarball holding a manifest with checksums, the node and cache records in the
+// binary record encoding, the cache stack and the custom data.
+func Write(storage graph.Storage, w io.Writer) (*Manifest, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("storage cannot be nil")
+	}
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		Files:         map[string]FileInfo{},
+	}
+
+	keys, err := storage.GetAllKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keys: %w", err)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var nodesData, cachesData bytes.Buffer
+	for i := 0; i < len(keys); i += batchSize {
+		batch := keys[i:min(i+batchSize, len(keys))]
+		nodes, err := storage.GetNodes(batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nodes: %w", err)
+		}
+		caches, err := storage.GetCaches(batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get caches: %w", err)
+		}
+		for _, id := range batch {
+			if node, ok := nodes[id]; ok {
+				record, err := node.MarshalBinary()
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode node %d: %w", id, err)
+				}
+				nodesData.Write(protowire.AppendBytes(nil, record))
+				manifest.Nodes++
+			}
+			if cache, ok := caches[id]; ok && cache != nil {
+				record, err := cache.MarshalBinary()
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode cache %d: %w", id, err)
+				}
+				cachesData.Write(protowire.AppendBytes(nil, record))
+				manifest.Caches++
+			}
+		}
+	}
+
+	stack, err := storage.ToBeCached()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache stack: %w", err)
+	}
+	stack = uniqueIDs(stack)
+	manifest.CacheStack = len(stack)
+	stackData, err := json.Marshal(stack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cache stack: %w", err)
+	}
+
+	customDataKeys, err := storage.GetCustomDataKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom data keys: %w", err)
+	}
+	records := make([]CustomDataRecord, 0, len(customDataKeys))
+	for _, key := range customDataKeys {
+		data, err := storage.GetCustomData(key.Tag, key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get custom data %s:%s: %w", key.Tag, key.Key, err)
+		}
+		records = append(records, CustomDataRecord{Tag: key.Tag, Key: key.Key, Data: data})
+	}
+	manifest.CustomData = len(records)
+	customData, err := json.Marshal(records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal custom data: %w", err)
+	}
+
+	if manifest.IDCounter, err = storage.GetIDCounter(); err != nil {
+		return nil, fmt.Errorf("failed to get ID counter: %w", err)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{NodesFile, nodesData.Bytes()},
+		{CachesFile, cachesData.Bytes()},
+		{CacheStackFile, stackData},
+		{CustomDataFile, customData},
+	}
+	for _, f := range files {
+		sum := sha256.Sum256(f.data)
+		manifest.Files[f.name] = FileInfo{Size: int64(len(f.data)), SHA256: hex.EncodeToString(sum[:])}
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if err := writeFile(tw, ManifestFile, manifestData, manifest.CreatedAt); err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if err := writeFile(tw, f.name, f.data, manifest.CreatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish tar archive: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish gzip stream: %w", err)
+	}
+	return manifest, nil
+}
+
+// Restore reads an archive written by Write and loads it into the storage, which must be empty.
+// Every checksum is verified before anything is written, and node IDs and the ID counter are kept as they were,
+// so saved leaderboards and queries that refer to IDs stay valid.
+func Restore(storage graph.Storage, r io.Reader) (*Manifest, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("storage cannot be nil")
+	}
+	manifest, files, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := storage.GetAllKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keys: %w", err)
+	}
+	if len(keys) > 0 {
+		return nil, fmt.Errorf("cannot restore into a storage that already contains %d nodes", len(keys))
+	}
+
+	err = readRecords(files[NodesFile], func(record []byte) error {
+		var node graph.Node
+		if err := node.UnmarshalBinary(record); err != nil {
+			return fmt.Errorf("failed to decode node: %w", err)
+		}
+		if err := storage.SaveNode(&node); err != nil {
+			return fmt.Errorf("failed to save node %d: %w", node.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	caches := make([]*graph.NodeCache, 0, batchSize)
+	err = readRecords(files[CachesFile], func(record []byte) error {
+		cache := &graph.NodeCache{}
+		if err := cache.UnmarshalBinary(record); err != nil {
+			return fmt.Errorf("failed to decode cache: %w", err)
+		}
+		caches = append(caches, cache)
+		if len(caches) == batchSize {
+			if err := storage.SaveCaches(caches); err != nil {
+				return fmt.Errorf("failed to save caches: %w", err)
+			}
+			caches = caches[:0]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(caches) > 0 {
+		if err := storage.SaveCaches(caches); err != nil {
+			return nil, fmt.Errorf("failed to save caches: %w", err)
+		}
+	}
+
+	// Saving nodes pushes them onto the cache stack, so it is rebuilt from the archive
+	var stack []uint32
+	if err := json.Unmarshal(files[CacheStackFile], &stack); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cache stack: %w", err)
+	}
+	if err := storage.ClearCacheStack(); err != nil {
+		return nil, fmt.Errorf("failed to clear cache stack: %w", err)
+	}
+	for _, id := range stack {
+		if err := storage.AddNodeToCachedStack(id); err != nil {
+			return nil, fmt.Errorf("failed to add node %d to cache stack: %w", id, err)
+		}
+	}
+
+	var records []CustomDataRecord
+	if err := json.Unmarshal(files[CustomDataFile], &records); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal custom data: %w", err)
+	}
+	for _, record := range records {
+		for dataKey, value := range record.Data {
+			if err := storage.AddOrUpdateCustomData(record.Tag, record.Key, dataKey, value); err != nil {
+				return nil, fmt.Errorf("failed to save custom data %s:%s: %w", record.Tag, record.Key, err)
+			}
+		}
+	}
+
+	if err := storage.SetIDCounter(manifest.IDCounter); err != nil {
+		return nil, fmt.Errorf("failed to set ID counter: %w", err)
+	}
+	return manifest, nil
+}
+
+// Read reads an archive, checks its format version and verifies every file against the manifest checksums.
+func Read(r io.Reader) (*Manifest, map[string][]byte, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open gzip stream: %w", err)
+	}
+	defer gr.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s from archive: %w", header.Name, err)
+		}
+		files[header.Name] = data
+	}
+
+	manifestData, ok := files[ManifestFile]
+	if !ok {
+		return nil, nil, fmt.Errorf("archive is missing %s", ManifestFile)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+	if manifest.FormatVersion != FormatVersion {
+		return nil, nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, manifest.FormatVersion)
+	}
+	for _, name := range []string{NodesFile, CachesFile, CacheStackFile, CustomDataFile} {
+		info, ok := manifest.Files[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("manifest is missing the checksum of %s", name)
+		}
+		data, ok := files[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("archive is missing %s", name)
+		}
+		sum := sha256.Sum256(data)
+		if int64(len(data)) != info.Size || hex.EncodeToString(sum[:]) != info.SHA256 {
+			return nil, nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, name)
+		}
+	}
+	return &manifest, files, nil
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", name, err)
+	}
+	return nil
+}
+
+// readRecords calls fn for every length delimited record in data.
+func readRecords(data []byte, fn func(record []byte) error) error {
+	br := bufio.NewReader(bytes.NewReader(data))
+	for {
+		size, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read record length: %w", err)
+		}
+		record := make([]byte, size)
+		if _, err := io.ReadFull(br, record); err != nil {
+			return fmt.Errorf("failed to read record: %w", err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+func uniqueIDs(ids []uint32) []uint32 {
+	seen := make(map[uint32]bool, len(ids))
+	unique := make([]uint32, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
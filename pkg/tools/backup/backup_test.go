@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupStorage(t *testing.T, numNodes int) *graph.MockStorage {
+	t.Helper()
+	storage := graph.NewMockStorage()
+	var prev *graph.Node
+	for i := 0; i < numNodes; i++ {
+		node, err := graph.AddNode(storage, "library", map[string]any{"index": i}, fmt.Sprintf("pkg:generic/node%d@1.0.0", i))
+		require.NoError(t, err)
+		if prev != nil {
+			require.NoError(t, prev.SetDependency(storage, node))
+		}
+		prev = node
+	}
+	require.NoError(t, graph.Cache(storage))
+	require.NoError(t, storage.AddOrUpdateCustomData("owner", "pkg:generic/node0@1.0.0", "team", []byte("payments")))
+	return storage
+}
+
+func TestWriteAndRestore(t *testing.T) {
+	from := setupStorage(t, 5)
+	// Leave one node uncached so the cache stack is carried over too
+	_, err := graph.AddNode(from, "library", nil, "pkg:generic/uncached@1.0.0")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	manifest, err := Write(from, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, FormatVersion, manifest.FormatVersion)
+	assert.Equal(t, 6, manifest.Nodes)
+	assert.Equal(t, 1, manifest.CacheStack)
+	assert.Equal(t, 1, manifest.CustomData)
+	assert.Equal(t, uint32(6), manifest.IDCounter)
+
+	to := graph.NewMockStorage()
+	restored, err := Restore(to, bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Nodes, restored.Nodes)
+
+	for id := uint32(1); id <= 6; id++ {
+		fromNode, err := from.GetNode(id)
+		require.NoError(t, err)
+		toNode, err := to.GetNode(id)
+		require.NoError(t, err)
+		assert.Equal(t, fromNode.Name, toNode.Name)
+		assert.True(t, fromNode.Children.Equals(toNode.Children))
+		assert.True(t, fromNode.Parents.Equals(toNode.Parents))
+	}
+
+	fromCache, err := from.GetCache(1)
+	require.NoError(t, err)
+	toCache, err := to.GetCache(1)
+	require.NoError(t, err)
+	assert.True(t, fromCache.AllChildren.Equals(toCache.AllChildren))
+
+	stack, err := to.ToBeCached()
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{6}, stack)
+
+	data, err := to.GetCustomData("owner", "pkg:generic/node0@1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payments"), data["team"])
+
+	// New nodes continue after the restored IDs
+	id, err := to.GenerateID()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), id)
+}
+
+func TestRestoreRejectsNonEmptyStorage(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := Write(setupStorage(t, 2), &buf)
+	require.NoError(t, err)
+
+	to := graph.NewMockStorage()
+	_, err = graph.AddNode(to, "library", nil, "existing")
+	require.NoError(t, err)
+
+	_, err = Restore(to, &buf)
+	assert.ErrorContains(t, err, "already contains 1 nodes")
+}
+
+func TestReadDetectsCorruption(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := Write(setupStorage(t, 2), &buf)
+	require.NoError(t, err)
+
+	// Rewrite the archive with a tampered nodes file
+	gr, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	var out bytes.Buffer
+	gw := gzip.NewWriter(&out)
+	tw := tar.NewWriter(gw)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		if header.Name == NodesFile {
+			data[len(data)-1] ^= 0xff
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	_, _, err = Read(&out)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+}
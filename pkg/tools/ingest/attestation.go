@@ -0,0 +1,413 @@
+package ingest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/package-url/packageurl-go"
+)
+
+// PinnedAttribute is the edge attribute from a build to one of its materials telling whether the provenance gives
+// the digest of the material, "true" or "false".
+const PinnedAttribute = "pinned"
+
+const (
+	inTotoPayloadType = "application/vnd.in-toto+json"
+
+	// SLSAProvenanceV1 is the predicate type of SLSA provenance v1, see https://slsa.dev/spec/v1.0/provenance.
+	SLSAProvenanceV1 = "https://slsa.dev/provenance/v1"
+)
+
+// sbomPredicateTypes are the prefixes of the predicate types of SBOM attestations, which are followed by the version
+// of the format, as in https://spdx.dev/Document/v2.3.
+var sbomPredicateTypes = []string{"https://spdx.dev/Document", "https://cyclonedx.org/bom"}
+
+// dsseEnvelope is a DSSE envelope, see https://github.com/secure-systems-lab/dsse/blob/master/envelope.md.
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	// DSSEEnvelope is set instead when the envelope comes in a Sigstore bundle
+	DSSEEnvelope *dsseEnvelope `json:"dsseEnvelope"`
+}
+
+// InTotoStatement is an in-toto attestation statement, see https://github.com/in-toto/attestation/tree/main/spec.
+type InTotoStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     json.RawMessage      `json:"predicate"`
+}
+
+// ResourceDescriptor describes an artifact or a material of a build.
+type ResourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// SLSAProvenance is the predicate of SLSA provenance v1.
+type SLSAProvenance struct {
+	BuildDefinition struct {
+		BuildType            string               `json:"buildType"`
+		ExternalParameters   map[string]any       `json:"externalParameters"`
+		ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies"`
+	} `json:"buildDefinition"`
+	RunDetails struct {
+		Builder struct {
+			ID string `json:"id"`
+		} `json:"builder"`
+		Metadata struct {
+			InvocationID string `json:"invocationId"`
+			StartedOn    string `json:"startedOn"`
+			FinishedOn   string `json:"finishedOn"`
+		} `json:"metadata"`
+	} `json:"runDetails"`
+}
+
+// Build is the metadata of a build node.
+type Build struct {
+	BuildType    string     `json:"buildType"`
+	Builder      string     `json:"builder"`
+	InvocationID string     `json:"invocationId,omitempty"`
+	StartedOn    string     `json:"startedOn,omitempty"`
+	FinishedOn   string     `json:"finishedOn,omitempty"`
+	Source       string     `json:"source,omitempty"`
+	Subjects     []string   `json:"subjects"`
+	Materials    []Material `json:"materials,omitempty"`
+}
+
+// Material is a material of a build, pinned when the provenance gives its digest.
+type Material struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+	Pinned bool              `json:"pinned"`
+}
+
+// Artifact is the metadata of an artifact node.
+type Artifact struct {
+	Name   string            `json:"name,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// ArtifactNodeName returns the name of the node of an artifact, from its SHA-256 digest, or its SHA-512 one, or the
+// first of its other digests. It returns an empty string for artifacts without a digest.
+func ArtifactNodeName(digest map[string]string) string {
+	algorithms := make([]string, 0, len(digest))
+	for algorithm, value := range digest {
+		if value != "" {
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+	if len(algorithms) == 0 {
+		return ""
+	}
+	sort.Strings(algorithms)
+	algorithm := algorithms[0]
+	if digest["sha256"] != "" {
+		algorithm = "sha256"
+	} else if digest["sha512"] != "" {
+		algorithm = "sha512"
+	}
+	return "artifact:" + algorithm + ":" + strings.ToLower(digest[algorithm])
+}
+
+// Attestation ingests DSSE envelopes of in-toto statements, one JSON document or line each, or in Sigstore bundles,
+// and returns the number of statements ingested. SLSA provenance v1 adds the build of the subjects, and SBOM
+// predicates are ingested as SBOMs the subjects depend on. Statements with other predicates are skipped. Signatures
+// aren't verified, which is left to the tools producing and fetching the attestations.
+func Attestation(storage graph.Storage, data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, fmt.Errorf("data is empty")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	count, envelopes := 0, 0
+	for {
+		var envelope dsseEnvelope
+		if err := decoder.Decode(&envelope); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return count, fmt.Errorf("failed to decode DSSE envelope: %w", err)
+		}
+		envelopes++
+		if envelope.DSSEEnvelope != nil {
+			envelope = *envelope.DSSEEnvelope
+		}
+
+		statement, payload, err := decodeStatement(envelope)
+		if err != nil {
+			return count, err
+		}
+		ingested, err := ingestStatement(storage, statement, payload)
+		if err != nil {
+			return count, err
+		}
+		if ingested {
+			count++
+		}
+	}
+	if envelopes == 0 {
+		return 0, fmt.Errorf("no DSSE envelopes found")
+	}
+	return count, nil
+}
+
+// decodeStatement returns the in-toto statement of an envelope, along with the payload it was decoded from.
+func decodeStatement(envelope dsseEnvelope) (InTotoStatement, []byte, error) {
+	var statement InTotoStatement
+	if envelope.PayloadType != inTotoPayloadType {
+		return statement, nil, fmt.Errorf("unsupported DSSE payload type %q, expected %s", envelope.PayloadType, inTotoPayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		// The DSSE specification allows either base64 alphabet
+		if payload, err = base64.URLEncoding.DecodeString(envelope.Payload); err != nil {
+			return statement, nil, fmt.Errorf("failed to decode DSSE payload: %w", err)
+		}
+	}
+	if err := json.Unmarshal(payload, &statement); err != nil {
+		return statement, nil, fmt.Errorf("failed to decode in-toto statement: %w", err)
+	}
+	if !strings.HasPrefix(statement.Type, "https://in-toto.io/Statement/") {
+		return statement, nil, fmt.Errorf("unsupported in-toto statement type %q", statement.Type)
+	}
+	return statement, payload, nil
+}
+
+// ingestStatement ingests a statement, and reports whether its predicate type is supported.
+func ingestStatement(storage graph.Storage, statement InTotoStatement, payload []byte) (bool, error) {
+	isProvenance := statement.PredicateType == SLSAProvenanceV1
+	if !isProvenance && !isSBOMPredicateType(statement.PredicateType) {
+		log.Printf("Warning: Skipping in-toto statement with unsupported predicate type %q", statement.PredicateType)
+		return false, nil
+	}
+
+	var subjects []*graph.Node
+	for _, subject := range statement.Subject {
+		name := ArtifactNodeName(subject.Digest)
+		if name == "" {
+			log.Printf("Warning: Skipping subject %q without a digest", subject.Name)
+			continue
+		}
+		node, err := graph.AddNode(storage, tools.ArtifactType, Artifact{Name: subject.Name, Digest: subject.Digest}, name)
+		if err != nil {
+			return false, fmt.Errorf("failed to add artifact node %s: %w", name, err)
+		}
+		subjects = append(subjects, node)
+	}
+	if len(subjects) == 0 {
+		return false, fmt.Errorf("in-toto statement of %s has no subject with a digest", statement.PredicateType)
+	}
+
+	if isProvenance {
+		var provenance SLSAProvenance
+		if err := json.Unmarshal(statement.Predicate, &provenance); err != nil {
+			return false, fmt.Errorf("failed to decode SLSA provenance: %w", err)
+		}
+		return true, addBuild(storage, provenance, subjects, payload)
+	}
+
+	sbomNode, err := ingestSBOM(storage, statement.Predicate, SBOMOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to ingest SBOM predicate: %w", err)
+	}
+	if sbomNode == nil {
+		return true, nil
+	}
+	for _, subject := range subjects {
+		if err := subject.SetDependency(storage, sbomNode); err != nil {
+			return false, fmt.Errorf("failed to add edge %s -> %s: %w", subject.Name, sbomNode.Name, err)
+		}
+	}
+	return true, nil
+}
+
+func isSBOMPredicateType(predicateType string) bool {
+	for _, prefix := range sbomPredicateTypes {
+		if predicateType == prefix || strings.HasPrefix(predicateType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// addBuild adds the build node of a provenance, which the subjects depend on, and which depends on its builder,
+// source repository and materials. The build is named after its invocation id, or after the provenance otherwise.
+func addBuild(storage graph.Storage, provenance SLSAProvenance, subjects []*graph.Node, payload []byte) error {
+	definition, run := provenance.BuildDefinition, provenance.RunDetails
+	if run.Builder.ID == "" {
+		return fmt.Errorf("SLSA provenance has no builder id")
+	}
+	build := Build{
+		BuildType:    definition.BuildType,
+		Builder:      run.Builder.ID,
+		InvocationID: run.Metadata.InvocationID,
+		StartedOn:    run.Metadata.StartedOn,
+		FinishedOn:   run.Metadata.FinishedOn,
+		Source:       provenanceSource(provenance),
+	}
+	for _, subject := range subjects {
+		build.Subjects = append(build.Subjects, subject.Name)
+	}
+
+	name := "build:" + build.InvocationID
+	if build.InvocationID == "" {
+		sum := sha256.Sum256(payload)
+		name = "build:sha256:" + hex.EncodeToString(sum[:])
+	}
+	buildNode, err := graph.AddNode(storage, tools.BuildType, build, name)
+	if err != nil {
+		return fmt.Errorf("failed to add build node %s: %w", name, err)
+	}
+
+	builderNode, err := graph.AddNode(storage, tools.BuilderType, nil, "builder:"+build.Builder)
+	if err != nil {
+		return fmt.Errorf("failed to add builder node: %w", err)
+	}
+	if err := buildNode.SetDependency(storage, builderNode); err != nil {
+		return fmt.Errorf("failed to add edge %s -> %s: %w", buildNode.Name, builderNode.Name, err)
+	}
+	if build.Source != "" {
+		if _, err := addMaterialNode(storage, buildNode, RepositoryNodeName(build.Source), tools.RepositoryType, Repository{Name: build.Source}); err != nil {
+			return err
+		}
+	}
+
+	unpinned := 0
+	for _, dependency := range definition.ResolvedDependencies {
+		material := Material{URI: dependency.URI, Digest: dependency.Digest, Pinned: ArtifactNodeName(dependency.Digest) != ""}
+		if material.URI == "" {
+			material.URI = dependency.Name
+		}
+		materialNode, err := addMaterial(storage, buildNode, material)
+		if err != nil {
+			return err
+		}
+		if materialNode == nil {
+			continue
+		}
+		if err := graph.SetEdgeAttribute(storage, buildNode.Name, materialNode.Name, PinnedAttribute, strconv.FormatBool(material.Pinned)); err != nil {
+			return err
+		}
+		build.Materials = append(build.Materials, material)
+		if !material.Pinned {
+			unpinned++
+		}
+	}
+
+	// The node already existed if the provenance was ingested before, so refresh its metadata
+	buildNode.Metadata = build
+	if err := storage.SaveNode(buildNode); err != nil {
+		return fmt.Errorf("failed to save build node: %w", err)
+	}
+	if err := graph.SetField(storage, buildNode.Name, graph.UnpinnedMaterialsField, strconv.Itoa(unpinned)); err != nil {
+		return err
+	}
+	for _, subject := range subjects {
+		if err := subject.SetDependency(storage, buildNode); err != nil {
+			return fmt.Errorf("failed to add edge %s -> %s: %w", subject.Name, buildNode.Name, err)
+		}
+		if err := graph.SetField(storage, subject.Name, graph.UnpinnedMaterialsField, strconv.Itoa(unpinned)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMaterial adds the node of a material of a build: the library node of a package URL, the repository node of a
+// git repository, the artifact node of a material only known by its digest, or else a material node named after its
+// URI. It returns nil for materials with neither a URI nor a digest.
+func addMaterial(storage graph.Storage, buildNode *graph.Node, material Material) (*graph.Node, error) {
+	switch {
+	case strings.HasPrefix(material.URI, pkg):
+		purl, err := packageurl.FromString(material.URI)
+		if err != nil {
+			break
+		}
+		p := lockfilePackage{Name: purl.Name, Version: purl.Version, PURL: purl.ToString()}
+		node, err := addMaterialNode(storage, buildNode, p.PURL, tools.LibraryType, lockfileNode(p))
+		if err != nil {
+			return nil, err
+		}
+		if err := linkVulnerabilities(storage, []string{p.PURL}); err != nil {
+			return nil, fmt.Errorf("failed to link vulnerabilities: %w", err)
+		}
+		if err := linkRepositories(storage, []string{p.PURL}); err != nil {
+			return nil, fmt.Errorf("failed to link repositories: %w", err)
+		}
+		return node, nil
+	case strings.HasPrefix(material.URI, "git+"):
+		if repository := gitRepository(material.URI); repository != "" {
+			return addMaterialNode(storage, buildNode, RepositoryNodeName(repository), tools.RepositoryType, Repository{Name: repository})
+		}
+	case material.URI == "":
+		name := ArtifactNodeName(material.Digest)
+		if name == "" {
+			return nil, nil
+		}
+		return addMaterialNode(storage, buildNode, name, tools.ArtifactType, Artifact{Digest: material.Digest})
+	}
+	return addMaterialNode(storage, buildNode, "material:"+material.URI, tools.MaterialType, material)
+}
+
+func addMaterialNode(storage graph.Storage, buildNode *graph.Node, name, nodeType string, metadata any) (*graph.Node, error) {
+	node, err := graph.AddNode(storage, nodeType, metadata, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s node %s: %w", nodeType, name, err)
+	}
+	if err := buildNode.SetDependency(storage, node); err != nil {
+		return nil, fmt.Errorf("failed to add edge %s -> %s: %w", buildNode.Name, node.Name, err)
+	}
+	return node, nil
+}
+
+// provenanceSource returns the repository a build was run from: the repository of the workflow of GitHub Actions
+// builds, or else the first git material.
+func provenanceSource(provenance SLSAProvenance) string {
+	if workflow, ok := provenance.BuildDefinition.ExternalParameters["workflow"].(map[string]any); ok {
+		if repository, ok := workflow["repository"].(string); ok {
+			if source := NormalizeRepository(repository); source != "" {
+				return source
+			}
+		}
+	}
+	for _, dependency := range provenance.BuildDefinition.ResolvedDependencies {
+		if strings.HasPrefix(dependency.URI, "git+") {
+			if source := gitRepository(dependency.URI); source != "" {
+				return source
+			}
+		}
+	}
+	return ""
+}
+
+// gitRepository returns the repository of a git URI of SLSA, which names the revision after an @, as in
+// git+https://github.com/octocat/hello-world@refs/heads/main.
+func gitRepository(uri string) string {
+	uri = strings.TrimPrefix(uri, "git+")
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		scheme, rest = "", uri
+	}
+	// Credentials come before the host, and the revision after the path
+	host, path, _ := strings.Cut(rest, "/")
+	if at := strings.LastIndex(path, "@"); at >= 0 {
+		path = path[:at]
+	}
+	if scheme != "" {
+		return NormalizeRepository(scheme + "://" + host + "/" + path)
+	}
+	return NormalizeRepository(host + "/" + path)
+}
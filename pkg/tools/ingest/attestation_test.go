@@ -0,0 +1,182 @@
+package ingest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLinuxArtifact  = "artifact:sha256:6e3fa3bf1c9ad1d1d3b1e1b0f0f17b8bcb1e5a9f1ef3b9ecb1e5a9e3f3c9d0a1"
+	testDarwinArtifact = "artifact:sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	testBuild          = "build:https://github.com/octocat/hello-world/actions/runs/1234567890/attempts/1"
+)
+
+func TestAttestation(t *testing.T) {
+	storage := graph.NewMockStorage()
+	data, err := os.ReadFile("../../../testdata/attestations/hello-world.intoto.jsonl")
+	require.NoError(t, err)
+
+	// The provenance and the SBOM are ingested, and the vulnerability scan is skipped
+	count, err := Attestation(storage, data)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	node := func(name string) *graph.Node {
+		t.Helper()
+		id, err := storage.NameToID(name)
+		require.NoError(t, err, name)
+		node, err := storage.GetNode(id)
+		require.NoError(t, err)
+		return node
+	}
+	names := func(ids []uint32) []string {
+		t.Helper()
+		var names []string
+		for _, id := range ids {
+			node, err := storage.GetNode(id)
+			require.NoError(t, err)
+			names = append(names, node.Name)
+		}
+		return names
+	}
+
+	build := node(testBuild)
+	assert.Equal(t, tools.BuildType, build.Type)
+	assert.ElementsMatch(t, []string{testLinuxArtifact, testDarwinArtifact}, names(build.Parents.ToArray()))
+	assert.ElementsMatch(t, []string{
+		"builder:https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@refs/tags/v2.0.0",
+		RepositoryNodeName("github.com/octocat/hello-world"),
+		"pkg:npm/left-pad@1.3.0",
+		"material:https://example.com/toolchain/go1.22.4.linux-amd64.tar.gz",
+		"material:https://example.com/scripts/install.sh",
+	}, names(build.Children.ToArray()))
+	assert.Equal(t, tools.LibraryType, node("pkg:npm/left-pad@1.3.0").Type)
+
+	metadata := build.Metadata.(Build)
+	assert.Equal(t, "github.com/octocat/hello-world", metadata.Source)
+	assert.Len(t, metadata.Materials, 4)
+
+	attributes, err := graph.GetEdgeAttributes(storage, "pkg:npm/left-pad@1.3.0")
+	require.NoError(t, err)
+	assert.Equal(t, "false", attributes[testBuild][PinnedAttribute])
+	attributes, err = graph.GetEdgeAttributes(storage, RepositoryNodeName("github.com/octocat/hello-world"))
+	require.NoError(t, err)
+	assert.Equal(t, "true", attributes[testBuild][PinnedAttribute])
+
+	for _, name := range []string{testBuild, testLinuxArtifact, testDarwinArtifact} {
+		fields, err := graph.GetFields(storage, name)
+		require.NoError(t, err)
+		assert.Equal(t, "2", fields[graph.UnpinnedMaterialsField], name)
+	}
+
+	// The SBOM predicate describes the linux artifact
+	assert.Contains(t, names(node(testLinuxArtifact).Children.ToArray()), "sbom:urn:uuid:8c2b4f3e-6d1a-4c6e-9a3b-2f5e7d9c1b4a")
+
+	// Which materials of an artifact aren't pinned
+	require.NoError(t, graph.Cache(storage))
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	nodes, err := storage.GetNodes(keys)
+	require.NoError(t, err)
+	caches, err := storage.GetCaches(keys)
+	require.NoError(t, err)
+	result, err := graph.ParseAndExecute("dependencies library "+testLinuxArtifact+" where edge.pinned = false", storage, "", nodes, caches, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg:npm/left-pad@1.3.0"}, names(result.ToArray()))
+	result, err = graph.ParseAndExecute("dependents artifact "+RepositoryNodeName("github.com/octocat/hello-world")+" where unpinned_materials > 0", storage, "", nodes, caches, true)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{testLinuxArtifact, testDarwinArtifact}, names(result.ToArray()))
+
+	// Ingesting the attestations again changes nothing
+	keys, err = storage.GetAllKeys()
+	require.NoError(t, err)
+	_, err = Attestation(storage, data)
+	require.NoError(t, err)
+	again, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	assert.Len(t, again, len(keys))
+}
+
+func TestAttestation_SigstoreBundle(t *testing.T) {
+	storage := graph.NewMockStorage()
+	bundle := map[string]any{
+		"mediaType":    "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"dsseEnvelope": testEnvelope(t, testProvenance("")),
+	}
+	data, err := json.Marshal(bundle)
+	require.NoError(t, err)
+
+	count, err := Attestation(storage, data)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Without an invocation id, the build is named after the provenance
+	id, err := storage.NameToID(testLinuxArtifact)
+	require.NoError(t, err)
+	artifact, err := storage.GetNode(id)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), artifact.Children.GetCardinality())
+	build, err := storage.GetNode(artifact.Children.ToArray()[0])
+	require.NoError(t, err)
+	assert.Regexp(t, "^build:sha256:[0-9a-f]{64}$", build.Name)
+}
+
+func TestAttestation_Errors(t *testing.T) {
+	storage := graph.NewMockStorage()
+	envelope := func(v any) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(data)
+	}
+	noSubjects := testProvenance("builder")
+	noSubjects["subject"] = []map[string]any{{"name": "app"}}
+	noBuilder := testProvenance("")
+	noBuilder["predicate"].(map[string]any)["runDetails"] = map[string]any{}
+
+	for name, data := range map[string]string{
+		"empty":          "",
+		"not json":       "{",
+		"no envelopes":   " \n",
+		"payload type":   `{"payloadType": "application/json", "payload": ""}`,
+		"payload":        `{"payloadType": "application/vnd.in-toto+json", "payload": "not base64!"}`,
+		"statement type": envelope(testEnvelope(t, map[string]any{"_type": "https://example.com/Statement"})),
+		"no subjects":    envelope(testEnvelope(t, noSubjects)),
+		"no builder":     envelope(testEnvelope(t, noBuilder)),
+	} {
+		_, err := Attestation(storage, []byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func testProvenance(invocationID string) map[string]any {
+	return map[string]any{
+		"_type":         "https://in-toto.io/Statement/v1",
+		"subject":       []map[string]any{{"name": "app", "digest": map[string]string{"sha256": testLinuxArtifact[len("artifact:sha256:"):]}}},
+		"predicateType": SLSAProvenanceV1,
+		"predicate": map[string]any{
+			"buildDefinition": map[string]any{"buildType": "https://example.com/buildtype/v1"},
+			"runDetails": map[string]any{
+				"builder":  map[string]any{"id": "https://example.com/builder"},
+				"metadata": map[string]any{"invocationId": invocationID},
+			},
+		},
+	}
+}
+
+func testEnvelope(t *testing.T, statement map[string]any) map[string]any {
+	t.Helper()
+	payload, err := json.Marshal(statement)
+	require.NoError(t, err)
+	return map[string]any{
+		"payloadType": "application/vnd.in-toto+json",
+		"payload":     base64.StdEncoding.EncodeToString(payload),
+		"signatures":  []map[string]any{},
+	}
+}
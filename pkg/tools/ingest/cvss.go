@@ -0,0 +1,323 @@
+package ingest
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+)
+
+// OSV severity types holding CVSS vectors, see https://ossf.github.io/osv-schema/#severity-field.
+const (
+	SeverityTypeCVSSv2 = "CVSS_V2"
+	SeverityTypeCVSSv3 = "CVSS_V3"
+	SeverityTypeCVSSv4 = "CVSS_V4"
+)
+
+// Qualitative severity ratings of CVSS scores. CVSS v2 has no critical rating.
+const (
+	RatingNone     = "none"
+	RatingLow      = "low"
+	RatingMedium   = "medium"
+	RatingHigh     = "high"
+	RatingCritical = "critical"
+)
+
+// CVSS is a scored CVSS vector.
+type CVSS struct {
+	// Version is "2.0", "3.0", "3.1" or "4.0".
+	Version   string
+	Vector    string
+	BaseScore float64
+	Rating    string
+}
+
+// ParseCVSS parses a CVSS v2, v3.x or v4.0 vector and computes its base score and rating. v3.x and v4.0 vectors start
+// with their version, such as CVSS:3.1/, and v2 vectors, which don't, may be wrapped in parentheses. Temporal and
+// environmental metrics are validated but don't change the score, except for the v4.0 threat and security
+// requirement metrics, which are part of the CVSS-BT and CVSS-BE scores.
+func ParseCVSS(vector string) (*CVSS, error) {
+	vector = strings.TrimSpace(vector)
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3.0/"), strings.HasPrefix(vector, "CVSS:3.1/"):
+		metrics, err := parseCVSSMetrics(vector[len("CVSS:3.x/"):], cvss3Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CVSS vector %q: %w", vector, err)
+		}
+		version := vector[len("CVSS:"):len("CVSS:3.x")]
+		score := cvss3BaseScore(metrics, version)
+		return &CVSS{Version: version, Vector: vector, BaseScore: score, Rating: cvssRating(score)}, nil
+	case strings.HasPrefix(vector, "CVSS:4.0/"):
+		metrics, err := parseCVSSMetrics(vector[len("CVSS:4.0/"):], cvss4Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CVSS vector %q: %w", vector, err)
+		}
+		score := cvss4Score(metrics)
+		return &CVSS{Version: "4.0", Vector: vector, BaseScore: score, Rating: cvssRating(score)}, nil
+	case strings.HasPrefix(vector, "CVSS:"):
+		return nil, fmt.Errorf("unsupported CVSS version in %q", vector)
+	default:
+		metrics, err := parseCVSSMetrics(strings.TrimSuffix(strings.TrimPrefix(vector, "("), ")"), cvss2Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CVSS vector %q: %w", vector, err)
+		}
+		score := cvss2BaseScore(metrics)
+		return &CVSS{Version: "2.0", Vector: vector, BaseScore: score, Rating: cvss2Rating(score)}, nil
+	}
+}
+
+// cvssMetric is a metric of a CVSS version, with the values it accepts. Required metrics are the base metrics.
+type cvssMetric struct {
+	values   []string
+	required bool
+}
+
+// cvssBase and cvssOptional return metrics accepting the given values, or the given letters when there is a single
+// argument.
+func cvssBase(values ...string) cvssMetric {
+	return cvssMetric{values: metricValues(values), required: true}
+}
+
+func cvssOptional(values ...string) cvssMetric {
+	return cvssMetric{values: metricValues(values)}
+}
+
+func metricValues(values []string) []string {
+	if len(values) == 1 {
+		return strings.Split(values[0], "")
+	}
+	return values
+}
+
+var cvss2Metrics = map[string]cvssMetric{
+	"AV": cvssBase("LAN"), "AC": cvssBase("HML"), "Au": cvssBase("MSN"),
+	"C": cvssBase("NPC"), "I": cvssBase("NPC"), "A": cvssBase("NPC"),
+	"E":   cvssOptional("U", "POC", "F", "H", "ND"),
+	"RL":  cvssOptional("OF", "TF", "W", "U", "ND"),
+	"RC":  cvssOptional("UC", "UR", "C", "ND"),
+	"CDP": cvssOptional("N", "L", "LM", "MH", "H", "ND"),
+	"TD":  cvssOptional("N", "L", "M", "H", "ND"),
+	"CR":  cvssOptional("L", "M", "H", "ND"),
+	"IR":  cvssOptional("L", "M", "H", "ND"),
+	"AR":  cvssOptional("L", "M", "H", "ND"),
+}
+
+var cvss3Metrics = map[string]cvssMetric{
+	"AV": cvssBase("NALP"), "AC": cvssBase("LH"), "PR": cvssBase("NLH"), "UI": cvssBase("NR"),
+	"S": cvssBase("UC"), "C": cvssBase("HLN"), "I": cvssBase("HLN"), "A": cvssBase("HLN"),
+	"E": cvssOptional("XUPFH"), "RL": cvssOptional("XOTWU"), "RC": cvssOptional("XURC"),
+	"CR": cvssOptional("XLMH"), "IR": cvssOptional("XLMH"), "AR": cvssOptional("XLMH"),
+	"MAV": cvssOptional("XNALP"), "MAC": cvssOptional("XLH"), "MPR": cvssOptional("XNLH"), "MUI": cvssOptional("XNR"),
+	"MS": cvssOptional("XUC"), "MC": cvssOptional("XHLN"), "MI": cvssOptional("XHLN"), "MA": cvssOptional("XHLN"),
+}
+
+var cvss4Metrics = map[string]cvssMetric{
+	"AV": cvssBase("NALP"), "AC": cvssBase("LH"), "AT": cvssBase("NP"), "PR": cvssBase("NLH"), "UI": cvssBase("NPA"),
+	"VC": cvssBase("HLN"), "VI": cvssBase("HLN"), "VA": cvssBase("HLN"),
+	"SC": cvssBase("HLN"), "SI": cvssBase("HLN"), "SA": cvssBase("HLN"),
+	"E": cvssOptional("XAPU"), "CR": cvssOptional("XHML"), "IR": cvssOptional("XHML"), "AR": cvssOptional("XHML"),
+	"MAV": cvssOptional("XNALP"), "MAC": cvssOptional("XLH"), "MAT": cvssOptional("XNP"), "MPR": cvssOptional("XNLH"),
+	"MUI": cvssOptional("XNPA"), "MVC": cvssOptional("XHLN"), "MVI": cvssOptional("XHLN"), "MVA": cvssOptional("XHLN"),
+	"MSC": cvssOptional("XHLN"), "MSI": cvssOptional("XSHLN"), "MSA": cvssOptional("XSHLN"),
+	"S": cvssOptional("XNP"), "AU": cvssOptional("XNY"), "R": cvssOptional("XAUI"), "V": cvssOptional("XDC"),
+	"RE": cvssOptional("XLMH"), "U": cvssOptional("X", "Clear", "Green", "Amber", "Red"),
+}
+
+// parseCVSSMetrics parses the metrics of a vector, such as AV:N/AC:L, checking that every base metric is given once
+// with a valid value.
+func parseCVSSMetrics(vector string, known map[string]cvssMetric) (map[string]string, error) {
+	metrics := map[string]string{}
+	for _, part := range strings.Split(vector, "/") {
+		name, value, ok := strings.Cut(part, ":")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("malformed metric %q", part)
+		}
+		metric, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown metric %s", name)
+		}
+		if !slices.Contains(metric.values, value) {
+			return nil, fmt.Errorf("invalid value %s for metric %s", value, name)
+		}
+		if _, ok := metrics[name]; ok {
+			return nil, fmt.Errorf("metric %s is given twice", name)
+		}
+		metrics[name] = value
+	}
+	for name, metric := range known {
+		if _, ok := metrics[name]; metric.required && !ok {
+			return nil, fmt.Errorf("missing metric %s", name)
+		}
+	}
+	return metrics, nil
+}
+
+// cvssRating returns the qualitative rating of a CVSS v3.x or v4.0 score.
+func cvssRating(score float64) string {
+	switch {
+	case score == 0:
+		return RatingNone
+	case score < 4:
+		return RatingLow
+	case score < 7:
+		return RatingMedium
+	case score < 9:
+		return RatingHigh
+	default:
+		return RatingCritical
+	}
+}
+
+// cvss2Rating returns the qualitative rating of a CVSS v2 score, as NVD gives them.
+func cvss2Rating(score float64) string {
+	switch {
+	case score < 4:
+		return RatingLow
+	case score < 7:
+		return RatingMedium
+	default:
+		return RatingHigh
+	}
+}
+
+// cvss2BaseScore computes a CVSS v2 base score, see https://www.first.org/cvss/v2/guide#3-2-1-Base-Equation.
+func cvss2BaseScore(m map[string]string) float64 {
+	impactWeight := map[string]float64{"N": 0, "P": 0.275, "C": 0.660}
+	impact := 10.41 * (1 - (1-impactWeight[m["C"]])*(1-impactWeight[m["I"]])*(1-impactWeight[m["A"]]))
+	exploitability := 20 *
+		map[string]float64{"L": 0.395, "A": 0.646, "N": 1}[m["AV"]] *
+		map[string]float64{"H": 0.35, "M": 0.61, "L": 0.71}[m["AC"]] *
+		map[string]float64{"M": 0.45, "S": 0.56, "N": 0.704}[m["Au"]]
+	if impact == 0 {
+		return 0
+	}
+	return math.Round(((0.6*impact)+(0.4*exploitability)-1.5)*1.176*10) / 10
+}
+
+// cvss3BaseScore computes a CVSS v3.x base score, see
+// https://www.first.org/cvss/v3.1/specification-document#7-1-Base-Metrics-Equations.
+func cvss3BaseScore(m map[string]string, version string) float64 {
+	impactWeight := map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+	iss := 1 - (1-impactWeight[m["C"]])*(1-impactWeight[m["I"]])*(1-impactWeight[m["A"]])
+	changed := m["S"] == "C"
+	var impact float64
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	privileges := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if changed {
+		privileges = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+	exploitability := 8.22 *
+		map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2}[m["AV"]] *
+		map[string]float64{"L": 0.77, "H": 0.44}[m["AC"]] *
+		privileges[m["PR"]] *
+		map[string]float64{"N": 0.85, "R": 0.62}[m["UI"]]
+	if impact <= 0 {
+		return 0
+	}
+	score := impact + exploitability
+	if changed {
+		score *= 1.08
+	}
+	return cvss3Roundup(math.Min(score, 10), version)
+}
+
+// cvss3Roundup rounds up to one decimal, avoiding the floating point errors v3.1 fixed.
+func cvss3Roundup(score float64, version string) float64 {
+	if version == "3.0" {
+		return math.Ceil(score*10) / 10
+	}
+	scaled := int64(math.Round(score * 100000))
+	if scaled%10000 == 0 {
+		return float64(scaled) / 100000
+	}
+	return float64(scaled/10000+1) / 10
+}
+
+// HighestCVSS returns the CVSS vector of an advisory, or of one of the packages it affects, with the highest rating,
+// and then the highest score. It returns nil when the advisory has no CVSS vector that parses.
+func HighestCVSS(vuln Vulnerability) *CVSS {
+	severities := vuln.Severity
+	for _, affected := range vuln.Affected {
+		severities = append(severities, affected.Severity...)
+	}
+	var highest *CVSS
+	for _, severity := range severities {
+		if severity.Type != SeverityTypeCVSSv2 && severity.Type != SeverityTypeCVSSv3 && severity.Type != SeverityTypeCVSSv4 {
+			continue
+		}
+		cvss, err := ParseCVSS(severity.Score)
+		if err != nil {
+			continue
+		}
+		if highest == nil || cvss.higherThan(highest) {
+			highest = cvss
+		}
+	}
+	return highest
+}
+
+// higherThan reports whether the vector has a higher rating than another one, or the same rating and a higher score.
+func (c *CVSS) higherThan(other *CVSS) bool {
+	if compared := graph.CompareFieldValues(graph.SeverityField, c.Rating, other.Rating); compared != 0 {
+		return compared > 0
+	}
+	return c.BaseScore > other.BaseScore
+}
+
+// updateSeverity sets the severity fields of a vuln node from the highest rated CVSS vector of the advisories it
+// represents, leaving out withdrawn ones. The fields are removed when none of them has a CVSS vector.
+func updateSeverity(storage graph.Storage, canonical string) error {
+	group, err := storage.GetCustomData(OSVAliasGroupsTag, canonical)
+	if err != nil {
+		return fmt.Errorf("failed to get the advisories of %s: %w", canonical, err)
+	}
+	ids := make([]string, 0, len(group))
+	for id := range group {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var highest *CVSS
+	for _, id := range ids {
+		advisory, err := getAdvisory(storage, id)
+		if err != nil {
+			return err
+		}
+		if advisory == nil || advisory.Withdrawn != "" {
+			continue
+		}
+		cvss := HighestCVSS(*advisory)
+		if cvss == nil {
+			continue
+		}
+		if highest == nil || cvss.higherThan(highest) {
+			highest = cvss
+		}
+	}
+	if highest == nil {
+		for _, key := range []string{graph.SeverityField, graph.CVSSScoreField, graph.CVSSVectorField} {
+			if err := graph.DeleteField(storage, canonical, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for key, value := range map[string]string{
+		graph.SeverityField:   highest.Rating,
+		graph.CVSSScoreField:  strconv.FormatFloat(highest.BaseScore, 'f', 1, 64),
+		graph.CVSSVectorField: highest.Vector,
+	} {
+		if err := graph.SetField(storage, canonical, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
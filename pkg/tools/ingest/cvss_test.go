@@ -0,0 +1,124 @@
+package ingest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCVSS(t *testing.T) {
+	tests := []struct {
+		vector  string
+		version string
+		score   float64
+		rating  string
+	}{
+		{"AV:N/AC:L/Au:N/C:C/I:C/A:C", "2.0", 10, RatingHigh},
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", "2.0", 7.5, RatingHigh},
+		{"(AV:N/AC:M/Au:N/C:N/I:P/A:N/E:POC/RL:OF/RC:C)", "2.0", 4.3, RatingMedium},
+		{"AV:L/AC:H/Au:M/C:N/I:N/A:N", "2.0", 0, RatingLow},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "3.1", 9.8, RatingCritical},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", "3.1", 10, RatingCritical},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", "3.1", 6.1, RatingMedium},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", "3.1", 7.5, RatingHigh},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H/E:P/RL:O", "3.1", 7.8, RatingHigh},
+		{"CVSS:3.0/AV:N/AC:H/PR:L/UI:N/S:U/C:L/I:N/A:N", "3.0", 3.1, RatingLow},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", "3.1", 0, RatingNone},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", "4.0", 9.3, RatingCritical},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H", "4.0", 10, RatingCritical},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", "4.0", 8.7, RatingHigh},
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", "4.0", 8.5, RatingHigh},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:H/SC:N/SI:N/SA:N", "4.0", 8.7, RatingHigh},
+		{"CVSS:4.0/AV:N/AC:H/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", "4.0", 9.2, RatingCritical},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:L/VI:N/VA:N/SC:N/SI:N/SA:N", "4.0", 6.9, RatingMedium},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:L/UI:P/VC:N/VI:N/VA:N/SC:L/SI:L/SA:N", "4.0", 5.1, RatingMedium},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", "4.0", 0, RatingNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			cvss, err := ParseCVSS(tt.vector)
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, cvss.Version)
+			assert.Equal(t, tt.score, cvss.BaseScore)
+			assert.Equal(t, tt.rating, cvss.Rating)
+		})
+	}
+}
+
+func TestParseCVSS_Errors(t *testing.T) {
+	for _, vector := range []string{
+		"",
+		"CVSS:2.0/AV:N/AC:L/Au:N/C:C/I:C/A:C",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/A:L",
+		"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/XX:Y",
+		"CVSS:4.0/AV:N/AC:L/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+		"AV:N/AC:L/Au:N/C:C/I:C",
+		"HIGH",
+	} {
+		_, err := ParseCVSS(vector)
+		assert.Error(t, err, vector)
+	}
+}
+
+func TestHighestCVSS(t *testing.T) {
+	vuln := Vulnerability{
+		Severity: []Severity{
+			{Type: SeverityTypeCVSSv2, Score: "AV:N/AC:L/Au:N/C:C/I:C/A:C"},
+			{Type: "Ubuntu", Score: "high"},
+			{Type: SeverityTypeCVSSv3, Score: "not a vector"},
+		},
+		Affected: []Affected{{Severity: []Severity{
+			{Type: SeverityTypeCVSSv3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+		}}},
+	}
+	// A critical rating beats the higher score of a CVSS v2 vector, which can only be rated high
+	highest := HighestCVSS(vuln)
+	require.NotNil(t, highest)
+	assert.Equal(t, "3.1", highest.Version)
+	assert.Equal(t, RatingCritical, highest.Rating)
+
+	assert.Nil(t, HighestCVSS(Vulnerability{Severity: []Severity{{Type: "Ubuntu", Score: "high"}}}))
+}
+
+func TestVulnerabilities_Severity(t *testing.T) {
+	storage, _, _ := setupDjangoGraph(t)
+	advisory := func(id, modified, withdrawn, vector string, aliases ...string) []byte {
+		var vuln Vulnerability
+		require.NoError(t, json.Unmarshal(osvAdvisory(id, modified, withdrawn, "4.2.1", aliases...), &vuln))
+		vuln.Severity = []Severity{{Type: SeverityTypeCVSSv3, Score: vector}}
+		data, err := json.Marshal(vuln)
+		require.NoError(t, err)
+		return data
+	}
+	fields := func() map[string]string {
+		t.Helper()
+		fields, err := graph.GetFields(storage, "GHSA-1")
+		require.NoError(t, err)
+		return fields
+	}
+
+	require.NoError(t, Vulnerabilities(storage, advisory("GHSA-1", "2024-01-01T00:00:00Z", "", "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", "CVE-2024-1")))
+	assert.Equal(t, map[string]string{
+		graph.SeverityField:   RatingMedium,
+		graph.CVSSScoreField:  "6.1",
+		graph.CVSSVectorField: "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N",
+	}, fields())
+
+	// The vuln node gets the highest rating of the advisories it represents
+	require.NoError(t, Vulnerabilities(storage, advisory("CVE-2024-1", "2024-01-01T00:00:00Z", "", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "GHSA-1")))
+	assert.Equal(t, RatingCritical, fields()[graph.SeverityField])
+	assert.Equal(t, "9.8", fields()[graph.CVSSScoreField])
+
+	// Until that advisory is withdrawn
+	require.NoError(t, Vulnerabilities(storage, advisory("CVE-2024-1", "2024-02-01T00:00:00Z", "2024-02-01T00:00:00Z", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "GHSA-1")))
+	assert.Equal(t, RatingMedium, fields()[graph.SeverityField])
+
+	// And it has none once the only scored advisory is withdrawn too
+	require.NoError(t, Vulnerabilities(storage, advisory("GHSA-1", "2024-03-01T00:00:00Z", "2024-03-01T00:00:00Z", "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", "CVE-2024-1")))
+	assert.Empty(t, fields())
+}
@@ -0,0 +1,241 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+)
+
+const (
+	// EPSSTag is the custom data tag keeping the EPSS scores of every CVE, so that vuln nodes ingested after them get
+	// them too.
+	EPSSTag = "epss"
+	// KEVTag is the custom data tag keeping the CISA Known Exploited Vulnerabilities catalog entries of every CVE.
+	KEVTag = "kev"
+)
+
+// enrichmentTags are the custom data tags holding node fields by advisory id.
+var enrichmentTags = []string{EPSSTag, KEVTag}
+
+// EPSS ingests the EPSS scores CSV published at https://www.first.org/epss/data_stats, gzipped or not, and returns
+// how many scores it holds. Each score sets the epss, epss_percentile and epss_date fields of the vuln node of the
+// CVE, which can be named after an alias of it.
+func EPSS(storage graph.Storage, data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, fmt.Errorf("data is empty")
+	}
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read gzipped EPSS scores: %w", err)
+		}
+		if data, err = io.ReadAll(reader); err != nil {
+			return 0, fmt.Errorf("failed to read gzipped EPSS scores: %w", err)
+		}
+	}
+
+	// The scores are preceded by a comment such as #model_version:v2023.03.01,score_date:2024-01-01T00:00:00+0000
+	buffered := bufio.NewReader(bytes.NewReader(data))
+	scoreDate := ""
+	if first, err := buffered.Peek(1); err == nil && first[0] == '#' {
+		comment, err := buffered.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("failed to read EPSS scores: %w", err)
+		}
+		for _, pair := range strings.Split(strings.TrimSpace(strings.TrimPrefix(comment, "#")), ",") {
+			if key, value, ok := strings.Cut(pair, ":"); ok && key == "score_date" {
+				scoreDate, _, _ = strings.Cut(value, "T")
+			}
+		}
+	}
+
+	reader := csv.NewReader(buffered)
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the EPSS header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"cve", "epss", "percentile"} {
+		if _, ok := columns[name]; !ok {
+			return 0, fmt.Errorf("not an EPSS scores CSV: no %s column", name)
+		}
+	}
+
+	count := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to read EPSS scores: %w", err)
+		}
+		cve, epss, percentile := record[columns["cve"]], record[columns["epss"]], record[columns["percentile"]]
+		for _, score := range []string{epss, percentile} {
+			if _, err := strconv.ParseFloat(score, 64); err != nil {
+				return count, fmt.Errorf("invalid EPSS score %q for %s", score, cve)
+			}
+		}
+		fields := map[string]string{graph.EPSSField: epss, graph.EPSSPercentileField: percentile}
+		if scoreDate != "" {
+			fields[graph.EPSSDateField] = scoreDate
+		}
+		if err := enrichVulnerability(storage, EPSSTag, cve, fields); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// KEV ingests the CISA Known Exploited Vulnerabilities catalog, the JSON published at
+// https://www.cisa.gov/known-exploited-vulnerabilities-catalog, and returns how many entries it holds. Each entry
+// sets the kev field of the vuln node of the CVE to true, along with kev_date_added, kev_due_date and
+// kev_ransomware. The catalog replaces the one ingested before it, so CVEs dropped from it lose their fields.
+func KEV(storage graph.Storage, data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, fmt.Errorf("data is empty")
+	}
+	var catalog struct {
+		CatalogVersion  string `json:"catalogVersion"`
+		Vulnerabilities []struct {
+			CVEID                      string `json:"cveID"`
+			DateAdded                  string `json:"dateAdded"`
+			DueDate                    string `json:"dueDate"`
+			KnownRansomwareCampaignUse string `json:"knownRansomwareCampaignUse"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal KEV catalog: %w", err)
+	}
+	if catalog.CatalogVersion == "" {
+		return 0, fmt.Errorf("not a KEV catalog: no catalogVersion")
+	}
+
+	for i, entry := range catalog.Vulnerabilities {
+		if entry.CVEID == "" {
+			return i, fmt.Errorf("KEV entry %d has no cveID", i)
+		}
+		fields := map[string]string{graph.KEVField: "true"}
+		for key, value := range map[string]string{
+			graph.KEVDateAddedField:  entry.DateAdded,
+			graph.KEVDueDateField:    entry.DueDate,
+			graph.KEVRansomwareField: entry.KnownRansomwareCampaignUse,
+		} {
+			if value != "" {
+				fields[key] = value
+			}
+		}
+		if err := enrichVulnerability(storage, KEVTag, entry.CVEID, fields); err != nil {
+			return i, err
+		}
+	}
+
+	listed := make(map[string]bool, len(catalog.Vulnerabilities))
+	for _, entry := range catalog.Vulnerabilities {
+		listed[entry.CVEID] = true
+	}
+	if err := removeKEVEntries(storage, listed); err != nil {
+		return len(catalog.Vulnerabilities), err
+	}
+	return len(catalog.Vulnerabilities), nil
+}
+
+// removeKEVEntries removes the recorded KEV entries of the CVEs that aren't listed, along with the KEV fields of their
+// vuln nodes, unless a listed alias of them shares the node.
+func removeKEVEntries(storage graph.Storage, listed map[string]bool) error {
+	keys, err := storage.GetCustomDataKeys()
+	if err != nil {
+		return fmt.Errorf("failed to get custom data keys: %w", err)
+	}
+	keptNodes := map[string]bool{}
+	for id := range listed {
+		canonical, err := canonicalVulnerabilityName(storage, id)
+		if err != nil {
+			return err
+		}
+		keptNodes[canonical] = true
+	}
+
+	for _, key := range keys {
+		if key.Tag != KEVTag || listed[key.Key] {
+			continue
+		}
+		entry, err := storage.GetCustomData(KEVTag, key.Key)
+		if err != nil {
+			return fmt.Errorf("failed to get the KEV entry of %s: %w", key.Key, err)
+		}
+		for field := range entry {
+			if err := storage.DeleteCustomData(KEVTag, key.Key, field); err != nil {
+				return fmt.Errorf("failed to remove %s of %s: %w", field, key.Key, err)
+			}
+		}
+
+		canonical, err := canonicalVulnerabilityName(storage, key.Key)
+		if err != nil {
+			return err
+		}
+		if keptNodes[canonical] {
+			continue
+		}
+		for _, field := range []string{graph.KEVField, graph.KEVDateAddedField, graph.KEVDueDateField, graph.KEVRansomwareField} {
+			if err := graph.DeleteField(storage, canonical, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// enrichVulnerability records the fields of an advisory id, and sets them on its vuln node when the advisory, or one
+// of its aliases, was already ingested. Advisories ingested later get them from applyEnrichment.
+func enrichVulnerability(storage graph.Storage, tag, id string, fields map[string]string) error {
+	for key, value := range fields {
+		if err := storage.AddOrUpdateCustomData(tag, id, key, []byte(value)); err != nil {
+			return fmt.Errorf("failed to record %s of %s: %w", key, id, err)
+		}
+	}
+	canonical, err := canonicalVulnerabilityName(storage, id)
+	if err != nil {
+		return err
+	}
+	if _, err := storage.NameToID(canonical); err != nil {
+		return nil
+	}
+	for key, value := range fields {
+		if err := graph.SetField(storage, canonical, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyEnrichment sets the fields recorded for an advisory and its aliases on its vuln node.
+func applyEnrichment(storage graph.Storage, canonical string, ids []string) error {
+	for _, tag := range enrichmentTags {
+		for _, id := range ids {
+			fields, err := storage.GetCustomData(tag, id)
+			if err != nil {
+				return fmt.Errorf("failed to get the %s data of %s: %w", tag, id, err)
+			}
+			for key, value := range fields {
+				if err := graph.SetField(storage, canonical, key, string(value)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package ingest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEPSS(t *testing.T) {
+	storage, _, _ := setupDjangoGraph(t)
+	// The advisory is ingested before the scores, and named after its GHSA
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("GHSA-1", "2024-01-01T00:00:00Z", "", "4.2.1", "CVE-2022-32149")))
+
+	data, err := os.ReadFile("../../../testdata/epss/epss_scores.csv")
+	require.NoError(t, err)
+	count, err := EPSS(storage, data)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	fields, err := graph.GetFields(storage, "GHSA-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		graph.EPSSField:           "0.00181",
+		graph.EPSSPercentileField: "0.55673",
+		graph.EPSSDateField:       "2024-06-01",
+	}, fields)
+
+	// Gzipped scores, as they are published, are ingested the same
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	_, err = writer.Write(bytes.ReplaceAll(data, []byte("0.00181"), []byte("0.00200")))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	_, err = EPSS(storage, gzipped.Bytes())
+	require.NoError(t, err)
+	fields, err = graph.GetFields(storage, "GHSA-1")
+	require.NoError(t, err)
+	assert.Equal(t, "0.00200", fields[graph.EPSSField])
+}
+
+func TestKEV(t *testing.T) {
+	storage, _, _ := setupDjangoGraph(t)
+	data, err := os.ReadFile("../../../testdata/kev/known_exploited_vulnerabilities.json")
+	require.NoError(t, err)
+	count, err := KEV(storage, data)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// The advisory is ingested after the catalog, and gets its entry through its alias
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("GHSA-1", "2024-01-01T00:00:00Z", "", "4.2.1", "CVE-2021-44228")))
+	fields, err := graph.GetFields(storage, "GHSA-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		graph.KEVField:           "true",
+		graph.KEVDateAddedField:  "2021-12-10",
+		graph.KEVDueDateField:    "2021-12-24",
+		graph.KEVRansomwareField: "Known",
+	}, fields)
+
+	fields, err = graph.GetFields(storage, "CVE-2023-44487")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	// A later catalog without the CVE takes its entry away
+	count, err = KEV(storage, []byte(`{"catalogVersion": "2024.07.01", "vulnerabilities": [{"cveID": "CVE-2023-44487", "dateAdded": "2023-10-10"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	fields, err = graph.GetFields(storage, "GHSA-1")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+	entry, err := storage.GetCustomData(KEVTag, "CVE-2021-44228")
+	require.NoError(t, err)
+	assert.Empty(t, entry)
+}
+
+func TestEnrichment_Errors(t *testing.T) {
+	storage := graph.NewMockStorage()
+	for name, data := range map[string]string{
+		"empty":          "",
+		"no header":      "#model_version:v2023.03.01\n",
+		"missing column": "cve,score\nCVE-2024-1,0.5\n",
+		"invalid score":  "cve,epss,percentile\nCVE-2024-1,high,0.5\n",
+	} {
+		_, err := EPSS(storage, []byte(data))
+		assert.Error(t, err, name)
+	}
+	for name, data := range map[string]string{
+		"empty":       "",
+		"not json":    "{",
+		"not catalog": `{"vulnerabilities": []}`,
+		"no cve":      `{"catalogVersion": "2024.06.01", "vulnerabilities": [{"dateAdded": "2024-06-01"}]}`,
+	} {
+		_, err := KEV(storage, []byte(data))
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,100 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/package-url/packageurl-go"
+)
+
+// ReachableAttribute is the edge attribute telling whether govulncheck found the vulnerable symbols of the
+// vulnerability an edge points to being called, "true" or "false", through the module the edge starts from.
+const ReachableAttribute = "reachable"
+
+// govulncheckMessage is one of the messages `govulncheck -json` streams, see
+// https://pkg.go.dev/golang.org/x/vuln/internal/govulncheck.
+type govulncheckMessage struct {
+	Config  json.RawMessage `json:"config"`
+	OSV     json.RawMessage `json:"osv"`
+	Finding *struct {
+		OSV   string `json:"osv"`
+		Trace []struct {
+			Module   string `json:"module"`
+			Version  string `json:"version"`
+			Package  string `json:"package"`
+			Function string `json:"function"`
+		} `json:"trace"`
+	} `json:"finding"`
+}
+
+// Govulncheck ingests the output of `govulncheck -json`. The advisories it carries are ingested like OSV
+// advisories, and the edges from the modules it found vulnerable get a ReachableAttribute: "true" when a vulnerable
+// symbol is called, "false" when the vulnerable code is only required or imported. Query filters can then keep the
+// reachable vulnerabilities with `where edge.reachable = true`.
+func Govulncheck(storage graph.Storage, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("data is empty")
+	}
+
+	var advisories [][]byte
+	reachable := map[SBOMEdge]bool{}
+	configured := false
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var message govulncheckMessage
+		if err := decoder.Decode(&message); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to unmarshal govulncheck output: %w", err)
+		}
+		if message.Config != nil {
+			configured = true
+		}
+		if message.OSV != nil {
+			advisories = append(advisories, message.OSV)
+		}
+		// The first frame of a trace is the vulnerable code, and only has a function when it is called
+		if finding := message.Finding; finding != nil && len(finding.Trace) > 0 && finding.Trace[0].Module != "" {
+			frame := finding.Trace[0]
+			module := newLockfilePackage(packageurl.TypeGolang, frame.Module, frame.Version, nil)
+			edge := SBOMEdge{From: module.PURL, To: finding.OSV}
+			reachable[edge] = reachable[edge] || frame.Function != ""
+		}
+	}
+	if !configured {
+		return fmt.Errorf("not govulncheck -json output: no config message")
+	}
+
+	if len(advisories) > 0 {
+		if err := BulkVulnerabilities(storage, advisories); err != nil {
+			return fmt.Errorf("failed to ingest govulncheck advisories: %w", err)
+		}
+	}
+
+	edges := make([]SBOMEdge, 0, len(reachable))
+	for edge := range reachable {
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	for _, edge := range edges {
+		vulnName, err := canonicalVulnerabilityName(storage, edge.To)
+		if err != nil {
+			return err
+		}
+		if err := graph.SetEdgeAttribute(storage, edge.From, vulnName, ReachableAttribute, strconv.FormatBool(reachable[edge])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package ingest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGovulncheck(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, GoModGraph(storage, []byte(`example.com/app golang.org/x/net@v0.10.0
+example.com/app golang.org/x/text@v0.3.7
+golang.org/x/net@v0.10.0 golang.org/x/text@v0.3.7
+`)))
+	data, err := os.ReadFile("../../../testdata/govulncheck/govulncheck.json")
+	require.NoError(t, err)
+	require.NoError(t, Govulncheck(storage, data))
+
+	textID, err := storage.NameToID("pkg:golang/golang.org/x/text@v0.3.7")
+	require.NoError(t, err)
+	netID, err := storage.NameToID("pkg:golang/golang.org/x/net@v0.10.0")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{textID}, vulnerabilityParents(t, storage, "GO-2022-1059"))
+	assert.Equal(t, []uint32{netID}, vulnerabilityParents(t, storage, "GO-2023-2102"))
+
+	// A called symbol makes the vulnerability reachable, even when other findings only import its package
+	attributes, err := graph.GetEdgeAttributes(storage, "GO-2022-1059")
+	require.NoError(t, err)
+	assert.Equal(t, "true", attributes["pkg:golang/golang.org/x/text@v0.3.7"][ReachableAttribute])
+	attributes, err = graph.GetEdgeAttributes(storage, "GO-2023-2102")
+	require.NoError(t, err)
+	assert.Equal(t, "false", attributes["pkg:golang/golang.org/x/net@v0.10.0"][ReachableAttribute])
+}
+
+func TestGovulncheck_Errors(t *testing.T) {
+	storage := graph.NewMockStorage()
+	assert.Error(t, Govulncheck(storage, nil))
+	assert.Error(t, Govulncheck(storage, []byte(`{"config": {}`)))
+	assert.Error(t, Govulncheck(storage, []byte(`{"progress": {"message": "Scanning..."}}`)))
+}
@@ -0,0 +1,130 @@
+package ingest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/protobom/protobom/pkg/sbom"
+)
+
+// DigestsTag is the custom data tag indexing the nodes of SBOM components by the SHA-256 and SHA-512 digests the
+// SBOMs give for them, keyed by digest, as in sha256:<hex>, and then by node name. The same artifact described by
+// two tools can get two nodes, because the tools disagree on its package URL, and share a digest.
+const DigestsTag = "digests"
+
+// indexedHashAlgorithms are the hash algorithms of the digests identifying components, by their name in digests.
+var indexedHashAlgorithms = map[sbom.HashAlgorithm]string{
+	sbom.HashAlgorithm_SHA256: "sha256",
+	sbom.HashAlgorithm_SHA512: "sha512",
+}
+
+// DuplicateGroup is a set of nodes with the same digest, which likely describe the same artifact.
+type DuplicateGroup struct {
+	// Digest is the shared digest, as in sha256:<hex>.
+	Digest string `json:"digest"`
+	// Nodes are the names of the nodes, sorted, including the artifact node of the digest when there is one.
+	Nodes []string `json:"nodes"`
+}
+
+// digestKey returns the key of a digest in the index.
+func digestKey(algorithm, value string) string {
+	return algorithm + ":" + strings.ToLower(value)
+}
+
+// indexDigests records the SHA-256 and SHA-512 digests of an SBOM component, for the node with the given name.
+func indexDigests(storage graph.Storage, node *sbom.Node, name string) error {
+	for algorithm, value := range node.GetHashes() {
+		algorithmName, ok := indexedHashAlgorithms[sbom.HashAlgorithm(algorithm)]
+		if !ok || value == "" {
+			continue
+		}
+		if err := storage.AddOrUpdateCustomData(DigestsTag, digestKey(algorithmName, value), name, nil); err != nil {
+			return fmt.Errorf("failed to index the digest of %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// FindDuplicates returns the groups of nodes that share a digest, sorted by digest. The artifact node of a digest,
+// added for the subjects of attestations, counts as one of the nodes, since it names the same content.
+func FindDuplicates(storage graph.Storage) ([]DuplicateGroup, error) {
+	keys, err := storage.GetCustomDataKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get custom data keys: %w", err)
+	}
+
+	var groups []DuplicateGroup
+	for _, key := range keys {
+		if key.Tag != DigestsTag {
+			continue
+		}
+		data, err := storage.GetCustomData(DigestsTag, key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the nodes with digest %s: %w", key.Key, err)
+		}
+		candidates := make([]string, 0, len(data)+1)
+		for name := range data {
+			candidates = append(candidates, name)
+		}
+		algorithm, value, _ := strings.Cut(key.Key, ":")
+		candidates = append(candidates, ArtifactNodeName(map[string]string{algorithm: value}))
+
+		// Nodes removed since their SBOM was ingested are left out
+		var nodes []string
+		for _, name := range candidates {
+			if _, err := storage.NameToID(name); err == nil {
+				nodes = append(nodes, name)
+			}
+		}
+		if len(nodes) < 2 {
+			continue
+		}
+		sort.Strings(nodes)
+		groups = append(groups, DuplicateGroup{Digest: key.Key, Nodes: nodes})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Digest < groups[j].Digest
+	})
+	return groups, nil
+}
+
+// LinkDuplicates resolves the identity of the nodes of each group by linking them to the artifact node of their
+// digest, added when needed, so that `dependents library artifact:sha256:<hex>` lists every name of an artifact.
+// Nodes aren't merged, since the SBOMs, vulnerabilities and annotations of each are kept apart. It returns the number
+// of nodes linked.
+func LinkDuplicates(storage graph.Storage, groups []DuplicateGroup) (int, error) {
+	linked := 0
+	for _, group := range groups {
+		algorithm, value, _ := strings.Cut(group.Digest, ":")
+		digest := map[string]string{algorithm: value}
+		artifactName := ArtifactNodeName(digest)
+		artifactNode, err := graph.AddNode(storage, tools.ArtifactType, Artifact{Digest: digest}, artifactName)
+		if err != nil {
+			return linked, fmt.Errorf("failed to add artifact node %s: %w", artifactName, err)
+		}
+		for _, name := range group.Nodes {
+			if name == artifactName {
+				continue
+			}
+			id, err := storage.NameToID(name)
+			if err != nil {
+				return linked, fmt.Errorf("failed to find node %s: %w", name, err)
+			}
+			node, err := storage.GetNode(id)
+			if err != nil {
+				return linked, fmt.Errorf("failed to get node %s: %w", name, err)
+			}
+			if node.Children.Contains(artifactNode.ID) {
+				continue
+			}
+			if err := node.SetDependency(storage, artifactNode); err != nil {
+				return linked, fmt.Errorf("failed to add edge %s -> %s: %w", name, artifactName, err)
+			}
+			linked++
+		}
+	}
+	return linked, nil
+}
@@ -0,0 +1,86 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDuplicateDigest = "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+
+// testHashedSBOM returns a CycloneDX document with a single component with the given package URL and SHA-256 digest.
+func testHashedSBOM(serialNumber, purl, sha256 string) []byte {
+	return []byte(fmt.Sprintf(`{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "serialNumber": "urn:uuid:%s",
+  "version": 1,
+  "components": [
+    {"bom-ref": "c", "type": "library", "name": "commons-text", "version": "1.10.0", "purl": %q,
+     "hashes": [{"alg": "SHA-256", "content": %q}, {"alg": "MD5", "content": "0cc175b9c0f1b6a831c399e269772661"}]}
+  ]
+}`, serialNumber, purl, sha256))
+}
+
+func TestFindDuplicates(t *testing.T) {
+	storage := graph.NewMockStorage()
+	// Two tools describing the same jar with different package URLs, and an unrelated one
+	require.NoError(t, SBOM(storage, testHashedSBOM("00000000-0000-0000-0000-000000000001", "pkg:maven/org.apache.commons/commons-text@1.10.0", testDuplicateDigest)))
+	require.NoError(t, SBOM(storage, testHashedSBOM("00000000-0000-0000-0000-000000000002", "pkg:maven/org.apache.commons/commons-text@1.10.0?type=jar", testDuplicateDigest)))
+	require.NoError(t, SBOM(storage, testHashedSBOM("00000000-0000-0000-0000-000000000003", "pkg:maven/org.apache.commons/commons-lang3@3.12.0", "ffff"+testDuplicateDigest[4:])))
+
+	groups, err := FindDuplicates(storage)
+	require.NoError(t, err)
+	assert.Equal(t, []DuplicateGroup{{
+		Digest: "sha256:" + testDuplicateDigest,
+		Nodes: []string{
+			"pkg:maven/org.apache.commons/commons-text@1.10.0",
+			"pkg:maven/org.apache.commons/commons-text@1.10.0?type=jar",
+		},
+	}}, groups)
+
+	linked, err := LinkDuplicates(storage, groups)
+	require.NoError(t, err)
+	assert.Equal(t, 2, linked)
+	artifactID, err := storage.NameToID("artifact:sha256:" + testDuplicateDigest)
+	require.NoError(t, err)
+	artifact, err := storage.GetNode(artifactID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), artifact.Parents.GetCardinality())
+
+	// The artifact node is part of the group from then on, and linking again changes nothing
+	groups, err = FindDuplicates(storage)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Contains(t, groups[0].Nodes, "artifact:sha256:"+testDuplicateDigest)
+	linked, err = LinkDuplicates(storage, groups)
+	require.NoError(t, err)
+	assert.Equal(t, 0, linked)
+}
+
+func TestFindDuplicates_Artifact(t *testing.T) {
+	storage := graph.NewMockStorage()
+	// A component with the digest of an attested artifact is a candidate duplicate of it
+	require.NoError(t, SBOM(storage, testHashedSBOM("00000000-0000-0000-0000-000000000001", "pkg:generic/hello-world@1.0.0", testLinuxArtifact[len("artifact:sha256:"):])))
+	groups, err := FindDuplicates(storage)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	_, err = Attestation(storage, []byte(mustJSON(t, testEnvelope(t, testProvenance("1")))))
+	require.NoError(t, err)
+	groups, err = FindDuplicates(storage)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, []string{testLinuxArtifact, "pkg:generic/hello-world@1.0.0"}, groups[0].Nodes)
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,332 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+)
+
+const (
+	// LicenseSourceAttribute is the edge attribute telling where the license a library depends on comes from: the
+	// licenses the library declares, the license concluded for it, or both, as "concluded,declared".
+	LicenseSourceAttribute = "license_source"
+	// LicenseExpressionAttribute is the edge attribute holding the SPDX expression that applies to the library, which
+	// license compliance checks evaluate, since a library licensed "MIT OR GPL-3.0-only" doesn't require GPL-3.0-only.
+	LicenseExpressionAttribute = "license_expression"
+
+	LicenseSourceDeclared  = "declared"
+	LicenseSourceConcluded = "concluded"
+)
+
+// License is the metadata of a license node.
+type License struct {
+	// ID is the SPDX license identifier, with its exception when it has one, such as
+	// "GPL-2.0-only WITH Classpath-exception-2.0", or a LicenseRef.
+	ID string `json:"id"`
+}
+
+// LicenseNodeName returns the name of the node of a license.
+func LicenseNodeName(id string) string {
+	return "license:" + id
+}
+
+// LicenseExpression is a parsed SPDX license expression, see
+// https://spdx.github.io/spdx-spec/v2.3/SPDX-license-expressions/.
+type LicenseExpression struct {
+	// Operator is "AND" or "OR" for compound expressions and empty for a single license.
+	Operator string
+	// License is the license of a single license expression, with its exception when it has one.
+	License  string
+	Operands []*LicenseExpression
+}
+
+// ParseLicenseExpression parses an SPDX license expression. Operators are matched case-insensitively, WITH binds
+// tighter than AND, which binds tighter than OR.
+func ParseLicenseExpression(expression string) (*LicenseExpression, error) {
+	tokens := strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expression))
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("license expression is empty")
+	}
+	p := &licenseParser{tokens: tokens}
+	parsed, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid license expression %q: %w", expression, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid license expression %q: unexpected %q", expression, p.tokens[p.pos])
+	}
+	return parsed, nil
+}
+
+type licenseParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *licenseParser) peek(operator string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], operator)
+}
+
+func (p *licenseParser) parseOr() (*LicenseExpression, error) {
+	return p.parseCompound("OR", p.parseAnd)
+}
+
+func (p *licenseParser) parseAnd() (*LicenseExpression, error) {
+	return p.parseCompound("AND", p.parseSimple)
+}
+
+func (p *licenseParser) parseCompound(operator string, operand func() (*LicenseExpression, error)) (*LicenseExpression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*LicenseExpression{first}
+	for p.peek(operator) {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &LicenseExpression{Operator: operator, Operands: operands}, nil
+}
+
+func (p *licenseParser) parseSimple() (*LicenseExpression, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch {
+	case token == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return inner, nil
+	case token == ")" || strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH"):
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+
+	license := token
+	if p.peek("WITH") {
+		p.pos++
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] == "(" || p.tokens[p.pos] == ")" {
+			return nil, fmt.Errorf("missing exception after WITH")
+		}
+		license += " WITH " + p.tokens[p.pos]
+		p.pos++
+	}
+	return &LicenseExpression{License: license}, nil
+}
+
+// Licenses returns the licenses the expression mentions, sorted.
+func (e *LicenseExpression) Licenses() []string {
+	var licenses []string
+	var collect func(e *LicenseExpression)
+	collect = func(e *LicenseExpression) {
+		if e.Operator == "" {
+			licenses = append(licenses, e.License)
+			return
+		}
+		for _, operand := range e.Operands {
+			collect(operand)
+		}
+	}
+	collect(e)
+	return uniqueSorted(licenses)
+}
+
+// Satisfiable reports whether the expression can be complied with using only the licenses allowed accepts.
+func (e *LicenseExpression) Satisfiable(allowed func(license string) bool) bool {
+	switch e.Operator {
+	case "AND":
+		for _, operand := range e.Operands {
+			if !operand.Satisfiable(allowed) {
+				return false
+			}
+		}
+		return true
+	case "OR":
+		for _, operand := range e.Operands {
+			if operand.Satisfiable(allowed) {
+				return true
+			}
+		}
+		return false
+	default:
+		return allowed(e.License)
+	}
+}
+
+// isLicenseAsserted reports whether an SPDX license field holds a license, rather than being empty, NOASSERTION or
+// NONE.
+func isLicenseAsserted(expression string) bool {
+	expression = strings.TrimSpace(expression)
+	return expression != "" && !strings.EqualFold(expression, "NOASSERTION") && !strings.EqualFold(expression, "NONE")
+}
+
+// effectiveLicenseExpression returns the expression that applies to a library: the concluded one when there is one,
+// and the declared ones together otherwise.
+func effectiveLicenseExpression(declared []string, concluded string) string {
+	if isLicenseAsserted(concluded) {
+		return strings.TrimSpace(concluded)
+	}
+	var asserted []string
+	for _, expression := range declared {
+		if isLicenseAsserted(expression) {
+			asserted = append(asserted, strings.TrimSpace(expression))
+		}
+	}
+	if len(asserted) == 1 {
+		return asserted[0]
+	}
+	for i, expression := range asserted {
+		asserted[i] = "(" + expression + ")"
+	}
+	return strings.Join(asserted, " AND ")
+}
+
+// expressionLicenses returns the licenses an expression mentions. An expression that doesn't parse is kept whole as
+// one license, so it still shows up in the graph.
+func expressionLicenses(expression string) []string {
+	parsed, err := ParseLicenseExpression(expression)
+	if err != nil {
+		return []string{strings.TrimSpace(expression)}
+	}
+	return parsed.Licenses()
+}
+
+// componentLicenses are the license expressions of an SBOM component.
+type componentLicenses struct {
+	declared  []string
+	concluded string
+}
+
+// cycloneDXLicenses returns the licenses of the components of a CycloneDX JSON document by bom-ref. protobom keeps
+// only the first license of a component and reports it as concluded, while components can list several and, since
+// CycloneDX 1.6, acknowledge the ones that were concluded rather than declared.
+func cycloneDXLicenses(data []byte) map[string]componentLicenses {
+	type cdxLicenseChoice struct {
+		License *struct {
+			ID              string `json:"id"`
+			Name            string `json:"name"`
+			Acknowledgement string `json:"acknowledgement"`
+		} `json:"license"`
+		Expression      string `json:"expression"`
+		Acknowledgement string `json:"acknowledgement"`
+	}
+	type cdxComponent struct {
+		BOMRef     string             `json:"bom-ref"`
+		Licenses   []cdxLicenseChoice `json:"licenses"`
+		Components []cdxComponent     `json:"components"`
+	}
+	var bom struct {
+		BOMFormat string `json:"bomFormat"`
+		Metadata  struct {
+			Component *cdxComponent `json:"component"`
+		} `json:"metadata"`
+		Components []cdxComponent `json:"components"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil || bom.BOMFormat != "CycloneDX" {
+		return nil
+	}
+
+	licenses := map[string]componentLicenses{}
+	var collect func(components []cdxComponent)
+	collect = func(components []cdxComponent) {
+		for _, component := range components {
+			collect(component.Components)
+			if component.BOMRef == "" || len(component.Licenses) == 0 {
+				continue
+			}
+			var declared, concluded []string
+			for _, choice := range component.Licenses {
+				expression, acknowledgement := choice.Expression, choice.Acknowledgement
+				if choice.License != nil {
+					expression, acknowledgement = choice.License.ID, choice.License.Acknowledgement
+					if expression == "" {
+						expression = choice.License.Name
+					}
+				}
+				if !isLicenseAsserted(expression) {
+					continue
+				}
+				if acknowledgement == LicenseSourceConcluded {
+					concluded = append(concluded, expression)
+				} else {
+					declared = append(declared, expression)
+				}
+			}
+			licenses[component.BOMRef] = componentLicenses{
+				declared:  declared,
+				concluded: effectiveLicenseExpression(concluded, ""),
+			}
+		}
+	}
+	if bom.Metadata.Component != nil {
+		collect([]cdxComponent{*bom.Metadata.Component})
+	}
+	collect(bom.Components)
+	return licenses
+}
+
+// addLicenses adds the license nodes of the declared and concluded license expressions of a library, with edges
+// from the library, and returns the edges.
+func addLicenses(storage graph.Storage, library *graph.Node, declared []string, concluded string) ([]SBOMEdge, error) {
+	sources := map[string][]string{}
+	for _, expression := range declared {
+		if isLicenseAsserted(expression) {
+			for _, license := range expressionLicenses(expression) {
+				sources[license] = append(sources[license], LicenseSourceDeclared)
+			}
+		}
+	}
+	if isLicenseAsserted(concluded) {
+		for _, license := range expressionLicenses(concluded) {
+			sources[license] = append(sources[license], LicenseSourceConcluded)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	effective := effectiveLicenseExpression(declared, concluded)
+
+	licenses := make([]string, 0, len(sources))
+	for license := range sources {
+		licenses = append(licenses, license)
+	}
+	sort.Strings(licenses)
+
+	edges := make([]SBOMEdge, 0, len(licenses))
+	for _, license := range licenses {
+		name := LicenseNodeName(license)
+		licenseNode, err := graph.AddNode(storage, tools.LicenseType, License{ID: license}, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add license node %s: %w", name, err)
+		}
+		if err := library.SetDependency(storage, licenseNode); err != nil {
+			return nil, fmt.Errorf("failed to add edge %s -> %s: %w", library.Name, name, err)
+		}
+		if err := graph.SetEdgeAttribute(storage, library.Name, name, LicenseSourceAttribute, strings.Join(uniqueSorted(sources[license]), ",")); err != nil {
+			return nil, err
+		}
+		if err := graph.SetEdgeAttribute(storage, library.Name, name, LicenseExpressionAttribute, effective); err != nil {
+			return nil, err
+		}
+		edges = append(edges, SBOMEdge{From: library.Name, To: name})
+	}
+	return edges, nil
+}
@@ -0,0 +1,133 @@
+package ingest
+
+import (
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLicenseExpression(t *testing.T) {
+	tests := []struct {
+		expression string
+		licenses   []string
+	}{
+		{"MIT", []string{"MIT"}},
+		{"MIT OR Apache-2.0", []string{"Apache-2.0", "MIT"}},
+		{"(MIT or Apache-2.0) AND BSD-3-Clause", []string{"Apache-2.0", "BSD-3-Clause", "MIT"}},
+		{"GPL-2.0-only WITH Classpath-exception-2.0 OR MIT", []string{"GPL-2.0-only WITH Classpath-exception-2.0", "MIT"}},
+		{"((LicenseRef-custom))", []string{"LicenseRef-custom"}},
+		{"GPL-2.0+ AND MIT AND MIT", []string{"GPL-2.0+", "MIT"}},
+	}
+	for _, tt := range tests {
+		parsed, err := ParseLicenseExpression(tt.expression)
+		require.NoError(t, err, tt.expression)
+		assert.Equal(t, tt.licenses, parsed.Licenses(), tt.expression)
+	}
+
+	for _, expression := range []string{"", "MIT OR", "(MIT", "MIT)", "AND MIT", "MIT WITH", "MIT Apache-2.0"} {
+		_, err := ParseLicenseExpression(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestLicenseExpression_Satisfiable(t *testing.T) {
+	denyGPL := func(license string) bool { return license != "GPL-3.0-only" }
+	tests := map[string]bool{
+		"MIT":                                  true,
+		"GPL-3.0-only":                         false,
+		"MIT OR GPL-3.0-only":                  true,
+		"MIT AND GPL-3.0-only":                 false,
+		"(MIT AND GPL-3.0-only) OR Apache-2.0": true,
+		"MIT AND (GPL-3.0-only OR Apache-2.0)": true,
+		"GPL-3.0-only WITH GCC-exception-3.1":  true,
+	}
+	for expression, satisfiable := range tests {
+		parsed, err := ParseLicenseExpression(expression)
+		require.NoError(t, err)
+		assert.Equal(t, satisfiable, parsed.Satisfiable(denyGPL), expression)
+	}
+}
+
+const testLicensedCycloneDXSBOM = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "serialNumber": "urn:uuid:4e671687-395b-41f5-a30f-a58921a69b79",
+  "version": 1,
+  "metadata": {
+    "component": {"bom-ref": "app", "type": "application", "name": "app", "purl": "pkg:npm/app@1.0.0", "licenses": [{"license": {"id": "MIT"}}]}
+  },
+  "components": [
+    {"bom-ref": "a", "type": "library", "name": "a", "version": "1.0.0", "purl": "pkg:npm/a@1.0.0",
+     "licenses": [{"expression": "MIT OR GPL-3.0-only"}]},
+    {"bom-ref": "b", "type": "library", "name": "b", "version": "1.0.0", "purl": "pkg:npm/b@1.0.0",
+     "licenses": [{"license": {"id": "Apache-2.0"}}, {"license": {"id": "GPL-3.0-only", "acknowledgement": "concluded"}}]},
+    {"bom-ref": "c", "type": "library", "name": "c", "version": "1.0.0", "purl": "pkg:npm/c@1.0.0",
+     "licenses": [{"license": {"name": "NOASSERTION"}}]}
+  ],
+  "dependencies": [
+    {"ref": "app", "dependsOn": ["a"]},
+    {"ref": "a", "dependsOn": ["b", "c"]}
+  ]
+}`
+
+func TestSBOM_Licenses(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, SBOM(storage, []byte(testLicensedCycloneDXSBOM)))
+
+	licenses, err := storage.GetNodesByGlob("license:*")
+	require.NoError(t, err)
+	names := map[string]*graph.Node{}
+	for _, license := range licenses {
+		assert.Equal(t, tools.LicenseType, license.Type)
+		names[license.Name] = license
+	}
+	require.ElementsMatch(t, []string{"license:Apache-2.0", "license:GPL-3.0-only", "license:MIT"}, keysOf(names))
+
+	gplAttributes, err := graph.GetEdgeAttributes(storage, "license:GPL-3.0-only")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"pkg:npm/a@1.0.0": {LicenseSourceAttribute: LicenseSourceDeclared, LicenseExpressionAttribute: "MIT OR GPL-3.0-only"},
+		"pkg:npm/b@1.0.0": {LicenseSourceAttribute: LicenseSourceConcluded, LicenseExpressionAttribute: "GPL-3.0-only"},
+	}, gplAttributes)
+
+	cID, err := storage.NameToID("pkg:npm/c@1.0.0")
+	require.NoError(t, err)
+	c, err := storage.GetNode(cID)
+	require.NoError(t, err)
+	assert.True(t, c.Children.IsEmpty(), "NOASSERTION isn't a license")
+
+	// The licenses in the dependencies of the app can be queried
+	require.NoError(t, graph.Cache(storage))
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	nodes, err := storage.GetNodes(keys)
+	require.NoError(t, err)
+	caches, err := storage.GetCaches(keys)
+	require.NoError(t, err)
+	result, err := graph.ParseAndExecute("dependencies license pkg:npm/app@1.0.0", storage, "", nodes, caches, true)
+	require.NoError(t, err)
+	expected := roaring.New()
+	for _, license := range names {
+		expected.Add(license.ID)
+	}
+	assert.Equal(t, expected.ToArray(), result.ToArray())
+}
+
+func keysOf[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestEffectiveLicenseExpression(t *testing.T) {
+	assert.Equal(t, "MIT", effectiveLicenseExpression([]string{"Apache-2.0"}, "MIT"))
+	assert.Equal(t, "Apache-2.0", effectiveLicenseExpression([]string{"Apache-2.0"}, "NOASSERTION"))
+	assert.Equal(t, "(MIT OR Apache-2.0) AND (BSD-3-Clause)", effectiveLicenseExpression([]string{"MIT OR Apache-2.0", "NONE", "BSD-3-Clause"}, ""))
+	assert.Equal(t, "", effectiveLicenseExpression(nil, ""))
+}
@@ -0,0 +1,151 @@
+package ingest
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/package-url/packageurl-go"
+	"github.com/protobom/protobom/pkg/sbom"
+)
+
+// lockfilePackage is a package a lockfile pins.
+type lockfilePackage struct {
+	Name    string
+	Version string
+	PURL    string
+}
+
+// lockfileGraph is what a lockfile says about a project: the packages it pins and the dependencies between them, by
+// package URL.
+type lockfileGraph struct {
+	// Root is the project itself, for lockfiles that name it.
+	Root     *lockfilePackage
+	Packages []lockfilePackage
+	Edges    []SBOMEdge
+}
+
+// lockfileParsers are the supported lockfiles, by file name.
+var lockfileParsers = map[string]func(data []byte) (*lockfileGraph, error){
+	"go.mod":            parseGoMod,
+	"go.sum":            parseGoSum,
+	"package-lock.json": parsePackageLock,
+	"yarn.lock":         parseYarnLock,
+	"Cargo.lock":        parseCargoLock,
+	"poetry.lock":       parsePoetryLock,
+	"Gemfile.lock":      parseGemfileLock,
+	"requirements.txt":  parseRequirements,
+}
+
+// IsLockfile reports whether a file, by name, is a lockfile Lockfile can ingest.
+func IsLockfile(filename string) bool {
+	_, ok := lockfileParsers[path.Base(filename)]
+	return ok
+}
+
+// Lockfile ingests a lockfile, for projects that can't produce an SBOM. The format is picked from the file name,
+// which can be a path. Every pinned package becomes a library node, with edges for the dependencies the lockfile
+// records, and from the project when the lockfile names it.
+func Lockfile(storage graph.Storage, filename string, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("data is empty")
+	}
+	parse, ok := lockfileParsers[path.Base(filename)]
+	if !ok {
+		return fmt.Errorf("unsupported lockfile %s", filename)
+	}
+	lockfile, err := parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return addLockfileGraph(storage, lockfile)
+}
+
+// GoModGraph ingests the output of `go mod graph`, which has the precise edges between the modules of a build that
+// go.mod doesn't record.
+func GoModGraph(storage graph.Storage, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("data is empty")
+	}
+	modGraph, err := parseGoModGraph(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse go mod graph: %w", err)
+	}
+	return addLockfileGraph(storage, modGraph)
+}
+
+// addLockfileGraph adds the packages of a lockfile as library nodes, with the edges between them.
+func addLockfileGraph(storage graph.Storage, lockfile *lockfileGraph) error {
+	packages := lockfile.Packages
+	if lockfile.Root != nil {
+		packages = append([]lockfilePackage{*lockfile.Root}, packages...)
+	}
+	nodes := map[string]*graph.Node{}
+	var components []string
+	for _, p := range packages {
+		if _, ok := nodes[p.PURL]; ok {
+			continue
+		}
+		node, err := graph.AddNode(storage, tools.LibraryType, lockfileNode(p), p.PURL)
+		if err != nil {
+			return fmt.Errorf("failed to add node: %w", err)
+		}
+		nodes[p.PURL] = node
+		components = append(components, p.PURL)
+	}
+
+	for _, edge := range lockfile.Edges {
+		from, to := nodes[edge.From], nodes[edge.To]
+		if from == nil || to == nil || from.ID == to.ID {
+			continue
+		}
+		if err := from.SetDependency(storage, to); err != nil {
+			return fmt.Errorf("failed to add edge %s -> %s: %w", edge.From, edge.To, err)
+		}
+	}
+
+	if err := linkVulnerabilities(storage, components); err != nil {
+		return fmt.Errorf("failed to link vulnerabilities: %w", err)
+	}
+	if err := linkRepositories(storage, components); err != nil {
+		return fmt.Errorf("failed to link repositories: %w", err)
+	}
+	return nil
+}
+
+// lockfileNode returns the metadata of the library node of a package, in the shape SBOM ingestion gives it.
+func lockfileNode(p lockfilePackage) *sbom.Node {
+	return &sbom.Node{
+		Id:          p.PURL,
+		Type:        sbom.Node_PACKAGE,
+		Name:        p.Name,
+		Version:     p.Version,
+		Identifiers: map[int32]string{int32(sbom.SoftwareIdentifierType_PURL): p.PURL},
+	}
+}
+
+// newLockfilePackage returns a package with its package URL. The namespace is split off the name for the package
+// types that have one, such as the scope of npm packages and the path of Go modules.
+func newLockfilePackage(purlType, name, version string, qualifiers map[string]string) lockfilePackage {
+	namespace, purlName := "", name
+	switch purlType {
+	case packageurl.TypeNPM, packageurl.TypeGolang:
+		if i := strings.LastIndex(name, "/"); i > 0 {
+			namespace, purlName = name[:i], name[i+1:]
+		}
+	case packageurl.TypePyPi:
+		purlName = normalizePythonName(name)
+	}
+	purl := packageurl.NewPackageURL(purlType, namespace, purlName, version, packageurl.QualifiersFromMap(qualifiers), "")
+	return lockfilePackage{Name: name, Version: version, PURL: graph.CanonicalName(purl.ToString())}
+}
+
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName normalizes a Python package name the way PEP 503 and package URLs do.
+func normalizePythonName(name string) string {
+	return pythonNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
@@ -0,0 +1,791 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/package-url/packageurl-go"
+)
+
+// lockfileBuilder collects the packages and edges of a lockfile, without duplicates.
+type lockfileBuilder struct {
+	graph    lockfileGraph
+	packages map[string]bool
+	edges    map[SBOMEdge]bool
+}
+
+func newLockfileBuilder() *lockfileBuilder {
+	return &lockfileBuilder{packages: map[string]bool{}, edges: map[SBOMEdge]bool{}}
+}
+
+func (b *lockfileBuilder) addPackage(p lockfilePackage) {
+	if b.packages[p.PURL] {
+		return
+	}
+	b.packages[p.PURL] = true
+	b.graph.Packages = append(b.graph.Packages, p)
+}
+
+func (b *lockfileBuilder) addEdge(from, to string) {
+	edge := SBOMEdge{From: from, To: to}
+	if from == to || b.edges[edge] {
+		return
+	}
+	b.edges[edge] = true
+	b.graph.Edges = append(b.graph.Edges, edge)
+}
+
+// Go modules. go.mod lists the module and, since Go 1.17, every module its build needs, so the module depends on all
+// of them. go.sum only lists the modules that were downloaded, without their dependencies.
+
+func parseGoMod(data []byte) (*lockfileGraph, error) {
+	type requirement struct {
+		path, version string
+	}
+	var module string
+	var requires []requirement
+	replaces := map[string]requirement{}
+
+	block := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		for i := range fields {
+			fields[i] = unquoteGoMod(fields[i])
+		}
+
+		switch fields[0] {
+		case "module":
+			if len(fields) > 1 {
+				module = fields[1]
+			}
+		case "require":
+			if len(fields) > 2 {
+				requires = append(requires, requirement{path: fields[1], version: fields[2]})
+			}
+		case "replace":
+			arrow := -1
+			for i, field := range fields {
+				if field == "=>" {
+					arrow = i
+				}
+			}
+			if arrow < 2 || arrow == len(fields)-1 {
+				return nil, fmt.Errorf("invalid replace directive %q", strings.TrimSpace(line))
+			}
+			old := fields[1]
+			if arrow == 3 {
+				old += "@" + fields[2]
+			}
+			replacement := requirement{path: fields[arrow+1]}
+			if arrow+2 < len(fields) {
+				replacement.version = fields[arrow+2]
+			} else {
+				// A local directory, which has no version
+				replacement.path = fields[1]
+			}
+			replaces[old] = replacement
+		}
+	}
+	if module == "" {
+		return nil, fmt.Errorf("no module directive")
+	}
+
+	b := newLockfileBuilder()
+	root := newLockfilePackage(packageurl.TypeGolang, module, "", nil)
+	b.graph.Root = &root
+	for _, require := range requires {
+		if replacement, ok := replaces[require.path+"@"+require.version]; ok {
+			require = replacement
+		} else if replacement, ok := replaces[require.path]; ok {
+			require = replacement
+		}
+		p := newLockfilePackage(packageurl.TypeGolang, require.path, require.version, nil)
+		b.addPackage(p)
+		b.addEdge(root.PURL, p.PURL)
+	}
+	return &b.graph, nil
+}
+
+func unquoteGoMod(field string) string {
+	if unquoted, err := strconv.Unquote(field); err == nil {
+		return unquoted
+	}
+	return field
+}
+
+func parseGoSum(data []byte) (*lockfileGraph, error) {
+	b := newLockfileBuilder()
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected a module, a version and a hash", i+1)
+		}
+		// The hashes of go.mod files are only needed to resolve the module graph
+		if strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		b.addPackage(newLockfilePackage(packageurl.TypeGolang, fields[0], fields[1], nil))
+	}
+	return &b.graph, nil
+}
+
+// parseGoModGraph parses `go mod graph` output, a line per edge of the module graph such as
+// "example.com/app golang.org/x/text@v0.14.0", where the main module is the one without a version. The go and
+// toolchain requirements, such as go@1.21.0, aren't modules.
+func parseGoModGraph(data []byte) (*lockfileGraph, error) {
+	b := newLockfileBuilder()
+	packages := map[string]lockfilePackage{}
+	module := func(field string) (lockfilePackage, bool) {
+		path, version, _ := strings.Cut(field, "@")
+		if path == "go" || path == "toolchain" {
+			return lockfilePackage{}, false
+		}
+		if p, ok := packages[field]; ok {
+			return p, true
+		}
+		p := newLockfilePackage(packageurl.TypeGolang, path, version, nil)
+		packages[field] = p
+		if version == "" && b.graph.Root == nil {
+			b.graph.Root = &p
+		} else if b.graph.Root == nil || p.PURL != b.graph.Root.PURL {
+			b.addPackage(p)
+		}
+		return p, true
+	}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected a module and one of its requirements", i+1)
+		}
+		from, ok := module(fields[0])
+		if !ok {
+			continue
+		}
+		to, ok := module(fields[1])
+		if !ok {
+			continue
+		}
+		b.addEdge(from.PURL, to.PURL)
+	}
+	if b.graph.Root == nil && len(b.graph.Packages) == 0 {
+		return nil, fmt.Errorf("no modules")
+	}
+	return &b.graph, nil
+}
+
+// npm. package-lock.json lists packages by their install path since lockfileVersion 2, and as nested dependencies
+// before that. Dependencies resolve to the closest node_modules directory holding them, like Node.js does.
+
+type npmPackage struct {
+	name         string
+	version      string
+	dependencies []string
+}
+
+func parsePackageLock(data []byte) (*lockfileGraph, error) {
+	type v1Dependency struct {
+		Version      string            `json:"version"`
+		Requires     map[string]string `json:"requires"`
+		Dependencies json.RawMessage   `json:"dependencies"`
+	}
+	var lock struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		Packages map[string]struct {
+			Name                 string            `json:"name"`
+			Version              string            `json:"version"`
+			Link                 bool              `json:"link"`
+			Resolved             string            `json:"resolved"`
+			Dependencies         map[string]string `json:"dependencies"`
+			DevDependencies      map[string]string `json:"devDependencies"`
+			OptionalDependencies map[string]string `json:"optionalDependencies"`
+			PeerDependencies     map[string]string `json:"peerDependencies"`
+		} `json:"packages"`
+		Dependencies json.RawMessage `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal package-lock.json: %w", err)
+	}
+
+	packages := map[string]*npmPackage{}
+	links := map[string]string{}
+	var rootDependencies []string
+	rootName, rootVersion := lock.Name, lock.Version
+
+	if lock.Packages != nil {
+		for path, entry := range lock.Packages {
+			var dependencies []string
+			for _, deps := range []map[string]string{entry.Dependencies, entry.DevDependencies, entry.OptionalDependencies, entry.PeerDependencies} {
+				for name := range deps {
+					dependencies = append(dependencies, name)
+				}
+			}
+			sort.Strings(dependencies)
+			if path == "" {
+				if entry.Name != "" {
+					rootName, rootVersion = entry.Name, entry.Version
+				}
+				rootDependencies = dependencies
+				continue
+			}
+			if entry.Link {
+				links[path] = entry.Resolved
+				continue
+			}
+			name := entry.Name
+			if name == "" {
+				name = npmPathName(path)
+			}
+			packages[path] = &npmPackage{name: name, version: entry.Version, dependencies: dependencies}
+		}
+	} else {
+		// Before lockfileVersion 2 the root only records the packages it installs, not the ones it depends on
+		required := map[string]bool{}
+		var collect func(parent string, raw json.RawMessage) error
+		collect = func(parent string, raw json.RawMessage) error {
+			if len(raw) == 0 {
+				return nil
+			}
+			var dependencies map[string]v1Dependency
+			if err := json.Unmarshal(raw, &dependencies); err != nil {
+				return fmt.Errorf("failed to unmarshal dependencies: %w", err)
+			}
+			for name, dependency := range dependencies {
+				path := npmChildPath(parent, name)
+				p := &npmPackage{name: name, version: dependency.Version}
+				for requirement := range dependency.Requires {
+					p.dependencies = append(p.dependencies, requirement)
+					required[requirement] = true
+				}
+				sort.Strings(p.dependencies)
+				packages[path] = p
+				if err := collect(path, dependency.Dependencies); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := collect("", lock.Dependencies); err != nil {
+			return nil, err
+		}
+		for path, p := range packages {
+			if npmParentPath(path) == "" && !required[p.name] {
+				rootDependencies = append(rootDependencies, p.name)
+			}
+		}
+		sort.Strings(rootDependencies)
+	}
+
+	resolve := func(from, name string) *npmPackage {
+		for dir := from; ; dir = npmParentPath(dir) {
+			path := npmChildPath(dir, name)
+			if target, ok := links[path]; ok {
+				path = target
+			}
+			if p, ok := packages[path]; ok {
+				return p
+			}
+			if dir == "" {
+				return nil
+			}
+		}
+	}
+
+	b := newLockfileBuilder()
+	paths := make([]string, 0, len(packages))
+	for path := range packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		b.addPackage(npmLockfilePackage(packages[path]))
+	}
+	for _, path := range paths {
+		from := npmLockfilePackage(packages[path])
+		for _, name := range packages[path].dependencies {
+			if to := resolve(path, name); to != nil {
+				b.addEdge(from.PURL, npmLockfilePackage(to).PURL)
+			}
+		}
+	}
+	if rootName != "" {
+		root := newLockfilePackage(packageurl.TypeNPM, rootName, rootVersion, nil)
+		b.graph.Root = &root
+		for _, name := range rootDependencies {
+			if to := resolve("", name); to != nil {
+				b.addEdge(root.PURL, npmLockfilePackage(to).PURL)
+			}
+		}
+	}
+	return &b.graph, nil
+}
+
+// npmLockfilePackage returns the package of an installed npm package, which is installed under an alias when its
+// version is of the form npm:<name>@<version>.
+func npmLockfilePackage(p *npmPackage) lockfilePackage {
+	name, version := p.name, p.version
+	if alias, ok := strings.CutPrefix(version, "npm:"); ok {
+		if i := strings.LastIndex(alias, "@"); i > 0 {
+			name, version = alias[:i], alias[i+1:]
+		}
+	}
+	return newLockfilePackage(packageurl.TypeNPM, name, version, nil)
+}
+
+func npmPathName(path string) string {
+	if i := strings.LastIndex(path, "node_modules/"); i >= 0 {
+		return path[i+len("node_modules/"):]
+	}
+	return path
+}
+
+func npmChildPath(parent, name string) string {
+	if parent == "" {
+		return "node_modules/" + name
+	}
+	return parent + "/node_modules/" + name
+}
+
+func npmParentPath(path string) string {
+	if i := strings.LastIndex(path, "/node_modules/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// yarn.lock, both the classic format and the YAML one of Yarn 2 and later. Entries are keyed by the descriptors,
+// name@range, that resolve to them, which is how their dependencies are resolved too.
+
+type yarnEntry struct {
+	name         string
+	version      string
+	workspace    string
+	dependencies [][2]string
+}
+
+func parseYarnLock(data []byte) (*lockfileGraph, error) {
+	descriptors := map[string]*yarnEntry{}
+	var entries []*yarnEntry
+	var current *yarnEntry
+	inDependencies := false
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \r")
+		content := strings.TrimSpace(line)
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		switch {
+		case indent == 0:
+			current, inDependencies = nil, false
+			keys, ok := strings.CutSuffix(content, ":")
+			if !ok || keys == "__metadata" {
+				continue
+			}
+			current = &yarnEntry{}
+			for _, descriptor := range strings.Split(keys, ",") {
+				descriptor = unquoteYarn(strings.TrimSpace(descriptor))
+				descriptors[descriptor] = current
+				if current.name == "" {
+					current.name, _ = splitYarnDescriptor(descriptor)
+				}
+			}
+			entries = append(entries, current)
+		case current == nil:
+			continue
+		case indent <= 2:
+			key, value := yarnField(content)
+			inDependencies = key == "dependencies" || key == "optionalDependencies"
+			switch key {
+			case "version":
+				current.version = value
+			case "resolution":
+				if _, reference := splitYarnDescriptor(value); strings.HasPrefix(reference, "workspace:") {
+					current.workspace = strings.TrimPrefix(reference, "workspace:")
+				}
+			}
+		case inDependencies:
+			name, reference := yarnField(content)
+			current.dependencies = append(current.dependencies, [2]string{name, reference})
+		}
+	}
+
+	b := newLockfileBuilder()
+	purls := map[*yarnEntry]string{}
+	for _, entry := range entries {
+		if entry.name == "" {
+			continue
+		}
+		version := entry.version
+		if entry.workspace != "" {
+			// Workspaces are the project itself, their version is a placeholder
+			version = ""
+		}
+		p := newLockfilePackage(packageurl.TypeNPM, entry.name, version, nil)
+		purls[entry] = p.PURL
+		if entry.workspace == "." {
+			b.graph.Root = &p
+			continue
+		}
+		b.addPackage(p)
+	}
+	for _, entry := range entries {
+		from, ok := purls[entry]
+		if !ok {
+			continue
+		}
+		for _, dependency := range entry.dependencies {
+			to, ok := descriptors[dependency[0]+"@"+dependency[1]]
+			if !ok {
+				to, ok = descriptors[dependency[0]+"@npm:"+dependency[1]]
+			}
+			if ok && purls[to] != "" {
+				b.addEdge(from, purls[to])
+			}
+		}
+	}
+	return &b.graph, nil
+}
+
+// splitYarnDescriptor splits name@range, where scoped names start with @.
+func splitYarnDescriptor(descriptor string) (string, string) {
+	if i := strings.Index(descriptor[min(1, len(descriptor)):], "@"); i >= 0 {
+		return descriptor[:i+1], descriptor[i+2:]
+	}
+	return descriptor, ""
+}
+
+// yarnField splits a field, `key "value"` in the classic format and `key: value` in the YAML one.
+func yarnField(content string) (string, string) {
+	var key, rest string
+	if strings.HasPrefix(content, `"`) {
+		end := strings.Index(content[1:], `"`)
+		if end < 0 {
+			return unquoteYarn(content), ""
+		}
+		key, rest = content[1:end+1], content[end+2:]
+	} else if i := strings.IndexAny(content, ": "); i >= 0 {
+		key, rest = content[:i], content[i:]
+	} else {
+		return content, ""
+	}
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+	return key, unquoteYarn(rest)
+}
+
+func unquoteYarn(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// TOML lockfiles. Cargo.lock and poetry.lock only use a small part of TOML: arrays of package tables with string
+// keys, string arrays and inline tables, which are parsed line by line.
+
+var tomlKey = regexp.MustCompile(`^("[^"]+"|[A-Za-z0-9_.-]+)\s*=\s*(.*)$`)
+
+// tomlTables calls fn with the header of the table every key belongs to, such as "[[package]]", and the key and its
+// value. Multi-line arrays are joined into one value. A header without keys is reported with an empty key.
+func tomlTables(data []byte, fn func(header, key, value string)) {
+	header := ""
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			header = line
+			fn(header, "", "")
+			continue
+		}
+		match := tomlKey.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		key, value := unquoteYarn(match[1]), match[2]
+		if strings.HasPrefix(value, "[") {
+			for depth := strings.Count(value, "[") - strings.Count(value, "]"); depth > 0 && i+1 < len(lines); {
+				i++
+				next := strings.TrimSpace(lines[i])
+				depth += strings.Count(next, "[") - strings.Count(next, "]")
+				value += " " + next
+			}
+		}
+		fn(header, key, value)
+	}
+}
+
+var tomlString = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+
+func tomlStrings(value string) []string {
+	var values []string
+	for _, match := range tomlString.FindAllStringSubmatch(value, -1) {
+		values = append(values, match[1])
+	}
+	return values
+}
+
+func tomlStringValue(value string) string {
+	if values := tomlStrings(value); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func parseCargoLock(data []byte) (*lockfileGraph, error) {
+	type cargoPackage struct {
+		name, version, source string
+		dependencies          []string
+	}
+	var packages []*cargoPackage
+	var current *cargoPackage
+	tomlTables(data, func(header, key, value string) {
+		if key == "" {
+			current = nil
+			if header == "[[package]]" {
+				current = &cargoPackage{}
+				packages = append(packages, current)
+			}
+			return
+		}
+		if current == nil {
+			return
+		}
+		switch key {
+		case "name":
+			current.name = tomlStringValue(value)
+		case "version":
+			current.version = tomlStringValue(value)
+		case "source":
+			current.source = tomlStringValue(value)
+		case "dependencies":
+			current.dependencies = tomlStrings(value)
+		}
+	})
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no packages")
+	}
+
+	byName := map[string][]*cargoPackage{}
+	var local []*cargoPackage
+	for _, p := range packages {
+		byName[p.name] = append(byName[p.name], p)
+		if p.source == "" {
+			local = append(local, p)
+		}
+	}
+	purl := func(p *cargoPackage) string {
+		return newLockfilePackage(packageurl.TypeCargo, p.name, p.version, nil).PURL
+	}
+
+	b := newLockfileBuilder()
+	var root *cargoPackage
+	if len(local) == 1 {
+		// The crate the lockfile belongs to, workspaces have several
+		root = local[0]
+		p := newLockfilePackage(packageurl.TypeCargo, root.name, root.version, nil)
+		b.graph.Root = &p
+	}
+	for _, p := range packages {
+		if p != root {
+			b.addPackage(newLockfilePackage(packageurl.TypeCargo, p.name, p.version, nil))
+		}
+	}
+	for _, p := range packages {
+		for _, dependency := range p.dependencies {
+			// "name", "name version" or "name version (source)", the version only being there when it's ambiguous
+			fields := strings.Fields(dependency)
+			if len(fields) == 0 {
+				continue
+			}
+			for _, candidate := range byName[fields[0]] {
+				if len(fields) == 1 || candidate.version == fields[1] {
+					b.addEdge(purl(p), purl(candidate))
+					break
+				}
+			}
+		}
+	}
+	return &b.graph, nil
+}
+
+func parsePoetryLock(data []byte) (*lockfileGraph, error) {
+	type poetryPackage struct {
+		name, version string
+		dependencies  []string
+	}
+	var packages []*poetryPackage
+	var current *poetryPackage
+	tomlTables(data, func(header, key, value string) {
+		switch {
+		case key == "" && header == "[[package]]":
+			current = &poetryPackage{}
+			packages = append(packages, current)
+		case key == "" && !strings.HasPrefix(header, "[package."):
+			current = nil
+		case current == nil || key == "":
+		case header == "[[package]]" && key == "name":
+			current.name = tomlStringValue(value)
+		case header == "[[package]]" && key == "version":
+			current.version = tomlStringValue(value)
+		case header == "[package.dependencies]":
+			current.dependencies = append(current.dependencies, key)
+		}
+	})
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no packages")
+	}
+
+	b := newLockfileBuilder()
+	purls := map[string]string{}
+	for _, p := range packages {
+		lp := newLockfilePackage(packageurl.TypePyPi, p.name, p.version, nil)
+		purls[normalizePythonName(p.name)] = lp.PURL
+		b.addPackage(lp)
+	}
+	for _, p := range packages {
+		for _, dependency := range p.dependencies {
+			if to, ok := purls[normalizePythonName(dependency)]; ok {
+				b.addEdge(purls[normalizePythonName(p.name)], to)
+			}
+		}
+	}
+	return &b.graph, nil
+}
+
+// Gemfile.lock lists the gems of each source under "specs:", indented by four spaces, with their dependencies
+// indented by six. Gems built for a platform carry it in their version, such as 1.13.8-x86_64-linux.
+
+var gemSpec = regexp.MustCompile(`^(\S+)(?: \(([^)]*)\))?$`)
+
+func parseGemfileLock(data []byte) (*lockfileGraph, error) {
+	type gem struct {
+		purl         string
+		dependencies []string
+	}
+	var gems []*gem
+	byName := map[string][]*gem{}
+	var current *gem
+	inSpecs := false
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		content := strings.TrimSpace(line)
+		switch {
+		case indent == 0:
+			inSpecs, current = false, nil
+		case indent == 2:
+			inSpecs, current = content == "specs:", nil
+		case !inSpecs:
+		case indent == 4:
+			match := gemSpec.FindStringSubmatch(content)
+			if match == nil {
+				return nil, fmt.Errorf("invalid gem %q", content)
+			}
+			name, version := match[1], match[2]
+			var qualifiers map[string]string
+			if v, platform, ok := strings.Cut(version, "-"); ok {
+				version, qualifiers = v, map[string]string{"platform": platform}
+			}
+			current = &gem{purl: newLockfilePackage(packageurl.TypeGem, name, version, qualifiers).PURL}
+			gems = append(gems, current)
+			byName[name] = append(byName[name], current)
+		case indent == 6 && current != nil:
+			if match := gemSpec.FindStringSubmatch(content); match != nil {
+				current.dependencies = append(current.dependencies, match[1])
+			} else if fields := strings.Fields(content); len(fields) > 0 {
+				current.dependencies = append(current.dependencies, fields[0])
+			}
+		}
+	}
+	if len(gems) == 0 {
+		return nil, fmt.Errorf("no gems")
+	}
+
+	b := newLockfileBuilder()
+	for _, g := range gems {
+		parsed, err := packageurl.FromString(g.purl)
+		if err != nil {
+			return nil, err
+		}
+		b.addPackage(lockfilePackage{Name: parsed.Name, Version: parsed.Version, PURL: g.purl})
+	}
+	for _, g := range gems {
+		for _, dependency := range g.dependencies {
+			// Every platform variant of the dependency
+			for _, to := range byName[dependency] {
+				b.addEdge(g.purl, to.purl)
+			}
+		}
+	}
+	return &b.graph, nil
+}
+
+// requirements.txt only pins versions, with ==, without recording dependencies. Requirements that aren't pinned
+// are added without a version.
+
+var requirementLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*(.*)$`)
+
+func parseRequirements(data []byte) (*lockfileGraph, error) {
+	b := newLockfileBuilder()
+	content := strings.ReplaceAll(strings.ReplaceAll(string(data), "\r\n", "\n"), "\\\n", " ")
+	for i, line := range strings.Split(content, "\n") {
+		if j := strings.Index(line, "#"); j >= 0 && (j == 0 || line[j-1] == ' ' || line[j-1] == '\t') {
+			line = line[:j]
+		}
+		line, _, _ = strings.Cut(line, ";")
+		line = strings.TrimSpace(line)
+		// Options, such as -r other.txt, -e ./local or --index-url
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if j := strings.Index(line, " --"); j >= 0 {
+			line = strings.TrimSpace(line[:j])
+		}
+		match := requirementLine.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: invalid requirement %q", i+1, line)
+		}
+		name, specifier := match[1], strings.TrimSpace(match[2])
+		version := ""
+		if pinned, ok := strings.CutPrefix(specifier, "=="); ok && !strings.ContainsAny(pinned, ",*") {
+			version = strings.TrimSpace(strings.TrimPrefix(pinned, "="))
+		}
+		b.addPackage(newLockfilePackage(packageurl.TypePyPi, name, version, nil))
+	}
+	return &b.graph, nil
+}
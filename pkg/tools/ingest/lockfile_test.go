@@ -0,0 +1,257 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLockfiles(t *testing.T) {
+	tests := []struct {
+		path     string
+		root     string
+		packages []string
+		edges    []SBOMEdge
+	}{
+		{
+			path: "go/go.mod",
+			root: "pkg:golang/example.com/app",
+			packages: []string{
+				"pkg:golang/github.com/google/uuid@v1.6.0",
+				"pkg:golang/github.com/new/module@v1.1.0",
+				"pkg:golang/github.com/spf13/cobra",
+				"pkg:golang/golang.org/x/text@v0.14.0",
+			},
+			edges: []SBOMEdge{
+				{From: "pkg:golang/example.com/app", To: "pkg:golang/github.com/google/uuid@v1.6.0"},
+				{From: "pkg:golang/example.com/app", To: "pkg:golang/github.com/new/module@v1.1.0"},
+				{From: "pkg:golang/example.com/app", To: "pkg:golang/github.com/spf13/cobra"},
+				{From: "pkg:golang/example.com/app", To: "pkg:golang/golang.org/x/text@v0.14.0"},
+			},
+		},
+		{
+			path:     "go/go.sum",
+			packages: []string{"pkg:golang/github.com/google/uuid@v1.6.0", "pkg:golang/golang.org/x/text@v0.14.0"},
+		},
+		{
+			path: "npm/package-lock.json",
+			root: "pkg:npm/app@1.0.0",
+			packages: []string{
+				"pkg:npm/%40types/node@20.11.0",
+				"pkg:npm/debug@4.3.4",
+				"pkg:npm/ms@2.1.2",
+				"pkg:npm/ms@2.1.3",
+				"pkg:npm/shared@0.1.0",
+				"pkg:npm/string-width@4.2.3",
+			},
+			edges: []SBOMEdge{
+				{From: "pkg:npm/app@1.0.0", To: "pkg:npm/%40types/node@20.11.0"},
+				{From: "pkg:npm/app@1.0.0", To: "pkg:npm/debug@4.3.4"},
+				{From: "pkg:npm/app@1.0.0", To: "pkg:npm/ms@2.1.3"},
+				{From: "pkg:npm/app@1.0.0", To: "pkg:npm/shared@0.1.0"},
+				{From: "pkg:npm/debug@4.3.4", To: "pkg:npm/ms@2.1.2"},
+				{From: "pkg:npm/shared@0.1.0", To: "pkg:npm/debug@4.3.4"},
+				{From: "pkg:npm/shared@0.1.0", To: "pkg:npm/string-width@4.2.3"},
+			},
+		},
+		{
+			path:     "npm-v1/package-lock.json",
+			root:     "pkg:npm/legacy@2.0.0",
+			packages: []string{"pkg:npm/debug@4.3.4", "pkg:npm/ms@2.1.2", "pkg:npm/ms@2.1.3"},
+			edges: []SBOMEdge{
+				{From: "pkg:npm/debug@4.3.4", To: "pkg:npm/ms@2.1.2"},
+				{From: "pkg:npm/legacy@2.0.0", To: "pkg:npm/debug@4.3.4"},
+			},
+		},
+		{
+			path:     "yarn/yarn.lock",
+			packages: []string{"pkg:npm/%40babel/code-frame@7.12.13", "pkg:npm/%40babel/highlight@7.13.10", "pkg:npm/js-tokens@4.0.0"},
+			edges: []SBOMEdge{
+				{From: "pkg:npm/%40babel/code-frame@7.12.13", To: "pkg:npm/%40babel/highlight@7.13.10"},
+				{From: "pkg:npm/%40babel/highlight@7.13.10", To: "pkg:npm/js-tokens@4.0.0"},
+			},
+		},
+		{
+			path:     "yarn-berry/yarn.lock",
+			root:     "pkg:npm/app",
+			packages: []string{"pkg:npm/%40babel/code-frame@7.12.13", "pkg:npm/%40babel/highlight@7.13.10", "pkg:npm/js-tokens@4.0.0"},
+			edges: []SBOMEdge{
+				{From: "pkg:npm/%40babel/code-frame@7.12.13", To: "pkg:npm/%40babel/highlight@7.13.10"},
+				{From: "pkg:npm/%40babel/highlight@7.13.10", To: "pkg:npm/js-tokens@4.0.0"},
+				{From: "pkg:npm/app", To: "pkg:npm/%40babel/code-frame@7.12.13"},
+			},
+		},
+		{
+			path:     "cargo/Cargo.lock",
+			root:     "pkg:cargo/app@0.1.0",
+			packages: []string{"pkg:cargo/rand@0.7.3", "pkg:cargo/rand@0.8.5", "pkg:cargo/rand_core@0.6.4", "pkg:cargo/serde@1.0.197"},
+			edges: []SBOMEdge{
+				{From: "pkg:cargo/app@0.1.0", To: "pkg:cargo/rand@0.8.5"},
+				{From: "pkg:cargo/app@0.1.0", To: "pkg:cargo/serde@1.0.197"},
+				{From: "pkg:cargo/rand@0.8.5", To: "pkg:cargo/rand_core@0.6.4"},
+				{From: "pkg:cargo/serde@1.0.197", To: "pkg:cargo/rand@0.7.3"},
+			},
+		},
+		{
+			path:     "poetry/poetry.lock",
+			packages: []string{"pkg:pypi/certifi@2024.2.2", "pkg:pypi/charset-normalizer@3.3.2", "pkg:pypi/requests@2.31.0", "pkg:pypi/urllib3@2.2.1"},
+			edges: []SBOMEdge{
+				{From: "pkg:pypi/requests@2.31.0", To: "pkg:pypi/certifi@2024.2.2"},
+				{From: "pkg:pypi/requests@2.31.0", To: "pkg:pypi/charset-normalizer@3.3.2"},
+				{From: "pkg:pypi/requests@2.31.0", To: "pkg:pypi/urllib3@2.2.1"},
+			},
+		},
+		{
+			path:     "ruby/Gemfile.lock",
+			packages: []string{"pkg:gem/mini_portile2@2.8.5", "pkg:gem/nokogiri@1.16.2", "pkg:gem/nokogiri@1.16.2?platform=x86_64-linux", "pkg:gem/racc@1.7.3"},
+			edges: []SBOMEdge{
+				{From: "pkg:gem/nokogiri@1.16.2", To: "pkg:gem/mini_portile2@2.8.5"},
+				{From: "pkg:gem/nokogiri@1.16.2", To: "pkg:gem/racc@1.7.3"},
+				{From: "pkg:gem/nokogiri@1.16.2?platform=x86_64-linux", To: "pkg:gem/racc@1.7.3"},
+			},
+		},
+		{
+			path:     "python/requirements.txt",
+			packages: []string{"pkg:pypi/charset-normalizer@3.3.2", "pkg:pypi/django@4.2.0", "pkg:pypi/flask", "pkg:pypi/requests@2.31.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("../../../testdata/lockfiles", tt.path))
+			require.NoError(t, err)
+			lockfile, err := lockfileParsers[filepath.Base(tt.path)](data)
+			require.NoError(t, err)
+
+			if tt.root == "" {
+				assert.Nil(t, lockfile.Root)
+			} else if assert.NotNil(t, lockfile.Root) {
+				assert.Equal(t, tt.root, lockfile.Root.PURL)
+			}
+			var packages []string
+			for _, p := range lockfile.Packages {
+				packages = append(packages, p.PURL)
+			}
+			sort.Strings(packages)
+			assert.Equal(t, tt.packages, packages)
+			edges := append([]SBOMEdge(nil), lockfile.Edges...)
+			sort.Slice(edges, func(i, j int) bool {
+				if edges[i].From != edges[j].From {
+					return edges[i].From < edges[j].From
+				}
+				return edges[i].To < edges[j].To
+			})
+			assert.Equal(t, tt.edges, edges)
+		})
+	}
+}
+
+func TestParseLockfiles_Errors(t *testing.T) {
+	tests := map[string]string{
+		"go.mod":            "go 1.22\n",
+		"go.sum":            "github.com/google/uuid v1.6.0\n",
+		"package-lock.json": "{",
+		"Cargo.lock":        "version = 3\n",
+		"poetry.lock":       "[metadata]\n",
+		"Gemfile.lock":      "PLATFORMS\n  ruby\n",
+		"requirements.txt":  "==1.0\n",
+	}
+	for filename, data := range tests {
+		_, err := lockfileParsers[filename]([]byte(data))
+		assert.Error(t, err, filename)
+	}
+}
+
+func TestLockfile(t *testing.T) {
+	storage := graph.NewMockStorage()
+	data, err := os.ReadFile("../../../testdata/lockfiles/cargo/Cargo.lock")
+	require.NoError(t, err)
+	require.NoError(t, Lockfile(storage, "path/to/Cargo.lock", data))
+	// Ingesting the same lockfile again doesn't change anything
+	require.NoError(t, Lockfile(storage, "Cargo.lock", data))
+
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 5)
+
+	rootID, err := storage.NameToID("pkg:cargo/app@0.1.0")
+	require.NoError(t, err)
+	root, err := storage.GetNode(rootID)
+	require.NoError(t, err)
+	assert.Equal(t, tools.LibraryType, root.Type)
+	assert.Equal(t, uint64(2), root.Children.GetCardinality())
+
+	assert.True(t, IsLockfile("path/to/Cargo.lock"))
+	assert.False(t, IsLockfile("Cargo.toml"))
+	assert.Error(t, Lockfile(storage, "Cargo.toml", data))
+	assert.Error(t, Lockfile(storage, "Cargo.lock", nil))
+}
+
+func TestLockfile_LinksVulnerabilities(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("GHSA-1", "2024-01-01T00:00:00Z", "", "4.2.1")))
+	require.NoError(t, Lockfile(storage, "requirements.txt", []byte("django==4.2.0\n")))
+
+	libraryID, err := storage.NameToID("pkg:pypi/django@4.2.0")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{libraryID}, vulnerabilityParents(t, storage, "GHSA-1"))
+}
+
+func TestLockfile_CanonicalNames(t *testing.T) {
+	storage := graph.NewMockStorage()
+	// The SBOM doesn't percent-encode the scope of the package, the lockfile ingester does
+	require.NoError(t, SBOM(storage, testHashedSBOM("00000000-0000-0000-0000-000000000001", "pkg:npm/@types/node@20.11.0", testDuplicateDigest)))
+	data, err := os.ReadFile("../../../testdata/lockfiles/npm/package-lock.json")
+	require.NoError(t, err)
+	require.NoError(t, Lockfile(storage, "package-lock.json", data))
+
+	names, err := storage.GetAllNames()
+	require.NoError(t, err)
+	assert.Contains(t, names, "pkg:npm/%40types/node@20.11.0")
+	assert.NotContains(t, names, "pkg:npm/@types/node@20.11.0")
+	node, err := storage.GetNode(names["pkg:npm/%40types/node@20.11.0"])
+	require.NoError(t, err)
+	// Depended on by the sbom node and by the root of the lockfile
+	assert.Equal(t, uint64(2), node.Parents.GetCardinality())
+}
+
+func TestGoModGraph(t *testing.T) {
+	data := []byte(`example.com/app golang.org/x/net@v0.10.0
+example.com/app golang.org/x/text@v0.3.7
+example.com/app go@1.22.1
+golang.org/x/net@v0.10.0 golang.org/x/text@v0.3.7
+golang.org/x/net@v0.10.0 go@1.17
+go@1.22.1 toolchain@go1.22.1
+`)
+	modGraph, err := parseGoModGraph(data)
+	require.NoError(t, err)
+	require.NotNil(t, modGraph.Root)
+	assert.Equal(t, "pkg:golang/example.com/app", modGraph.Root.PURL)
+	assert.ElementsMatch(t, []SBOMEdge{
+		{From: "pkg:golang/example.com/app", To: "pkg:golang/golang.org/x/net@v0.10.0"},
+		{From: "pkg:golang/example.com/app", To: "pkg:golang/golang.org/x/text@v0.3.7"},
+		{From: "pkg:golang/golang.org/x/net@v0.10.0", To: "pkg:golang/golang.org/x/text@v0.3.7"},
+	}, modGraph.Edges)
+
+	storage := graph.NewMockStorage()
+	require.NoError(t, GoModGraph(storage, data))
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 3)
+
+	netID, err := storage.NameToID("pkg:golang/golang.org/x/net@v0.10.0")
+	require.NoError(t, err)
+	net, err := storage.GetNode(netID)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), net.Children.GetCardinality())
+
+	assert.Error(t, GoModGraph(storage, nil))
+	assert.Error(t, GoModGraph(storage, []byte("example.com/app\n")))
+	assert.Error(t, GoModGraph(storage, []byte("go@1.22.1 toolchain@go1.22.1\n")))
+}
@@ -0,0 +1,210 @@
+package ingest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/package-url/packageurl-go"
+	"github.com/protobom/protobom/pkg/sbom"
+)
+
+const (
+	// RepositoriesTag is the custom data tag recording the source repository of packages, keyed by the package URL
+	// of the package without its version and qualifiers, so that every version of a package shares it.
+	RepositoriesTag = "repositories"
+
+	repositoryDataKey = "repository"
+)
+
+// codeHosts are the hosts whose repositories are always at host/owner/name, so that anything after that, such as
+// the tree of a branch, can be dropped.
+var codeHosts = map[string]bool{
+	"github.com":    true,
+	"gitlab.com":    true,
+	"bitbucket.org": true,
+}
+
+// Repository is the metadata of a repository node. Scorecards are attached to repositories rather than to library
+// nodes, since they score the source of a package and not one of its versions.
+type Repository struct {
+	// Name is the URL of the repository without its scheme, such as github.com/pypa/setuptools.
+	Name string `json:"name"`
+}
+
+// RepositoryNodeName returns the name of the node of a repository.
+func RepositoryNodeName(name string) string {
+	return "repository:" + name
+}
+
+// NormalizeRepository returns the name of a repository from its URL, which is how scorecards name it: without
+// scheme, credentials, ".git" suffix or trailing path, as github.com/pypa/setuptools for
+// git+https://github.com/pypa/setuptools.git or git@github.com:pypa/setuptools. It returns an empty string for
+// URLs that don't name a repository.
+func NormalizeRepository(url string) string {
+	ref := strings.TrimPrefix(strings.TrimSpace(url), "git+")
+	if i := strings.Index(ref, "://"); i >= 0 {
+		ref = ref[i+len("://"):]
+	} else if strings.HasPrefix(ref, "git@") {
+		ref = strings.Replace(ref, ":", "/", 1)
+	}
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	if at, slash := strings.Index(ref, "@"), strings.Index(ref, "/"); at >= 0 && (slash < 0 || at < slash) {
+		ref = ref[at+1:]
+	}
+
+	parts := strings.Split(strings.Trim(ref, "/"), "/")
+	parts[0] = strings.TrimPrefix(strings.ToLower(parts[0]), "www.")
+	if codeHosts[parts[0]] {
+		if len(parts) < 3 {
+			return ""
+		}
+		// Code hosts match owners and names case-insensitively
+		parts = strings.Split(strings.ToLower(strings.Join(parts[:3], "/")), "/")
+	}
+	parts[len(parts)-1] = strings.TrimSuffix(parts[len(parts)-1], ".git")
+	if len(parts) < 2 {
+		return ""
+	}
+	for _, part := range parts {
+		if part == "" {
+			return ""
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// purlRepository guesses the source repository of a package from its package URL: the vcs_url qualifier, GitHub and
+// Bitbucket package URLs, and Go modules hosted on a code host.
+func purlRepository(purl packageurl.PackageURL) string {
+	if vcsURL := purl.Qualifiers.Map()["vcs_url"]; vcsURL != "" {
+		if repository := NormalizeRepository(vcsURL); repository != "" {
+			return repository
+		}
+	}
+	switch purl.Type {
+	case packageurl.TypeGithub:
+		return NormalizeRepository("github.com/" + purl.Namespace + "/" + purl.Name)
+	case packageurl.TypeBitbucket:
+		return NormalizeRepository("bitbucket.org/" + purl.Namespace + "/" + purl.Name)
+	case packageurl.TypeGolang:
+		// Vanity import paths, such as golang.org/x/net, don't tell where the module is hosted
+		if host, _, _ := strings.Cut(purl.Namespace, "/"); codeHosts[host] {
+			return NormalizeRepository(purl.Namespace + "/" + purl.Name)
+		}
+	}
+	return ""
+}
+
+// packageIdentity returns the package URL of a package without its version, qualifiers and subpath.
+func packageIdentity(purl packageurl.PackageURL) string {
+	purl.Version = ""
+	purl.Qualifiers = nil
+	purl.Subpath = ""
+	return purl.ToString()
+}
+
+// recordRepository records the source repository of the package of a package URL.
+func recordRepository(storage graph.Storage, name, repository string) error {
+	purl, err := packageurl.FromString(name)
+	if err != nil {
+		return nil
+	}
+	if err := storage.AddOrUpdateCustomData(RepositoriesTag, packageIdentity(purl), repositoryDataKey, []byte(repository)); err != nil {
+		return fmt.Errorf("failed to record the repository of %s: %w", name, err)
+	}
+	return nil
+}
+
+// libraryRepository returns the source repository of a library, as recorded from SBOM external references and
+// scorecards, or guessed from its package URL. It returns an empty string when it isn't known.
+func libraryRepository(storage graph.Storage, name string) (string, error) {
+	purl, err := packageurl.FromString(name)
+	if err != nil {
+		return "", nil
+	}
+	data, err := storage.GetCustomData(RepositoriesTag, packageIdentity(purl))
+	if err != nil {
+		return "", fmt.Errorf("failed to get the repository of %s: %w", name, err)
+	}
+	if repository := string(data[repositoryDataKey]); repository != "" {
+		return repository, nil
+	}
+	return purlRepository(purl), nil
+}
+
+// sbomNodeRepository returns the repository of the first VCS external reference of an SBOM component.
+func sbomNodeRepository(node *sbom.Node) string {
+	for _, ref := range node.GetExternalReferences() {
+		if ref.GetType() != sbom.ExternalReference_VCS {
+			continue
+		}
+		if repository := NormalizeRepository(ref.GetUrl()); repository != "" {
+			return repository
+		}
+	}
+	return ""
+}
+
+// linkRepositories adds edges from library nodes to the repository nodes of their source repositories. Repository
+// nodes are only added with scorecards and build provenance, so libraries ingested before a scorecard are linked by
+// Scorecards.
+func linkRepositories(storage graph.Storage, names []string) error {
+	for _, name := range uniqueSorted(names) {
+		if !strings.HasPrefix(name, pkg) {
+			continue
+		}
+		repository, err := libraryRepository(storage, name)
+		if err != nil {
+			return err
+		}
+		if repository == "" {
+			continue
+		}
+		repositoryID, err := storage.NameToID(RepositoryNodeName(repository))
+		if err != nil {
+			continue
+		}
+		if err := linkRepository(storage, name, repositoryID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func linkRepository(storage graph.Storage, name string, repositoryID uint32) error {
+	id, err := storage.NameToID(name)
+	if err != nil {
+		return fmt.Errorf("failed to find node %s: %w", name, err)
+	}
+	node, err := storage.GetNode(id)
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", name, err)
+	}
+	if node.Type != tools.LibraryType {
+		return nil
+	}
+	repositoryNode, err := storage.GetNode(repositoryID)
+	if err != nil {
+		return fmt.Errorf("failed to get repository node: %w", err)
+	}
+	if err := node.SetDependency(storage, repositoryNode); err != nil {
+		return fmt.Errorf("failed to add edge %s -> %s: %w", name, repositoryNode.Name, err)
+	}
+
+	// Libraries get the Scorecard fields of their repository, so that queries for libraries can filter on them
+	repositoryFields, err := graph.GetFields(storage, repositoryNode.Name)
+	if err != nil {
+		return err
+	}
+	fields := map[string]string{}
+	for key, value := range repositoryFields {
+		if isScorecardField(key) {
+			fields[key] = value
+		}
+	}
+	return setScorecardFields(storage, name, fields)
+}
@@ -0,0 +1,169 @@
+package ingest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeRepository(t *testing.T) {
+	for url, want := range map[string]string{
+		"github.com/pypa/setuptools":                          "github.com/pypa/setuptools",
+		"https://github.com/pypa/setuptools":                  "github.com/pypa/setuptools",
+		"git+https://github.com/PyPA/setuptools.git":          "github.com/pypa/setuptools",
+		"git@github.com:pypa/setuptools.git":                  "github.com/pypa/setuptools",
+		"ssh://git@github.com/pypa/setuptools":                "github.com/pypa/setuptools",
+		"https://www.github.com/pypa/setuptools/tree/main":    "github.com/pypa/setuptools",
+		"https://gitlab.com/gitlab-org/gitlab.git#v16.0.0":    "gitlab.com/gitlab-org/gitlab",
+		"https://go.googlesource.com/net":                     "go.googlesource.com/net",
+		"https://git.example.com/group/subgroup/project.git/": "git.example.com/group/subgroup/project",
+		"https://github.com/pypa":                             "",
+		"https://example.com":                                 "",
+		"":                                                    "",
+	} {
+		assert.Equal(t, want, NormalizeRepository(url), url)
+	}
+}
+
+func TestScorecards_Repositories(t *testing.T) {
+	storage := graph.NewMockStorage()
+	addLibrary := func(name string) *graph.Node {
+		node, err := graph.AddNode(storage, tools.LibraryType, nil, name)
+		require.NoError(t, err)
+		return node
+	}
+	older := addLibrary("pkg:pypi/setuptools@65.5.1")
+	newer := addLibrary("pkg:pypi/setuptools@70.0.0")
+	module := addLibrary("pkg:golang/github.com/google/uuid@v1.6.0")
+	vanity := addLibrary("pkg:golang/golang.org/x/net@v0.25.0")
+
+	scorecards, err := json.Marshal([]ScorecardResult{
+		{
+			PURL:      "pkg:pypi/setuptools@65.5.1",
+			Success:   true,
+			Scorecard: ScorecardData{Repo: Repo{Name: "github.com/pypa/setuptools"}, Score: 5.3},
+		},
+		{
+			PURL:      "pkg:golang/github.com/google/uuid@v1.0.0",
+			Success:   true,
+			Scorecard: ScorecardData{Repo: Repo{Name: "github.com/google/uuid"}, Score: 6.1},
+			GitHubURL: "https://github.com/google/uuid",
+		},
+		{
+			PURL:      "pkg:golang/golang.org/x/net@v0.25.0",
+			Success:   true,
+			Scorecard: ScorecardData{Repo: Repo{Name: "github.com/golang/net"}, Score: 7.0},
+		},
+		{PURL: "pkg:npm/left-pad@1.3.0", Success: false},
+	})
+	require.NoError(t, err)
+	require.NoError(t, Scorecards(storage, scorecards))
+
+	repositoryNode := func(name string) *graph.Node {
+		t.Helper()
+		id, err := storage.NameToID(RepositoryNodeName(name))
+		require.NoError(t, err)
+		node, err := storage.GetNode(id)
+		require.NoError(t, err)
+		return node
+	}
+
+	// Every version of the scored package depends on the repository, which depends on its scorecard
+	setuptools := repositoryNode("github.com/pypa/setuptools")
+	assert.ElementsMatch(t, []uint32{older.ID, newer.ID}, setuptools.Parents.ToArray())
+	scorecardID, err := storage.NameToID(getScorecardNodeName("github.com/pypa/setuptools"))
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{scorecardID}, setuptools.Children.ToArray())
+
+	assert.Equal(t, []uint32{module.ID}, repositoryNode("github.com/google/uuid").Parents.ToArray())
+	// Modules with vanity import paths are matched through the package the scorecard was requested for
+	assert.Equal(t, []uint32{vanity.ID}, repositoryNode("github.com/golang/net").Parents.ToArray())
+
+	// The libraries get the fields of the scorecard of their repository
+	fields, err := graph.GetFields(storage, newer.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{graph.ScorecardScoreField: "5.3"}, fields)
+
+	// A later version is linked when it is ingested
+	require.NoError(t, Lockfile(storage, "requirements.txt", []byte("setuptools==71.0.0\n")))
+	latestID, err := storage.NameToID("pkg:pypi/setuptools@71.0.0")
+	require.NoError(t, err)
+	assert.Contains(t, repositoryNode("github.com/pypa/setuptools").Parents.ToArray(), latestID)
+}
+
+func TestSBOM_RepositoryExternalReferences(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, SBOM(storage, []byte(`{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "serialNumber": "urn:uuid:2f5c5e0c-3d3c-4f4e-9a53-8f3e1f3a6b1d",
+  "version": 1,
+  "components": [
+    {"bom-ref": "lodash", "type": "library", "name": "lodash", "version": "4.17.21", "purl": "pkg:npm/lodash@4.17.21",
+     "externalReferences": [
+       {"type": "website", "url": "https://lodash.com/"},
+       {"type": "vcs", "url": "git+https://github.com/lodash/lodash.git"}
+     ]}
+  ]
+}`)))
+
+	scorecards, err := json.Marshal([]ScorecardResult{{
+		PURL:      "pkg:github/lodash/lodash@v4.17.21",
+		Success:   true,
+		Scorecard: ScorecardData{Repo: Repo{Name: "github.com/lodash/lodash"}, Score: 4.2},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, Scorecards(storage, scorecards))
+
+	libraryID, err := storage.NameToID("pkg:npm/lodash@4.17.21")
+	require.NoError(t, err)
+	repositoryID, err := storage.NameToID(RepositoryNodeName("github.com/lodash/lodash"))
+	require.NoError(t, err)
+	repository, err := storage.GetNode(repositoryID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{libraryID}, repository.Parents.ToArray())
+}
+
+func TestScorecards_Fields(t *testing.T) {
+	storage := graph.NewMockStorage()
+	library, err := graph.AddNode(storage, tools.LibraryType, nil, "pkg:pypi/setuptools@65.5.1")
+	require.NoError(t, err)
+
+	scorecard := func(checks ...Check) []byte {
+		data, err := json.Marshal([]ScorecardResult{{
+			PURL:      library.Name,
+			Success:   true,
+			Scorecard: ScorecardData{Repo: Repo{Name: "github.com/pypa/setuptools"}, Score: 5.3, Checks: checks},
+		}})
+		require.NoError(t, err)
+		return data
+	}
+	require.NoError(t, Scorecards(storage, scorecard(
+		Check{Name: "Code-Review", Score: 0},
+		Check{Name: "Maintained", Score: 10},
+		Check{Name: "Packaging", Score: -1},
+	)))
+	want := map[string]string{
+		graph.ScorecardScoreField:                "5.3",
+		graph.ScorecardCheckField("Code-Review"): "0",
+		graph.ScorecardCheckField("Maintained"):  "10",
+	}
+	for _, name := range []string{library.Name, RepositoryNodeName("github.com/pypa/setuptools"), getScorecardNodeName("github.com/pypa/setuptools")} {
+		fields, err := graph.GetFields(storage, name)
+		require.NoError(t, err)
+		assert.Equal(t, want, fields, name)
+	}
+
+	// A newer scorecard replaces the fields of the previous one
+	require.NoError(t, Scorecards(storage, scorecard(Check{Name: "Maintained", Score: 2})))
+	fields, err := graph.GetFields(storage, library.Name)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		graph.ScorecardScoreField:               "5.3",
+		graph.ScorecardCheckField("Maintained"): "2",
+	}, fields)
+}
@@ -2,16 +2,72 @@ package ingest
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/package-url/packageurl-go"
 	"github.com/protobom/protobom/pkg/reader"
+	"github.com/protobom/protobom/pkg/sbom"
 )
 
+// SBOMNodeType is the type of the nodes that represent ingested SBOM documents.
+const SBOMNodeType = "sbom"
+
+// SBOMDocument is the metadata of an sbom node. The sbom node depends on the root components of the document, and
+// Components lists every component the document declared, including ones not reachable from a root.
+type SBOMDocument struct {
+	Name string `json:"name"`
+	// Identity is what versions of the same document have in common, see SBOMOptions.
+	Identity     string   `json:"identity"`
+	SerialNumber string   `json:"serialNumber,omitempty"`
+	Version      string   `json:"version,omitempty"`
+	Created      string   `json:"created,omitempty"`
+	Tools        []string `json:"tools,omitempty"`
+	SHA256       string   `json:"sha256"`
+	Components   []string `json:"components"`
+}
+
+// SBOMNodeName returns the name of the sbom node for a document: its serial number (the CycloneDX serialNumber or
+// the SPDX document namespace) when it has one, and the SHA-256 of the file otherwise.
+func SBOMNodeName(serialNumber, sha256Hex string) string {
+	if serialNumber != "" {
+		return "sbom:" + serialNumber
+	}
+	return "sbom:sha256:" + sha256Hex
+}
+
+// SBOMOptions controls how SBOMWithOptions ingests a document.
+type SBOMOptions struct {
+	// Replace retires the previously ingested version of the document: edges only it contributed are removed, along
+	// with the components that are no longer declared by any SBOM or depended on by any node.
+	Replace bool
+	// Identity ties versions of a document together. It defaults to the package URLs of the root components
+	// without their versions and qualifiers, such as pkg:golang/example.com/app for pkg:golang/example.com/app@v1.2.0.
+	Identity string
+}
+
+// SBOM ingests a document, adding to whatever earlier versions of it contributed to the graph.
 func SBOM(storage graph.Storage, data []byte) error {
+	return SBOMWithOptions(storage, data, SBOMOptions{})
+}
+
+// SBOMWithOptions ingests a document, replacing the previous version of it when opts.Replace is set.
+func SBOMWithOptions(storage graph.Storage, data []byte, opts SBOMOptions) error {
+	_, err := ingestSBOM(storage, data, opts)
+	return err
+}
+
+// ingestSBOM ingests a document and returns its sbom node, which is nil for documents without components.
+func ingestSBOM(storage graph.Storage, data []byte, opts SBOMOptions) (*graph.Node, error) {
 	if len(data) == 0 {
-		return fmt.Errorf("data is empty")
+		return nil, fmt.Errorf("data is empty")
 	}
 	// Create a new protobom reader
 	r := reader.New()
@@ -19,58 +75,262 @@ func SBOM(storage graph.Storage, data []byte) error {
 	// Parse the SBOM file
 	document, err := r.ParseStream(bytes.NewReader(data))
 	if err != nil {
-		return fmt.Errorf("failed to parse SBOM file: %w", err)
+		return nil, fmt.Errorf("failed to parse SBOM file: %w", err)
 	}
 
 	// Get the node list from the document
 	nodeList := document.GetNodeList()
 	if nodeList == nil {
-		return nil
+		return nil, nil
 	}
 
 	// Process each node in the SBOM
 
 	nameToId := map[string]uint32{}
+	idToName := map[string]string{}
+	var components []string
+	var edges []SBOMEdge
+	// protobom loses most of the licenses of CycloneDX components, so read them from the document itself
+	cdxLicenses := cycloneDXLicenses(data)
 
 	for _, node := range nodeList.GetNodes() {
 		purl := string(node.Purl())
 		if purl == "" {
 			purl = fmt.Sprintf("pkg:%s@%s", node.GetName(), node.GetVersion())
 		}
+		purl = graph.CanonicalName(purl)
 
 		graphNode, err := graph.AddNode(storage, "library", node, purl)
 		if err != nil {
 			if errors.Is(err, graph.ErrNodeAlreadyExists) {
 				// log.Printf("Skipping node %s: %s\n", node.GetName(), err)
 			} else {
-				return fmt.Errorf("failed to add node: %w", err)
+				return nil, fmt.Errorf("failed to add node: %w", err)
+			}
+		}
+
+		if err := indexDigests(storage, node, purl); err != nil {
+			return nil, err
+		}
+		if repository := sbomNodeRepository(node); repository != "" {
+			if err := recordRepository(storage, purl, repository); err != nil {
+				return nil, err
 			}
 		}
 
 		nameToId[node.Id] = graphNode.ID
+		idToName[node.Id] = purl
+		components = append(components, purl)
+
+		licenses := componentLicenses{declared: node.GetLicenses(), concluded: node.GetLicenseConcluded()}
+		if cdx, ok := cdxLicenses[node.Id]; ok {
+			licenses = cdx
+		}
+		licenseEdges, err := addLicenses(storage, graphNode, licenses.declared, licenses.concluded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add licenses of %s: %w", purl, err)
+		}
+		edges = append(edges, licenseEdges...)
 	}
 
 	for _, edge := range nodeList.Edges {
 		fromNode, err := storage.GetNode(nameToId[edge.From])
 		if err != nil {
-			return fmt.Errorf("failed to get from node %s: %w", edge.From, err)
+			return nil, fmt.Errorf("failed to get from node %s: %w", edge.From, err)
 		}
 
 		for _, to := range edge.To {
 
 			toNode, err := storage.GetNode(nameToId[to])
 			if err != nil {
-				return fmt.Errorf("failed to to get node %s: %w", edge.To, err)
+				return nil, fmt.Errorf("failed to to get node %s: %w", edge.To, err)
 			}
 
 			if fromNode.ID != toNode.ID {
 				if err := fromNode.SetDependency(storage, toNode); err != nil {
-					return fmt.Errorf("failed to add edge %s -> %s: %w", edge.From, to, err)
+					return nil, fmt.Errorf("failed to add edge %s -> %s: %w", edge.From, to, err)
 				}
+				edges = append(edges, SBOMEdge{From: idToName[edge.From], To: idToName[to]})
+			}
+
+		}
+	}
+
+	if err := linkVulnerabilities(storage, components); err != nil {
+		return nil, fmt.Errorf("failed to link vulnerabilities: %w", err)
+	}
+	if err := linkRepositories(storage, components); err != nil {
+		return nil, fmt.Errorf("failed to link repositories: %w", err)
+	}
+
+	sbomNode, rootEdges, err := addSBOMNode(storage, document, data, components, idToName, opts.Identity)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordSBOM(storage, sbomNode, append(edges, rootEdges...), opts.Replace); err != nil {
+		return nil, err
+	}
+	return sbomNode, nil
+}
+
+// addSBOMNode records the provenance of an ingested document as an sbom node depending on its root components,
+// and returns the node along with the edges to the roots. Ingesting a document with the same serial number again
+// updates the existing node.
+func addSBOMNode(storage graph.Storage, document *sbom.Document, data []byte, components []string, idToName map[string]string, identity string) (*graph.Node, []SBOMEdge, error) {
+	sum := sha256.Sum256(data)
+	doc := SBOMDocument{
+		SHA256:     hex.EncodeToString(sum[:]),
+		Components: uniqueSorted(components),
+	}
+
+	rootIds := document.GetNodeList().GetRootElements()
+	var roots []string
+	for _, id := range rootIds {
+		if name, ok := idToName[id]; ok {
+			roots = append(roots, name)
+		}
+	}
+	if md := document.GetMetadata(); md != nil {
+		doc.Name = md.GetName()
+		doc.SerialNumber = md.GetId()
+		doc.Version = md.GetVersion()
+		if date := md.GetDate(); date != nil && (date.GetSeconds() != 0 || date.GetNanos() != 0) {
+			doc.Created = date.AsTime().UTC().Format(time.RFC3339)
+		}
+		for _, tool := range md.GetTools() {
+			doc.Tools = append(doc.Tools, formatTool(tool.GetName(), tool.GetVersion()))
+		}
+	}
+	// protobom leaves the CycloneDX timestamp and tools out, so read them from the document itself
+	if doc.Created == "" || len(doc.Tools) == 0 {
+		created, tools := cycloneDXCreationInfo(data)
+		if doc.Created == "" {
+			doc.Created = created
+		}
+		if len(doc.Tools) == 0 {
+			doc.Tools = tools
+		}
+	}
+	if doc.Name == "" {
+		// Fall back to the name of the component the document describes
+		for _, id := range rootIds {
+			if node := document.GetNodeList().GetNodeByID(id); node != nil && node.GetName() != "" {
+				doc.Name = node.GetName()
+				break
 			}
+		}
+	}
+
+	name := SBOMNodeName(doc.SerialNumber, doc.SHA256)
+	doc.Identity = identity
+	if doc.Identity == "" {
+		doc.Identity = defaultSBOMIdentity(roots, name)
+	}
+
+	sbomNode, err := graph.AddNode(storage, SBOMNodeType, doc, name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to add sbom node: %w", err)
+	}
+	// The node already existed if the document was ingested before, so refresh its metadata
+	sbomNode.Metadata = doc
+	if err := storage.SaveNode(sbomNode); err != nil {
+		return nil, nil, fmt.Errorf("failed to save sbom node: %w", err)
+	}
 
+	var edges []SBOMEdge
+	for _, root := range uniqueSorted(roots) {
+		rootID, err := storage.NameToID(root)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to find root node %s: %w", root, err)
+		}
+		rootNode, err := storage.GetNode(rootID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get root node %s: %w", root, err)
 		}
+		if err := sbomNode.SetDependency(storage, rootNode); err != nil {
+			return nil, nil, fmt.Errorf("failed to add edge %s -> %s: %w", sbomNode.Name, rootNode.Name, err)
+		}
+		edges = append(edges, SBOMEdge{From: sbomNode.Name, To: root})
 	}
+	return sbomNode, edges, nil
+}
 
-	return nil
+// defaultSBOMIdentity returns the package URLs of the roots without versions and qualifiers, or the name of the
+// sbom node for documents without roots.
+func defaultSBOMIdentity(roots []string, sbomNodeName string) string {
+	if len(roots) == 0 {
+		return sbomNodeName
+	}
+	identities := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if purl, err := packageurl.FromString(root); err == nil {
+			purl.Version = ""
+			purl.Qualifiers = nil
+			purl.Subpath = ""
+			identities = append(identities, purl.ToString())
+		} else if i := strings.LastIndex(root, "@"); i > 0 {
+			identities = append(identities, root[:i])
+		} else {
+			identities = append(identities, root)
+		}
+	}
+	return strings.Join(uniqueSorted(identities), ",")
+}
+
+func uniqueSorted(values []string) []string {
+	if len(values) == 0 {
+		return values
+	}
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	unique := sorted[:1]
+	for _, value := range sorted[1:] {
+		if value != unique[len(unique)-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// cycloneDXCreationInfo returns the timestamp and tools of a CycloneDX JSON document, which are listed either as
+// an array of tools or, since CycloneDX 1.5, as tool components and services.
+func cycloneDXCreationInfo(data []byte) (string, []string) {
+	type cdxTool struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	var bom struct {
+		Metadata struct {
+			Timestamp string          `json:"timestamp"`
+			Tools     json.RawMessage `json:"tools"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &bom); err != nil {
+		return "", nil
+	}
+
+	var tools []cdxTool
+	if err := json.Unmarshal(bom.Metadata.Tools, &tools); err != nil {
+		var grouped struct {
+			Components []cdxTool `json:"components"`
+			Services   []cdxTool `json:"services"`
+		}
+		if err := json.Unmarshal(bom.Metadata.Tools, &grouped); err == nil {
+			tools = append(grouped.Components, grouped.Services...)
+		}
+	}
+	var names []string
+	for _, tool := range tools {
+		if tool.Name != "" {
+			names = append(names, formatTool(tool.Name, tool.Version))
+		}
+	}
+	return bom.Metadata.Timestamp, names
+}
+
+func formatTool(name, version string) string {
+	if version == "" {
+		return name
+	}
+	return name + "@" + version
 }
@@ -0,0 +1,278 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+)
+
+const (
+	// SBOMIdentityTag is the custom data tag mapping a document identity to the sbom node of its latest version.
+	SBOMIdentityTag = "sbom_identity"
+	// SBOMComponentsTag is the custom data tag recording, for every component name, the sbom nodes declaring it.
+	SBOMComponentsTag = "sbom_components"
+	// SBOMContributionsTag is the custom data tag recording, for every sbom node, the components and edges its
+	// document declared.
+	SBOMContributionsTag = "sbom_contributions"
+
+	latestDataKey     = "latest"
+	edgesDataKey      = "edges"
+	componentsDataKey = "components"
+)
+
+// SBOMEdge is an edge between two nodes, by name, that an SBOM document declared.
+type SBOMEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// recordSBOM records what the document of sbomNode contributed to the graph, so a later version of it can replace
+// it, and retires the previous version of the document when replace is set.
+func recordSBOM(storage graph.Storage, sbomNode *graph.Node, edges []SBOMEdge, replace bool) error {
+	doc, ok := sbomNode.Metadata.(SBOMDocument)
+	if !ok {
+		return fmt.Errorf("sbom node %s has no document metadata", sbomNode.Name)
+	}
+
+	latest, err := storage.GetCustomData(SBOMIdentityTag, doc.Identity)
+	if err != nil {
+		return fmt.Errorf("failed to get the latest version of %s: %w", doc.Identity, err)
+	}
+	previous := string(latest[latestDataKey])
+
+	// The previous contributions have to be read before they are overwritten when the same document is re-ingested
+	var previousEdges []SBOMEdge
+	var previousComponents []string
+	if replace && previous != "" {
+		if previousEdges, err = getSBOMEdges(storage, previous); err != nil {
+			return err
+		}
+		if previousComponents, err = getSBOMComponents(storage, previous); err != nil {
+			return err
+		}
+	}
+
+	if err := setContribution(storage, sbomNode.Name, edgesDataKey, edges); err != nil {
+		return err
+	}
+	if err := setContribution(storage, sbomNode.Name, componentsDataKey, doc.Components); err != nil {
+		return err
+	}
+	for _, component := range doc.Components {
+		if err := storage.AddOrUpdateCustomData(SBOMComponentsTag, component, sbomNode.Name, nil); err != nil {
+			return fmt.Errorf("failed to record sbom component %s: %w", component, err)
+		}
+	}
+	if err := storage.AddOrUpdateCustomData(SBOMIdentityTag, doc.Identity, latestDataKey, []byte(sbomNode.Name)); err != nil {
+		return fmt.Errorf("failed to record the latest version of %s: %w", doc.Identity, err)
+	}
+
+	if !replace || previous == "" {
+		return nil
+	}
+	return retireSBOM(storage, previous, previousEdges, previousComponents, sbomNode.Name, edges, doc.Components)
+}
+
+// retireSBOM removes what the previous version of a document contributed and the current version no longer
+// declares. Edges and components another SBOM still declares are kept, and so are components that something else,
+// such as a vulnerability or another package, still depends on.
+func retireSBOM(storage graph.Storage, previous string, previousEdges []SBOMEdge, previousComponents []string, current string, edges []SBOMEdge, components []string) error {
+	currentEdges := make(map[SBOMEdge]bool, len(edges))
+	for _, edge := range edges {
+		currentEdges[edge] = true
+	}
+	currentComponents := make(map[string]bool, len(components))
+	for _, component := range components {
+		currentComponents[component] = true
+	}
+	others := &otherSBOMs{storage: storage, exclude: map[string]bool{previous: true, current: true}, edges: map[string][]SBOMEdge{}}
+
+	for _, edge := range previousEdges {
+		if currentEdges[edge] {
+			continue
+		}
+		declared, err := others.declareEdge(edge)
+		if err != nil {
+			return err
+		}
+		if declared {
+			continue
+		}
+		if err := removeEdge(storage, edge); err != nil {
+			return err
+		}
+	}
+
+	if previous != current {
+		id, err := storage.NameToID(previous)
+		if err == nil {
+			if err := graph.RemoveNode(storage, id); err != nil {
+				return fmt.Errorf("failed to remove sbom node %s: %w", previous, err)
+			}
+		}
+		for _, dataKey := range []string{edgesDataKey, componentsDataKey} {
+			if err := storage.DeleteCustomData(SBOMContributionsTag, previous, dataKey); err != nil {
+				return fmt.Errorf("failed to remove the contributions of %s: %w", previous, err)
+			}
+		}
+	}
+
+	var stale []string
+	for _, component := range previousComponents {
+		declared := currentComponents[component]
+		// A re-ingested document keeps declaring its current components under the same name
+		if previous != current || !declared {
+			if err := storage.DeleteCustomData(SBOMComponentsTag, component, previous); err != nil {
+				return fmt.Errorf("failed to update sbom component %s: %w", component, err)
+			}
+		}
+		if !declared {
+			stale = append(stale, component)
+		}
+	}
+	return removeOrphans(storage, stale)
+}
+
+// removeOrphans removes the components that no SBOM declares and nothing depends on. Removing a component can
+// orphan its dependencies, so it repeats until nothing else is removed.
+func removeOrphans(storage graph.Storage, candidates []string) error {
+	for removed := true; removed; {
+		removed = false
+		remaining := candidates[:0]
+		for _, name := range candidates {
+			id, err := storage.NameToID(name)
+			if err != nil {
+				// Already removed
+				continue
+			}
+			declaredBy, err := storage.GetCustomData(SBOMComponentsTag, name)
+			if err != nil {
+				return fmt.Errorf("failed to get the sboms declaring %s: %w", name, err)
+			}
+			if len(declaredBy) > 0 {
+				continue
+			}
+			node, err := storage.GetNode(id)
+			if err != nil {
+				return fmt.Errorf("failed to get node %s: %w", name, err)
+			}
+			if !node.Parents.IsEmpty() {
+				remaining = append(remaining, name)
+				continue
+			}
+			if err := graph.RemoveNode(storage, id); err != nil {
+				return fmt.Errorf("failed to remove orphaned node %s: %w", name, err)
+			}
+			removed = true
+		}
+		candidates = remaining
+	}
+	return nil
+}
+
+func removeEdge(storage graph.Storage, edge SBOMEdge) error {
+	fromID, err := storage.NameToID(edge.From)
+	if err != nil {
+		return nil
+	}
+	toID, err := storage.NameToID(edge.To)
+	if err != nil {
+		return nil
+	}
+	nodes, err := storage.GetNodes([]uint32{fromID, toID})
+	if err != nil {
+		return fmt.Errorf("failed to get nodes of edge %s -> %s: %w", edge.From, edge.To, err)
+	}
+	from, to := nodes[fromID], nodes[toID]
+	if from == nil || to == nil || !from.Children.Contains(toID) {
+		return nil
+	}
+	if err := from.RemoveDependency(storage, to); err != nil {
+		return fmt.Errorf("failed to remove edge %s -> %s: %w", edge.From, edge.To, err)
+	}
+	return nil
+}
+
+// otherSBOMs answers whether SBOMs other than the ones being replaced declare an edge.
+type otherSBOMs struct {
+	storage graph.Storage
+	exclude map[string]bool
+	edges   map[string][]SBOMEdge
+}
+
+func (o *otherSBOMs) declareEdge(edge SBOMEdge) (bool, error) {
+	// Only SBOMs declaring the dependent can declare the edge
+	declaredBy, err := o.storage.GetCustomData(SBOMComponentsTag, edge.From)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the sboms declaring %s: %w", edge.From, err)
+	}
+	for name := range declaredBy {
+		if o.exclude[name] {
+			continue
+		}
+		edges, ok := o.edges[name]
+		if !ok {
+			if edges, err = getSBOMEdges(o.storage, name); err != nil {
+				return false, err
+			}
+			o.edges[name] = edges
+		}
+		for _, other := range edges {
+			if other == edge {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func setContribution(storage graph.Storage, sbomNodeName, dataKey string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the %s of %s: %w", dataKey, sbomNodeName, err)
+	}
+	if err := storage.AddOrUpdateCustomData(SBOMContributionsTag, sbomNodeName, dataKey, data); err != nil {
+		return fmt.Errorf("failed to record the %s of %s: %w", dataKey, sbomNodeName, err)
+	}
+	return nil
+}
+
+// getSBOMEdges returns the edges an sbom node declared. Names recorded before node names were canonical are returned
+// in their canonical form, like the names of the components of getSBOMComponents.
+func getSBOMEdges(storage graph.Storage, sbomNodeName string) ([]SBOMEdge, error) {
+	var edges []SBOMEdge
+	if err := getContribution(storage, sbomNodeName, edgesDataKey, &edges); err != nil {
+		return nil, err
+	}
+	for i, edge := range edges {
+		edges[i] = SBOMEdge{From: graph.CanonicalName(edge.From), To: graph.CanonicalName(edge.To)}
+	}
+	return edges, nil
+}
+
+func getSBOMComponents(storage graph.Storage, sbomNodeName string) ([]string, error) {
+	var components []string
+	if err := getContribution(storage, sbomNodeName, componentsDataKey, &components); err != nil {
+		return nil, err
+	}
+	for i, component := range components {
+		components[i] = graph.CanonicalName(component)
+	}
+	return components, nil
+}
+
+func getContribution(storage graph.Storage, sbomNodeName, dataKey string, value any) error {
+	data, err := storage.GetCustomData(SBOMContributionsTag, sbomNodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get the %s of %s: %w", dataKey, sbomNodeName, err)
+	}
+	raw, ok := data[dataKey]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(raw, value); err != nil {
+		return fmt.Errorf("failed to unmarshal the %s of %s: %w", dataKey, sbomNodeName, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package ingest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testComponent struct {
+	ref, purl string
+	deps      []string
+}
+
+// cycloneDX builds a CycloneDX document whose first component is the root.
+func cycloneDX(serial string, components ...testComponent) []byte {
+	var comps, deps []string
+	for _, c := range components[1:] {
+		comps = append(comps, fmt.Sprintf(`{"bom-ref": %q, "type": "library", "name": %q, "purl": %q}`, c.ref, c.ref, c.purl))
+	}
+	for _, c := range components {
+		quoted := make([]string, len(c.deps))
+		for i, dep := range c.deps {
+			quoted[i] = fmt.Sprintf("%q", dep)
+		}
+		deps = append(deps, fmt.Sprintf(`{"ref": %q, "dependsOn": [%s]}`, c.ref, strings.Join(quoted, ",")))
+	}
+	root := components[0]
+	return []byte(fmt.Sprintf(`{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "serialNumber": %q,
+  "version": 1,
+  "metadata": {"component": {"bom-ref": %q, "type": "application", "name": %q, "purl": %q}},
+  "components": [%s],
+  "dependencies": [%s]
+}`, serial, root.ref, root.ref, root.purl, strings.Join(comps, ","), strings.Join(deps, ",")))
+}
+
+var (
+	appV1 = cycloneDX("urn:uuid:00000000-0000-0000-0000-000000000001",
+		testComponent{"app", "pkg:golang/example.com/app@v1.0.0", []string{"a", "b"}},
+		testComponent{"a", "pkg:golang/example.com/a@v1.0.0", nil},
+		testComponent{"b", "pkg:golang/example.com/b@v1.0.0", []string{"c"}},
+		testComponent{"c", "pkg:golang/example.com/c@v1.0.0", nil},
+	)
+	appV2 = cycloneDX("urn:uuid:00000000-0000-0000-0000-000000000002",
+		testComponent{"app", "pkg:golang/example.com/app@v1.1.0", []string{"a", "d"}},
+		testComponent{"a", "pkg:golang/example.com/a@v1.0.0", nil},
+		testComponent{"d", "pkg:golang/example.com/d@v1.0.0", nil},
+	)
+	other = cycloneDX("urn:uuid:00000000-0000-0000-0000-000000000003",
+		testComponent{"other", "pkg:golang/example.com/other@v1.0.0", []string{"b"}},
+		testComponent{"b", "pkg:golang/example.com/b@v1.0.0", nil},
+	)
+)
+
+func nodeByName(t *testing.T, storage graph.Storage, name string) *graph.Node {
+	t.Helper()
+	id, err := storage.NameToID(name)
+	require.NoError(t, err, name)
+	node, err := storage.GetNode(id)
+	require.NoError(t, err, name)
+	return node
+}
+
+func exists(storage graph.Storage, name string) bool {
+	_, err := storage.NameToID(name)
+	return err == nil
+}
+
+func TestSBOMWithOptions_Replace(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, SBOM(storage, other))
+	require.NoError(t, SBOM(storage, appV1))
+	require.NoError(t, SBOMWithOptions(storage, appV2, SBOMOptions{Replace: true}))
+
+	v1 := SBOMNodeName("urn:uuid:00000000-0000-0000-0000-000000000001", "")
+	v2 := SBOMNodeName("urn:uuid:00000000-0000-0000-0000-000000000002", "")
+	assert.False(t, exists(storage, v1), "the previous sbom node is retired")
+	assert.True(t, exists(storage, v2))
+
+	// The old root and the dependency only it pulled in are orphaned
+	assert.False(t, exists(storage, "pkg:golang/example.com/app@v1.0.0"))
+	assert.False(t, exists(storage, "pkg:golang/example.com/c@v1.0.0"))
+
+	// A component the new version still declares keeps only its new dependents
+	a := nodeByName(t, storage, "pkg:golang/example.com/a@v1.0.0")
+	appNew := nodeByName(t, storage, "pkg:golang/example.com/app@v1.1.0")
+	assert.Equal(t, []uint32{appNew.ID}, a.Parents.ToArray())
+
+	// A component another SBOM declares is kept, without the edge only the old version declared
+	b := nodeByName(t, storage, "pkg:golang/example.com/b@v1.0.0")
+	otherRoot := nodeByName(t, storage, "pkg:golang/example.com/other@v1.0.0")
+	assert.Equal(t, []uint32{otherRoot.ID}, b.Parents.ToArray())
+	assert.True(t, b.Children.IsEmpty())
+
+	declaredBy, err := storage.GetCustomData(SBOMComponentsTag, "pkg:golang/example.com/a@v1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, []string{v2}, keys(declaredBy))
+
+	// The caches of the affected nodes are rebuilt
+	require.NoError(t, graph.Cache(storage))
+	dependents, err := a.QueryDependents(storage)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint32{a.ID, appNew.ID, nodeByName(t, storage, v2).ID}, dependents.ToArray())
+}
+
+func TestSBOMWithOptions_ReplaceKeepsDependedOnComponents(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, SBOM(storage, appV1))
+
+	// Something outside of any SBOM depends on c
+	c := nodeByName(t, storage, "pkg:golang/example.com/c@v1.0.0")
+	pinned, err := graph.AddNode(storage, "library", nil, "pinned")
+	require.NoError(t, err)
+	require.NoError(t, pinned.SetDependency(storage, c))
+
+	require.NoError(t, SBOMWithOptions(storage, appV2, SBOMOptions{Replace: true}))
+	assert.True(t, exists(storage, "pkg:golang/example.com/c@v1.0.0"))
+	assert.False(t, exists(storage, "pkg:golang/example.com/b@v1.0.0"))
+}
+
+func TestSBOMWithOptions_WithoutReplace(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, SBOM(storage, appV1))
+	require.NoError(t, SBOM(storage, appV2))
+
+	for _, name := range []string{
+		SBOMNodeName("urn:uuid:00000000-0000-0000-0000-000000000001", ""),
+		"pkg:golang/example.com/app@v1.0.0",
+		"pkg:golang/example.com/c@v1.0.0",
+	} {
+		assert.True(t, exists(storage, name), name)
+	}
+}
+
+func TestSBOMWithOptions_ReplaceSameDocument(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, SBOM(storage, appV1))
+
+	// The same serial number with fewer dependencies
+	trimmed := cycloneDX("urn:uuid:00000000-0000-0000-0000-000000000001",
+		testComponent{"app", "pkg:golang/example.com/app@v1.0.0", []string{"a"}},
+		testComponent{"a", "pkg:golang/example.com/a@v1.0.0", nil},
+	)
+	require.NoError(t, SBOMWithOptions(storage, trimmed, SBOMOptions{Replace: true}))
+
+	assert.True(t, exists(storage, SBOMNodeName("urn:uuid:00000000-0000-0000-0000-000000000001", "")))
+	assert.True(t, exists(storage, "pkg:golang/example.com/a@v1.0.0"))
+	assert.False(t, exists(storage, "pkg:golang/example.com/b@v1.0.0"))
+	assert.False(t, exists(storage, "pkg:golang/example.com/c@v1.0.0"))
+
+	app := nodeByName(t, storage, "pkg:golang/example.com/app@v1.0.0")
+	assert.Equal(t, uint64(1), app.Children.GetCardinality())
+}
+
+func TestSBOMWithOptions_Identity(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, SBOM(storage, other))
+
+	// Replacing under an explicit identity leaves documents with other identities alone
+	require.NoError(t, SBOMWithOptions(storage, appV2, SBOMOptions{Replace: true, Identity: "app"}))
+	assert.True(t, exists(storage, SBOMNodeName("urn:uuid:00000000-0000-0000-0000-000000000003", "")))
+
+	latest, err := storage.GetCustomData(SBOMIdentityTag, "app")
+	require.NoError(t, err)
+	assert.Equal(t, SBOMNodeName("urn:uuid:00000000-0000-0000-0000-000000000002", ""), string(latest["latest"]))
+}
+
+func TestDefaultSBOMIdentity(t *testing.T) {
+	assert.Equal(t, "pkg:golang/example.com/app", defaultSBOMIdentity([]string{"pkg:golang/example.com/app@v1.0.0?goos=linux"}, "sbom:x"))
+	assert.Equal(t, "pkg:a,pkg:b", defaultSBOMIdentity([]string{"pkg:b@", "pkg:a@1"}, "sbom:x"))
+	assert.Equal(t, "sbom:x", defaultSBOMIdentity(nil, "sbom:x"))
+}
+
+func keys(m map[string][]byte) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
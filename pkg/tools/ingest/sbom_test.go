@@ -1,12 +1,15 @@
 package ingest
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestIngestSBOM(t *testing.T) {
@@ -40,9 +43,95 @@ func TestIngestSBOM(t *testing.T) {
 		t.Fatalf("Failed to get all keys: %v", err)
 	}
 
-	// Verify we have the expected number of nodes
-	if len(keys) != 1600 {
-		t.Fatalf("Expected 1600 nodes to be created from SBOM ingestion, got %d", len(keys))
+	// Verify we have the expected number of nodes, one sbom node per document and one license node per license
+	if len(keys) != 1631 {
+		t.Fatalf("Expected 1631 nodes to be created from SBOM ingestion, got %d", len(keys))
 	}
 
 }
+
+const testCycloneDXSBOM = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+  "version": 1,
+  "metadata": {
+    "timestamp": "2024-08-23T12:49:02Z",
+    "tools": {"components": [{"type": "application", "name": "syft", "version": "1.11.0"}]},
+    "component": {"bom-ref": "app", "type": "application", "name": "example-app", "purl": "pkg:golang/example.com/app@v1.0.0"}
+  },
+  "components": [
+    {"bom-ref": "log4j", "type": "library", "name": "log4j-core", "version": "2.14.1", "purl": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"},
+    {"bom-ref": "uuid", "type": "library", "name": "uuid", "version": "v1.6.0", "purl": "pkg:golang/github.com/google/uuid@v1.6.0"}
+  ]
+}`
+
+func TestIngestSBOM_ProvenanceNode(t *testing.T) {
+	storage := graph.NewMockStorage()
+	if err := SBOM(storage, []byte(testCycloneDXSBOM)); err != nil {
+		t.Fatalf("Failed to ingest SBOM: %v", err)
+	}
+
+	name := SBOMNodeName("urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79", "")
+	id, err := storage.NameToID(name)
+	if err != nil {
+		t.Fatalf("Failed to find sbom node %s: %v", name, err)
+	}
+	sbomNode, err := storage.GetNode(id)
+	if err != nil {
+		t.Fatalf("Failed to get sbom node: %v", err)
+	}
+	if sbomNode.Type != SBOMNodeType {
+		t.Errorf("Expected type %s, got %s", SBOMNodeType, sbomNode.Type)
+	}
+
+	sum := sha256.Sum256([]byte(testCycloneDXSBOM))
+	want := SBOMDocument{
+		Name:         "example-app",
+		Identity:     "pkg:golang/example.com/app",
+		SerialNumber: "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+		Version:      "1",
+		Created:      "2024-08-23T12:49:02Z",
+		Tools:        []string{"syft@1.11.0"},
+		SHA256:       hex.EncodeToString(sum[:]),
+		Components: []string{
+			"pkg:golang/example.com/app@v1.0.0",
+			"pkg:golang/github.com/google/uuid@v1.6.0",
+			"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1",
+		},
+	}
+	if diff := cmp.Diff(want, sbomNode.Metadata); diff != "" {
+		t.Errorf("Unexpected sbom metadata (-want +got):\n%s", diff)
+	}
+
+	// The sbom node only depends on the root component
+	rootID, err := storage.NameToID("pkg:golang/example.com/app@v1.0.0")
+	if err != nil {
+		t.Fatalf("Failed to find root component: %v", err)
+	}
+	if got := sbomNode.Children.ToArray(); len(got) != 1 || got[0] != rootID {
+		t.Errorf("Expected the sbom node to depend on %d, got %v", rootID, got)
+	}
+
+	// Ingesting the same document again reuses the node
+	keys, err := storage.GetAllKeys()
+	if err != nil {
+		t.Fatalf("Failed to get all keys: %v", err)
+	}
+	if err := SBOM(storage, []byte(testCycloneDXSBOM)); err != nil {
+		t.Fatalf("Failed to ingest SBOM again: %v", err)
+	}
+	again, err := storage.GetAllKeys()
+	if err != nil {
+		t.Fatalf("Failed to get all keys: %v", err)
+	}
+	if len(again) != len(keys) {
+		t.Errorf("Expected %d nodes after re-ingesting, got %d", len(keys), len(again))
+	}
+}
+
+func TestSBOMNodeName(t *testing.T) {
+	if got := SBOMNodeName("", "abc"); got != "sbom:sha256:abc" {
+		t.Errorf("Expected the hash to name a document without a serial number, got %s", got)
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-
+	"strconv"
 	"strings"
 
 	"github.com/bitbomdev/minefield/pkg/graph"
@@ -45,7 +45,10 @@ type ScorecardResult struct {
 	GitHubURL string        `json:"github_url,omitempty"`
 }
 
-// Scorecard processes the Scorecard JSON data and stores it in the graph.
+// Scorecards ingests Scorecard results. Scorecards score repositories rather than versions of packages, so each is
+// attached to the repository node of the repository it scored, which every version of the libraries built from that
+// repository depends on. Libraries are matched to their repository through SBOM external references, the package
+// the scorecard was requested for, and their package URLs, see linkRepositories.
 func Scorecards(storage graph.Storage, data []byte) error {
 	if len(data) == 0 {
 		return fmt.Errorf("data is empty")
@@ -56,25 +59,31 @@ func Scorecards(storage graph.Storage, data []byte) error {
 		return fmt.Errorf("failed to decode Scorecard data: %w", err)
 	}
 
-	scorecardResults := map[string][]ScorecardResult{}
-
 	for _, result := range results {
 		if !result.Success {
 			continue
 		}
-		purl, err := packageurl.FromString(result.PURL)
-		if err != nil {
-			// Log and skip invalid PURLs instead of failing
-			log.Printf("Warning: Invalid PURL %q: %v", result.PURL, err)
+		repository := NormalizeRepository(result.Scorecard.Repo.Name)
+		if repository == "" {
+			repository = NormalizeRepository(result.GitHubURL)
+		}
+		if repository == "" {
+			// Log and skip scorecards without a repository instead of failing
+			log.Printf("Warning: No repository in the Scorecard of %q", result.PURL)
 			continue
 		}
 
-		if purl.Name == "" {
-			log.Printf("Warning: Empty package name in PURL %q", result.PURL)
-			continue
+		if result.PURL != "" {
+			if _, err := packageurl.FromString(result.PURL); err != nil {
+				log.Printf("Warning: Invalid PURL %q: %v", result.PURL, err)
+			} else if err := recordRepository(storage, result.PURL, repository); err != nil {
+				return err
+			}
 		}
 
-		scorecardResults[purl.Name] = append(scorecardResults[purl.Name], result)
+		if err := addScorecard(storage, repository, result); err != nil {
+			return err
+		}
 	}
 
 	keys, err := storage.GetAllKeys()
@@ -87,46 +96,85 @@ func Scorecards(storage graph.Storage, data []byte) error {
 		return fmt.Errorf("failed to get nodes from storage: %w", err)
 	}
 
+	var libraries []string
 	for _, node := range nodes {
-		if node.Type == tools.LibraryType && strings.HasPrefix(node.Name, pkg) {
-			purl, err := PURLToPackage(node.Name)
-			if err != nil {
-				continue
-			}
+		if node.Type == tools.LibraryType {
+			libraries = append(libraries, node.Name)
+		}
+	}
+	return linkRepositories(storage, libraries)
+}
 
-			scorecardData, ok := scorecardResults[purl.Name]
-			if !ok {
-				continue
-			}
+// addScorecard adds the scorecard node of a repository, along with the repository node depending on it. The
+// scorecard ingested last replaces the previous one of the repository.
+func addScorecard(storage graph.Storage, repository string, result ScorecardResult) error {
+	repositoryNode, err := graph.AddNode(storage, tools.RepositoryType, Repository{Name: repository}, RepositoryNodeName(repository))
+	if err != nil {
+		return fmt.Errorf("failed to add repository node to storage: %w", err)
+	}
 
-			for _, scorecardResult := range scorecardData {
-
-				if scorecardResult.Success {
-					scorecardPurl, err := PURLToPackage(scorecardResult.PURL)
-					if err != nil {
-						continue
-					}
-
-					// The scorecard data is found based on the packages name, but then we need
-					// to check whether the scorecard data is for the current packages version
-					if scorecardPurl.Version == purl.Version {
-						scorecardNode, err := graph.AddNode(storage, tools.ScorecardType, scorecardResult, getScorecardNodeName(scorecardResult.PURL))
-						if err != nil {
-							return fmt.Errorf("failed to add Scorecard node to storage: %w", err)
-						}
-
-						if err := node.SetDependency(storage, scorecardNode); err != nil {
-							return fmt.Errorf("failed to add dependency edge to Scorecard node: %w", err)
-						}
-					}
-				}
-			}
+	scorecardNode, err := graph.AddNode(storage, tools.ScorecardType, result, getScorecardNodeName(repository))
+	if err != nil {
+		return fmt.Errorf("failed to add Scorecard node to storage: %w", err)
+	}
+	// The node already existed if the repository was scored before, so refresh its metadata
+	scorecardNode.Metadata = result
+	if err := storage.SaveNode(scorecardNode); err != nil {
+		return fmt.Errorf("failed to save Scorecard node: %w", err)
+	}
+
+	if err := repositoryNode.SetDependency(storage, scorecardNode); err != nil {
+		return fmt.Errorf("failed to add dependency edge to Scorecard node: %w", err)
+	}
+
+	fields := scorecardFields(result)
+	for _, name := range []string{scorecardNode.Name, repositoryNode.Name} {
+		if err := setScorecardFields(storage, name, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scorecardFields returns the fields of the aggregate score and the scored checks of a scorecard.
+func scorecardFields(result ScorecardResult) map[string]string {
+	fields := map[string]string{
+		graph.ScorecardScoreField: strconv.FormatFloat(result.Scorecard.Score, 'f', -1, 64),
+	}
+	for _, check := range result.Scorecard.Checks {
+		if check.Name == "" || check.Score < 0 {
+			continue
 		}
+		fields[graph.ScorecardCheckField(check.Name)] = strconv.Itoa(check.Score)
 	}
+	return fields
+}
 
+// setScorecardFields replaces the Scorecard fields of the node with the given name.
+func setScorecardFields(storage graph.Storage, name string, fields map[string]string) error {
+	existing, err := graph.GetFields(storage, name)
+	if err != nil {
+		return err
+	}
+	for key := range existing {
+		if _, ok := fields[key]; !ok && isScorecardField(key) {
+			if err := graph.DeleteField(storage, name, key); err != nil {
+				return err
+			}
+		}
+	}
+	for key, value := range fields {
+		if err := graph.SetField(storage, name, key, value); err != nil {
+			return fmt.Errorf("failed to set field %s of %s: %w", key, name, err)
+		}
+	}
 	return nil
 }
 
+func isScorecardField(key string) bool {
+	return key == graph.ScorecardScoreField || strings.HasPrefix(key, graph.ScorecardCheckFieldPrefix)
+}
+
 func getScorecardNodeName(name string) string {
 	return "scorecard:" + name
 }
@@ -75,7 +75,20 @@ func TestScorecards(t *testing.T) {
 		t.Fatalf("Failed to get all keys, %v", err)
 	}
 
-	if len(keys) != numberOfNodes+1 {
-		t.Fatalf("Expected number of nodes to be %d, got %d", numberOfNodes+1, len(keys))
+	// A repository node and the scorecard node attached to it
+	if len(keys) != numberOfNodes+2 {
+		t.Fatalf("Expected number of nodes to be %d, got %d", numberOfNodes+2, len(keys))
+	}
+
+	repositoryID, err := storage.NameToID(RepositoryNodeName("github.com/pypa/setuptools"))
+	if err != nil {
+		t.Fatalf("Failed to find repository node: %v", err)
+	}
+	repositoryNode, err := storage.GetNode(repositoryID)
+	if err != nil {
+		t.Fatalf("Failed to get repository node: %v", err)
+	}
+	if repositoryNode.Parents.GetCardinality() != 1 {
+		t.Fatalf("Expected the setuptools library to depend on the repository, got %d dependents", repositoryNode.Parents.GetCardinality())
 	}
 }
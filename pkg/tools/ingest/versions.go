@@ -0,0 +1,800 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// versionComparators compare two versions of an ecosystem, returning an error when either is not a valid version
+// of it.
+var versionComparators = map[Ecosystem]func(v1, v2 string) (int, error){
+	EcosystemGo:        compareSemver,
+	EcosystemNPM:       compareSemver,
+	EcosystemCratesIO:  compareSemver,
+	EcosystemHex:       compareSemver,
+	EcosystemPyPI:      comparePyPI,
+	EcosystemRubyGems:  compareRubyGems,
+	EcosystemPackagist: comparePackagist,
+	EcosystemMaven:     compareMaven,
+	EcosystemNuGet:     compareNuGet,
+	EcosystemDebian:    compareDebian,
+	EcosystemAlpine:    compareAlpine,
+}
+
+// compareEcosystemVersions compares two versions the way their ecosystem orders them. Releases such as "Debian:12"
+// or "Alpine:v3.18" use the comparator of their ecosystem, and versions the ecosystem can't parse, like those of
+// ecosystems without a comparator, fall back to string comparison.
+func compareEcosystemVersions(v1, v2, ecosystem string) int {
+	name, _, _ := strings.Cut(ecosystem, ":")
+	if compare, ok := versionComparators[Ecosystem(name)]; ok {
+		if result, err := compare(v1, v2); err == nil {
+			return result
+		}
+	}
+	return strings.Compare(v1, v2)
+}
+
+func compareSemver(v1, v2 string) (int, error) {
+	ver1, err := semver.NewVersion(v1)
+	if err != nil {
+		return 0, err
+	}
+	ver2, err := semver.NewVersion(v2)
+	if err != nil {
+		return 0, err
+	}
+	return ver1.Compare(ver2), nil
+}
+
+// compareDigits compares two runs of digits numerically, without overflowing on long runs.
+func compareDigits(d1, d2 string) int {
+	d1 = strings.TrimLeft(d1, "0")
+	d2 = strings.TrimLeft(d2, "0")
+	if len(d1) != len(d2) {
+		return compareInts(len(d1), len(d2))
+	}
+	return strings.Compare(d1, d2)
+}
+
+func compareInts(i1, i2 int) int {
+	switch {
+	case i1 < i2:
+		return -1
+	case i1 > i2:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAllDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// PyPI versions follow PEP 440.
+
+var pep440Regexp = regexp.MustCompile(`^v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(?P<pre_l>alpha|beta|preview|pre|a|b|c|rc)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?:-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+	`(?:[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+type pep440Version struct {
+	epoch   string
+	release []string
+	// preRank orders versions without a pre-release segment: -1 for development releases of a final release, which
+	// come before its pre-releases, 1 for everything else, and 0 for pre-releases, ordered by pre.
+	preRank int
+	pre     [2]string
+	hasPost bool
+	post    string
+	hasDev  bool
+	dev     string
+	local   []string
+}
+
+func parsePEP440(version string) (*pep440Version, error) {
+	match := pep440Regexp.FindStringSubmatch(strings.ToLower(strings.TrimSpace(version)))
+	if match == nil {
+		return nil, fmt.Errorf("invalid PEP 440 version %q", version)
+	}
+	group := func(name string) string {
+		return match[pep440Regexp.SubexpIndex(name)]
+	}
+
+	v := &pep440Version{epoch: group("epoch")}
+	release := strings.Split(group("release"), ".")
+	// Trailing zeros don't matter, 1.0 == 1.0.0
+	for len(release) > 1 && strings.Trim(release[len(release)-1], "0") == "" {
+		release = release[:len(release)-1]
+	}
+	v.release = release
+
+	if preLabel := group("pre_l"); preLabel != "" {
+		switch preLabel {
+		case "alpha":
+			preLabel = "a"
+		case "beta":
+			preLabel = "b"
+		case "c", "pre", "preview":
+			preLabel = "rc"
+		}
+		v.pre = [2]string{preLabel, group("pre_n")}
+	}
+	if n := group("post_n1"); n != "" {
+		v.hasPost, v.post = true, n
+	} else if group("post_l") != "" {
+		v.hasPost, v.post = true, group("post_n2")
+	}
+	if group("dev_l") != "" {
+		v.hasDev, v.dev = true, group("dev_n")
+	}
+	switch {
+	case v.pre[0] != "":
+		v.preRank = 0
+	case v.hasDev && !v.hasPost:
+		v.preRank = -1
+	default:
+		v.preRank = 1
+	}
+	if local := group("local"); local != "" {
+		v.local = strings.FieldsFunc(local, func(r rune) bool { return r == '-' || r == '_' || r == '.' })
+	}
+	return v, nil
+}
+
+func comparePyPI(v1, v2 string) (int, error) {
+	a, err := parsePEP440(v1)
+	if err != nil {
+		return 0, err
+	}
+	b, err := parsePEP440(v2)
+	if err != nil {
+		return 0, err
+	}
+
+	if c := compareDigits(a.epoch, b.epoch); c != 0 {
+		return c, nil
+	}
+	for i := 0; i < len(a.release) || i < len(b.release); i++ {
+		r1, r2 := "0", "0"
+		if i < len(a.release) {
+			r1 = a.release[i]
+		}
+		if i < len(b.release) {
+			r2 = b.release[i]
+		}
+		if c := compareDigits(r1, r2); c != 0 {
+			return c, nil
+		}
+	}
+	if c := compareInts(a.preRank, b.preRank); c != 0 {
+		return c, nil
+	}
+	if a.preRank == 0 {
+		// "a" < "b" < "rc"
+		if c := strings.Compare(a.pre[0], b.pre[0]); c != 0 {
+			return c, nil
+		}
+		if c := compareDigits(a.pre[1], b.pre[1]); c != 0 {
+			return c, nil
+		}
+	}
+	// Post-releases come after their release
+	if a.hasPost != b.hasPost {
+		if a.hasPost {
+			return 1, nil
+		}
+		return -1, nil
+	}
+	if c := compareDigits(a.post, b.post); c != 0 {
+		return c, nil
+	}
+	// Development releases come before their release
+	if a.hasDev != b.hasDev {
+		if a.hasDev {
+			return -1, nil
+		}
+		return 1, nil
+	}
+	if c := compareDigits(a.dev, b.dev); c != 0 {
+		return c, nil
+	}
+	return comparePEP440Local(a.local, b.local), nil
+}
+
+// comparePEP440Local compares local version labels, where numeric segments come after alphanumeric ones and a
+// version with a label comes after the same version without one.
+func comparePEP440Local(l1, l2 []string) int {
+	for i := 0; i < len(l1) && i < len(l2); i++ {
+		n1, n2 := isAllDigits(l1[i]), isAllDigits(l2[i])
+		switch {
+		case n1 && n2:
+			if c := compareDigits(l1[i], l2[i]); c != 0 {
+				return c
+			}
+		case n1:
+			return 1
+		case n2:
+			return -1
+		default:
+			if c := strings.Compare(l1[i], l2[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(l1), len(l2))
+}
+
+// RubyGems versions follow Gem::Version.
+
+var rubyGemsRegexp = regexp.MustCompile(`^[0-9]+(?:\.[0-9a-zA-Z]+)*(?:-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+var rubyGemsSegmentRegexp = regexp.MustCompile(`[0-9]+|[a-zA-Z]+`)
+
+// rubyGemsSegments returns the canonical segments of a gem version: trailing zeros are dropped from the release
+// and from the pre-release part, which starts at the first string segment.
+func rubyGemsSegments(version string) ([]string, error) {
+	version = strings.TrimSpace(version)
+	if !rubyGemsRegexp.MatchString(version) {
+		return nil, fmt.Errorf("invalid gem version %q", version)
+	}
+	segments := rubyGemsSegmentRegexp.FindAllString(strings.ReplaceAll(version, "-", ".pre."), -1)
+	split := len(segments)
+	for i, segment := range segments {
+		if !isAllDigits(segment) {
+			split = i
+			break
+		}
+	}
+	trim := func(part []string) []string {
+		for len(part) > 0 && strings.Trim(part[len(part)-1], "0") == "" && isAllDigits(part[len(part)-1]) {
+			part = part[:len(part)-1]
+		}
+		return part
+	}
+	return append(trim(segments[:split:split]), trim(segments[split:])...), nil
+}
+
+func compareRubyGems(v1, v2 string) (int, error) {
+	a, err := rubyGemsSegments(v1)
+	if err != nil {
+		return 0, err
+	}
+	b, err := rubyGemsSegments(v2)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		s1, s2 := "0", "0"
+		if i < len(a) {
+			s1 = a[i]
+		}
+		if i < len(b) {
+			s2 = b[i]
+		}
+		n1, n2 := isAllDigits(s1), isAllDigits(s2)
+		switch {
+		case n1 && n2:
+			if c := compareDigits(s1, s2); c != 0 {
+				return c, nil
+			}
+		// Pre-release strings come before numbers
+		case n1:
+			return 1, nil
+		case n2:
+			return -1, nil
+		default:
+			if c := strings.Compare(s1, s2); c != 0 {
+				return c, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// Packagist versions are compared like PHP's version_compare, which Composer uses.
+
+// phpSpecialForms orders the strings version_compare knows, anything else comes before all of them.
+var phpSpecialForms = []struct {
+	prefix string
+	order  int
+}{
+	{"dev", 0}, {"alpha", 1}, {"a", 1}, {"beta", 2}, {"b", 2}, {"RC", 3}, {"rc", 3}, {"#", 4}, {"pl", 5}, {"p", 5},
+}
+
+func phpSpecialFormOrder(form string) int {
+	for _, special := range phpSpecialForms {
+		if strings.HasPrefix(form, special.prefix) {
+			return special.order
+		}
+	}
+	return -6
+}
+
+// phpVersionParts splits a version at anything but letters and digits, and between letters and digits.
+func phpVersionParts(version string) []string {
+	var parts []string
+	start := -1
+	for i := 0; i <= len(version); i++ {
+		separator := i == len(version) || !isDigit(version[i]) && !isLetter(version[i])
+		if start >= 0 && (separator || isDigit(version[i]) != isDigit(version[start])) {
+			parts = append(parts, version[start:i])
+			start = -1
+		}
+		if !separator && start < 0 {
+			start = i
+		}
+	}
+	return parts
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func comparePackagist(v1, v2 string) (int, error) {
+	a := phpVersionParts(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(v1), "v"), "V"))
+	b := phpVersionParts(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(v2), "v"), "V"))
+	if len(a) == 0 || len(b) == 0 {
+		return 0, fmt.Errorf("invalid packagist versions %q and %q", v1, v2)
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var c int
+		switch {
+		case i >= len(a):
+			if isAllDigits(b[i]) {
+				return -1, nil
+			}
+			c = compareInts(phpSpecialFormOrder("#"), phpSpecialFormOrder(b[i]))
+		case i >= len(b):
+			if isAllDigits(a[i]) {
+				return 1, nil
+			}
+			c = compareInts(phpSpecialFormOrder(a[i]), phpSpecialFormOrder("#"))
+		default:
+			n1, n2 := isAllDigits(a[i]), isAllDigits(b[i])
+			switch {
+			case n1 && n2:
+				c = compareDigits(a[i], b[i])
+			case n1:
+				c = compareInts(phpSpecialFormOrder("#"), phpSpecialFormOrder(b[i]))
+			case n2:
+				c = compareInts(phpSpecialFormOrder(a[i]), phpSpecialFormOrder("#"))
+			default:
+				c = compareInts(phpSpecialFormOrder(a[i]), phpSpecialFormOrder(b[i]))
+			}
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+	return 0, nil
+}
+
+// Maven versions follow the version order specification of ComparableVersion,
+// https://maven.apache.org/pom.html#version-order-specification.
+
+type mavenToken struct {
+	// hyphen is set for tokens following a '-' or a transition between digits and letters, and unset for tokens
+	// following a '.'
+	hyphen  bool
+	numeric bool
+	value   string
+}
+
+func (t mavenToken) isNull() bool {
+	if t.numeric {
+		return strings.Trim(t.value, "0") == ""
+	}
+	return mavenQualifierOrder(t.value) == mavenReleaseOrder
+}
+
+const mavenReleaseOrder = 5
+
+var mavenQualifiers = map[string]int{
+	"alpha": 0, "beta": 1, "milestone": 2, "rc": 3, "cr": 3, "snapshot": 4,
+	"": mavenReleaseOrder, "ga": mavenReleaseOrder, "final": mavenReleaseOrder, "release": mavenReleaseOrder,
+	"sp": 6,
+}
+
+// mavenQualifierOrder orders the well known qualifiers, unknown ones come after all of them.
+func mavenQualifierOrder(qualifier string) int {
+	if order, ok := mavenQualifiers[qualifier]; ok {
+		return order
+	}
+	return len(mavenQualifiers)
+}
+
+func parseMaven(version string) ([]mavenToken, error) {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if version == "" {
+		return nil, fmt.Errorf("invalid maven version %q", version)
+	}
+
+	var tokens []mavenToken
+	start, hyphen := 0, false
+	add := func(end int, nextHyphen bool) {
+		value := version[start:end]
+		if value == "" {
+			value = "0"
+		}
+		tokens = append(tokens, mavenToken{hyphen: hyphen, numeric: isAllDigits(value), value: value})
+		hyphen = nextHyphen
+	}
+	for i := 0; i < len(version); i++ {
+		switch c := version[i]; {
+		case c == '.' || c == '-':
+			add(i, c == '-')
+			start = i + 1
+		case i > start && isDigit(c) != isDigit(version[i-1]):
+			add(i, true)
+			start = i
+			// a1, b1 and m1 are shorthands for alpha-1, beta-1 and milestone-1
+			if isDigit(c) {
+				last := &tokens[len(tokens)-1]
+				switch last.value {
+				case "a":
+					last.value = "alpha"
+				case "b":
+					last.value = "beta"
+				case "m":
+					last.value = "milestone"
+				}
+			}
+		}
+	}
+	add(len(version), false)
+
+	// Trailing null values are removed from the end and before every remaining hyphen
+	var trimmed []mavenToken
+	for end := len(tokens); end > 0; {
+		start := end - 1
+		for start > 0 && !tokens[start].hyphen {
+			start--
+		}
+		group := tokens[start:end]
+		for len(group) > 0 && group[len(group)-1].isNull() {
+			group = group[:len(group)-1]
+		}
+		trimmed = append(append([]mavenToken(nil), group...), trimmed...)
+		end = start
+	}
+	return trimmed, nil
+}
+
+// compareMavenTokens compares two tokens, where ".qualifier" = "-qualifier" < "-number" < ".number".
+func compareMavenTokens(t1, t2 mavenToken) int {
+	switch {
+	case t1.numeric && t2.numeric:
+		if t1.hyphen != t2.hyphen {
+			if t1.hyphen {
+				return -1
+			}
+			return 1
+		}
+		return compareDigits(t1.value, t2.value)
+	case t1.numeric:
+		return 1
+	case t2.numeric:
+		return -1
+	}
+	o1, o2 := mavenQualifierOrder(t1.value), mavenQualifierOrder(t2.value)
+	if o1 != o2 {
+		return compareInts(o1, o2)
+	}
+	if o1 == len(mavenQualifiers) {
+		return strings.Compare(t1.value, t2.value)
+	}
+	return 0
+}
+
+func compareMaven(v1, v2 string) (int, error) {
+	a, err := parseMaven(v1)
+	if err != nil {
+		return 0, err
+	}
+	b, err := parseMaven(v2)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var t1, t2 mavenToken
+		// The shorter version is padded with null values of the kind and prefix of the other one
+		switch {
+		case i >= len(a):
+			t2 = b[i]
+			t1 = mavenToken{hyphen: t2.hyphen, numeric: t2.numeric}
+		case i >= len(b):
+			t1 = a[i]
+			t2 = mavenToken{hyphen: t1.hyphen, numeric: t1.numeric}
+		default:
+			t1, t2 = a[i], b[i]
+		}
+		if t1.numeric && t1.value == "" {
+			t1.value = "0"
+		}
+		if t2.numeric && t2.value == "" {
+			t2.value = "0"
+		}
+		if c := compareMavenTokens(t1, t2); c != 0 {
+			return c, nil
+		}
+	}
+	return 0, nil
+}
+
+// NuGet versions are SemVer 2.0 with an optional fourth release number.
+
+func parseNuGet(version string) ([]string, []string, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	version, _, _ = strings.Cut(version, "+")
+	release, prerelease, _ := strings.Cut(version, "-")
+	numbers := strings.Split(release, ".")
+	if len(numbers) > 4 {
+		return nil, nil, fmt.Errorf("invalid nuget version %q", version)
+	}
+	for _, number := range numbers {
+		if !isAllDigits(number) {
+			return nil, nil, fmt.Errorf("invalid nuget version %q", version)
+		}
+	}
+	var labels []string
+	if prerelease != "" {
+		labels = strings.Split(strings.ToLower(prerelease), ".")
+	}
+	return numbers, labels, nil
+}
+
+func compareNuGet(v1, v2 string) (int, error) {
+	r1, p1, err := parseNuGet(v1)
+	if err != nil {
+		return 0, err
+	}
+	r2, p2, err := parseNuGet(v2)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < 4; i++ {
+		n1, n2 := "0", "0"
+		if i < len(r1) {
+			n1 = r1[i]
+		}
+		if i < len(r2) {
+			n2 = r2[i]
+		}
+		if c := compareDigits(n1, n2); c != 0 {
+			return c, nil
+		}
+	}
+	// A release comes after its pre-releases
+	if len(p1) == 0 || len(p2) == 0 {
+		return compareInts(len(p2), len(p1)), nil
+	}
+	for i := 0; i < len(p1) && i < len(p2); i++ {
+		n1, n2 := isAllDigits(p1[i]), isAllDigits(p2[i])
+		switch {
+		case n1 && n2:
+			if c := compareDigits(p1[i], p2[i]); c != 0 {
+				return c, nil
+			}
+		case n1:
+			return -1, nil
+		case n2:
+			return 1, nil
+		default:
+			if c := strings.Compare(p1[i], p2[i]); c != 0 {
+				return c, nil
+			}
+		}
+	}
+	return compareInts(len(p1), len(p2)), nil
+}
+
+// Debian versions are compared like dpkg does, see deb-version(7).
+
+func parseDebian(version string) (epoch, upstream, revision string, err error) {
+	version = strings.TrimSpace(version)
+	upstream = version
+	if e, rest, ok := strings.Cut(upstream, ":"); ok {
+		if !isAllDigits(e) {
+			return "", "", "", fmt.Errorf("invalid debian version %q", version)
+		}
+		epoch, upstream = e, rest
+	}
+	if i := strings.LastIndexByte(upstream, '-'); i >= 0 {
+		upstream, revision = upstream[:i], upstream[i+1:]
+	}
+	if upstream == "" || !isDigit(upstream[0]) {
+		return "", "", "", fmt.Errorf("invalid debian version %q", version)
+	}
+	return epoch, upstream, revision, nil
+}
+
+// dpkgOrder orders the characters of the non-digit parts: '~' before everything, even the end of the part, then
+// letters, then everything else.
+func dpkgOrder(part string, i int) int {
+	if i >= len(part) {
+		return 0
+	}
+	switch c := part[i]; {
+	case c == '~':
+		return -1
+	case isDigit(c):
+		return 0
+	case isLetter(c):
+		return int(c)
+	default:
+		return int(c) + 256
+	}
+}
+
+// dpkgCompare compares alternating non-digit and digit parts of an upstream version or a revision.
+func dpkgCompare(s1, s2 string) int {
+	for s1 != "" || s2 != "" {
+		i, j := 0, 0
+		for (i < len(s1) && !isDigit(s1[i])) || (j < len(s2) && !isDigit(s2[j])) {
+			if c := compareInts(dpkgOrder(s1, i), dpkgOrder(s2, j)); c != 0 {
+				return c
+			}
+			if i < len(s1) && !isDigit(s1[i]) {
+				i++
+			}
+			if j < len(s2) && !isDigit(s2[j]) {
+				j++
+			}
+		}
+		s1, s2 = s1[i:], s2[j:]
+		i, j = 0, 0
+		for i < len(s1) && isDigit(s1[i]) {
+			i++
+		}
+		for j < len(s2) && isDigit(s2[j]) {
+			j++
+		}
+		if c := compareDigits(s1[:i], s2[:j]); c != 0 {
+			return c
+		}
+		s1, s2 = s1[i:], s2[j:]
+	}
+	return 0
+}
+
+func compareDebian(v1, v2 string) (int, error) {
+	e1, u1, r1, err := parseDebian(v1)
+	if err != nil {
+		return 0, err
+	}
+	e2, u2, r2, err := parseDebian(v2)
+	if err != nil {
+		return 0, err
+	}
+	if c := compareDigits(e1, e2); c != 0 {
+		return c, nil
+	}
+	if c := dpkgCompare(u1, u2); c != 0 {
+		return c, nil
+	}
+	return dpkgCompare(r1, r2), nil
+}
+
+// Alpine versions are compared like apk does.
+
+type apkTokenType int
+
+// The order of the token types decides the order of versions that are equal up to where one has a token of a
+// different type than the other: the version with the later token type comes first.
+const (
+	apkDigit apkTokenType = iota
+	apkLetter
+	apkSuffix
+	apkSuffixNumber
+	apkHash
+	apkRevision
+	apkEnd
+)
+
+type apkToken struct {
+	kind apkTokenType
+	// value is the digits of numbers, the letter, the hash, or the order of a suffix
+	value string
+	order int
+}
+
+// apkSuffixes orders the suffixes apk knows, pre-release suffixes have a negative order.
+var apkSuffixes = map[string]int{
+	"alpha": -4, "beta": -3, "pre": -2, "rc": -1,
+	"cvs": 1, "svn": 2, "git": 3, "hg": 4, "p": 5,
+}
+
+var apkRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)*)([a-z]?)((?:_[a-z]+[0-9]*)*)(?:~([0-9a-f]+))?(?:-r([0-9]+))?$`)
+
+var apkSuffixRegexp = regexp.MustCompile(`_([a-z]+)([0-9]*)`)
+
+func parseAlpine(version string) ([]apkToken, error) {
+	match := apkRegexp.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return nil, fmt.Errorf("invalid alpine version %q", version)
+	}
+	var tokens []apkToken
+	for _, number := range strings.Split(match[1], ".") {
+		tokens = append(tokens, apkToken{kind: apkDigit, value: number})
+	}
+	if match[2] != "" {
+		tokens = append(tokens, apkToken{kind: apkLetter, value: match[2]})
+	}
+	for _, suffix := range apkSuffixRegexp.FindAllStringSubmatch(match[3], -1) {
+		order, ok := apkSuffixes[suffix[1]]
+		if !ok {
+			return nil, fmt.Errorf("invalid alpine version %q: unknown suffix %q", version, suffix[1])
+		}
+		tokens = append(tokens, apkToken{kind: apkSuffix, order: order})
+		if suffix[2] != "" {
+			tokens = append(tokens, apkToken{kind: apkSuffixNumber, value: suffix[2]})
+		}
+	}
+	if match[4] != "" {
+		tokens = append(tokens, apkToken{kind: apkHash, value: match[4]})
+	}
+	if match[5] != "" {
+		tokens = append(tokens, apkToken{kind: apkRevision, value: match[5]})
+	}
+	return append(tokens, apkToken{kind: apkEnd}), nil
+}
+
+func compareAlpine(v1, v2 string) (int, error) {
+	a, err := parseAlpine(v1)
+	if err != nil {
+		return 0, err
+	}
+	b, err := parseAlpine(v2)
+	if err != nil {
+		return 0, err
+	}
+	i := 0
+	for ; a[i].kind == b[i].kind && a[i].kind != apkEnd; i++ {
+		t1, t2 := a[i], b[i]
+		var c int
+		switch t1.kind {
+		case apkDigit:
+			// Numbers after the first with a leading zero are compared as fractions
+			if i > 0 && (strings.HasPrefix(t1.value, "0") || strings.HasPrefix(t2.value, "0")) {
+				c = strings.Compare(t1.value, t2.value)
+			} else {
+				c = compareDigits(t1.value, t2.value)
+			}
+		case apkSuffix:
+			c = compareInts(t1.order, t2.order)
+		case apkLetter, apkHash:
+			c = strings.Compare(t1.value, t2.value)
+		default:
+			c = compareDigits(t1.value, t2.value)
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+	t1, t2 := a[i], b[i]
+	// A pre-release suffix comes before the end of the version it is added to
+	if t1.kind == apkSuffix && t1.order < 0 {
+		return -1, nil
+	}
+	if t2.kind == apkSuffix && t2.order < 0 {
+		return 1, nil
+	}
+	return compareInts(int(t2.kind), int(t1.kind)), nil
+}
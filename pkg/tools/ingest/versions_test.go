@@ -0,0 +1,106 @@
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestVersionOrder checks that every version of each list comes before the ones after it.
+func TestVersionOrder(t *testing.T) {
+	tests := []struct {
+		ecosystem Ecosystem
+		versions  []string
+	}{
+		{EcosystemGo, []string{"0.0.0-20200101000000-abcdef123456", "0.1.0", "1.2.0-rc.1", "1.2.0", "1.10.0", "2.0.0+incompatible"}},
+		{EcosystemNPM, []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.10", "4.17.21"}},
+		{EcosystemCratesIO, []string{"0.9.0", "0.10.0", "1.0.0-pre.1", "1.0.0"}},
+		{EcosystemPyPI, []string{
+			"1.0.dev456", "1.0a1", "1.0a2.dev456", "1.0a12.dev456", "1.0a12", "1.0b1.dev456", "1.0b2",
+			"1.0b2.post345.dev456", "1.0b2.post345", "1.0rc1.dev456", "1.0rc1", "1.0", "1.0+abc.5", "1.0+abc.7",
+			"1.0+5", "1.0.post456.dev34", "1.0.post456", "1.0.15", "1.1.dev1", "1!0.1",
+		}},
+		{EcosystemRubyGems, []string{"1.0.a", "1.0.a.2", "1.0.b1", "1.0", "1.0.1", "1.8.2", "1.10", "2.0.0-rc1", "2.0.0"}},
+		{EcosystemPackagist, []string{"1.0.0-dev", "1.0.0-alpha1", "1.0.0-beta1", "1.0.0-RC1", "1.0.0", "1.0.0-pl1", "1.0.1", "1.10.0", "v2.0.0"}},
+		{EcosystemMaven, []string{
+			"1-alpha-1", "1-alpha2", "1-beta", "1-milestone-1", "1-rc", "1-snapshot", "1", "1-sp", "1-foo2", "1-foo10",
+			"1-1", "1.1", "1.2", "1.10", "2.0.0-beta",
+		}},
+		{EcosystemNuGet, []string{"1.0.0-alpha", "1.0.0-alpha.2", "1.0.0-alpha.10", "1.0.0-beta", "1.0.0", "1.0.0.1", "1.0.1", "1.10.0"}},
+		{EcosystemDebian, []string{"1.0~rc1", "1.0", "1.0-1", "1.0-1+deb11u1", "1.0-2", "1.0a", "1.0.1", "1.2", "1.10", "1:0.9"}},
+		{EcosystemAlpine, []string{"1.2.3_alpha", "1.2.3_beta2", "1.2.3_rc1", "1.2.3", "1.2.3-r1", "1.2.3_p1", "1.2.3a", "1.2.4", "1.10.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.ecosystem), func(t *testing.T) {
+			for i := range tt.versions {
+				for j := i + 1; j < len(tt.versions); j++ {
+					assert.Equal(t, -1, compareEcosystemVersions(tt.versions[i], tt.versions[j], string(tt.ecosystem)), "%s < %s", tt.versions[i], tt.versions[j])
+					assert.Equal(t, 1, compareEcosystemVersions(tt.versions[j], tt.versions[i], string(tt.ecosystem)), "%s > %s", tt.versions[j], tt.versions[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVersionEquality(t *testing.T) {
+	tests := []struct {
+		ecosystem Ecosystem
+		v1, v2    string
+	}{
+		{EcosystemPyPI, "1.0", "1.0.0"},
+		{EcosystemPyPI, "1.0alpha1", "1.0a1"},
+		{EcosystemPyPI, "1.0-1", "1.0.post1"},
+		{EcosystemPyPI, "1.0c1", "1.0rc1"},
+		{EcosystemRubyGems, "1.0", "1.0.0"},
+		{EcosystemMaven, "1", "1.0.0"},
+		{EcosystemMaven, "1-ga", "1.final"},
+		{EcosystemMaven, "1.foo", "1-foo"},
+		{EcosystemMaven, "1-a1", "1-alpha-1"},
+		{EcosystemMaven, "1-ga-1", "1-1"},
+		{EcosystemMaven, "1-RC1", "1-cr1"},
+		{EcosystemNuGet, "1.0", "1.0.0.0"},
+		{EcosystemNuGet, "1.0.0-Beta", "1.0.0-beta+build"},
+		{EcosystemDebian, "0:1.0", "1.0"},
+		{EcosystemDebian, "1.0-0", "1.0-00"},
+		{EcosystemPackagist, "1.0.0-rc1", "1.0.0RC1"},
+		{Ecosystem("Debian:12"), "1:2.0", "1:2.0"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, 0, compareEcosystemVersions(tt.v1, tt.v2, string(tt.ecosystem)), "%s: %s == %s", tt.ecosystem, tt.v1, tt.v2)
+	}
+}
+
+func TestCompareEcosystemVersions_Fallback(t *testing.T) {
+	// Versions the ecosystem can't parse, and ecosystems without a comparator, are compared as strings
+	assert.Equal(t, -1, compareEcosystemVersions("not a version", "other", string(EcosystemPyPI)))
+	assert.Equal(t, -1, compareEcosystemVersions("10", "9", "unknown"))
+	// Releases of an ecosystem use its comparator
+	assert.Equal(t, -1, compareEcosystemVersions("1.9.0-r0", "1.10.0-r0", "Alpine:v3.18"))
+}
+
+func TestIsVersionInRanges_Ecosystem(t *testing.T) {
+	ranges := []Range{{
+		Type: "ECOSYSTEM",
+		Events: []Event{
+			{Fixed: "2.10.1"},
+			{Introduced: "0"},
+			{Introduced: "2.9.0"},
+			{Fixed: "2.3.0"},
+		},
+	}}
+
+	for version, affected := range map[string]bool{
+		"1.0":      true,
+		"2.2.9":    true,
+		"2.3.0":    false,
+		"2.8":      false,
+		"2.9.0rc1": false,
+		"2.10.0":   true,
+		"2.10.1":   false,
+		"2.11":     false,
+	} {
+		assert.Equal(t, affected, isVersionInRanges(version, ranges, string(EcosystemPyPI)), version)
+	}
+}
@@ -0,0 +1,386 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+)
+
+const (
+	// VEXStatementsTag is the custom data tag recording VEX statements, keyed by product and then by vulnerability.
+	VEXStatementsTag = "vex_statements"
+	// VEXProductsTag is the custom data tag listing, for every vulnerability, the products VEX statements were
+	// recorded for, so that they reach its vuln node when the advisory, or one it's an alias of, is ingested later.
+	VEXProductsTag = "vex_products"
+)
+
+// VEXStatement is what a VEX document says about one vulnerability in one product.
+type VEXStatement struct {
+	Product       string `json:"product"`
+	Vulnerability string `json:"vulnerability"`
+	Status        string `json:"status"`
+	Justification string `json:"justification,omitempty"`
+	Impact        string `json:"impact,omitempty"`
+	Action        string `json:"action,omitempty"`
+	Timestamp     string `json:"timestamp,omitempty"`
+	// Document is the id of the VEX document the statement comes from.
+	Document string `json:"document,omitempty"`
+}
+
+// VEX ingests an OpenVEX or CycloneDX VEX document. Each statement is recorded for its product and vulnerability,
+// and its status is set on the edge from the product to the vuln node, where not_affected and fixed keep the
+// vulnerability out of queries. A statement older than the one already recorded for the pair is ignored. Statements
+// about advisories that aren't ingested yet reach the vuln node once they are, even as aliases of another advisory.
+func VEX(storage graph.Storage, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("data is empty")
+	}
+	statements, err := parseVEX(data)
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if err := applyVEXStatement(storage, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseVEX(data []byte) ([]VEXStatement, error) {
+	var probe struct {
+		Context    string          `json:"@context"`
+		BOMFormat  string          `json:"bomFormat"`
+		Statements json.RawMessage `json:"statements"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal VEX document: %w", err)
+	}
+	switch {
+	case probe.BOMFormat == "CycloneDX":
+		return parseCycloneDXVEX(data)
+	case strings.Contains(probe.Context, "openvex") || probe.Statements != nil:
+		return parseOpenVEX(data)
+	default:
+		return nil, fmt.Errorf("unsupported VEX document: expected OpenVEX or CycloneDX")
+	}
+}
+
+// OpenVEX, see https://github.com/openvex/spec. Vulnerabilities and products are plain strings in the first
+// versions of the spec and objects since v0.2.0.
+
+type openVEXVulnerability struct {
+	ID   string `json:"@id"`
+	Name string `json:"name"`
+}
+
+type openVEXProduct struct {
+	ID          string `json:"@id"`
+	Identifiers struct {
+		Purl string `json:"purl"`
+	} `json:"identifiers"`
+	Subcomponents []json.RawMessage `json:"subcomponents"`
+}
+
+func parseOpenVEX(data []byte) ([]VEXStatement, error) {
+	var document struct {
+		ID         string `json:"@id"`
+		Timestamp  string `json:"timestamp"`
+		Statements []struct {
+			Vulnerability   json.RawMessage   `json:"vulnerability"`
+			Products        []json.RawMessage `json:"products"`
+			Subcomponents   []json.RawMessage `json:"subcomponents"`
+			Status          string            `json:"status"`
+			Justification   string            `json:"justification"`
+			ImpactStatement string            `json:"impact_statement"`
+			ActionStatement string            `json:"action_statement"`
+			Timestamp       string            `json:"timestamp"`
+		} `json:"statements"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal OpenVEX document: %w", err)
+	}
+
+	var statements []VEXStatement
+	for i, s := range document.Statements {
+		vulnerability, err := openVEXVulnerabilityName(s.Vulnerability)
+		if err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i, err)
+		}
+		timestamp := s.Timestamp
+		if timestamp == "" {
+			timestamp = document.Timestamp
+		}
+		var products []string
+		for _, raw := range s.Products {
+			names, err := openVEXProductNames(raw)
+			if err != nil {
+				return nil, fmt.Errorf("statement %d: %w", i, err)
+			}
+			products = append(products, names...)
+		}
+		for _, raw := range s.Subcomponents {
+			names, err := openVEXProductNames(raw)
+			if err != nil {
+				return nil, fmt.Errorf("statement %d: %w", i, err)
+			}
+			products = append(products, names...)
+		}
+		for _, product := range uniqueSorted(products) {
+			statements = append(statements, VEXStatement{
+				Product:       product,
+				Vulnerability: vulnerability,
+				Status:        s.Status,
+				Justification: s.Justification,
+				Impact:        s.ImpactStatement,
+				Action:        s.ActionStatement,
+				Timestamp:     timestamp,
+				Document:      document.ID,
+			})
+		}
+	}
+	return statements, nil
+}
+
+func openVEXVulnerabilityName(raw json.RawMessage) (string, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil && name != "" {
+		return name, nil
+	}
+	var vulnerability openVEXVulnerability
+	if err := json.Unmarshal(raw, &vulnerability); err != nil {
+		return "", fmt.Errorf("invalid vulnerability: %w", err)
+	}
+	if vulnerability.Name != "" {
+		return vulnerability.Name, nil
+	}
+	if vulnerability.ID != "" {
+		return vulnerability.ID, nil
+	}
+	return "", fmt.Errorf("vulnerability has no name")
+}
+
+// openVEXProductNames returns the name of a product along with the names of its subcomponents.
+func openVEXProductNames(raw json.RawMessage) ([]string, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil && name != "" {
+		return []string{name}, nil
+	}
+	var product openVEXProduct
+	if err := json.Unmarshal(raw, &product); err != nil {
+		return nil, fmt.Errorf("invalid product: %w", err)
+	}
+	name = product.Identifiers.Purl
+	if name == "" {
+		name = product.ID
+	}
+	if name == "" {
+		return nil, fmt.Errorf("product has no id")
+	}
+	names := []string{name}
+	for _, subcomponent := range product.Subcomponents {
+		subcomponentNames, err := openVEXProductNames(subcomponent)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, subcomponentNames...)
+	}
+	return names, nil
+}
+
+// CycloneDX VEX, see https://cyclonedx.org/capabilities/vex/. The analysis states map to the VEX statuses, and the
+// affected refs are resolved to the package URLs of the components they point to.
+
+var cycloneDXAnalysisStates = map[string]string{
+	"not_affected":           graph.VEXStatusNotAffected,
+	"false_positive":         graph.VEXStatusNotAffected,
+	"resolved":               graph.VEXStatusFixed,
+	"resolved_with_pedigree": graph.VEXStatusFixed,
+	"exploitable":            graph.VEXStatusAffected,
+	"in_triage":              graph.VEXStatusUnderInvestigation,
+}
+
+type cycloneDXVEXComponent struct {
+	BOMRef     string                  `json:"bom-ref"`
+	Purl       string                  `json:"purl"`
+	Components []cycloneDXVEXComponent `json:"components"`
+}
+
+func parseCycloneDXVEX(data []byte) ([]VEXStatement, error) {
+	var document struct {
+		SerialNumber string `json:"serialNumber"`
+		Metadata     struct {
+			Timestamp string                 `json:"timestamp"`
+			Component *cycloneDXVEXComponent `json:"component"`
+		} `json:"metadata"`
+		Components      []cycloneDXVEXComponent `json:"components"`
+		Vulnerabilities []struct {
+			ID       string `json:"id"`
+			Analysis struct {
+				State         string   `json:"state"`
+				Justification string   `json:"justification"`
+				Response      []string `json:"response"`
+				Detail        string   `json:"detail"`
+				LastUpdated   string   `json:"lastUpdated"`
+			} `json:"analysis"`
+			Affects []struct {
+				Ref string `json:"ref"`
+			} `json:"affects"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal CycloneDX VEX document: %w", err)
+	}
+
+	purls := map[string]string{}
+	var collect func(components []cycloneDXVEXComponent)
+	collect = func(components []cycloneDXVEXComponent) {
+		for _, component := range components {
+			if component.BOMRef != "" && component.Purl != "" {
+				purls[component.BOMRef] = component.Purl
+			}
+			collect(component.Components)
+		}
+	}
+	if document.Metadata.Component != nil {
+		collect([]cycloneDXVEXComponent{*document.Metadata.Component})
+	}
+	collect(document.Components)
+
+	var statements []VEXStatement
+	for _, vulnerability := range document.Vulnerabilities {
+		if vulnerability.Analysis.State == "" {
+			// Without an analysis the document doesn't say anything about the vulnerability
+			continue
+		}
+		status, ok := cycloneDXAnalysisStates[vulnerability.Analysis.State]
+		if !ok {
+			return nil, fmt.Errorf("vulnerability %s: unknown analysis state %q", vulnerability.ID, vulnerability.Analysis.State)
+		}
+		timestamp := vulnerability.Analysis.LastUpdated
+		if timestamp == "" {
+			timestamp = document.Metadata.Timestamp
+		}
+		for _, affects := range vulnerability.Affects {
+			statements = append(statements, VEXStatement{
+				Product:       resolveCycloneDXRef(affects.Ref, purls),
+				Vulnerability: vulnerability.ID,
+				Status:        status,
+				Justification: vulnerability.Analysis.Justification,
+				Impact:        vulnerability.Analysis.Detail,
+				Action:        strings.Join(vulnerability.Analysis.Response, ","),
+				Timestamp:     timestamp,
+				Document:      document.SerialNumber,
+			})
+		}
+	}
+	return statements, nil
+}
+
+// resolveCycloneDXRef returns the package URL a bom-ref, or the bom-ref of a BOM-Link such as
+// urn:cdx:<serial>/1#<bom-ref>, points to, falling back to the ref itself.
+func resolveCycloneDXRef(ref string, purls map[string]string) string {
+	if purl, ok := purls[ref]; ok {
+		return purl
+	}
+	if strings.HasPrefix(ref, "urn:cdx:") {
+		if _, fragment, ok := strings.Cut(ref, "#"); ok {
+			if purl, ok := purls[fragment]; ok {
+				return purl
+			}
+			return fragment
+		}
+	}
+	return ref
+}
+
+func applyVEXStatement(storage graph.Storage, statement VEXStatement) error {
+	switch statement.Status {
+	case graph.VEXStatusNotAffected, graph.VEXStatusAffected, graph.VEXStatusFixed, graph.VEXStatusUnderInvestigation:
+	default:
+		return fmt.Errorf("statement for %s in %s: unknown status %q", statement.Vulnerability, statement.Product, statement.Status)
+	}
+	if statement.Product == "" || statement.Vulnerability == "" {
+		return fmt.Errorf("statement has no product or vulnerability")
+	}
+	statement.Product = graph.CanonicalName(statement.Product)
+
+	recorded, err := storage.GetCustomData(VEXStatementsTag, statement.Product)
+	if err != nil {
+		return fmt.Errorf("failed to get the VEX statements of %s: %w", statement.Product, err)
+	}
+	if raw, ok := recorded[statement.Vulnerability]; ok {
+		var previous VEXStatement
+		if err := json.Unmarshal(raw, &previous); err == nil && modifiedAfter(previous.Timestamp, statement.Timestamp) {
+			return nil
+		}
+	}
+	data, err := json.Marshal(statement)
+	if err != nil {
+		return fmt.Errorf("failed to marshal VEX statement: %w", err)
+	}
+	if err := storage.AddOrUpdateCustomData(VEXStatementsTag, statement.Product, statement.Vulnerability, data); err != nil {
+		return fmt.Errorf("failed to record the VEX statement for %s in %s: %w", statement.Vulnerability, statement.Product, err)
+	}
+	if err := storage.AddOrUpdateCustomData(VEXProductsTag, statement.Vulnerability, statement.Product, nil); err != nil {
+		return fmt.Errorf("failed to index the VEX statement for %s in %s: %w", statement.Vulnerability, statement.Product, err)
+	}
+
+	// Statements usually name the CVE while the vuln node may be named after one of its aliases
+	vulnName, err := canonicalVulnerabilityName(storage, statement.Vulnerability)
+	if err != nil {
+		return err
+	}
+	return graph.SetEdgeAttribute(storage, statement.Product, vulnName, graph.VEXStatusAttribute, statement.Status)
+}
+
+// applyVEXStatements sets the status of the statements recorded for an advisory and its aliases on the edges to its
+// vuln node. A product with statements for several of the ids gets the status of the newest one.
+func applyVEXStatements(storage graph.Storage, canonical string, ids []string) error {
+	latest := map[string]VEXStatement{}
+	for _, id := range ids {
+		products, err := storage.GetCustomData(VEXProductsTag, id)
+		if err != nil {
+			return fmt.Errorf("failed to get the VEX products of %s: %w", id, err)
+		}
+		for product := range products {
+			statements, err := GetVEXStatements(storage, product)
+			if err != nil {
+				return err
+			}
+			statement, ok := statements[id]
+			if !ok {
+				continue
+			}
+			if previous, ok := latest[product]; ok && !modifiedAfter(statement.Timestamp, previous.Timestamp) {
+				continue
+			}
+			latest[product] = statement
+		}
+	}
+	for product, statement := range latest {
+		if err := graph.SetEdgeAttribute(storage, product, canonical, graph.VEXStatusAttribute, statement.Status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetVEXStatements returns the VEX statements recorded for a product, keyed by vulnerability.
+func GetVEXStatements(storage graph.Storage, product string) (map[string]VEXStatement, error) {
+	recorded, err := storage.GetCustomData(VEXStatementsTag, graph.CanonicalName(product))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the VEX statements of %s: %w", product, err)
+	}
+	statements := make(map[string]VEXStatement, len(recorded))
+	for vulnerability, raw := range recorded {
+		var statement VEXStatement
+		if err := json.Unmarshal(raw, &statement); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the VEX statement for %s in %s: %w", vulnerability, product, err)
+		}
+		statements[vulnerability] = statement
+	}
+	return statements, nil
+}
@@ -0,0 +1,155 @@
+package ingest
+
+import (
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVEX_OpenVEX(t *testing.T) {
+	document := `{
+		"@context": "https://openvex.dev/ns/v0.2.0",
+		"@id": "https://example.com/vex/1",
+		"timestamp": "2024-01-01T00:00:00Z",
+		"statements": [
+			{
+				"vulnerability": {"name": "CVE-2024-1"},
+				"products": [{
+					"@id": "pkg:oci/app@sha256:abc",
+					"subcomponents": [{"@id": "pkg:npm/a@1.0.0"}]
+				}],
+				"status": "not_affected",
+				"justification": "vulnerable_code_not_in_execute_path"
+			},
+			{
+				"vulnerability": "CVE-2024-2",
+				"products": ["pkg:npm/b@1.0.0"],
+				"status": "affected",
+				"action_statement": "upgrade",
+				"timestamp": "2024-02-01T00:00:00Z"
+			}
+		]
+	}`
+
+	statements, err := parseVEX([]byte(document))
+	require.NoError(t, err)
+	assert.Equal(t, []VEXStatement{
+		{Product: "pkg:npm/a@1.0.0", Vulnerability: "CVE-2024-1", Status: graph.VEXStatusNotAffected, Justification: "vulnerable_code_not_in_execute_path", Timestamp: "2024-01-01T00:00:00Z", Document: "https://example.com/vex/1"},
+		{Product: "pkg:oci/app@sha256:abc", Vulnerability: "CVE-2024-1", Status: graph.VEXStatusNotAffected, Justification: "vulnerable_code_not_in_execute_path", Timestamp: "2024-01-01T00:00:00Z", Document: "https://example.com/vex/1"},
+		{Product: "pkg:npm/b@1.0.0", Vulnerability: "CVE-2024-2", Status: graph.VEXStatusAffected, Action: "upgrade", Timestamp: "2024-02-01T00:00:00Z", Document: "https://example.com/vex/1"},
+	}, statements)
+}
+
+func TestParseVEX_CycloneDX(t *testing.T) {
+	document := `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+		"metadata": {"timestamp": "2024-01-01T00:00:00Z"},
+		"components": [{"bom-ref": "lib-a", "purl": "pkg:npm/a@1.0.0"}],
+		"vulnerabilities": [
+			{
+				"id": "CVE-2024-1",
+				"analysis": {"state": "false_positive", "detail": "not reachable"},
+				"affects": [{"ref": "lib-a"}, {"ref": "urn:cdx:3e671687-395b-41f5-a30f-a58921a69b79/1#lib-a"}, {"ref": "pkg:npm/c@1.0.0"}]
+			},
+			{
+				"id": "CVE-2024-2",
+				"analysis": {"state": "resolved", "response": ["update"], "lastUpdated": "2024-02-01T00:00:00Z"},
+				"affects": [{"ref": "lib-a"}]
+			},
+			{"id": "CVE-2024-3", "affects": [{"ref": "lib-a"}]}
+		]
+	}`
+
+	statements, err := parseVEX([]byte(document))
+	require.NoError(t, err)
+	serial := "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79"
+	assert.Equal(t, []VEXStatement{
+		{Product: "pkg:npm/a@1.0.0", Vulnerability: "CVE-2024-1", Status: graph.VEXStatusNotAffected, Impact: "not reachable", Timestamp: "2024-01-01T00:00:00Z", Document: serial},
+		{Product: "pkg:npm/a@1.0.0", Vulnerability: "CVE-2024-1", Status: graph.VEXStatusNotAffected, Impact: "not reachable", Timestamp: "2024-01-01T00:00:00Z", Document: serial},
+		{Product: "pkg:npm/c@1.0.0", Vulnerability: "CVE-2024-1", Status: graph.VEXStatusNotAffected, Impact: "not reachable", Timestamp: "2024-01-01T00:00:00Z", Document: serial},
+		{Product: "pkg:npm/a@1.0.0", Vulnerability: "CVE-2024-2", Status: graph.VEXStatusFixed, Action: "update", Timestamp: "2024-02-01T00:00:00Z", Document: serial},
+	}, statements)
+}
+
+func TestParseVEX_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{"invalid json", `{`},
+		{"unknown format", `{"bomFormat": "SPDX"}`},
+		{"unknown analysis state", `{"bomFormat": "CycloneDX", "vulnerabilities": [{"id": "CVE-2024-1", "analysis": {"state": "maybe"}}]}`},
+		{"vulnerability without name", `{"@context": "https://openvex.dev/ns/v0.2.0", "statements": [{"vulnerability": {}, "products": ["pkg:npm/a@1.0.0"], "status": "fixed"}]}`},
+		{"product without id", `{"@context": "https://openvex.dev/ns/v0.2.0", "statements": [{"vulnerability": "CVE-2024-1", "products": [{}], "status": "fixed"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseVEX([]byte(tt.document))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func openVEXDocument(product, vulnerability, status, timestamp string) []byte {
+	return []byte(`{
+		"@context": "https://openvex.dev/ns/v0.2.0",
+		"timestamp": "` + timestamp + `",
+		"statements": [{"vulnerability": {"name": "` + vulnerability + `"}, "products": [{"@id": "` + product + `"}], "status": "` + status + `"}]
+	}`)
+}
+
+func TestVEX(t *testing.T) {
+	storage, older, _ := setupDjangoGraph(t)
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("GHSA-1", "2024-01-01T00:00:00Z", "", "4.2.0", "CVE-2024-1")))
+
+	require.NoError(t, VEX(storage, openVEXDocument(older.Name, "CVE-2024-1", graph.VEXStatusNotAffected, "2024-02-01T00:00:00Z")))
+
+	// The statement names the CVE, the vuln node is named after the advisory it's an alias of
+	attributes, err := graph.GetEdgeAttributes(storage, "GHSA-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{older.Name: {graph.VEXStatusAttribute: graph.VEXStatusNotAffected}}, attributes)
+
+	// Older statements are ignored, newer ones replace the recorded one
+	require.NoError(t, VEX(storage, openVEXDocument(older.Name, "CVE-2024-1", graph.VEXStatusAffected, "2024-01-15T00:00:00Z")))
+	statements, err := GetVEXStatements(storage, older.Name)
+	require.NoError(t, err)
+	assert.Equal(t, graph.VEXStatusNotAffected, statements["CVE-2024-1"].Status)
+
+	require.NoError(t, VEX(storage, openVEXDocument(older.Name, "CVE-2024-1", graph.VEXStatusAffected, "2024-03-01T00:00:00Z")))
+	statements, err = GetVEXStatements(storage, older.Name)
+	require.NoError(t, err)
+	assert.Equal(t, graph.VEXStatusAffected, statements["CVE-2024-1"].Status)
+	attributes, err = graph.GetEdgeAttributes(storage, "GHSA-1")
+	require.NoError(t, err)
+	assert.Equal(t, graph.VEXStatusAffected, attributes[older.Name][graph.VEXStatusAttribute])
+
+	assert.Error(t, VEX(storage, nil))
+	assert.Error(t, VEX(storage, openVEXDocument(older.Name, "CVE-2024-1", "maybe", "2024-04-01T00:00:00Z")))
+}
+
+func TestVEX_BeforeAdvisory(t *testing.T) {
+	storage, older, _ := setupDjangoGraph(t)
+	// The statement names the CVE before the advisory making it an alias of GHSA-1 is ingested
+	require.NoError(t, VEX(storage, openVEXDocument(older.Name, "CVE-2024-1", graph.VEXStatusNotAffected, "2024-02-01T00:00:00Z")))
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("GHSA-1", "2024-01-01T00:00:00Z", "", "4.2.0", "CVE-2024-1")))
+
+	attributes, err := graph.GetEdgeAttributes(storage, "GHSA-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{older.Name: {graph.VEXStatusAttribute: graph.VEXStatusNotAffected}}, attributes)
+
+	// The vulnerability is suppressed in queries
+	require.NoError(t, graph.Cache(storage))
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	nodes, err := storage.GetNodes(keys)
+	require.NoError(t, err)
+	caches, err := storage.GetCaches(keys)
+	require.NoError(t, err)
+	result, err := graph.ParseAndExecute("dependencies vuln "+older.Name, storage, "", nodes, caches, true)
+	require.NoError(t, err)
+	assert.True(t, result.IsEmpty())
+}
@@ -1,8 +1,11 @@
 package ingest
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"sort"
 	"strings"
 
@@ -83,70 +86,239 @@ func Vulnerabilities(storage graph.Storage, data []byte) error {
 	if len(data) == 0 {
 		return fmt.Errorf("data is empty")
 	}
+	return BulkVulnerabilities(storage, [][]byte{data})
+}
 
-	vuln := Vulnerability{}
-	if err := json.Unmarshal(data, &vuln); err != nil {
-		return fmt.Errorf("failed to unmarshal vulnerabilityType data: %w", err)
+// BulkVulnerabilities ingests many advisories at once, looking up the library nodes of each package only once.
+func BulkVulnerabilities(storage graph.Storage, documents [][]byte) error {
+	index := NewPackageIndex(storage)
+	for i, data := range documents {
+		if err := ingestVulnerability(storage, index, data); err != nil {
+			return fmt.Errorf("failed to ingest vulnerability %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// VulnerabilitiesArchive ingests every JSON advisory in a zip archive, such as the all.zip of an OSV ecosystem, and
+// returns the number of advisories ingested.
+func VulnerabilitiesArchive(storage graph.Storage, archive []byte) (int, error) {
+	r, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open vulnerability archive: %w", err)
 	}
+	index := NewPackageIndex(storage)
+	count := 0
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return count, err
+		}
+		if err := ingestVulnerability(storage, index, data); err != nil {
+			return count, fmt.Errorf("failed to ingest vulnerability %s: %w", f.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in vulnerability archive: %w", f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s in vulnerability archive: %w", f.Name, err)
+	}
+	return data, nil
+}
+
+// packageKey identifies a package the way OSV advisories do.
+type packageKey struct {
+	Ecosystem string
+	Name      string
+}
+
+func (k packageKey) String() string {
+	return k.Ecosystem + "/" + k.Name
+}
 
-	errors := []error{}
+type indexedPackage struct {
+	ID   uint32
+	Info PackageInfo
+}
+
+// PackageIndex maps the packages OSV advisories name to the library nodes that are versions of them. It reads the
+// libraries of a package from OSVLibrariesTag the first time an advisory names it, so that ingesting an advisory
+// only touches the nodes of the packages it affects.
+type PackageIndex struct {
+	storage  graph.Storage
+	packages map[packageKey][]indexedPackage
+}
 
-	vulnMap := map[string][]Vulnerability{}
+// NewPackageIndex returns an index of the library nodes in storage.
+func NewPackageIndex(storage graph.Storage) *PackageIndex {
+	return &PackageIndex{storage: storage, packages: map[packageKey][]indexedPackage{}}
+}
 
+// candidates returns the library nodes of the packages an advisory affects.
+func (ix *PackageIndex) candidates(vuln Vulnerability) ([]indexedPackage, error) {
+	var candidates []indexedPackage
+	seen := map[packageKey]bool{}
 	for _, affected := range vuln.Affected {
-		vulnMap[affected.Package.Name] = append(vulnMap[affected.Package.Name], vuln)
+		key := packageKey{Ecosystem: affected.Package.Ecosystem, Name: affected.Package.Name}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		packages, ok := ix.packages[key]
+		if !ok {
+			var err error
+			if packages, err = ix.load(key); err != nil {
+				return nil, err
+			}
+			ix.packages[key] = packages
+		}
+		candidates = append(candidates, packages...)
+	}
+	return candidates, nil
+}
+
+// load reads the library nodes of a package, skipping the ones that were removed or are no longer libraries.
+func (ix *PackageIndex) load(key packageKey) ([]indexedPackage, error) {
+	names, err := ix.storage.GetCustomData(OSVLibrariesTag, key.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the libraries of %s: %w", key, err)
+	}
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	var packages []indexedPackage
+	for _, name := range sortedNames {
+		id, err := ix.storage.NameToID(name)
+		if err != nil {
+			continue
+		}
+		node, err := ix.storage.GetNode(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node %s: %w", name, err)
+		}
+		if node.Type != tools.LibraryType {
+			continue
+		}
+		pkgInfo, err := PURLToPackage(node.Name)
+		if err != nil {
+			continue
+		}
+		packages = append(packages, indexedPackage{ID: node.ID, Info: pkgInfo})
 	}
+	return packages, nil
+}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("errors occurred during vulnerabilities ingestion: %v", errors)
+// indexLibraries records the nodes with package URL names under the packages they are versions of. Nodes that aren't
+// libraries are recorded too, and left out when the index is read.
+func indexLibraries(storage graph.Storage, names []string) error {
+	for _, name := range names {
+		if !strings.HasPrefix(name, pkg) {
+			continue
+		}
+		pkgInfo, err := PURLToPackage(name)
+		if err != nil {
+			continue
+		}
+		key := packageKey{Ecosystem: pkgInfo.Ecosystem, Name: pkgInfo.Name}
+		if err := storage.AddOrUpdateCustomData(OSVLibrariesTag, key.String(), name, nil); err != nil {
+			return fmt.Errorf("failed to index library %s: %w", name, err)
+		}
 	}
+	return nil
+}
 
+// IndexLibrary records a node under the package it is a version of when it is a library, for nodes added outside of
+// the ingestion of SBOMs, lockfiles and attestations.
+func IndexLibrary(storage graph.Storage, node *graph.Node) error {
+	if node.Type != tools.LibraryType {
+		return nil
+	}
+	return indexLibraries(storage, []string{node.Name})
+}
+
+// IndexLibraries records every library node under the package it is a version of, for graphs ingested before the
+// index existed.
+func IndexLibraries(storage graph.Storage) error {
 	keys, err := storage.GetAllKeys()
 	if err != nil {
 		return fmt.Errorf("failed to get all keys: %w", err)
 	}
-
 	nodes, err := storage.GetNodes(keys)
 	if err != nil {
 		return fmt.Errorf("failed to get nodes from storage: %w", err)
 	}
-
+	var names []string
 	for _, node := range nodes {
-		if node.Type == tools.LibraryType && strings.HasPrefix(node.Name, pkg) {
-			pkgInfo, err := PURLToPackage(node.Name)
-			if err != nil {
-				continue
-			}
-			vulnsData, ok := vulnMap[pkgInfo.Name]
-			if !ok {
-				continue
-			}
-			if len(vulnsData) == 0 {
-				continue
-			}
-			for _, vuln := range vulnsData {
-				// We are using the vuln ID from the map instead of the vulnID from the vuln data, since the map key could be an alias of a vulnerabilityType ID
-				vulnData, err := json.Marshal(vuln)
-				if err != nil {
-					errors = append(errors, err)
-					continue
-				}
-
-				if isPackageAffected(vuln, pkgInfo) {
-					vulnNode, err := graph.AddNode(storage, tools.VulnerabilityType, vulnData, vuln.ID)
-					if err != nil {
-						return fmt.Errorf("failed to add vulnerabilityType node to storage: %w", err)
-					}
-
-					if err := node.SetDependency(storage, vulnNode); err != nil {
-						return fmt.Errorf("failed to add dependency edge to vulnerabilityType node: %w", err)
-					}
-				}
-			}
+		if node.Type == tools.LibraryType {
+			names = append(names, node.Name)
 		}
 	}
+	sort.Strings(names)
+	return indexLibraries(storage, names)
+}
 
-	return nil
+func ingestVulnerability(storage graph.Storage, index *PackageIndex, data []byte) error {
+	vuln := Vulnerability{}
+	if err := json.Unmarshal(data, &vuln); err != nil {
+		return fmt.Errorf("failed to unmarshal vulnerabilityType data: %w", err)
+	}
+	if vuln.ID == "" {
+		return fmt.Errorf("vulnerability has no id")
+	}
+
+	stored, err := getAdvisory(storage, vuln.ID)
+	if err != nil {
+		return err
+	}
+	if stored != nil && modifiedAfter(stored.Modified, vuln.Modified) {
+		// A newer version of the advisory was already ingested
+		return nil
+	}
+	canonical, err := canonicalVulnerability(storage, vuln)
+	if err != nil {
+		return err
+	}
+	if err := applyEnrichment(storage, canonical, append([]string{vuln.ID}, vuln.Aliases...)); err != nil {
+		return err
+	}
+	if err := applyVEXStatements(storage, canonical, append([]string{vuln.ID}, vuln.Aliases...)); err != nil {
+		return err
+	}
+	if err := recordAdvisory(storage, vuln, data); err != nil {
+		return err
+	}
+	if err := updateSeverity(storage, canonical); err != nil {
+		return err
+	}
+
+	var affected []uint32
+	if vuln.Withdrawn == "" {
+		candidates, err := index.candidates(vuln)
+		if err != nil {
+			return err
+		}
+		for _, candidate := range candidates {
+			if isPackageAffected(vuln, candidate.Info) {
+				affected = append(affected, candidate.ID)
+			}
+		}
+	}
+	return applyAdvisory(storage, vuln, canonical, affected, true)
 }
 
 // isPackageAffected checks if the package is affected by the vulnerabilityType.
@@ -182,7 +354,7 @@ func isVersionInRanges(version string, ranges []Range, ecosystem string) bool {
 		sortedEvents := sortRangeEvents(r.Events, r.Type, ecosystem)
 		for _, evt := range sortedEvents {
 			switch {
-			case evt.Introduced != "" && compareVersions(version, evt.Introduced, r.Type, ecosystem) >= 0:
+			case evt.Introduced != "" && (isIntroducedZero(evt) || compareVersions(version, evt.Introduced, r.Type, ecosystem) >= 0):
 				vulnerable = true
 			case evt.Fixed != "" && compareVersions(version, evt.Fixed, r.Type, ecosystem) >= 0:
 				vulnerable = false
@@ -203,8 +375,12 @@ func sortRangeEvents(events []Event, eventType string, ecosystem string) []Event
 	copy(sortedEvents, events)
 
 	lessFunc := func(i, j int) bool {
-		vi := getVersionFromEvent(events[i])
-		vj := getVersionFromEvent(events[j])
+		// "introduced": "0" is the earliest version, whatever the ecosystem orders before it
+		if isIntroducedZero(sortedEvents[i]) || isIntroducedZero(sortedEvents[j]) {
+			return isIntroducedZero(sortedEvents[i]) && !isIntroducedZero(sortedEvents[j])
+		}
+		vi := getVersionFromEvent(sortedEvents[i])
+		vj := getVersionFromEvent(sortedEvents[j])
 		return compareVersions(vi, vj, eventType, ecosystem) < 0
 	}
 
@@ -212,6 +388,10 @@ func sortRangeEvents(events []Event, eventType string, ecosystem string) []Event
 	return sortedEvents
 }
 
+func isIntroducedZero(evt Event) bool {
+	return evt.Introduced == "0"
+}
+
 func getVersionFromEvent(evt Event) string {
 	if evt.Introduced != "" {
 		return evt.Introduced
@@ -247,9 +427,3 @@ func compareVersions(v1, v2, eventType, ecosystem string) int {
 		return strings.Compare(v1, v2)
 	}
 }
-
-func compareEcosystemVersions(v1, v2, _ string) int {
-	// Implement ecosystem-specific version comparison logic here.
-	// Placeholder implementation:
-	return strings.Compare(v1, v2)
-}
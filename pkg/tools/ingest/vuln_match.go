@@ -0,0 +1,496 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+)
+
+const (
+	// OSVAdvisoriesTag is the custom data tag keeping every ingested advisory along with the libraries it was linked
+	// to, so that libraries ingested after it are still matched against it and its edges can be removed once it is
+	// withdrawn or updated.
+	OSVAdvisoriesTag = "osv_advisories"
+	// OSVPackagesTag is the custom data tag listing, for every package, the advisories that name it.
+	OSVPackagesTag = "osv_packages"
+	// OSVLibrariesTag is the custom data tag listing, for every package, the names of the library nodes that are
+	// versions of it.
+	OSVLibrariesTag = "osv_libraries"
+	// OSVAliasesTag is the custom data tag mapping advisory ids and their aliases to the vuln node that represents
+	// them all.
+	OSVAliasesTag = "osv_aliases"
+	// OSVAliasGroupsTag is the custom data tag listing, for every vuln node, the advisories it represents.
+	OSVAliasGroupsTag = "osv_alias_groups"
+
+	advisoryDataKey  = "advisory"
+	librariesDataKey = "libraries"
+	canonicalDataKey = "canonical"
+)
+
+// recordAdvisory keeps an advisory and indexes it by the packages it names.
+func recordAdvisory(storage graph.Storage, vuln Vulnerability, data []byte) error {
+	if err := storage.AddOrUpdateCustomData(OSVAdvisoriesTag, vuln.ID, advisoryDataKey, data); err != nil {
+		return fmt.Errorf("failed to record advisory %s: %w", vuln.ID, err)
+	}
+	for _, affected := range vuln.Affected {
+		key := packageKey{Ecosystem: affected.Package.Ecosystem, Name: affected.Package.Name}
+		if err := storage.AddOrUpdateCustomData(OSVPackagesTag, key.String(), vuln.ID, nil); err != nil {
+			return fmt.Errorf("failed to index advisory %s by package %s: %w", vuln.ID, key, err)
+		}
+	}
+	return nil
+}
+
+// canonicalVulnerability returns the name of the vuln node representing an advisory. Advisories that are aliases of
+// each other, like a GHSA and the CVE it was assigned, share the node named after whichever of them was ingested
+// first. An advisory bridging the nodes of advisories that didn't share aliases merges them into the node of the
+// first of its ids, and aliases, that has one.
+func canonicalVulnerability(storage graph.Storage, vuln Vulnerability) (string, error) {
+	ids := append([]string{vuln.ID}, vuln.Aliases...)
+	canonical := ""
+	var unmapped, merged []string
+	for _, id := range ids {
+		data, err := storage.GetCustomData(OSVAliasesTag, id)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the canonical id of %s: %w", id, err)
+		}
+		mapped := string(data[canonicalDataKey])
+		switch {
+		case mapped == "":
+			unmapped = append(unmapped, id)
+		case canonical == "":
+			canonical = mapped
+		case mapped != canonical && !slices.Contains(merged, mapped):
+			merged = append(merged, mapped)
+		}
+	}
+	if canonical == "" {
+		canonical = vuln.ID
+	}
+	for _, other := range merged {
+		if err := mergeVulnerabilities(storage, other, canonical); err != nil {
+			return "", err
+		}
+	}
+	for _, id := range unmapped {
+		if err := storage.AddOrUpdateCustomData(OSVAliasesTag, id, canonicalDataKey, []byte(canonical)); err != nil {
+			return "", fmt.Errorf("failed to record the canonical id of %s: %w", id, err)
+		}
+	}
+	if err := storage.AddOrUpdateCustomData(OSVAliasGroupsTag, canonical, vuln.ID, nil); err != nil {
+		return "", fmt.Errorf("failed to add %s to the aliases of %s: %w", vuln.ID, canonical, err)
+	}
+	return canonical, nil
+}
+
+// mergeVulnerabilities merges the vuln node of one group of aliases into the node of another. The advisories of the
+// group, and their aliases, move to the other node.
+func mergeVulnerabilities(storage graph.Storage, from, into string) error {
+	if _, err := storage.NameToID(from); err == nil {
+		if err := graph.MergeNode(storage, from, into); err != nil {
+			return fmt.Errorf("failed to merge vulnerabilityType node %s into %s: %w", from, into, err)
+		}
+	}
+
+	group, err := storage.GetCustomData(OSVAliasGroupsTag, from)
+	if err != nil {
+		return fmt.Errorf("failed to get the aliases of %s: %w", from, err)
+	}
+	for advisoryID := range group {
+		ids := []string{advisoryID}
+		advisory, err := getAdvisory(storage, advisoryID)
+		if err != nil {
+			return err
+		}
+		if advisory != nil {
+			ids = append(ids, advisory.Aliases...)
+		}
+		for _, id := range ids {
+			canonical, err := canonicalVulnerabilityName(storage, id)
+			if err != nil {
+				return err
+			}
+			if canonical != from {
+				continue
+			}
+			if err := storage.AddOrUpdateCustomData(OSVAliasesTag, id, canonicalDataKey, []byte(into)); err != nil {
+				return fmt.Errorf("failed to record the canonical id of %s: %w", id, err)
+			}
+		}
+		if err := storage.AddOrUpdateCustomData(OSVAliasGroupsTag, into, advisoryID, nil); err != nil {
+			return fmt.Errorf("failed to add %s to the aliases of %s: %w", advisoryID, into, err)
+		}
+		if err := storage.DeleteCustomData(OSVAliasGroupsTag, from, advisoryID); err != nil {
+			return fmt.Errorf("failed to remove %s from the aliases of %s: %w", advisoryID, from, err)
+		}
+	}
+	return nil
+}
+
+// canonicalVulnerabilityName returns the name of the vuln node of an advisory id, which is the id itself unless the
+// advisory is an alias of one ingested before it.
+func canonicalVulnerabilityName(storage graph.Storage, id string) (string, error) {
+	data, err := storage.GetCustomData(OSVAliasesTag, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the canonical id of %s: %w", id, err)
+	}
+	if canonical := string(data[canonicalDataKey]); canonical != "" {
+		return canonical, nil
+	}
+	return id, nil
+}
+
+// applyAdvisory links the canonical vuln node of an advisory to the affected libraries. With replace, affected is
+// every library the advisory affects, and the edges an earlier version of it added to other libraries are removed
+// unless an alias of it affects them too.
+func applyAdvisory(storage graph.Storage, vuln Vulnerability, canonical string, affected []uint32, replace bool) error {
+	previous, err := getAdvisoryLibraries(storage, vuln.ID)
+	if err != nil {
+		return err
+	}
+	libraries := map[string]bool{}
+	if !replace {
+		for _, name := range previous {
+			libraries[name] = true
+		}
+	}
+
+	if len(affected) > 0 {
+		vulnNode, err := addVulnerabilityNode(storage, canonical, vuln)
+		if err != nil {
+			return err
+		}
+		for _, id := range affected {
+			name, err := linkVulnerability(storage, id, vulnNode)
+			if err != nil {
+				return err
+			}
+			libraries[name] = true
+		}
+	}
+
+	if replace {
+		var stale []string
+		for _, name := range previous {
+			if !libraries[name] {
+				stale = append(stale, name)
+			}
+		}
+		if err := unlinkVulnerability(storage, canonical, vuln.ID, stale); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(libraries))
+	for name := range libraries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return setAdvisoryLibraries(storage, vuln.ID, names)
+}
+
+// addVulnerabilityNode returns the canonical vuln node of an advisory, adding it when needed. The canonical advisory
+// replaces the metadata of an existing node, which is how updated advisories reach the graph.
+func addVulnerabilityNode(storage graph.Storage, canonical string, vuln Vulnerability) (*graph.Node, error) {
+	vulnData, err := json.Marshal(vuln)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vulnerabilityType data: %w", err)
+	}
+	id, err := storage.NameToID(canonical)
+	if err != nil {
+		vulnNode, err := graph.AddNode(storage, tools.VulnerabilityType, vulnData, canonical)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add vulnerabilityType node to storage: %w", err)
+		}
+		return vulnNode, nil
+	}
+	vulnNode, err := storage.GetNode(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vulnerabilityType node %s: %w", canonical, err)
+	}
+	if vuln.ID == canonical {
+		vulnNode.Metadata = vulnData
+		if err := storage.SaveNode(vulnNode); err != nil {
+			return nil, fmt.Errorf("failed to update vulnerabilityType node %s: %w", canonical, err)
+		}
+	}
+	return vulnNode, nil
+}
+
+// linkVulnerability adds the edge from a library to a vuln node and returns the name of the library.
+func linkVulnerability(storage graph.Storage, nodeID uint32, vulnNode *graph.Node) (string, error) {
+	// The node is read again as edges to earlier advisories may have changed it
+	node, err := storage.GetNode(nodeID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get node %d: %w", nodeID, err)
+	}
+	if err := node.SetDependency(storage, vulnNode); err != nil {
+		return "", fmt.Errorf("failed to add dependency edge to vulnerabilityType node: %w", err)
+	}
+	return node.Name, nil
+}
+
+// unlinkVulnerability removes the edges from libraries an advisory no longer affects to its canonical vuln node,
+// keeping the ones another advisory of the node still adds. The node is removed once no library depends on it.
+func unlinkVulnerability(storage graph.Storage, canonical, advisoryID string, libraries []string) error {
+	if len(libraries) == 0 {
+		return nil
+	}
+	vulnID, err := storage.NameToID(canonical)
+	if err != nil {
+		return nil
+	}
+	group, err := storage.GetCustomData(OSVAliasGroupsTag, canonical)
+	if err != nil {
+		return fmt.Errorf("failed to get the aliases of %s: %w", canonical, err)
+	}
+	linkedByAliases := map[string]bool{}
+	for alias := range group {
+		if alias == advisoryID {
+			continue
+		}
+		names, err := getAdvisoryLibraries(storage, alias)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			linkedByAliases[name] = true
+		}
+	}
+
+	for _, name := range libraries {
+		if linkedByAliases[name] {
+			continue
+		}
+		if err := removeEdge(storage, SBOMEdge{From: name, To: canonical}); err != nil {
+			return err
+		}
+	}
+
+	vulnNode, err := storage.GetNode(vulnID)
+	if err != nil {
+		return fmt.Errorf("failed to get vulnerabilityType node %s: %w", canonical, err)
+	}
+	if vulnNode.Parents.IsEmpty() {
+		if err := graph.RemoveNode(storage, vulnID); err != nil {
+			return fmt.Errorf("failed to remove vulnerabilityType node %s: %w", canonical, err)
+		}
+	}
+	return nil
+}
+
+// linkVulnerabilities indexes library nodes by package and matches them against the advisories ingested before them,
+// so that it doesn't matter whether an SBOM or the advisories affecting it are ingested first.
+func linkVulnerabilities(storage graph.Storage, names []string) error {
+	names = uniqueSorted(names)
+	if err := indexLibraries(storage, names); err != nil {
+		return err
+	}
+	advisories := map[string]*Vulnerability{}
+	for _, name := range names {
+		if !strings.HasPrefix(name, pkg) {
+			continue
+		}
+		pkgInfo, err := PURLToPackage(name)
+		if err != nil {
+			continue
+		}
+		key := packageKey{Ecosystem: pkgInfo.Ecosystem, Name: pkgInfo.Name}
+		ids, err := storage.GetCustomData(OSVPackagesTag, key.String())
+		if err != nil {
+			return fmt.Errorf("failed to get the advisories of %s: %w", key, err)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		nodeID, err := storage.NameToID(name)
+		if err != nil {
+			return fmt.Errorf("failed to find node %s: %w", name, err)
+		}
+		node, err := storage.GetNode(nodeID)
+		if err != nil {
+			return fmt.Errorf("failed to get node %s: %w", name, err)
+		}
+		if node.Type != tools.LibraryType {
+			continue
+		}
+
+		sortedIDs := make([]string, 0, len(ids))
+		for id := range ids {
+			sortedIDs = append(sortedIDs, id)
+		}
+		sort.Strings(sortedIDs)
+		for _, id := range sortedIDs {
+			vuln, ok := advisories[id]
+			if !ok {
+				if vuln, err = getAdvisory(storage, id); err != nil {
+					return err
+				}
+				advisories[id] = vuln
+			}
+			if vuln == nil || vuln.Withdrawn != "" || !isPackageAffected(*vuln, pkgInfo) {
+				continue
+			}
+			canonical, err := canonicalVulnerabilityName(storage, vuln.ID)
+			if err != nil {
+				return err
+			}
+			if err := applyAdvisory(storage, *vuln, canonical, []uint32{nodeID}, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RebuildAdvisories records the advisories of the vuln nodes ingested before advisories were kept, from the OSV record
+// in their metadata, along with the libraries depending on them. Libraries ingested later are then matched against
+// them, and their edges are removed once they are withdrawn or updated. Advisories already recorded are left as is.
+func RebuildAdvisories(storage graph.Storage) error {
+	keys, err := storage.GetAllKeys()
+	if err != nil {
+		return fmt.Errorf("failed to get all keys: %w", err)
+	}
+	nodes, err := storage.GetNodes(keys)
+	if err != nil {
+		return fmt.Errorf("failed to get nodes from storage: %w", err)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	for _, id := range keys {
+		node, ok := nodes[id]
+		if !ok || node.Type != tools.VulnerabilityType {
+			continue
+		}
+		vuln, data, ok := vulnerabilityFromMetadata(node)
+		if !ok {
+			continue
+		}
+		stored, err := getAdvisory(storage, vuln.ID)
+		if err != nil {
+			return err
+		}
+		if stored != nil {
+			continue
+		}
+
+		if err := recordAdvisory(storage, vuln, data); err != nil {
+			return err
+		}
+		canonical, err := canonicalVulnerabilityName(storage, vuln.ID)
+		if err != nil {
+			return err
+		}
+		if canonical == vuln.ID {
+			if err := storage.AddOrUpdateCustomData(OSVAliasesTag, vuln.ID, canonicalDataKey, []byte(node.Name)); err != nil {
+				return fmt.Errorf("failed to record the canonical id of %s: %w", vuln.ID, err)
+			}
+			canonical = node.Name
+		}
+		if err := storage.AddOrUpdateCustomData(OSVAliasGroupsTag, canonical, vuln.ID, nil); err != nil {
+			return fmt.Errorf("failed to add %s to the aliases of %s: %w", vuln.ID, canonical, err)
+		}
+
+		libraries, err := storage.GetNodes(node.Parents.ToArray())
+		if err != nil {
+			return fmt.Errorf("failed to get the libraries of %s: %w", node.Name, err)
+		}
+		names := make([]string, 0, len(libraries))
+		for _, library := range libraries {
+			if library.Type == tools.LibraryType {
+				names = append(names, library.Name)
+			}
+		}
+		sort.Strings(names)
+		if err := setAdvisoryLibraries(storage, vuln.ID, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vulnerabilityFromMetadata returns the OSV record a vuln node was ingested from, and its JSON. The record is saved
+// as JSON bytes, which storages other than the in-memory one read back as a base64 string.
+func vulnerabilityFromMetadata(node *graph.Node) (Vulnerability, []byte, bool) {
+	raw, err := json.Marshal(node.Metadata)
+	if err != nil {
+		return Vulnerability{}, nil, false
+	}
+	var data []byte
+	if err := json.Unmarshal(raw, &data); err != nil {
+		data = raw
+	}
+	var vuln Vulnerability
+	if err := json.Unmarshal(data, &vuln); err != nil || vuln.ID == "" {
+		return Vulnerability{}, nil, false
+	}
+	return vuln, data, true
+}
+
+// getAdvisory returns a recorded advisory, or nil when there is none with the id.
+func getAdvisory(storage graph.Storage, id string) (*Vulnerability, error) {
+	data, err := storage.GetCustomData(OSVAdvisoriesTag, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get advisory %s: %w", id, err)
+	}
+	raw, ok := data[advisoryDataKey]
+	if !ok {
+		return nil, nil
+	}
+	var vuln Vulnerability
+	if err := json.Unmarshal(raw, &vuln); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal advisory %s: %w", id, err)
+	}
+	return &vuln, nil
+}
+
+// getAdvisoryLibraries returns the names of the libraries an advisory was linked to, in their canonical form since
+// names recorded before node names were canonical outlive the migration merging their nodes.
+func getAdvisoryLibraries(storage graph.Storage, id string) ([]string, error) {
+	data, err := storage.GetCustomData(OSVAdvisoriesTag, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the libraries of advisory %s: %w", id, err)
+	}
+	raw, ok := data[librariesDataKey]
+	if !ok {
+		return nil, nil
+	}
+	var libraries []string
+	if err := json.Unmarshal(raw, &libraries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the libraries of advisory %s: %w", id, err)
+	}
+	for i, library := range libraries {
+		libraries[i] = graph.CanonicalName(library)
+	}
+	return libraries, nil
+}
+
+func setAdvisoryLibraries(storage graph.Storage, id string, libraries []string) error {
+	data, err := json.Marshal(libraries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the libraries of advisory %s: %w", id, err)
+	}
+	if err := storage.AddOrUpdateCustomData(OSVAdvisoriesTag, id, librariesDataKey, data); err != nil {
+		return fmt.Errorf("failed to record the libraries of advisory %s: %w", id, err)
+	}
+	return nil
+}
+
+// modifiedAfter reports whether the modified timestamp of one version of an advisory is later than another's.
+func modifiedAfter(modified, other string) bool {
+	t1, err1 := time.Parse(time.RFC3339, modified)
+	t2, err2 := time.Parse(time.RFC3339, other)
+	if err1 != nil || err2 != nil {
+		return modified > other
+	}
+	return t1.After(t2)
+}
@@ -0,0 +1,311 @@
+package ingest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vulnerabilityEdges returns the edges from libraries to vulnerabilities, by name.
+func vulnerabilityEdges(t *testing.T, storage graph.Storage) []string {
+	t.Helper()
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	nodes, err := storage.GetNodes(keys)
+	require.NoError(t, err)
+	var edges []string
+	for _, node := range nodes {
+		if node.Type != tools.VulnerabilityType {
+			continue
+		}
+		for _, parent := range node.Parents.ToArray() {
+			edges = append(edges, nodes[parent].Name+" -> "+node.Name)
+		}
+	}
+	sort.Strings(edges)
+	return edges
+}
+
+func TestLinkVulnerabilities_IngestionOrder(t *testing.T) {
+	var sboms [][]byte
+	sbomFiles, err := os.ReadDir("../../../testdata/osv-sboms")
+	require.NoError(t, err)
+	for _, file := range sbomFiles {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("../../../testdata/osv-sboms", file.Name()))
+		require.NoError(t, err)
+		sboms = append(sboms, data)
+	}
+	var vulns [][]byte
+	for _, data := range readOSVTestVulns(t) {
+		vulns = append(vulns, data)
+	}
+
+	sbomsFirst := graph.NewMockStorage()
+	for _, data := range sboms {
+		require.NoError(t, SBOM(sbomsFirst, data))
+	}
+	require.NoError(t, BulkVulnerabilities(sbomsFirst, vulns))
+
+	vulnsFirst := graph.NewMockStorage()
+	require.NoError(t, BulkVulnerabilities(vulnsFirst, vulns))
+	for _, data := range sboms {
+		require.NoError(t, SBOM(vulnsFirst, data))
+	}
+
+	want := vulnerabilityEdges(t, sbomsFirst)
+	require.NotEmpty(t, want)
+	assert.Equal(t, want, vulnerabilityEdges(t, vulnsFirst))
+
+	keys, err := sbomsFirst.GetAllKeys()
+	require.NoError(t, err)
+	otherKeys, err := vulnsFirst.GetAllKeys()
+	require.NoError(t, err)
+	assert.Len(t, otherKeys, len(keys))
+}
+
+func TestLinkVulnerabilities(t *testing.T) {
+	storage := graph.NewMockStorage()
+	vuln := []byte(`{
+		"id": "PYSEC-1",
+		"affected": [{
+			"package": {"ecosystem": "PyPI", "name": "django"},
+			"ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "4.2.1"}]}]
+		}]
+	}`)
+	require.NoError(t, Vulnerabilities(storage, vuln))
+	_, err := storage.NameToID("PYSEC-1")
+	assert.Error(t, err, "advisories without affected libraries don't add nodes")
+
+	affected, err := graph.AddNode(storage, tools.LibraryType, nil, "pkg:pypi/django@4.2.0")
+	require.NoError(t, err)
+	fixed, err := graph.AddNode(storage, tools.LibraryType, nil, "pkg:pypi/django@4.2.1")
+	require.NoError(t, err)
+	require.NoError(t, linkVulnerabilities(storage, []string{affected.Name, fixed.Name, "pkg:npm/unrelated@1.0.0"}))
+
+	vulnID, err := storage.NameToID("PYSEC-1")
+	require.NoError(t, err)
+	vulnNode, err := storage.GetNode(vulnID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{affected.ID}, vulnNode.Parents.ToArray())
+
+	// Linking again changes nothing
+	require.NoError(t, linkVulnerabilities(storage, []string{affected.Name}))
+	vulnNode, err = storage.GetNode(vulnID)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{affected.ID}, vulnNode.Parents.ToArray())
+}
+
+func osvAdvisory(id, modified, withdrawn, fixed string, aliases ...string) []byte {
+	vuln := Vulnerability{
+		ID:        id,
+		Modified:  modified,
+		Withdrawn: withdrawn,
+		Aliases:   aliases,
+		Summary:   id + " modified " + modified,
+		Affected: []Affected{{
+			Package: Package{Ecosystem: "PyPI", Name: "django"},
+			Ranges:  []Range{{Type: "ECOSYSTEM", Events: []Event{{Introduced: "0"}, {Fixed: fixed}}}},
+		}},
+	}
+	data, _ := json.Marshal(vuln)
+	return data
+}
+
+func setupDjangoGraph(t *testing.T) (graph.Storage, *graph.Node, *graph.Node) {
+	t.Helper()
+	storage := graph.NewMockStorage()
+	older, err := graph.AddNode(storage, tools.LibraryType, nil, "pkg:pypi/django@4.1.0")
+	require.NoError(t, err)
+	newer, err := graph.AddNode(storage, tools.LibraryType, nil, "pkg:pypi/django@4.2.0")
+	require.NoError(t, err)
+	require.NoError(t, IndexLibraries(storage))
+	return storage, older, newer
+}
+
+func vulnerabilityParents(t *testing.T, storage graph.Storage, name string) []uint32 {
+	t.Helper()
+	id, err := storage.NameToID(name)
+	if err != nil {
+		return nil
+	}
+	node, err := storage.GetNode(id)
+	require.NoError(t, err)
+	return node.Parents.ToArray()
+}
+
+func TestIngestVulnerability_Aliases(t *testing.T) {
+	storage, older, newer := setupDjangoGraph(t)
+
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("GHSA-1", "2024-01-01T00:00:00Z", "", "4.2.0", "CVE-2024-1")))
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("CVE-2024-1", "2024-01-01T00:00:00Z", "", "4.2.1")))
+
+	_, err := storage.NameToID("CVE-2024-1")
+	assert.Error(t, err, "aliases share the node of the first advisory")
+	assert.ElementsMatch(t, []uint32{older.ID, newer.ID}, vulnerabilityParents(t, storage, "GHSA-1"))
+
+	// Withdrawing one alias keeps the edges the other one adds
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("GHSA-1", "2024-02-01T00:00:00Z", "2024-02-01T00:00:00Z", "4.2.0", "CVE-2024-1")))
+	assert.ElementsMatch(t, []uint32{older.ID, newer.ID}, vulnerabilityParents(t, storage, "GHSA-1"))
+
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("CVE-2024-1", "2024-02-01T00:00:00Z", "2024-02-01T00:00:00Z", "4.2.1")))
+	_, err = storage.NameToID("GHSA-1")
+	assert.Error(t, err, "the node is removed once every alias is withdrawn")
+}
+
+func TestIngestVulnerability_BridgingAlias(t *testing.T) {
+	storage, older, newer := setupDjangoGraph(t)
+
+	// Two advisories of one issue that don't list each other as aliases get a node each
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("GHSA-1", "2024-01-01T00:00:00Z", "", "4.2.0", "CVE-2024-1")))
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("PYSEC-1", "2024-01-01T00:00:00Z", "", "4.2.1")))
+	require.NoError(t, graph.SetAnnotation(storage, "PYSEC-1", "owner", "team-payments"))
+	assert.Equal(t, []uint32{older.ID}, vulnerabilityParents(t, storage, "GHSA-1"))
+	assert.ElementsMatch(t, []uint32{older.ID, newer.ID}, vulnerabilityParents(t, storage, "PYSEC-1"))
+
+	// Until an advisory listing both of them merges their nodes
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("CVE-2024-1", "2024-01-01T00:00:00Z", "", "4.2.0", "GHSA-1", "PYSEC-1")))
+	_, err := storage.NameToID("PYSEC-1")
+	assert.Error(t, err)
+	assert.ElementsMatch(t, []uint32{older.ID, newer.ID}, vulnerabilityParents(t, storage, "GHSA-1"))
+	annotations, err := graph.GetAnnotations(storage, "GHSA-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "team-payments"}, annotations)
+	group, err := storage.GetCustomData(OSVAliasGroupsTag, "GHSA-1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"GHSA-1", "PYSEC-1", "CVE-2024-1"}, mapKeys(group))
+	canonical, err := canonicalVulnerabilityName(storage, "PYSEC-1")
+	require.NoError(t, err)
+	assert.Equal(t, "GHSA-1", canonical)
+
+	// Withdrawing the merged advisory removes the edges only it added
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("PYSEC-1", "2024-02-01T00:00:00Z", "2024-02-01T00:00:00Z", "4.2.1")))
+	assert.Equal(t, []uint32{older.ID}, vulnerabilityParents(t, storage, "GHSA-1"))
+}
+
+func mapKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestIngestVulnerability_Withdrawn(t *testing.T) {
+	storage, older, _ := setupDjangoGraph(t)
+
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("PYSEC-1", "2024-01-01T00:00:00Z", "", "4.2.0")))
+	assert.Equal(t, []uint32{older.ID}, vulnerabilityParents(t, storage, "PYSEC-1"))
+
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("PYSEC-1", "2024-02-01T00:00:00Z", "2024-02-01T00:00:00Z", "4.2.0")))
+	_, err := storage.NameToID("PYSEC-1")
+	assert.Error(t, err)
+	olderNode, err := storage.GetNode(older.ID)
+	require.NoError(t, err)
+	assert.True(t, olderNode.Children.IsEmpty())
+
+	// Libraries ingested later aren't linked to withdrawn advisories
+	require.NoError(t, linkVulnerabilities(storage, []string{older.Name}))
+	_, err = storage.NameToID("PYSEC-1")
+	assert.Error(t, err)
+}
+
+func TestIngestVulnerability_WithdrawnAfterCanonicalizeNames(t *testing.T) {
+	storage := graph.NewMockStorage()
+	// A library stored before names were canonical, linked to the advisory under that name
+	id, err := storage.GenerateID()
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveNode(&graph.Node{ID: id, Type: tools.LibraryType, Name: "pkg:pypi/django@4.1.0?b=2&a=1", Children: roaring.New(), Parents: roaring.New()}))
+	require.NoError(t, IndexLibraries(storage))
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("PYSEC-1", "2024-01-01T00:00:00Z", "", "4.2.0")))
+	assert.Equal(t, []uint32{id}, vulnerabilityParents(t, storage, "PYSEC-1"))
+
+	merges, err := graph.CanonicalizeNames(storage)
+	require.NoError(t, err)
+	require.Len(t, merges, 1)
+	canonicalID, err := storage.NameToID("pkg:pypi/django@4.1.0?a=1&b=2")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{canonicalID}, vulnerabilityParents(t, storage, "PYSEC-1"))
+
+	// Withdrawing the advisory removes the edge from the canonical library
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("PYSEC-1", "2024-02-01T00:00:00Z", "2024-02-01T00:00:00Z", "4.2.0")))
+	_, err = storage.NameToID("PYSEC-1")
+	assert.Error(t, err)
+	library, err := storage.GetNode(canonicalID)
+	require.NoError(t, err)
+	assert.True(t, library.Children.IsEmpty())
+}
+
+func TestRebuildAdvisories(t *testing.T) {
+	storage, older, newer := setupDjangoGraph(t)
+	// A vuln node ingested before advisories were recorded, linked to the libraries it affected then
+	vuln, err := graph.AddNode(storage, tools.VulnerabilityType, osvAdvisory("PYSEC-1", "2024-01-01T00:00:00Z", "", "4.2.0"), "PYSEC-1")
+	require.NoError(t, err)
+	require.NoError(t, older.SetDependency(storage, vuln))
+
+	require.NoError(t, RebuildAdvisories(storage))
+	libraries, err := getAdvisoryLibraries(storage, "PYSEC-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{older.Name}, libraries)
+
+	// Libraries ingested later are linked to it
+	later, err := graph.AddNode(storage, tools.LibraryType, nil, "pkg:pypi/django@4.0.0")
+	require.NoError(t, err)
+	require.NoError(t, linkVulnerabilities(storage, []string{later.Name, newer.Name}))
+	assert.ElementsMatch(t, []uint32{older.ID, later.ID}, vulnerabilityParents(t, storage, "PYSEC-1"))
+
+	// And withdrawing it removes the edges added before the upgrade too
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("PYSEC-1", "2024-02-01T00:00:00Z", "2024-02-01T00:00:00Z", "4.2.0")))
+	_, err = storage.NameToID("PYSEC-1")
+	assert.Error(t, err)
+
+	// Running it again changes nothing
+	require.NoError(t, RebuildAdvisories(storage))
+	libraries, err = getAdvisoryLibraries(storage, "PYSEC-1")
+	require.NoError(t, err)
+	assert.Empty(t, libraries)
+}
+
+func TestIngestVulnerability_Modified(t *testing.T) {
+	storage, older, newer := setupDjangoGraph(t)
+
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("PYSEC-1", "2024-01-01T00:00:00Z", "", "4.3.0")))
+	assert.ElementsMatch(t, []uint32{older.ID, newer.ID}, vulnerabilityParents(t, storage, "PYSEC-1"))
+
+	// The update narrows the range and replaces the metadata
+	updated := osvAdvisory("PYSEC-1", "2024-02-01T00:00:00Z", "", "4.2.0")
+	require.NoError(t, Vulnerabilities(storage, updated))
+	assert.Equal(t, []uint32{older.ID}, vulnerabilityParents(t, storage, "PYSEC-1"))
+	id, err := storage.NameToID("PYSEC-1")
+	require.NoError(t, err)
+	node, err := storage.GetNode(id)
+	require.NoError(t, err)
+	var metadata Vulnerability
+	require.NoError(t, json.Unmarshal(node.Metadata.([]byte), &metadata))
+	assert.Equal(t, "2024-02-01T00:00:00Z", metadata.Modified)
+
+	// An older version of the advisory is ignored
+	require.NoError(t, Vulnerabilities(storage, osvAdvisory("PYSEC-1", "2024-01-01T00:00:00Z", "", "4.3.0")))
+	assert.Equal(t, []uint32{older.ID}, vulnerabilityParents(t, storage, "PYSEC-1"))
+	stored, err := getAdvisory(storage, "PYSEC-1")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-02-01T00:00:00Z", stored.Modified)
+}
+
+func TestModifiedAfter(t *testing.T) {
+	assert.True(t, modifiedAfter("2024-02-01T00:00:00Z", "2024-01-01T00:00:00Z"))
+	assert.False(t, modifiedAfter("2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z"))
+	assert.True(t, modifiedAfter("2024-01-01T01:00:00+00:00", "2024-01-01T02:00:00+02:00"))
+	assert.False(t, modifiedAfter("", "2024-01-01T00:00:00Z"))
+}
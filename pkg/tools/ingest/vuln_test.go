@@ -1,12 +1,16 @@
 package ingest
 
 import (
+	"archive/zip"
+	"bytes"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestVulnerabilities(t *testing.T) {
@@ -227,3 +231,133 @@ func TestCompareEcosystemVersions(t *testing.T) {
 		})
 	}
 }
+
+func loadOSVTestGraph(t *testing.T) (graph.Storage, int) {
+	t.Helper()
+	storage := graph.NewMockStorage()
+	sbomFiles, err := os.ReadDir("../../../testdata/osv-sboms")
+	require.NoError(t, err)
+	for _, file := range sbomFiles {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("../../../testdata/osv-sboms", file.Name()))
+		require.NoError(t, err)
+		require.NoError(t, SBOM(storage, data))
+	}
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	return storage, len(keys)
+}
+
+func readOSVTestVulns(t *testing.T) map[string][]byte {
+	t.Helper()
+	vulnFiles, err := os.ReadDir("../../../testdata/osv-vulns")
+	require.NoError(t, err)
+	vulns := map[string][]byte{}
+	for _, file := range vulnFiles {
+		data, err := os.ReadFile(filepath.Join("../../../testdata/osv-vulns", file.Name()))
+		require.NoError(t, err)
+		vulns[file.Name()] = data
+	}
+	return vulns
+}
+
+func TestBulkVulnerabilities(t *testing.T) {
+	storage, numberOfNodes := loadOSVTestGraph(t)
+
+	var documents [][]byte
+	for _, data := range readOSVTestVulns(t) {
+		documents = append(documents, data)
+	}
+	require.NoError(t, BulkVulnerabilities(storage, documents))
+
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, numberOfNodes+3)
+
+	// Ingesting the same advisories again adds nothing
+	require.NoError(t, BulkVulnerabilities(storage, documents))
+	keys, err = storage.GetAllKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, numberOfNodes+3)
+
+	assert.Error(t, BulkVulnerabilities(storage, [][]byte{[]byte("not json")}))
+}
+
+func TestVulnerabilitiesArchive(t *testing.T) {
+	storage, numberOfNodes := loadOSVTestGraph(t)
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, data := range readOSVTestVulns(t) {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+	}
+	f, err := w.Create("README.md")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("not an advisory"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	count, err := VulnerabilitiesArchive(storage, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	keys, err := storage.GetAllKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, numberOfNodes+3)
+
+	_, err = VulnerabilitiesArchive(storage, []byte("not a zip"))
+	assert.Error(t, err)
+}
+
+func TestPackageIndex(t *testing.T) {
+	storage := graph.NewMockStorage()
+	for _, name := range []string{"pkg:pypi/django@4.2.0", "pkg:pypi/django@5.0.0", "pkg:pypi/django@5.1.0", "pkg:npm/django@1.0.0", "pkg:maven/org.apache/commons@1.0"} {
+		_, err := graph.AddNode(storage, "library", nil, name)
+		require.NoError(t, err)
+	}
+	_, err := graph.AddNode(storage, "vuln", nil, "pkg:pypi/django@1.0.0")
+	require.NoError(t, err)
+	require.NoError(t, IndexLibraries(storage))
+	require.NoError(t, indexLibraries(storage, []string{"pkg:pypi/django@1.0.0"}))
+	id, err := storage.NameToID("pkg:pypi/django@5.1.0")
+	require.NoError(t, err)
+	require.NoError(t, graph.RemoveNode(storage, id))
+
+	// Libraries that were removed or aren't libraries are left out
+	candidates, err := NewPackageIndex(storage).candidates(Vulnerability{Affected: []Affected{
+		{Package: Package{Ecosystem: "PyPI", Name: "django"}},
+		{Package: Package{Ecosystem: "PyPI", Name: "django"}},
+		{Package: Package{Ecosystem: "Maven", Name: "org.apache:commons"}},
+	}})
+	require.NoError(t, err)
+	var versions []string
+	for _, candidate := range candidates {
+		versions = append(versions, candidate.Info.Ecosystem+":"+candidate.Info.Version)
+	}
+	assert.ElementsMatch(t, []string{"PyPI:4.2.0", "PyPI:5.0.0", "Maven:1.0"}, versions)
+}
+
+// scanCountingStorage counts the calls that read every node of the graph.
+type scanCountingStorage struct {
+	graph.Storage
+	scans int
+}
+
+func (s *scanCountingStorage) GetAllKeys() ([]uint32, error) {
+	s.scans++
+	return s.Storage.GetAllKeys()
+}
+
+func TestVulnerabilities_NoGraphScan(t *testing.T) {
+	storage, older, newer := setupDjangoGraph(t)
+	counting := &scanCountingStorage{Storage: storage}
+
+	require.NoError(t, Vulnerabilities(counting, osvAdvisory("PYSEC-1", "2024-01-01T00:00:00Z", "", "4.3.0")))
+	assert.Zero(t, counting.scans)
+	assert.ElementsMatch(t, []uint32{older.ID, newer.ID}, vulnerabilityParents(t, storage, "PYSEC-1"))
+}
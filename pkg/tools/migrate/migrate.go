@@ -0,0 +1,347 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+)
+
+// CheckpointTag is the custom data tag under which the destination storage records migration progress.
+// Records with this tag are never copied, so a migrated storage can itself be migrated again.
+const CheckpointTag = "minefield:migration"
+
+const (
+	checkpointDataKey = "checkpoint"
+	defaultBatchSize  = 500
+)
+
+type Phase string
+
+const (
+	PhaseNodes      Phase = "nodes"
+	PhaseCacheStack Phase = "cache-stack"
+	PhaseCustomData Phase = "custom-data"
+	PhaseIDCounter  Phase = "id-counter"
+	PhaseDone       Phase = "done"
+)
+
+// Checkpoint is the progress of a migration, saved to the destination after every completed step.
+type Checkpoint struct {
+	Phase  Phase  `json:"phase"`
+	LastID uint32 `json:"lastID"`
+}
+
+// Options configures a migration.
+type Options struct {
+	// SourceName identifies the source storage, checkpoints are kept per source so an interrupted migration can resume.
+	SourceName string
+	// BatchSize is the number of nodes and caches read and written at a time.
+	BatchSize int
+	// Progress, if set, is called after every batch with the number of processed and total items of the phase.
+	Progress func(phase Phase, done, total int)
+}
+
+// Counts holds the number of records of every kind in a storage.
+type Counts struct {
+	Nodes      int
+	Caches     int
+	CacheStack int
+	CustomData int
+	IDCounter  uint32
+}
+
+// Result describes a finished migration.
+type Result struct {
+	Source      Counts
+	Destination Counts
+	Resumed     bool
+}
+
+// Migrate copies every node, cache, cache stack entry, custom data record and the ID counter from one storage to
+// another, and verifies the record counts afterwards.
+// Progress is checkpointed in the destination, so calling Migrate again after an interruption resumes where it stopped.
+func Migrate(from, to graph.Storage, opts Options) (*Result, error) {
+	if from == nil || to == nil {
+		return nil, fmt.Errorf("source and destination storages cannot be nil")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.Progress == nil {
+		opts.Progress = func(Phase, int, int) {}
+	}
+
+	checkpoint, err := loadCheckpoint(to, opts.SourceName)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Resumed: checkpoint != nil}
+	if checkpoint == nil {
+		// Node IDs are copied as they are, so they must not collide with nodes already in the destination
+		keys, err := to.GetAllKeys()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get destination keys: %w", err)
+		}
+		if len(keys) > 0 {
+			return nil, fmt.Errorf("destination storage already contains %d nodes", len(keys))
+		}
+		// Save the checkpoint before writing anything, so a failure in the first batch can still be resumed
+		checkpoint = &Checkpoint{Phase: PhaseNodes}
+		if err := saveCheckpoint(to, opts.SourceName, checkpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	if checkpoint.Phase == PhaseNodes {
+		if err := migrateNodes(from, to, checkpoint, opts); err != nil {
+			return nil, err
+		}
+	}
+	if checkpoint.Phase == PhaseCacheStack {
+		if err := migrateCacheStack(from, to, checkpoint, opts); err != nil {
+			return nil, err
+		}
+	}
+	if checkpoint.Phase == PhaseCustomData {
+		if err := migrateCustomData(from, to, checkpoint, opts); err != nil {
+			return nil, err
+		}
+	}
+	if checkpoint.Phase == PhaseIDCounter {
+		counter, err := from.GetIDCounter()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source ID counter: %w", err)
+		}
+		if err := to.SetIDCounter(counter); err != nil {
+			return nil, fmt.Errorf("failed to set destination ID counter: %w", err)
+		}
+		if err := advance(to, opts.SourceName, checkpoint, PhaseDone); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Source, err = CountRecords(from); err != nil {
+		return nil, fmt.Errorf("failed to count source records: %w", err)
+	}
+	if result.Destination, err = CountRecords(to); err != nil {
+		return nil, fmt.Errorf("failed to count destination records: %w", err)
+	}
+	if err := verify(result.Source, result.Destination); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+func migrateNodes(from, to graph.Storage, checkpoint *Checkpoint, opts Options) error {
+	keys, err := from.GetAllKeys()
+	if err != nil {
+		return fmt.Errorf("failed to get source keys: %w", err)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	// Skip the nodes that were migrated before an interruption
+	start := sort.Search(len(keys), func(i int) bool { return keys[i] > checkpoint.LastID })
+	for i := start; i < len(keys); i += opts.BatchSize {
+		batch := keys[i:min(i+opts.BatchSize, len(keys))]
+
+		nodes, err := from.GetNodes(batch)
+		if err != nil {
+			return fmt.Errorf("failed to get source nodes: %w", err)
+		}
+		caches, err := from.GetCaches(batch)
+		if err != nil {
+			return fmt.Errorf("failed to get source caches: %w", err)
+		}
+
+		batchCaches := make([]*graph.NodeCache, 0, len(caches))
+		for _, id := range batch {
+			node, ok := nodes[id]
+			if !ok {
+				return fmt.Errorf("node %d disappeared from the source during migration", id)
+			}
+			if err := to.SaveNode(node); err != nil {
+				return fmt.Errorf("failed to save node %d: %w", id, err)
+			}
+			if cache, ok := caches[id]; ok && cache != nil {
+				batchCaches = append(batchCaches, cache)
+			}
+		}
+		if len(batchCaches) > 0 {
+			if err := to.SaveCaches(batchCaches); err != nil {
+				return fmt.Errorf("failed to save caches: %w", err)
+			}
+		}
+
+		checkpoint.LastID = batch[len(batch)-1]
+		if err := saveCheckpoint(to, opts.SourceName, checkpoint); err != nil {
+			return err
+		}
+		opts.Progress(PhaseNodes, i+len(batch), len(keys))
+	}
+	return advance(to, opts.SourceName, checkpoint, PhaseCacheStack)
+}
+
+func migrateCacheStack(from, to graph.Storage, checkpoint *Checkpoint, opts Options) error {
+	stack, err := uniqueCacheStack(from)
+	if err != nil {
+		return fmt.Errorf("failed to get source cache stack: %w", err)
+	}
+	// Saving nodes pushes them onto the destination's cache stack, so it is rebuilt from the source
+	if err := to.ClearCacheStack(); err != nil {
+		return fmt.Errorf("failed to clear destination cache stack: %w", err)
+	}
+	for i, id := range stack {
+		if err := to.AddNodeToCachedStack(id); err != nil {
+			return fmt.Errorf("failed to add node %d to destination cache stack: %w", id, err)
+		}
+		if (i+1)%opts.BatchSize == 0 || i == len(stack)-1 {
+			opts.Progress(PhaseCacheStack, i+1, len(stack))
+		}
+	}
+	return advance(to, opts.SourceName, checkpoint, PhaseCustomData)
+}
+
+func migrateCustomData(from, to graph.Storage, checkpoint *Checkpoint, opts Options) error {
+	keys, err := customDataKeys(from)
+	if err != nil {
+		return fmt.Errorf("failed to get source custom data keys: %w", err)
+	}
+	for i, key := range keys {
+		data, err := from.GetCustomData(key.Tag, key.Key)
+		if err != nil {
+			return fmt.Errorf("failed to get custom data %s:%s: %w", key.Tag, key.Key, err)
+		}
+		for dataKey, value := range data {
+			if err := to.AddOrUpdateCustomData(key.Tag, key.Key, dataKey, value); err != nil {
+				return fmt.Errorf("failed to save custom data %s:%s: %w", key.Tag, key.Key, err)
+			}
+		}
+		if (i+1)%opts.BatchSize == 0 || i == len(keys)-1 {
+			opts.Progress(PhaseCustomData, i+1, len(keys))
+		}
+	}
+	return advance(to, opts.SourceName, checkpoint, PhaseIDCounter)
+}
+
+// CountRecords counts the records of every kind in the storage, ignoring migration checkpoints.
+func CountRecords(storage graph.Storage) (Counts, error) {
+	keys, err := storage.GetAllKeys()
+	if err != nil {
+		return Counts{}, fmt.Errorf("failed to get keys: %w", err)
+	}
+	caches, err := storage.GetCaches(keys)
+	if err != nil {
+		return Counts{}, fmt.Errorf("failed to get caches: %w", err)
+	}
+	stack, err := uniqueCacheStack(storage)
+	if err != nil {
+		return Counts{}, fmt.Errorf("failed to get cache stack: %w", err)
+	}
+	customData, err := customDataKeys(storage)
+	if err != nil {
+		return Counts{}, fmt.Errorf("failed to get custom data keys: %w", err)
+	}
+	counter, err := storage.GetIDCounter()
+	if err != nil {
+		return Counts{}, fmt.Errorf("failed to get ID counter: %w", err)
+	}
+	return Counts{
+		Nodes:      len(keys),
+		Caches:     len(caches),
+		CacheStack: len(stack),
+		CustomData: len(customData),
+		IDCounter:  counter,
+	}, nil
+}
+
+func verify(source, destination Counts) error {
+	switch {
+	case source.Nodes != destination.Nodes:
+		return fmt.Errorf("node count mismatch: source has %d, destination has %d", source.Nodes, destination.Nodes)
+	case source.Caches != destination.Caches:
+		return fmt.Errorf("cache count mismatch: source has %d, destination has %d", source.Caches, destination.Caches)
+	case source.CacheStack != destination.CacheStack:
+		return fmt.Errorf("cache stack mismatch: source has %d entries, destination has %d", source.CacheStack, destination.CacheStack)
+	case source.CustomData != destination.CustomData:
+		return fmt.Errorf("custom data count mismatch: source has %d, destination has %d", source.CustomData, destination.CustomData)
+	case destination.IDCounter < source.IDCounter:
+		return fmt.Errorf("ID counter mismatch: source is at %d, destination is at %d", source.IDCounter, destination.IDCounter)
+	}
+	return nil
+}
+
+func uniqueCacheStack(storage graph.Storage) ([]uint32, error) {
+	stack, err := storage.ToBeCached()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[uint32]bool, len(stack))
+	unique := make([]uint32, 0, len(stack))
+	for _, id := range stack {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+func customDataKeys(storage graph.Storage) ([]graph.CustomDataKey, error) {
+	keys, err := storage.GetCustomDataKeys()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]graph.CustomDataKey, 0, len(keys))
+	for _, key := range keys {
+		if key.Tag != CheckpointTag {
+			result = append(result, key)
+		}
+	}
+	return result, nil
+}
+
+// loadCheckpoint returns the checkpoint of a previous migration from sourceName, or nil if there is none.
+func loadCheckpoint(to graph.Storage, sourceName string) (*Checkpoint, error) {
+	keys, err := to.GetCustomDataKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get destination custom data keys: %w", err)
+	}
+	for _, key := range keys {
+		if key.Tag != CheckpointTag || key.Key != sourceName {
+			continue
+		}
+		data, err := to.GetCustomData(CheckpointTag, sourceName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get migration checkpoint: %w", err)
+		}
+		raw, ok := data[checkpointDataKey]
+		if !ok {
+			return nil, nil
+		}
+		var checkpoint Checkpoint
+		if err := json.Unmarshal(raw, &checkpoint); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal migration checkpoint: %w", err)
+		}
+		return &checkpoint, nil
+	}
+	return nil, nil
+}
+
+func saveCheckpoint(to graph.Storage, sourceName string, checkpoint *Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal migration checkpoint: %w", err)
+	}
+	if err := to.AddOrUpdateCustomData(CheckpointTag, sourceName, checkpointDataKey, data); err != nil {
+		return fmt.Errorf("failed to save migration checkpoint: %w", err)
+	}
+	return nil
+}
+
+func advance(to graph.Storage, sourceName string, checkpoint *Checkpoint, phase Phase) error {
+	checkpoint.Phase = phase
+	checkpoint.LastID = 0
+	return saveCheckpoint(to, sourceName, checkpoint)
+}
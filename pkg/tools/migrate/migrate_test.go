@@ -0,0 +1,96 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSource(t *testing.T, numNodes int) *graph.MockStorage {
+	t.Helper()
+	storage := graph.NewMockStorage()
+	var prev *graph.Node
+	for i := 0; i < numNodes; i++ {
+		node, err := graph.AddNode(storage, "library", map[string]any{"index": i}, fmt.Sprintf("pkg:generic/node%d@1.0.0", i))
+		require.NoError(t, err)
+		if prev != nil {
+			require.NoError(t, prev.SetDependency(storage, node))
+		}
+		prev = node
+	}
+	require.NoError(t, graph.Cache(storage))
+	require.NoError(t, storage.AddOrUpdateCustomData("owner", "pkg:generic/node0@1.0.0", "team", []byte("payments")))
+	return storage
+}
+
+func TestMigrate(t *testing.T) {
+	from := setupSource(t, 10)
+	to := graph.NewMockStorage()
+
+	var progress []Phase
+	result, err := Migrate(from, to, Options{
+		SourceName: "source",
+		BatchSize:  3,
+		Progress: func(phase Phase, done, total int) {
+			progress = append(progress, phase)
+		},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Resumed)
+	assert.Equal(t, 10, result.Destination.Nodes)
+	assert.Equal(t, 10, result.Destination.Caches)
+	assert.Equal(t, 0, result.Destination.CacheStack)
+	assert.Equal(t, 1, result.Destination.CustomData)
+	assert.Equal(t, uint32(10), result.Destination.IDCounter)
+	assert.Contains(t, progress, PhaseNodes)
+
+	fromNode, err := from.GetNode(5)
+	require.NoError(t, err)
+	toNode, err := to.GetNode(5)
+	require.NoError(t, err)
+	assert.Equal(t, fromNode.Name, toNode.Name)
+	assert.True(t, fromNode.Children.Equals(toNode.Children))
+
+	data, err := to.GetCustomData("owner", "pkg:generic/node0@1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("payments"), data["team"])
+
+	// The checkpoint says the migration is done, so running it again only verifies the counts
+	result, err = Migrate(from, to, Options{SourceName: "source"})
+	require.NoError(t, err)
+	assert.True(t, result.Resumed)
+}
+
+func TestMigrateResumesAfterInterruption(t *testing.T) {
+	from := setupSource(t, 10)
+	to := graph.NewMockStorage()
+	to.SaveCachesErr = errors.New("connection reset")
+
+	_, err := Migrate(from, to, Options{SourceName: "source", BatchSize: 4})
+	require.Error(t, err)
+
+	// The first batch of nodes was written before the failure
+	keys, err := to.GetAllKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 4)
+
+	to.SaveCachesErr = nil
+	result, err := Migrate(from, to, Options{SourceName: "source", BatchSize: 4})
+	require.NoError(t, err)
+	assert.True(t, result.Resumed)
+	assert.Equal(t, result.Source, result.Destination)
+}
+
+func TestMigrateRejectsNonEmptyDestination(t *testing.T) {
+	from := setupSource(t, 2)
+	to := graph.NewMockStorage()
+	_, err := graph.AddNode(to, "library", nil, "existing")
+	require.NoError(t, err)
+
+	_, err = Migrate(from, to, Options{SourceName: "source"})
+	assert.ErrorContains(t, err, "destination storage already contains 1 nodes")
+}
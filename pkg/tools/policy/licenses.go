@@ -0,0 +1,134 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools"
+	"github.com/bitbomdev/minefield/pkg/tools/ingest"
+)
+
+// LicenseViolation is a library, among the dependencies of a root, that can't be used without a denied license.
+type LicenseViolation struct {
+	Root    string
+	Library string
+	License string
+	// Expression is the license expression that applies to the library.
+	Expression string
+}
+
+// CheckLicenses reports the libraries among the transitive dependencies of each root whose license expression
+// can't be complied with without one of the denied licenses, so "MIT OR GPL-3.0-only" passes when only
+// GPL-3.0-only is denied. Licenses are matched case-insensitively, including their exception, and every ingested
+// SBOM is checked when no roots are given. The dependencies come from the cache when the graph is fully cached.
+func CheckLicenses(storage graph.Storage, roots []string, deny []string) ([]LicenseViolation, error) {
+	if len(deny) == 0 {
+		return nil, fmt.Errorf("no denied licenses given")
+	}
+	denied := make(map[string]bool, len(deny))
+	for _, license := range deny {
+		denied[strings.ToLower(strings.TrimSpace(license))] = true
+	}
+	allowed := func(license string) bool {
+		return !denied[strings.ToLower(license)]
+	}
+
+	rootIDs, err := resolveRoots(storage, roots)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []LicenseViolation
+	for _, rootID := range rootIDs {
+		root, err := storage.GetNode(rootID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get root node %d: %w", rootID, err)
+		}
+		dependencies, err := root.QueryDependencies(storage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the dependencies of %s: %w", root.Name, err)
+		}
+		closure := dependencies.Clone()
+		closure.Add(rootID)
+
+		nodes, err := storage.GetNodes(closure.ToArray())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the dependencies of %s: %w", root.Name, err)
+		}
+		for _, node := range nodes {
+			if node.Type != tools.LicenseType {
+				continue
+			}
+			license := strings.TrimPrefix(node.Name, ingest.LicenseNodeName(""))
+			if allowed(license) {
+				continue
+			}
+			attributes, err := graph.GetEdgeAttributes(storage, node.Name)
+			if err != nil {
+				return nil, err
+			}
+			for _, parentID := range node.Parents.ToArray() {
+				parent, ok := nodes[parentID]
+				if !ok {
+					continue
+				}
+				expression := attributes[parent.Name][ingest.LicenseExpressionAttribute]
+				if parsed, err := ingest.ParseLicenseExpression(expression); err == nil && parsed.Satisfiable(allowed) {
+					continue
+				}
+				violations = append(violations, LicenseViolation{
+					Root:       root.Name,
+					Library:    parent.Name,
+					License:    license,
+					Expression: expression,
+				})
+			}
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.Root != b.Root {
+			return a.Root < b.Root
+		}
+		if a.Library != b.Library {
+			return a.Library < b.Library
+		}
+		return a.License < b.License
+	})
+	return violations, nil
+}
+
+// resolveRoots returns the ids of the named roots, or of every sbom node when no roots are given.
+func resolveRoots(storage graph.Storage, roots []string) ([]uint32, error) {
+	if len(roots) > 0 {
+		ids := make([]uint32, 0, len(roots))
+		for _, root := range roots {
+			id, err := storage.NameToID(graph.CanonicalName(root))
+			if err != nil {
+				return nil, fmt.Errorf("failed to find root %s: %w", root, err)
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+
+	keys, err := storage.GetAllKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all keys: %w", err)
+	}
+	nodes, err := storage.GetNodes(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
+	}
+	var ids []uint32
+	for id, node := range nodes {
+		if node.Type == ingest.SBOMNodeType {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
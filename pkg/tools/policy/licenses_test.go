@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/bitbomdev/minefield/pkg/graph"
+	"github.com/bitbomdev/minefield/pkg/tools/ingest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSBOM = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "serialNumber": "urn:uuid:4e671687-395b-41f5-a30f-a58921a69b79",
+  "version": 1,
+  "metadata": {
+    "component": {"bom-ref": "app", "type": "application", "name": "app", "purl": "pkg:npm/app@1.0.0", "licenses": [{"license": {"id": "MIT"}}]}
+  },
+  "components": [
+    {"bom-ref": "a", "type": "library", "name": "a", "version": "1.0.0", "purl": "pkg:npm/a@1.0.0",
+     "licenses": [{"expression": "MIT OR GPL-3.0-only"}]},
+    {"bom-ref": "b", "type": "library", "name": "b", "version": "1.0.0", "purl": "pkg:npm/b@1.0.0",
+     "licenses": [{"expression": "Apache-2.0 AND GPL-3.0-only"}]},
+    {"bom-ref": "c", "type": "library", "name": "c", "version": "1.0.0", "purl": "pkg:npm/c@1.0.0",
+     "licenses": [{"license": {"id": "AGPL-3.0-only"}}]}
+  ],
+  "dependencies": [
+    {"ref": "app", "dependsOn": ["a"]},
+    {"ref": "a", "dependsOn": ["b"]}
+  ]
+}`
+
+func TestCheckLicenses(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, ingest.SBOM(storage, []byte(testSBOM)))
+	require.NoError(t, graph.Cache(storage))
+
+	violations, err := CheckLicenses(storage, []string{"pkg:npm/app@1.0.0"}, []string{"gpl-3.0-only"})
+	require.NoError(t, err)
+	assert.Equal(t, []LicenseViolation{
+		{Root: "pkg:npm/app@1.0.0", Library: "pkg:npm/b@1.0.0", License: "GPL-3.0-only", Expression: "Apache-2.0 AND GPL-3.0-only"},
+	}, violations)
+
+	// c isn't a dependency of a
+	violations, err = CheckLicenses(storage, []string{"pkg:npm/a@1.0.0"}, []string{"AGPL-3.0-only"})
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	// Without roots every SBOM is checked
+	violations, err = CheckLicenses(storage, nil, []string{"AGPL-3.0-only", "MIT"})
+	require.NoError(t, err)
+	sbom := ingest.SBOMNodeName("urn:uuid:4e671687-395b-41f5-a30f-a58921a69b79", "")
+	assert.Equal(t, []LicenseViolation{
+		{Root: sbom, Library: "pkg:npm/app@1.0.0", License: "MIT", Expression: "MIT"},
+		{Root: sbom, Library: "pkg:npm/c@1.0.0", License: "AGPL-3.0-only", Expression: "AGPL-3.0-only"},
+	}, violations)
+}
+
+func TestCheckLicenses_Uncached(t *testing.T) {
+	storage := graph.NewMockStorage()
+	require.NoError(t, ingest.SBOM(storage, []byte(testSBOM)))
+
+	violations, err := CheckLicenses(storage, []string{"pkg:npm/app@1.0.0"}, []string{"GPL-3.0-only"})
+	require.NoError(t, err)
+	assert.Len(t, violations, 1)
+}
+
+func TestCheckLicenses_Errors(t *testing.T) {
+	storage := graph.NewMockStorage()
+	_, err := CheckLicenses(storage, nil, nil)
+	assert.Error(t, err)
+	_, err = CheckLicenses(storage, []string{"pkg:npm/missing@1.0.0"}, []string{"MIT"})
+	assert.Error(t, err)
+}
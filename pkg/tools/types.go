@@ -4,4 +4,13 @@ const (
 	LibraryType       = "library"
 	VulnerabilityType = "vuln"
 	ScorecardType     = "scorecard"
+	LicenseType       = "license"
+	RepositoryType    = "repository"
+
+	// Build provenance. An artifact depends on the build that produced it, which depends on its builder, its source
+	// repository and its materials.
+	BuildType    = "build"
+	ArtifactType = "artifact"
+	BuilderType  = "builder"
+	MaterialType = "material"
 )
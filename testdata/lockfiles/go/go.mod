@@ -0,0 +1,15 @@
+module example.com/app
+
+go 1.22
+
+require (
+	github.com/google/uuid v1.6.0
+	golang.org/x/text v0.14.0 // indirect
+	"github.com/old/module" v1.0.0
+)
+
+require github.com/spf13/cobra v1.8.0
+
+replace github.com/old/module => github.com/new/module v1.1.0
+
+replace github.com/spf13/cobra v1.8.0 => ../cobra